@@ -0,0 +1,61 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// GetAccount looks up the config.Account for the given publisher ID.
+//
+// Unknown accounts get a default Account with no overrides, unless the host has set account_required.
+// The returned errors are fatal for the request: the account is unknown and required, malformed, or disabled.
+func GetAccount(ctx context.Context, cfg *config.Configuration, fetcher stored_requests.AccountFetcher, accountID string) (*config.Account, []error) {
+	if accountID == pbsmetrics.PublisherUnknown {
+		if cfg.AccountRequired {
+			return nil, []error{accountRequiredError(accountID)}
+		}
+		return &config.Account{ID: accountID}, nil
+	}
+
+	accountJSON, errs := fetcher.FetchAccount(ctx, accountID)
+	if len(errs) > 0 || accountJSON == nil {
+		for _, err := range errs {
+			if _, ok := err.(stored_requests.NotFoundError); !ok {
+				glog.Errorf("Failed to fetch Account %s: %v", accountID, err)
+			}
+		}
+		if cfg.AccountRequired {
+			return nil, []error{accountRequiredError(accountID)}
+		}
+		return &config.Account{ID: accountID}, nil
+	}
+
+	var account config.Account
+	if err := json.Unmarshal(accountJSON, &account); err != nil {
+		return nil, []error{&errortypes.BadServerResponse{
+			Message: fmt.Sprintf("The prebid-server account config for account id \"%s\" is malformed. Please reach out to the prebid server host.", accountID),
+		}}
+	}
+	// The stored data doesn't need to repeat the ID it is keyed by
+	account.ID = accountID
+
+	if account.Disabled {
+		return nil, []error{&errortypes.BlacklistedAcct{
+			Message: fmt.Sprintf("Prebid-server has disabled Account ID: %s, please reach out to the prebid server host.", accountID),
+		}}
+	}
+	return &account, nil
+}
+
+func accountRequiredError(accountID string) error {
+	return &errortypes.AcctRequired{
+		Message: fmt.Sprintf("Prebid-server has been configured to discard requests without a valid Account ID. Account ID %s was not found, please reach out to the prebid server host.", accountID),
+	}
+}
@@ -0,0 +1,106 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockAccountData = map[string]json.RawMessage{
+	"valid_acct":     json.RawMessage(`{"disabled":false,"enabled_bidders":["appnexus"]}`),
+	"disabled_acct":  json.RawMessage(`{"disabled":true}`),
+	"malformed_acct": json.RawMessage(`{"disabled":"invalid type"}`),
+}
+
+type mockAccountFetcher struct{}
+
+func (mockAccountFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	if account, ok := mockAccountData[accountID]; ok {
+		return account, nil
+	}
+	if accountID == "broken_fetcher" {
+		return nil, []error{errors.New("database is down")}
+	}
+	return nil, []error{stored_requests.NotFoundError{ID: accountID, DataType: "Account"}}
+}
+
+func TestGetAccount(t *testing.T) {
+	testCases := []struct {
+		description     string
+		accountID       string
+		required        bool
+		expectedAccount *config.Account
+		expectedErrCode int
+	}{
+		{
+			description:     "Known account",
+			accountID:       "valid_acct",
+			expectedAccount: &config.Account{ID: "valid_acct", EnabledBidders: []string{"appnexus"}},
+		},
+		{
+			description:     "Known account, required",
+			accountID:       "valid_acct",
+			required:        true,
+			expectedAccount: &config.Account{ID: "valid_acct", EnabledBidders: []string{"appnexus"}},
+		},
+		{
+			description:     "Unknown account",
+			accountID:       "unknown_acct",
+			expectedAccount: &config.Account{ID: "unknown_acct"},
+		},
+		{
+			description:     "Unknown account, required",
+			accountID:       "unknown_acct",
+			required:        true,
+			expectedErrCode: errortypes.AcctRequiredCode,
+		},
+		{
+			description:     "Missing publisher ID",
+			accountID:       pbsmetrics.PublisherUnknown,
+			expectedAccount: &config.Account{ID: pbsmetrics.PublisherUnknown},
+		},
+		{
+			description:     "Missing publisher ID, required",
+			accountID:       pbsmetrics.PublisherUnknown,
+			required:        true,
+			expectedErrCode: errortypes.AcctRequiredCode,
+		},
+		{
+			description:     "Fetcher error falls back to a default account",
+			accountID:       "broken_fetcher",
+			expectedAccount: &config.Account{ID: "broken_fetcher"},
+		},
+		{
+			description:     "Disabled account",
+			accountID:       "disabled_acct",
+			expectedErrCode: errortypes.BlacklistedAcctCode,
+		},
+		{
+			description:     "Malformed account",
+			accountID:       "malformed_acct",
+			expectedErrCode: errortypes.BadServerResponseCode,
+		},
+	}
+
+	for _, test := range testCases {
+		cfg := &config.Configuration{AccountRequired: test.required}
+		account, errs := GetAccount(context.Background(), cfg, mockAccountFetcher{}, test.accountID)
+
+		if test.expectedErrCode != 0 {
+			assert.Nil(t, account, test.description)
+			if assert.Len(t, errs, 1, test.description) {
+				assert.Equal(t, test.expectedErrCode, errortypes.DecodeError(errs[0]), test.description)
+			}
+		} else {
+			assert.Empty(t, errs, test.description)
+			assert.Equal(t, test.expectedAccount, account, test.description)
+		}
+	}
+}
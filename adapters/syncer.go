@@ -1,6 +1,7 @@
 package adapters
 
 import (
+	"fmt"
 	"text/template"
 
 	"github.com/prebid/prebid-server/macros"
@@ -34,15 +35,79 @@ func NewSyncer(familyName string, vendorID uint16, urlTemplate *template.Templat
 	}
 }
 
-type SyncType string
+type SyncType = usersync.SyncType
 
 const (
-	SyncTypeRedirect SyncType = "redirect"
-	SyncTypeIframe   SyncType = "iframe"
+	SyncTypeRedirect = usersync.SyncTypeRedirect
+	SyncTypeIframe   = usersync.SyncTypeIframe
 )
 
 func (s *Syncer) GetUsersyncInfo(gdpr string, consent string) (*usersync.UsersyncInfo, error) {
-	userSyncURL, err := macros.ResolveMacros(*s.urlTemplate, macros.UserSyncTemplateParams{
+	return resolveUsersyncInfo(s.urlTemplate, s.syncType, gdpr, consent)
+}
+
+func (s *Syncer) SupportedSyncTypes() []SyncType {
+	return []SyncType{s.syncType}
+}
+
+func (s *Syncer) GetUsersyncInfoForType(syncType SyncType, gdpr string, consent string) (*usersync.UsersyncInfo, error) {
+	if syncType != s.syncType {
+		return nil, fmt.Errorf("%s doesn't support %s syncs", s.familyName, syncType)
+	}
+	return s.GetUsersyncInfo(gdpr, consent)
+}
+
+func (s *Syncer) FamilyName() string {
+	return s.familyName
+}
+
+func (s *Syncer) GDPRVendorID() uint16 {
+	return s.gdprVendorID
+}
+
+// WithSyncURLs returns a Usersyncer which runs the syncs of syncer, and also the syncs in urlTemplates.
+// It's used for bidders which support both types of sync, where the host has configured a URL for each.
+// The templates in urlTemplates take precedence over the ones in syncer for the same type.
+func WithSyncURLs(syncer usersync.Usersyncer, urlTemplates map[SyncType]*template.Template) usersync.Usersyncer {
+	if len(urlTemplates) == 0 {
+		return syncer
+	}
+	types := syncer.SupportedSyncTypes()
+	for _, syncType := range []SyncType{SyncTypeIframe, SyncTypeRedirect} {
+		if _, ok := urlTemplates[syncType]; ok && !hasSyncType(types, syncType) {
+			types = append(types, syncType)
+		}
+	}
+	return &multiTypeSyncer{
+		Usersyncer:   syncer,
+		urlTemplates: urlTemplates,
+		syncTypes:    types,
+	}
+}
+
+type multiTypeSyncer struct {
+	usersync.Usersyncer
+	urlTemplates map[SyncType]*template.Template
+	syncTypes    []SyncType
+}
+
+func (s *multiTypeSyncer) GetUsersyncInfo(gdpr string, consent string) (*usersync.UsersyncInfo, error) {
+	return s.GetUsersyncInfoForType(s.syncTypes[0], gdpr, consent)
+}
+
+func (s *multiTypeSyncer) SupportedSyncTypes() []SyncType {
+	return s.syncTypes
+}
+
+func (s *multiTypeSyncer) GetUsersyncInfoForType(syncType SyncType, gdpr string, consent string) (*usersync.UsersyncInfo, error) {
+	if urlTemplate, ok := s.urlTemplates[syncType]; ok {
+		return resolveUsersyncInfo(urlTemplate, syncType, gdpr, consent)
+	}
+	return s.Usersyncer.GetUsersyncInfoForType(syncType, gdpr, consent)
+}
+
+func resolveUsersyncInfo(urlTemplate *template.Template, syncType SyncType, gdpr string, consent string) (*usersync.UsersyncInfo, error) {
+	userSyncURL, err := macros.ResolveMacros(*urlTemplate, macros.UserSyncTemplateParams{
 		GDPR:        gdpr,
 		GDPRConsent: consent,
 	})
@@ -52,15 +117,16 @@ func (s *Syncer) GetUsersyncInfo(gdpr string, consent string) (*usersync.Usersyn
 
 	return &usersync.UsersyncInfo{
 		URL:         userSyncURL,
-		Type:        string(s.syncType),
+		Type:        string(syncType),
 		SupportCORS: false,
 	}, err
 }
 
-func (s *Syncer) FamilyName() string {
-	return s.familyName
-}
-
-func (s *Syncer) GDPRVendorID() uint16 {
-	return s.gdprVendorID
+func hasSyncType(types []SyncType, syncType SyncType) bool {
+	for _, t := range types {
+		if t == syncType {
+			return true
+		}
+	}
+	return false
 }
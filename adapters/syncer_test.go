@@ -0,0 +1,56 @@
+package adapters
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncerTypes(t *testing.T) {
+	syncer := NewSyncer("adnxs", 32, template.Must(template.New("sync").Parse("//redirect.com?gdpr={{.GDPR}}")), SyncTypeRedirect)
+	assert.Equal(t, []SyncType{SyncTypeRedirect}, syncer.SupportedSyncTypes())
+
+	syncInfo, err := syncer.GetUsersyncInfoForType(SyncTypeRedirect, "1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "//redirect.com?gdpr=1", syncInfo.URL)
+	assert.Equal(t, "redirect", syncInfo.Type)
+
+	_, err = syncer.GetUsersyncInfoForType(SyncTypeIframe, "1", "")
+	assert.EqualError(t, err, "adnxs doesn't support iframe syncs")
+}
+
+func TestWithSyncURLs(t *testing.T) {
+	syncer := NewSyncer("adnxs", 32, template.Must(template.New("sync").Parse("//redirect.com?gdpr={{.GDPR}}")), SyncTypeRedirect)
+	multi := WithSyncURLs(syncer, map[SyncType]*template.Template{
+		SyncTypeIframe: template.Must(template.New("sync").Parse("//iframe.com?consent={{.GDPRConsent}}")),
+	})
+
+	assert.Equal(t, []SyncType{SyncTypeRedirect, SyncTypeIframe}, multi.SupportedSyncTypes(), "The bidder's own sync type should be preferred")
+	assert.Equal(t, "adnxs", multi.FamilyName())
+	assert.EqualValues(t, 32, multi.GDPRVendorID())
+
+	syncInfo, err := multi.GetUsersyncInfo("1", "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw")
+	assert.NoError(t, err)
+	assert.Equal(t, "//redirect.com?gdpr=1", syncInfo.URL)
+	assert.Equal(t, "redirect", syncInfo.Type)
+
+	syncInfo, err = multi.GetUsersyncInfoForType(SyncTypeIframe, "1", "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw")
+	assert.NoError(t, err)
+	assert.Equal(t, "//iframe.com?consent=BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw", syncInfo.URL)
+	assert.Equal(t, "iframe", syncInfo.Type)
+}
+
+func TestWithSyncURLsOverride(t *testing.T) {
+	syncer := NewSyncer("adnxs", 32, template.Must(template.New("sync").Parse("//old.com")), SyncTypeRedirect)
+	multi := WithSyncURLs(syncer, map[SyncType]*template.Template{
+		SyncTypeRedirect: template.Must(template.New("sync").Parse("//new.com")),
+	})
+
+	assert.Equal(t, []SyncType{SyncTypeRedirect}, multi.SupportedSyncTypes())
+	syncInfo, err := multi.GetUsersyncInfo("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "//new.com", syncInfo.URL)
+
+	assert.True(t, WithSyncURLs(syncer, nil) == syncer, "No extra URLs should leave the syncer alone")
+}
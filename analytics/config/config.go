@@ -1,15 +1,21 @@
 package config
 
 import (
+	"net/http"
+
 	"github.com/golang/glog"
 	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/analytics/filesystem"
+	"github.com/prebid/prebid-server/analytics/httplogs"
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/pbsmetrics"
 )
 
 //Modules that need to be logged to need to be initialized here
-func NewPBSAnalytics(analytics *config.Analytics) analytics.PBSAnalyticsModule {
+//The returned function should be called on shutdown, so that buffering modules can send what they're holding
+func NewPBSAnalytics(analytics *config.Analytics, client *http.Client, metricsEngine pbsmetrics.MetricsEngine) (analytics.PBSAnalyticsModule, func()) {
 	modules := make(enabledAnalytics, 0)
+	var shutdowns []func()
 	if len(analytics.File.Filename) > 0 {
 		if mod, err := filesystem.NewFileLogger(analytics.File.Filename); err == nil {
 			modules = append(modules, mod)
@@ -17,7 +23,16 @@ func NewPBSAnalytics(analytics *config.Analytics) analytics.PBSAnalyticsModule {
 			glog.Fatalf("Could not initialize FileLogger for file %v :%v", analytics.File.Filename, err)
 		}
 	}
-	return modules
+	if len(analytics.HTTP.Endpoint) > 0 {
+		mod := httplogs.NewHTTPLogger(client, analytics.HTTP, metricsEngine)
+		modules = append(modules, mod)
+		shutdowns = append(shutdowns, mod.Shutdown)
+	}
+	return modules, func() {
+		for _, shutdown := range shutdowns {
+			shutdown()
+		}
+	}
 }
 
 //Collection of all the correctly configured analytics modules - implements the PBSAnalyticsModule interface
@@ -29,6 +44,12 @@ func (ea enabledAnalytics) LogAuctionObject(ao *analytics.AuctionObject) {
 	}
 }
 
+func (ea enabledAnalytics) LogVideoObject(vo *analytics.VideoObject) {
+	for _, module := range ea {
+		module.LogVideoObject(vo)
+	}
+}
+
 func (ea enabledAnalytics) LogCookieSyncObject(cso *analytics.CookieSyncObject) {
 	for _, module := range ea {
 		module.LogCookieSyncObject(cso)
@@ -46,3 +67,9 @@ func (ea enabledAnalytics) LogAmpObject(ao *analytics.AmpObject) {
 		module.LogAmpObject(ao)
 	}
 }
+
+func (ea enabledAnalytics) LogNotificationEventObject(ne *analytics.NotificationEvent) {
+	for _, module := range ea {
+		module.LogNotificationEventObject(ne)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
+	metricsConf "github.com/prebid/prebid-server/pbsmetrics/config"
 )
 
 const TEST_DIR string = "testFiles"
@@ -45,6 +46,16 @@ func TestSampleModule(t *testing.T) {
 	if count != 4 {
 		t.Errorf("PBSAnalyticsModule failed at LogAmpObject")
 	}
+
+	am.LogNotificationEventObject(&analytics.NotificationEvent{})
+	if count != 5 {
+		t.Errorf("PBSAnalyticsModule failed at LogNotificationEventObject")
+	}
+
+	am.LogVideoObject(&analytics.VideoObject{})
+	if count != 6 {
+		t.Errorf("PBSAnalyticsModule failed at LogVideoObject")
+	}
 }
 
 type sampleModule struct {
@@ -53,12 +64,16 @@ type sampleModule struct {
 
 func (m *sampleModule) LogAuctionObject(ao *analytics.AuctionObject) { *m.count++ }
 
+func (m *sampleModule) LogVideoObject(vo *analytics.VideoObject) { *m.count++ }
+
 func (m *sampleModule) LogCookieSyncObject(cso *analytics.CookieSyncObject) { *m.count++ }
 
 func (m *sampleModule) LogSetUIDObject(so *analytics.SetUIDObject) { *m.count++ }
 
 func (m *sampleModule) LogAmpObject(ao *analytics.AmpObject) { *m.count++ }
 
+func (m *sampleModule) LogNotificationEventObject(ne *analytics.NotificationEvent) { *m.count++ }
+
 func initAnalytics(count *int) analytics.PBSAnalyticsModule {
 	modules := make(enabledAnalytics, 0)
 	modules = append(modules, &sampleModule{count})
@@ -72,7 +87,8 @@ func TestNewPBSAnalytics(t *testing.T) {
 		}
 	}
 	defer os.RemoveAll(TEST_DIR)
-	mod := NewPBSAnalytics(&config.Analytics{File: config.FileLogs{Filename: TEST_DIR + "/test"}})
+	mod, shutdown := NewPBSAnalytics(&config.Analytics{File: config.FileLogs{Filename: TEST_DIR + "/test"}}, http.DefaultClient, &metricsConf.DummyMetricsEngine{})
+	defer shutdown()
 	switch modType := mod.(type) {
 	case enabledAnalytics:
 		if len(enabledAnalytics(modType)) != 1 {
@@ -82,3 +98,17 @@ func TestNewPBSAnalytics(t *testing.T) {
 		t.Fatalf("Failed to initialize analytics module")
 	}
 }
+
+func TestNewPBSAnalyticsHTTP(t *testing.T) {
+	mod, shutdown := NewPBSAnalytics(&config.Analytics{HTTP: config.HTTPLogs{
+		Endpoint:        "http://localhost:1",
+		BufferSize:      10,
+		MaxBatchEvents:  10,
+		MaxBatchBytes:   1000,
+		FlushIntervalMS: 1000,
+	}}, http.DefaultClient, &metricsConf.DummyMetricsEngine{})
+	defer shutdown()
+	if modules, ok := mod.(enabledAnalytics); !ok || len(modules) != 1 {
+		t.Fatalf("Failed to add the HTTP analytics module")
+	}
+}
@@ -2,6 +2,8 @@ package analytics
 
 import (
 	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/usersync"
 )
 
@@ -9,23 +11,39 @@ import (
   	PBSAnalyticsModule must be implemented by any analytics module that does transactional logging.
 
 	New modules can use the /analytics/endpoint_data_objects, extract the
-	information required and are responsible for handling all their logging activities inside LogAuctionObject, LogAmpObject
-	LogCookieSyncObject and LogSetUIDObject method implementations.
+	information required and are responsible for handling all their logging activities inside LogAuctionObject, LogVideoObject,
+	LogAmpObject, LogCookieSyncObject, LogSetUIDObject and LogNotificationEventObject method implementations.
 */
 
 type PBSAnalyticsModule interface {
 	LogAuctionObject(*AuctionObject)
+	LogVideoObject(*VideoObject)
 	LogCookieSyncObject(*CookieSyncObject)
 	LogSetUIDObject(*SetUIDObject)
 	LogAmpObject(*AmpObject)
+	LogNotificationEventObject(*NotificationEvent)
 }
 
 //Loggable object of a transaction at /openrtb2/auction endpoint
 type AuctionObject struct {
-	Status   int
-	Errors   []error
-	Request  *openrtb.BidRequest
-	Response *openrtb.BidResponse
+	Status    int
+	Errors    []error
+	Request   *openrtb.BidRequest
+	Response  *openrtb.BidResponse
+	AccountID string
+	Details   AuctionDetails
+}
+
+//Loggable object of a transaction at /openrtb2/video endpoint
+type VideoObject struct {
+	Status        int
+	Errors        []error
+	Request       *openrtb.BidRequest
+	Response      *openrtb.BidResponse
+	VideoRequest  *openrtb_ext.BidRequestVideo
+	VideoResponse *openrtb_ext.BidResponseVideo
+	AccountID     string
+	Details       AuctionDetails
 }
 
 //Loggable object of a transaction at /openrtb2/amp endpoint
@@ -36,8 +54,58 @@ type AmpObject struct {
 	AuctionResponse    *openrtb.BidResponse
 	AmpTargetingValues map[string]string
 	Origin             string
+	AccountID          string
+	Details            AuctionDetails
+}
+
+// AuctionDetails describes what each bidder did in an auction. The exchange fills it in while it runs the auction.
+type AuctionDetails struct {
+	Bidders map[openrtb_ext.BidderName]*BidderDetails `json:"bidders,omitempty"`
+	// StoredRequestVersions is filled in by the endpoint before the auction, if it used versioned Stored Requests or Imps.
+	StoredRequestVersions *openrtb_ext.ExtStoredRequestVersions `json:"stored_request_versions,omitempty"`
+	// StoredRequestConflicts is filled in by the endpoint before the auction. It lists the places where a deep merge
+	// replaced stored values with different ones from the HTTP request.
+	StoredRequestConflicts []string `json:"stored_request_conflicts,omitempty"`
+	// StoredRequestLineage is filled in by the endpoint before the auction, if it used Stored Requests or Imps which
+	// inherit from others.
+	StoredRequestLineage *openrtb_ext.ExtStoredRequestLineage `json:"stored_request_lineage,omitempty"`
 }
 
+// BidderDetails describes what one bidder did in an auction.
+type BidderDetails struct {
+	// RequestSent is false for bidders whose bids came from a stored auction response.
+	RequestSent        bool                         `json:"request_sent"`
+	ResponseTimeMillis int                          `json:"response_time_ms,omitempty"`
+	TimedOut           bool                         `json:"timed_out,omitempty"`
+	Errors             []openrtb_ext.ExtBidderError `json:"errors,omitempty"`
+	Bids               []*BidDetails                `json:"bids,omitempty"`
+}
+
+// BidDetails describes a bid, and what happened to it in the auction.
+type BidDetails struct {
+	Bid    *openrtb.Bid        `json:"bid"`
+	Type   openrtb_ext.BidType `json:"type"`
+	Status BidStatus           `json:"status"`
+}
+
+// BidStatus is the outcome of a bid in the auction.
+type BidStatus string
+
+const (
+	// BidWon is the highest bid for its imp.
+	BidWon BidStatus = "won"
+	// BidLost was outbid by another bid for its imp.
+	BidLost BidStatus = "lost"
+	// BidRejectedBelowFloor was below the price floor for its imp.
+	BidRejectedBelowFloor BidStatus = "rejected_below_floor"
+	// BidRejectedCategory had no single IAB category, or one which couldn't be mapped to the ad server's categories.
+	BidRejectedCategory BidStatus = "rejected_category"
+	// BidRejectedDuration was longer than every duration in ext.prebid.targeting.durationrangesec.
+	BidRejectedDuration BidStatus = "rejected_duration"
+	// BidRejectedDuplicate had the same price, category and duration as another bid, which was kept instead.
+	BidRejectedDuplicate BidStatus = "rejected_duplicate"
+)
+
 //Loggable object of a transaction at /setuid
 type SetUIDObject struct {
 	Status  int
@@ -45,6 +113,8 @@ type SetUIDObject struct {
 	UID     string
 	Errors  []error
 	Success bool
+	// Evicted lists the families whose UIDs were dropped from the uids cookie to keep it under the max size.
+	Evicted []string
 }
 
 //Loggable object of a transaction at /cookie_sync
@@ -53,3 +123,9 @@ type CookieSyncObject struct {
 	Errors       []error
 	BidderStatus []*usersync.CookieSyncBidders
 }
+
+//Loggable object of a transaction at /event
+type NotificationEvent struct {
+	Request *EventRequest   `json:"request"`
+	Account *config.Account `json:"account"`
+}
@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// EventType is the kind of notification sent to the /event endpoint.
+type EventType string
+
+const (
+	// Win is sent when a bid wins the ad server auction.
+	Win EventType = "win"
+	// Imp is sent when the winning creative renders.
+	Imp EventType = "imp"
+)
+
+// ResponseFormat picks what the /event endpoint sends back.
+type ResponseFormat string
+
+const (
+	// Image returns a 1x1 transparent pixel.
+	Image ResponseFormat = "i"
+	// Blank returns an empty 204 response.
+	Blank ResponseFormat = "b"
+)
+
+// Query parameters understood by the /event endpoint. They're short to keep the event URLs small.
+const (
+	TypeParameter      = "t"
+	BidIDParameter     = "b"
+	AccountIDParameter = "a"
+	BidderParameter    = "bidder"
+	TimestampParameter = "ts"
+	FormatParameter    = "f"
+)
+
+// EventRequest holds the data sent to the /event endpoint.
+type EventRequest struct {
+	Type      EventType      `json:"type"`
+	BidID     string         `json:"bidid"`
+	AccountID string         `json:"account_id"`
+	Bidder    string         `json:"bidder,omitempty"`
+	Timestamp int64          `json:"timestamp,omitempty"`
+	Format    ResponseFormat `json:"format,omitempty"`
+}
+
+// EventURL builds the /event URL which reports the request.
+func EventURL(externalURL string, req *EventRequest) string {
+	params := url.Values{}
+	params.Set(TypeParameter, string(req.Type))
+	params.Set(BidIDParameter, req.BidID)
+	params.Set(AccountIDParameter, req.AccountID)
+	if req.Bidder != "" {
+		params.Set(BidderParameter, req.Bidder)
+	}
+	if req.Timestamp > 0 {
+		params.Set(TimestampParameter, strconv.FormatInt(req.Timestamp, 10))
+	}
+	if req.Format != "" {
+		params.Set(FormatParameter, string(req.Format))
+	}
+	return externalURL + "/event?" + params.Encode()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/usersync"
 )
 
@@ -34,6 +35,21 @@ func TestAuctionObject_ToJson(t *testing.T) {
 	}
 }
 
+func TestVideoObject_ToJson(t *testing.T) {
+	vo := &analytics.VideoObject{
+		Status:        http.StatusOK,
+		VideoResponse: &openrtb_ext.BidResponseVideo{},
+		Details: analytics.AuctionDetails{
+			Bidders: map[openrtb_ext.BidderName]*analytics.BidderDetails{
+				openrtb_ext.BidderAppnexus: {RequestSent: true, Bids: []*analytics.BidDetails{{Bid: &openrtb.Bid{ID: "bid-id"}, Status: analytics.BidWon}}},
+			},
+		},
+	}
+	if voJson := jsonifyVideoObject(vo); strings.Contains(voJson, "Transactional Logs Error") {
+		t.Fatalf("VideoObject failed to convert to json")
+	}
+}
+
 func TestSetUIDObject_ToJson(t *testing.T) {
 	so := &analytics.SetUIDObject{
 		Status: http.StatusOK,
@@ -55,6 +71,19 @@ func TestCookieSyncObject_ToJson(t *testing.T) {
 	}
 }
 
+func TestNotificationEventObject_ToJson(t *testing.T) {
+	ne := &analytics.NotificationEvent{
+		Request: &analytics.EventRequest{
+			Type:      analytics.Win,
+			BidID:     "bid-id",
+			AccountID: "account-id",
+		},
+	}
+	if neJson := jsonifyNotificationEventObject(ne); strings.Contains(neJson, "Transactional Logs Error") {
+		t.Fatalf("NotificationEvent failed to convert to json")
+	}
+}
+
 func TestFileLogger_LogObjects(t *testing.T) {
 	if _, err := os.Stat(TEST_DIR); os.IsNotExist(err) {
 		if err = os.MkdirAll(TEST_DIR, 0755); err != nil {
@@ -64,9 +93,11 @@ func TestFileLogger_LogObjects(t *testing.T) {
 	defer os.RemoveAll(TEST_DIR)
 	if fl, err := NewFileLogger(TEST_DIR + "//test"); err == nil {
 		fl.LogAuctionObject(&analytics.AuctionObject{})
+		fl.LogVideoObject(&analytics.VideoObject{})
 		fl.LogAmpObject(&analytics.AmpObject{})
 		fl.LogSetUIDObject(&analytics.SetUIDObject{})
 		fl.LogCookieSyncObject(&analytics.CookieSyncObject{})
+		fl.LogNotificationEventObject(&analytics.NotificationEvent{})
 	} else {
 		t.Fatalf("Couldn't initialize file logger: %v", err)
 	}
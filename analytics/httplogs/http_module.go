@@ -0,0 +1,371 @@
+package httplogs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/usersync"
+)
+
+type RequestType string
+
+const (
+	COOKIE_SYNC        RequestType = "/cookie_sync"
+	AUCTION            RequestType = "/openrtb2/auction"
+	VIDEO              RequestType = "/openrtb2/video"
+	SETUID             RequestType = "/set_uid"
+	AMP                RequestType = "/openrtb2/amp"
+	NOTIFICATION_EVENT RequestType = "/event"
+)
+
+// HTTPLogger is an analytics module which buffers events, and POSTs them in batches to an HTTP endpoint.
+//
+// Each batch is a gzipped JSON array of events. A batch is sent once it reaches the configured number of events
+// or bytes, or on each tick of the flush interval. Only one batch is built or sent at a time, so events which arrive
+// while the buffer is full are dropped rather than using more memory.
+type HTTPLogger struct {
+	client        *http.Client
+	endpoint      string
+	timeout       time.Duration
+	maxEvents     int
+	maxBytes      int
+	flushInterval time.Duration
+	metrics       pbsmetrics.MetricsEngine
+
+	events    chan json.RawMessage
+	closing   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewHTTPLogger returns an HTTPLogger which has started sending events to cfg.Endpoint.
+// Call Shutdown to send the events which are still buffered.
+func NewHTTPLogger(client *http.Client, cfg config.HTTPLogs, metrics pbsmetrics.MetricsEngine) *HTTPLogger {
+	logger := newHTTPLogger(client, cfg, metrics)
+	go logger.run()
+	return logger
+}
+
+func newHTTPLogger(client *http.Client, cfg config.HTTPLogs, metrics pbsmetrics.MetricsEngine) *HTTPLogger {
+	return &HTTPLogger{
+		client:        client,
+		endpoint:      cfg.Endpoint,
+		timeout:       time.Duration(cfg.TimeoutMS) * time.Millisecond,
+		maxEvents:     cfg.MaxBatchEvents,
+		maxBytes:      cfg.MaxBatchBytes,
+		flushInterval: time.Duration(cfg.FlushIntervalMS) * time.Millisecond,
+		metrics:       metrics,
+		events:        make(chan json.RawMessage, cfg.BufferSize),
+		closing:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Buffers AuctionObject
+func (l *HTTPLogger) LogAuctionObject(ao *analytics.AuctionObject) {
+	if ao == nil {
+		return
+	}
+	l.log(&auctionEvent{
+		Type:      AUCTION,
+		Status:    ao.Status,
+		Errors:    errorStrings(ao.Errors),
+		Request:   ao.Request,
+		Response:  ao.Response,
+		AccountID: ao.AccountID,
+		Details:   ao.Details,
+	})
+}
+
+// Buffers VideoObject
+func (l *HTTPLogger) LogVideoObject(vo *analytics.VideoObject) {
+	if vo == nil {
+		return
+	}
+	l.log(&videoEvent{
+		Type:          VIDEO,
+		Status:        vo.Status,
+		Errors:        errorStrings(vo.Errors),
+		Request:       vo.Request,
+		Response:      vo.Response,
+		VideoRequest:  vo.VideoRequest,
+		VideoResponse: vo.VideoResponse,
+		AccountID:     vo.AccountID,
+		Details:       vo.Details,
+	})
+}
+
+// Buffers SetUIDObject
+func (l *HTTPLogger) LogSetUIDObject(so *analytics.SetUIDObject) {
+	if so == nil {
+		return
+	}
+	l.log(&setUIDEvent{
+		Type:    SETUID,
+		Status:  so.Status,
+		Errors:  errorStrings(so.Errors),
+		Bidder:  so.Bidder,
+		UID:     so.UID,
+		Success: so.Success,
+		Evicted: so.Evicted,
+	})
+}
+
+// Buffers CookieSyncObject
+func (l *HTTPLogger) LogCookieSyncObject(cso *analytics.CookieSyncObject) {
+	if cso == nil {
+		return
+	}
+	l.log(&cookieSyncEvent{
+		Type:         COOKIE_SYNC,
+		Status:       cso.Status,
+		Errors:       errorStrings(cso.Errors),
+		BidderStatus: cso.BidderStatus,
+	})
+}
+
+// Buffers AmpObject
+func (l *HTTPLogger) LogAmpObject(ao *analytics.AmpObject) {
+	if ao == nil {
+		return
+	}
+	l.log(&ampEvent{
+		Type:               AMP,
+		Status:             ao.Status,
+		Errors:             errorStrings(ao.Errors),
+		Request:            ao.Request,
+		AuctionResponse:    ao.AuctionResponse,
+		AmpTargetingValues: ao.AmpTargetingValues,
+		Origin:             ao.Origin,
+		AccountID:          ao.AccountID,
+		Details:            ao.Details,
+	})
+}
+
+// Buffers NotificationEvent
+func (l *HTTPLogger) LogNotificationEventObject(ne *analytics.NotificationEvent) {
+	if ne == nil {
+		return
+	}
+	l.log(&notificationEvent{
+		Type:    NOTIFICATION_EVENT,
+		Request: ne.Request,
+		Account: ne.Account,
+	})
+}
+
+// Shutdown stops the logger, after sending the events which are still buffered.
+// Events which are logged after Shutdown has been called are dropped.
+func (l *HTTPLogger) Shutdown() {
+	l.closeOnce.Do(func() {
+		close(l.closing)
+	})
+	<-l.done
+}
+
+// log adds the event to the buffer, or drops it if the buffer is full or the logger has been shut down
+func (l *HTTPLogger) log(event interface{}) {
+	select {
+	case <-l.closing:
+		l.metrics.RecordAnalyticsEventsDropped(pbsmetrics.AnalyticsShutdown, 1)
+		return
+	default:
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		glog.Errorf("Failed to marshal analytics event: %v", err)
+		return
+	}
+	select {
+	case l.events <- data:
+	default:
+		l.metrics.RecordAnalyticsEventsDropped(pbsmetrics.AnalyticsBufferFull, 1)
+	}
+}
+
+// run builds batches from the buffered events, and sends them until the logger is shut down.
+func (l *HTTPLogger) run() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	b := &batch{}
+	for {
+		select {
+		case event := <-l.events:
+			l.add(b, event)
+		case <-ticker.C:
+			l.flush(b)
+		case <-l.closing:
+			for {
+				select {
+				case event := <-l.events:
+					l.add(b, event)
+				default:
+					l.flush(b)
+					return
+				}
+			}
+		}
+	}
+}
+
+// batch holds the events which will be sent in the next request
+type batch struct {
+	events []json.RawMessage
+	bytes  int
+}
+
+func (l *HTTPLogger) add(b *batch, event json.RawMessage) {
+	if len(b.events) > 0 && b.bytes+len(event) > l.maxBytes {
+		l.flush(b)
+	}
+	b.events = append(b.events, event)
+	b.bytes += len(event)
+	if len(b.events) >= l.maxEvents || b.bytes >= l.maxBytes {
+		l.flush(b)
+	}
+}
+
+func (l *HTTPLogger) flush(b *batch) {
+	if len(b.events) == 0 {
+		return
+	}
+	if err := l.send(b.events); err != nil {
+		glog.Errorf("Failed to send %d analytics events to %s: %v", len(b.events), l.endpoint, err)
+		l.metrics.RecordAnalyticsEventsDropped(pbsmetrics.AnalyticsSendFailed, len(b.events))
+	}
+	b.events = b.events[:0]
+	b.bytes = 0
+}
+
+// send POSTs the events to the endpoint as a gzipped JSON array
+func (l *HTTPLogger) send(events []json.RawMessage) error {
+	body, err := encodeEvents(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", l.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	ctx := context.Background()
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	resp, err := l.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func encodeEvents(events []json.RawMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("["))
+	for i, event := range events {
+		if i > 0 {
+			zw.Write([]byte(","))
+		}
+		zw.Write(event)
+	}
+	zw.Write([]byte("]"))
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func errorStrings(errs []error) []string {
+	if len(errs) == 0 {
+		return nil
+	}
+	strs := make([]string, len(errs))
+	for i, err := range errs {
+		strs[i] = err.Error()
+	}
+	return strs
+}
+
+type auctionEvent struct {
+	Type      RequestType              `json:"type"`
+	Status    int                      `json:"status"`
+	Errors    []string                 `json:"errors,omitempty"`
+	Request   *openrtb.BidRequest      `json:"request,omitempty"`
+	Response  *openrtb.BidResponse     `json:"response,omitempty"`
+	AccountID string                   `json:"account_id,omitempty"`
+	Details   analytics.AuctionDetails `json:"details"`
+}
+
+type videoEvent struct {
+	Type          RequestType                   `json:"type"`
+	Status        int                           `json:"status"`
+	Errors        []string                      `json:"errors,omitempty"`
+	Request       *openrtb.BidRequest           `json:"request,omitempty"`
+	Response      *openrtb.BidResponse          `json:"response,omitempty"`
+	VideoRequest  *openrtb_ext.BidRequestVideo  `json:"video_request,omitempty"`
+	VideoResponse *openrtb_ext.BidResponseVideo `json:"video_response,omitempty"`
+	AccountID     string                        `json:"account_id,omitempty"`
+	Details       analytics.AuctionDetails      `json:"details"`
+}
+
+type ampEvent struct {
+	Type               RequestType              `json:"type"`
+	Status             int                      `json:"status"`
+	Errors             []string                 `json:"errors,omitempty"`
+	Request            *openrtb.BidRequest      `json:"request,omitempty"`
+	AuctionResponse    *openrtb.BidResponse     `json:"auction_response,omitempty"`
+	AmpTargetingValues map[string]string        `json:"amp_targeting_values,omitempty"`
+	Origin             string                   `json:"origin,omitempty"`
+	AccountID          string                   `json:"account_id,omitempty"`
+	Details            analytics.AuctionDetails `json:"details"`
+}
+
+type setUIDEvent struct {
+	Type    RequestType `json:"type"`
+	Status  int         `json:"status"`
+	Errors  []string    `json:"errors,omitempty"`
+	Bidder  string      `json:"bidder"`
+	UID     string      `json:"uid"`
+	Success bool        `json:"success"`
+	Evicted []string    `json:"evicted,omitempty"`
+}
+
+type cookieSyncEvent struct {
+	Type         RequestType                   `json:"type"`
+	Status       int                           `json:"status"`
+	Errors       []string                      `json:"errors,omitempty"`
+	BidderStatus []*usersync.CookieSyncBidders `json:"bidder_status,omitempty"`
+}
+
+type notificationEvent struct {
+	Type    RequestType             `json:"type"`
+	Request *analytics.EventRequest `json:"request"`
+	Account *config.Account         `json:"account,omitempty"`
+}
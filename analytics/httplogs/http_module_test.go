@@ -0,0 +1,220 @@
+package httplogs
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// batchServer records the batches of events it receives
+type batchServer struct {
+	mutex   sync.Mutex
+	batches [][]map[string]interface{}
+	status  int
+}
+
+func (s *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var batch []map[string]interface{}
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		if zr, err := gzip.NewReader(r.Body); err == nil {
+			json.NewDecoder(zr).Decode(&batch)
+		}
+	}
+	s.mutex.Lock()
+	s.batches = append(s.batches, batch)
+	s.mutex.Unlock()
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+	}
+}
+
+func (s *batchServer) received() [][]map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.batches
+}
+
+// waitFor returns true once the server has received the given number of batches, or false if it takes too long
+func (s *batchServer) waitFor(batches int) bool {
+	for i := 0; i < 100; i++ {
+		if len(s.received()) >= batches {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func testConfig(endpoint string) config.HTTPLogs {
+	return config.HTTPLogs{
+		Endpoint:        endpoint,
+		BufferSize:      100,
+		MaxBatchEvents:  100,
+		MaxBatchBytes:   100000,
+		FlushIntervalMS: 60000,
+		TimeoutMS:       1000,
+	}
+}
+
+func TestFlushOnBatchSize(t *testing.T) {
+	handler := &batchServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cfg := testConfig(server.URL)
+	cfg.MaxBatchEvents = 2
+	logger := NewHTTPLogger(server.Client(), cfg, &pbsmetrics.MetricsEngineMock{})
+
+	logger.LogAuctionObject(&analytics.AuctionObject{Status: http.StatusOK, Errors: []error{errors.New("auction error")}, Request: &openrtb.BidRequest{ID: "req-id"}})
+	logger.LogSetUIDObject(&analytics.SetUIDObject{Status: http.StatusOK, Bidder: "appnexus", UID: "uid", Success: true})
+	logger.LogCookieSyncObject(&analytics.CookieSyncObject{Status: http.StatusOK})
+
+	assert.True(t, handler.waitFor(1), "The first two events should be sent as soon as the batch is full")
+
+	logger.Shutdown()
+
+	batches := handler.received()
+	if assert.Len(t, batches, 2, "The last event should be sent on shutdown") {
+		if assert.Len(t, batches[0], 2) {
+			assert.Equal(t, string(AUCTION), batches[0][0]["type"])
+			assert.Equal(t, []interface{}{"auction error"}, batches[0][0]["errors"])
+			assert.Equal(t, string(SETUID), batches[0][1]["type"])
+		}
+		if assert.Len(t, batches[1], 1) {
+			assert.Equal(t, string(COOKIE_SYNC), batches[1][0]["type"])
+		}
+	}
+}
+
+func TestAuctionDetails(t *testing.T) {
+	handler := &batchServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	logger := NewHTTPLogger(server.Client(), testConfig(server.URL), &pbsmetrics.MetricsEngineMock{})
+	logger.LogVideoObject(&analytics.VideoObject{
+		Status:    http.StatusOK,
+		AccountID: "acct",
+		Details: analytics.AuctionDetails{
+			Bidders: map[openrtb_ext.BidderName]*analytics.BidderDetails{
+				openrtb_ext.BidderAppnexus: {
+					RequestSent:        true,
+					ResponseTimeMillis: 20,
+					Bids:               []*analytics.BidDetails{{Bid: &openrtb.Bid{ID: "bid-id"}, Type: openrtb_ext.BidTypeVideo, Status: analytics.BidRejectedDuration}},
+				},
+			},
+		},
+	})
+	logger.Shutdown()
+
+	batches := handler.received()
+	if assert.Len(t, batches, 1) && assert.Len(t, batches[0], 1) {
+		event := batches[0][0]
+		assert.Equal(t, string(VIDEO), event["type"])
+		assert.Equal(t, "acct", event["account_id"])
+		appnexus := event["details"].(map[string]interface{})["bidders"].(map[string]interface{})["appnexus"].(map[string]interface{})
+		assert.Equal(t, true, appnexus["request_sent"])
+		assert.Equal(t, float64(20), appnexus["response_time_ms"])
+		bid := appnexus["bids"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, string(analytics.BidRejectedDuration), bid["status"])
+		assert.Equal(t, "bid-id", bid["bid"].(map[string]interface{})["id"])
+	}
+}
+
+func TestFlushOnBatchBytes(t *testing.T) {
+	handler := &batchServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cfg := testConfig(server.URL)
+	cfg.MaxBatchBytes = 60
+	logger := NewHTTPLogger(server.Client(), cfg, &pbsmetrics.MetricsEngineMock{})
+
+	logger.LogAmpObject(&analytics.AmpObject{Status: http.StatusOK, Origin: "http://publisher.com"})
+	logger.LogAmpObject(&analytics.AmpObject{Status: http.StatusOK, Origin: "http://publisher.com"})
+	logger.Shutdown()
+
+	batches := handler.received()
+	if assert.Len(t, batches, 2, "Events which would overflow the batch should start a new one") {
+		assert.Len(t, batches[0], 1)
+		assert.Len(t, batches[1], 1)
+	}
+}
+
+func TestFlushOnInterval(t *testing.T) {
+	handler := &batchServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cfg := testConfig(server.URL)
+	cfg.FlushIntervalMS = 10
+	logger := NewHTTPLogger(server.Client(), cfg, &pbsmetrics.MetricsEngineMock{})
+	defer logger.Shutdown()
+
+	logger.LogNotificationEventObject(&analytics.NotificationEvent{Request: &analytics.EventRequest{Type: analytics.Win, BidID: "bid-id", AccountID: "acct"}})
+
+	assert.True(t, handler.waitFor(1), "The event should be sent once the interval passes")
+}
+
+func TestDropWhenBufferFull(t *testing.T) {
+	metrics := &pbsmetrics.MetricsEngineMock{}
+	metrics.On("RecordAnalyticsEventsDropped", pbsmetrics.AnalyticsBufferFull, 1).Return()
+
+	cfg := testConfig("http://localhost")
+	cfg.BufferSize = 1
+	// Don't start the logger, so that nothing empties the buffer
+	logger := newHTTPLogger(http.DefaultClient, cfg, metrics)
+
+	logger.LogSetUIDObject(&analytics.SetUIDObject{Bidder: "appnexus"})
+	logger.LogSetUIDObject(&analytics.SetUIDObject{Bidder: "rubicon"})
+
+	assert.Len(t, logger.events, 1)
+	metrics.AssertNumberOfCalls(t, "RecordAnalyticsEventsDropped", 1)
+}
+
+func TestDropWhenSendFails(t *testing.T) {
+	handler := &batchServer{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	metrics := &pbsmetrics.MetricsEngineMock{}
+	metrics.On("RecordAnalyticsEventsDropped", mock.Anything, mock.Anything).Return()
+
+	logger := NewHTTPLogger(server.Client(), testConfig(server.URL), metrics)
+	logger.LogSetUIDObject(&analytics.SetUIDObject{Bidder: "appnexus"})
+	logger.LogSetUIDObject(&analytics.SetUIDObject{Bidder: "rubicon"})
+	logger.Shutdown()
+
+	assert.Len(t, handler.received(), 1)
+	metrics.AssertCalled(t, "RecordAnalyticsEventsDropped", pbsmetrics.AnalyticsSendFailed, 2)
+}
+
+func TestLogAfterShutdown(t *testing.T) {
+	handler := &batchServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	metrics := &pbsmetrics.MetricsEngineMock{}
+	metrics.On("RecordAnalyticsEventsDropped", pbsmetrics.AnalyticsShutdown, 1).Return()
+
+	logger := NewHTTPLogger(server.Client(), testConfig(server.URL), metrics)
+	logger.Shutdown()
+	logger.LogSetUIDObject(&analytics.SetUIDObject{Bidder: "appnexus"})
+	logger.Shutdown()
+
+	assert.Empty(t, handler.received())
+	metrics.AssertNumberOfCalls(t, "RecordAnalyticsEventsDropped", 1)
+}
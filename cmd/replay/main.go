@@ -0,0 +1,71 @@
+// Replay sends captured auction requests to one or two Prebid Servers, and reports how they did.
+//
+// Usage:
+//
+//	replay -requests auctions.log http://localhost:8000 [http://localhost:8001]
+//	replay -requests auctions.log before.yaml [after.yaml]
+//
+// The requests can be logged by the analytics/filesystem module, or be bare OpenRTB requests, one per line.
+// URL targets get the requests at /openrtb2/auction, unless the URL has another path. Other targets are
+// PBS config files. Their auctions run in process, with the bidders' servers stubbed out.
+//
+// With two targets, the report ends with the changes in bid rates, latencies and errors from the first to the second.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+func main() {
+	requestsFile := flag.String("requests", "", "File with one auction request per line.")
+	concurrency := flag.Int("concurrency", 10, "How many auctions to run at a time.")
+	timeout := flag.Duration("timeout", 2*time.Second, "How long to wait for each auction.")
+	infoDir := flag.String("bidder-info", "./static/bidder-info", "Directory with the bidder info files, for config file targets.")
+	stubPrice := flag.Float64("stub-price", 1, "CPM of the stubbed bidders' bids, for config file targets.")
+	stubLatency := flag.Duration("stub-latency", 50*time.Millisecond, "Response time of the stubbed bidders, for config file targets.")
+	flag.Parse()
+
+	if *requestsFile == "" || flag.NArg() < 1 || flag.NArg() > 2 {
+		fmt.Fprintln(os.Stderr, "usage: replay -requests FILE TARGET [TARGET]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(*requestsFile)
+	if err != nil {
+		glog.Fatalf("Failed to open %s: %v", *requestsFile, err)
+	}
+	requests, skipped, err := readRequests(file)
+	file.Close()
+	if err != nil {
+		glog.Fatalf("Failed to read %s: %v", *requestsFile, err)
+	}
+	fmt.Printf("Replaying %d requests. Skipped %d lines without one.\n\n", len(requests), skipped)
+
+	transport := &stubTransport{price: *stubPrice, latency: *stubLatency}
+	runs := make([]*runStats, 0, flag.NArg())
+	for _, arg := range flag.Args() {
+		var t target
+		if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+			t, err = newHTTPTarget(arg, &http.Client{Timeout: *timeout})
+		} else {
+			t, err = newExchangeTarget(arg, *infoDir, transport)
+		}
+		if err != nil {
+			glog.Fatalf("Bad target %s: %v", arg, err)
+		}
+		stats := replay(t, requests, *concurrency, *timeout)
+		stats.write(os.Stdout)
+		runs = append(runs, stats)
+	}
+	if len(runs) == 2 {
+		writeComparison(os.Stdout, runs[0], runs[1])
+	}
+}
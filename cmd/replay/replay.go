@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+)
+
+type auctionResult struct {
+	latency  time.Duration
+	response *openrtb.BidResponse
+	err      error
+}
+
+// replay runs every request against the target, with up to concurrency auctions at a time.
+func replay(t target, requests []json.RawMessage, concurrency int, timeout time.Duration) *runStats {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	work := make(chan json.RawMessage)
+	results := make(chan auctionResult)
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for request := range work {
+				results <- runAuction(t, request, timeout)
+			}
+		}()
+	}
+	go func() {
+		for _, request := range requests {
+			work <- request
+		}
+		close(work)
+		wg.Wait()
+		close(results)
+	}()
+
+	stats := newRunStats(t.name())
+	for result := range results {
+		stats.add(result.latency, result.response, result.err)
+	}
+	return stats
+}
+
+func runAuction(t target, request json.RawMessage, timeout time.Duration) auctionResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	response, err := t.auction(ctx, request)
+	return auctionResult{
+		latency:  time.Since(start),
+		response: response,
+		err:      err,
+	}
+}
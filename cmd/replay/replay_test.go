@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaceReplay(t *testing.T) {
+	requests := make([]json.RawMessage, 100)
+	for i := range requests {
+		requests[i] = json.RawMessage(`{"id":"req","imp":[{"id":"1"}]}`)
+	}
+	target := &fakeTarget{}
+
+	stats := replay(target, requests, 8, time.Second)
+
+	assert.Equal(t, int64(100), target.calls, "Every request should be replayed once")
+	assert.Equal(t, 100, stats.requests)
+	assert.Equal(t, 50, stats.failed)
+	assert.Equal(t, 50, stats.withBids)
+	assert.Len(t, stats.latencies, 100)
+}
+
+type fakeTarget struct {
+	calls int64
+}
+
+func (t *fakeTarget) name() string {
+	return "fake"
+}
+
+// auction bids on every other call, and fails on the rest.
+func (t *fakeTarget) auction(ctx context.Context, request json.RawMessage) (*openrtb.BidResponse, error) {
+	if atomic.AddInt64(&t.calls, 1)%2 == 0 {
+		return nil, errors.New("no bids")
+	}
+	return &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Seat: "appnexus", Bid: []openrtb.Bid{{ID: "1"}}}}}, nil
+}
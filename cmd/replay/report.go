@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+var percentiles = []float64{50, 90, 95, 99}
+
+// errorCodeNames names the codes which PBS uses in bidresponse.ext.errors.
+var errorCodeNames = map[int]string{
+	errortypes.TimeoutCode:                   "timeout",
+	errortypes.BadInputCode:                  "bad input",
+	errortypes.BlacklistedAppCode:            "blacklisted app",
+	errortypes.BadServerResponseCode:         "bad server response",
+	errortypes.FailedToRequestBidsCode:       "failed to request bids",
+	errortypes.BidderTemporarilyDisabledCode: "bidder temporarily disabled",
+	errortypes.BlacklistedAcctCode:           "blacklisted account",
+	errortypes.AcctRequiredCode:              "account required",
+	errortypes.BidBelowFloorCode:             "bid below floor",
+	errortypes.UnknownErrorCode:              "unknown",
+}
+
+// runStats summarizes the replay of all the requests against one target.
+type runStats struct {
+	target    string
+	requests  int
+	failed    int
+	latencies []time.Duration
+	// errors counts the auctions which failed, and the bidder errors in the ones which didn't, by category.
+	errors map[string]int
+	// withBids counts the auctions which returned at least one bid.
+	withBids int
+	bids     int
+	// bidderBids counts the auctions in which each bidder returned at least one bid.
+	bidderBids map[string]int
+}
+
+func newRunStats(target string) *runStats {
+	return &runStats{
+		target:     target,
+		errors:     make(map[string]int),
+		bidderBids: make(map[string]int),
+	}
+}
+
+// add records the result of one auction.
+func (s *runStats) add(latency time.Duration, response *openrtb.BidResponse, err error) {
+	s.requests++
+	s.latencies = append(s.latencies, latency)
+	if err != nil {
+		s.failed++
+		s.errors[errorCategory(err)]++
+		return
+	}
+
+	bids := 0
+	for _, seatBid := range response.SeatBid {
+		if len(seatBid.Bid) > 0 {
+			s.bidderBids[seatBid.Seat]++
+			bids += len(seatBid.Bid)
+		}
+	}
+	if bids > 0 {
+		s.withBids++
+		s.bids += bids
+	}
+
+	var ext openrtb_ext.ExtBidResponse
+	if len(response.Ext) > 0 && json.Unmarshal(response.Ext, &ext) == nil {
+		for bidder, bidderErrors := range ext.Errors {
+			for _, bidderError := range bidderErrors {
+				s.errors[fmt.Sprintf("%s: %s", bidder, errorCodeName(bidderError.Code))]++
+			}
+		}
+	}
+}
+
+// errorCategory groups the reasons why an auction failed, so that they can be counted.
+func errorCategory(err error) string {
+	if err == context.DeadlineExceeded {
+		return "timeout"
+	}
+	if statusErr, ok := err.(*statusError); ok {
+		return fmt.Sprintf("status %d", statusErr.status)
+	}
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "connection error"
+	}
+	return "other"
+}
+
+func errorCodeName(code int) string {
+	if name, ok := errorCodeNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("code %d", code)
+}
+
+// percentile returns the latency which p percent of the auctions didn't exceed.
+func (s *runStats) percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	} else if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// bidRate returns the share of auctions in which the bidder returned bids. An empty bidder means any bidder.
+func (s *runStats) bidRate(bidder string) float64 {
+	if s.requests == 0 {
+		return 0
+	}
+	if bidder == "" {
+		return float64(s.withBids) / float64(s.requests)
+	}
+	return float64(s.bidderBids[bidder]) / float64(s.requests)
+}
+
+func (s *runStats) write(w io.Writer) {
+	fmt.Fprintf(w, "== %s ==\n", s.target)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "requests\t%d\n", s.requests)
+	fmt.Fprintf(tw, "failed\t%d\n", s.failed)
+	fmt.Fprintf(tw, "bid rate\t%.1f%%\n", 100*s.bidRate(""))
+	if s.requests > 0 {
+		fmt.Fprintf(tw, "bids per request\t%.2f\n", float64(s.bids)/float64(s.requests))
+	}
+	for _, p := range percentiles {
+		fmt.Fprintf(tw, "latency p%g\t%v\n", p, s.percentile(p))
+	}
+	fmt.Fprintf(tw, "latency max\t%v\n", s.percentile(100))
+	tw.Flush()
+
+	if len(s.errors) > 0 {
+		fmt.Fprintln(w, "errors:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, category := range sortedKeys(s.errors) {
+			fmt.Fprintf(tw, "  %s\t%d\n", category, s.errors[category])
+		}
+		tw.Flush()
+	}
+	if len(s.bidderBids) > 0 {
+		fmt.Fprintln(w, "bid rate by bidder:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, bidder := range sortedKeys(s.bidderBids) {
+			fmt.Fprintf(tw, "  %s\t%.1f%%\n", bidder, 100*s.bidRate(bidder))
+		}
+		tw.Flush()
+	}
+	fmt.Fprintln(w)
+}
+
+// writeComparison shows how the second run differs from the first.
+func writeComparison(w io.Writer, a *runStats, b *runStats) {
+	fmt.Fprintf(w, "== %s -> %s ==\n", a.target, b.target)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\tbefore\tafter\tchange\t\n")
+	writeRateRow(tw, "bid rate", a.bidRate(""), b.bidRate(""))
+	for _, p := range percentiles {
+		writeLatencyRow(tw, fmt.Sprintf("latency p%g", p), a.percentile(p), b.percentile(p))
+	}
+	writeCountRow(tw, "failed", a.failed, b.failed)
+
+	bidders := make(map[string]int, len(a.bidderBids)+len(b.bidderBids))
+	for bidder := range a.bidderBids {
+		bidders[bidder]++
+	}
+	for bidder := range b.bidderBids {
+		bidders[bidder]++
+	}
+	for _, bidder := range sortedKeys(bidders) {
+		writeRateRow(tw, bidder+" bid rate", a.bidRate(bidder), b.bidRate(bidder))
+	}
+
+	categories := make(map[string]int, len(a.errors)+len(b.errors))
+	for category := range a.errors {
+		categories[category]++
+	}
+	for category := range b.errors {
+		categories[category]++
+	}
+	for _, category := range sortedKeys(categories) {
+		writeCountRow(tw, category, a.errors[category], b.errors[category])
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+func writeRateRow(w io.Writer, label string, before float64, after float64) {
+	fmt.Fprintf(w, "%s\t%.1f%%\t%.1f%%\t%+.1fpp\t\n", label, 100*before, 100*after, 100*(after-before))
+}
+
+func writeLatencyRow(w io.Writer, label string, before time.Duration, after time.Duration) {
+	change := after - before
+	sign := ""
+	if change >= 0 {
+		sign = "+"
+	}
+	fmt.Fprintf(w, "%s\t%v\t%v\t%s%v\t\n", label, before, after, sign, change)
+}
+
+func writeCountRow(w io.Writer, label string, before int, after int) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%+d\t\n", label, before, after, after-before)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
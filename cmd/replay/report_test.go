@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunStats(t *testing.T) {
+	stats := newRunStats("test")
+	stats.add(10*time.Millisecond, &openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{
+			{Seat: "appnexus", Bid: []openrtb.Bid{{ID: "1"}, {ID: "2"}}},
+			{Seat: "rubicon", Bid: []openrtb.Bid{{ID: "3"}}},
+		},
+	}, nil)
+	stats.add(20*time.Millisecond, &openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{{Seat: "appnexus", Bid: []openrtb.Bid{{ID: "4"}}}},
+		Ext:     []byte(`{"errors":{"rubicon":[{"code":1,"message":"timed out"}],"pubmatic":[{"code":42,"message":"?"}]}}`),
+	}, nil)
+	stats.add(30*time.Millisecond, &openrtb.BidResponse{}, nil)
+	stats.add(40*time.Millisecond, nil, &statusError{status: 400, body: "Invalid request"})
+
+	assert.Equal(t, 4, stats.requests)
+	assert.Equal(t, 1, stats.failed)
+	assert.Equal(t, 0.5, stats.bidRate(""))
+	assert.Equal(t, 0.5, stats.bidRate("appnexus"))
+	assert.Equal(t, 0.25, stats.bidRate("rubicon"))
+	assert.Equal(t, 4, stats.bids)
+	assert.Equal(t, map[string]int{
+		"status 400":        1,
+		"rubicon: timeout":  1,
+		"pubmatic: code 42": 1,
+	}, stats.errors)
+	assert.Equal(t, 20*time.Millisecond, stats.percentile(50))
+	assert.Equal(t, 40*time.Millisecond, stats.percentile(99))
+	assert.Equal(t, 40*time.Millisecond, stats.percentile(100))
+}
+
+func TestErrorCategory(t *testing.T) {
+	assert.Equal(t, "timeout", errorCategory(context.DeadlineExceeded))
+	assert.Equal(t, "status 503", errorCategory(&statusError{status: 503}))
+	assert.Equal(t, "other", errorCategory(errors.New("no bidders")))
+}
+
+func TestWriteComparison(t *testing.T) {
+	before := newRunStats("before.yaml")
+	before.add(10*time.Millisecond, &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Seat: "appnexus", Bid: []openrtb.Bid{{ID: "1"}}}}}, nil)
+	before.add(10*time.Millisecond, &openrtb.BidResponse{}, nil)
+	after := newRunStats("after.yaml")
+	after.add(15*time.Millisecond, &openrtb.BidResponse{}, nil)
+	after.add(15*time.Millisecond, nil, context.DeadlineExceeded)
+
+	var out bytes.Buffer
+	writeComparison(&out, before, after)
+
+	assert.Contains(t, out.String(), "== before.yaml -> after.yaml ==")
+	assert.Regexp(t, `bid rate +50\.0% +0\.0% +-50\.0pp`, out.String())
+	assert.Regexp(t, `appnexus bid rate +50\.0% +0\.0% +-50\.0pp`, out.String())
+	assert.Regexp(t, `latency p50 +10ms +15ms +\+5ms`, out.String())
+	assert.Regexp(t, `timeout +0 +1 +\+1`, out.String())
+}
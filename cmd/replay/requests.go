@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/mxmCherry/openrtb"
+)
+
+// maxLineSize bounds the lines in a replay file. Logged auctions include the response, so they can get big.
+const maxLineSize = 10 * 1024 * 1024
+
+// loggedObject holds the parts of an analytics/filesystem log line which the replay needs.
+type loggedObject struct {
+	Type    string          `json:"type"`
+	Request json.RawMessage `json:"Request"`
+}
+
+// readRequests reads one OpenRTB request per line. Lines may hold the auction, amp or video objects which the
+// analytics/filesystem module logs, or a bare BidRequest. Log prefixes before the JSON are ignored.
+//
+// It returns the requests which it found, and the number of lines which didn't hold one.
+func readRequests(r io.Reader) (requests []json.RawMessage, skipped int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if request := parseRequest(line); request != nil {
+			requests = append(requests, request)
+		} else {
+			skipped++
+		}
+	}
+	return requests, skipped, scanner.Err()
+}
+
+// parseRequest returns the OpenRTB request in the line, or nil if it doesn't have one.
+func parseRequest(line []byte) json.RawMessage {
+	start := bytes.IndexByte(line, '{')
+	if start < 0 {
+		return nil
+	}
+	line = line[start:]
+
+	var logged loggedObject
+	if err := json.Unmarshal(line, &logged); err != nil {
+		return nil
+	}
+	request := line
+	if logged.Type != "" {
+		request = logged.Request
+	}
+
+	var bidRequest openrtb.BidRequest
+	if err := json.Unmarshal(request, &bidRequest); err != nil || len(bidRequest.Imp) == 0 {
+		return nil
+	}
+	// Copy the request, since the scanner reuses its buffer.
+	return append(json.RawMessage(nil), request...)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRequests(t *testing.T) {
+	lines := strings.Join([]string{
+		`2019/10/01 12:00:00 [DEBUG] {"type":"/openrtb2/auction","Status":200,"Request":{"id":"logged-auction","imp":[{"id":"1"}]},"Response":{"id":"logged-auction"}}`,
+		`{"type":"/openrtb2/amp","Status":200,"Request":{"id":"logged-amp","imp":[{"id":"1"}]}}`,
+		`{"type":"/cookie_sync","Status":200,"bidder_status":[]}`,
+		``,
+		`{"id":"bare","imp":[{"id":"1"}]}`,
+		`{"id":"no-imps"}`,
+		`not json`,
+	}, "\n")
+
+	requests, skipped, err := readRequests(strings.NewReader(lines))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, skipped, "Lines without a request should be skipped, but blank lines shouldn't count")
+	if assert.Len(t, requests, 3) {
+		assert.JSONEq(t, `{"id":"logged-auction","imp":[{"id":"1"}]}`, string(requests[0]), "Auctions logged by analytics/filesystem should be read")
+		assert.JSONEq(t, `{"id":"logged-amp","imp":[{"id":"1"}]}`, string(requests[1]), "AMP requests logged by analytics/filesystem should be read")
+		assert.JSONEq(t, `{"id":"bare","imp":[{"id":"1"}]}`, string(requests[2]), "Bare requests should be read")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/gdpr"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/prebid_cache_client"
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
+	"github.com/prebid/prebid-server/usersync"
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/spf13/viper"
+)
+
+// target runs the auctions which get replayed.
+type target interface {
+	name() string
+	auction(ctx context.Context, request json.RawMessage) (*openrtb.BidResponse, error)
+}
+
+// statusError flags a response from PBS which didn't have a 200 status.
+type statusError struct {
+	status int
+	body   string
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("PBS responded with status %d: %s", err.status, err.body)
+}
+
+// httpTarget sends the requests to the /openrtb2/auction endpoint of a running PBS.
+type httpTarget struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newHTTPTarget(pbsURL string, client *http.Client) (*httpTarget, error) {
+	parsed, err := url.Parse(pbsURL)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Trim(parsed.Path, "/") == "" {
+		parsed.Path = "/openrtb2/auction"
+	}
+	return &httpTarget{
+		endpoint: parsed.String(),
+		client:   client,
+	}, nil
+}
+
+func (t *httpTarget) name() string {
+	return t.endpoint
+}
+
+func (t *httpTarget) auction(ctx context.Context, request json.RawMessage) (*openrtb.BidResponse, error) {
+	httpReq, err := http.NewRequest("POST", t.endpoint, bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := t.client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, &statusError{status: httpResp.StatusCode, body: string(body)}
+	}
+
+	var response openrtb.BidResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	return &response, nil
+}
+
+// exchangeTarget runs the auctions in process, with the config from a PBS config file. The bidders' HTTP calls
+// are answered by a stubTransport, so the results only depend on the requests and the config.
+type exchangeTarget struct {
+	configFile string
+	ex         exchange.Exchange
+	categories stored_requests.CategoryFetcher
+}
+
+func newExchangeTarget(configFile string, infoDir string, transport http.RoundTripper) (*exchangeTarget, error) {
+	v := viper.New()
+	config.SetupViper(v, "")
+	v.SetConfigFile(configFile)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", configFile, err)
+	}
+	cfg, err := config.New(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config in %s: %v", configFile, err)
+	}
+
+	bidders := openrtb_ext.BidderList()
+	infos := adapters.ParseBidderInfos(cfg.Adapters, infoDir, bidders)
+	metricsEngine := pbsmetrics.NewMetrics(metrics.NewRegistry(), bidders)
+	return &exchangeTarget{
+		configFile: configFile,
+		ex:         exchange.NewExchange(&http.Client{Transport: transport}, stubCache{}, cfg, metricsEngine, infos, gdpr.AlwaysAllow{}, currencies.NewRateConverterDefault(), empty_fetcher.EmptyFetcher{}),
+		categories: empty_fetcher.EmptyFetcher{},
+	}, nil
+}
+
+func (t *exchangeTarget) name() string {
+	return t.configFile
+}
+
+func (t *exchangeTarget) auction(ctx context.Context, request json.RawMessage) (*openrtb.BidResponse, error) {
+	var bidRequest openrtb.BidRequest
+	if err := json.Unmarshal(request, &bidRequest); err != nil {
+		return nil, err
+	}
+	labels := pbsmetrics.Labels{
+		Source: pbsmetrics.DemandWeb,
+		RType:  pbsmetrics.ReqTypeORTB2Web,
+		PubID:  pbsmetrics.PublisherUnknown,
+	}
+	if bidRequest.App != nil {
+		labels.Source = pbsmetrics.DemandApp
+		labels.RType = pbsmetrics.ReqTypeORTB2App
+		if bidRequest.App.Publisher != nil && bidRequest.App.Publisher.ID != "" {
+			labels.PubID = bidRequest.App.Publisher.ID
+		}
+	} else if bidRequest.Site != nil && bidRequest.Site.Publisher != nil && bidRequest.Site.Publisher.ID != "" {
+		labels.PubID = bidRequest.Site.Publisher.ID
+	}
+	if bidRequest.TMax > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(bidRequest.TMax)*time.Millisecond)
+		defer cancel()
+	}
+	return t.ex.HoldAuction(ctx, &bidRequest, usersync.NewPBSCookie(), labels, &config.Account{ID: labels.PubID}, &t.categories, nil)
+}
+
+// stubTransport answers the bidders' HTTP calls without leaving the process. OpenRTB requests get one bid
+// for each Imp, at the configured price. Everything else gets a 204, which the adapters treat as no bid.
+type stubTransport struct {
+	price   float64
+	latency time.Duration
+}
+
+func (t *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var bidRequest openrtb.BidRequest
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		json.Unmarshal(body, &bidRequest)
+	}
+
+	select {
+	case <-time.After(t.latency):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	if len(bidRequest.Imp) == 0 {
+		return stubResponse(req, http.StatusNoContent, nil), nil
+	}
+	bids := make([]openrtb.Bid, 0, len(bidRequest.Imp))
+	for _, imp := range bidRequest.Imp {
+		bid := openrtb.Bid{
+			ID:    imp.ID + "-stub",
+			ImpID: imp.ID,
+			Price: t.price,
+			AdM:   "<!-- replay stub -->",
+			CrID:  "replay-stub",
+		}
+		if imp.Banner != nil && len(imp.Banner.Format) > 0 {
+			bid.W = imp.Banner.Format[0].W
+			bid.H = imp.Banner.Format[0].H
+		}
+		bids = append(bids, bid)
+	}
+	body, err := json.Marshal(openrtb.BidResponse{
+		ID:      bidRequest.ID,
+		SeatBid: []openrtb.SeatBid{{Bid: bids}},
+		Cur:     "USD",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stubResponse(req, http.StatusOK, body), nil
+}
+
+func stubResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}
+}
+
+// stubCache stands in for Prebid Cache. It doesn't save anything.
+type stubCache struct{}
+
+func (stubCache) PutJson(ctx context.Context, values []prebid_cache_client.Cacheable) ([]string, []error) {
+	return make([]string, len(values)), nil
+}
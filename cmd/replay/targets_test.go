@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openrtb2/auction" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req openrtb.BidRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid request"))
+			return
+		}
+		w.Write([]byte(`{"id":"` + req.ID + `","seatbid":[{"seat":"appnexus","bid":[{"id":"1","impid":"1","price":1}]}]}`))
+	}))
+	defer server.Close()
+
+	target, err := newHTTPTarget(server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("Failed to build the target: %v", err)
+	}
+	assert.Equal(t, server.URL+"/openrtb2/auction", target.name(), "The auction endpoint should be the default path")
+
+	resp, err := target.auction(context.Background(), json.RawMessage(`{"id":"good","imp":[{"id":"1"}]}`))
+	assert.NoError(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, "good", resp.ID)
+	}
+
+	_, err = target.auction(context.Background(), json.RawMessage(`{"id":"bad","imp":[{"id":"1"}]}`))
+	assert.Equal(t, &statusError{status: http.StatusBadRequest, body: "Invalid request"}, err)
+}
+
+func TestStubTransport(t *testing.T) {
+	client := &http.Client{Transport: &stubTransport{price: 2.5}}
+
+	resp, err := client.Post("http://bidder.example.com", "application/json", strings.NewReader(`{"id":"req","imp":[{"id":"1","banner":{"format":[{"w":300,"h":250}]}},{"id":"2"}]}`))
+	if err != nil {
+		t.Fatalf("The stub shouldn't fail: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var bidResp openrtb.BidResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bidResp); err != nil {
+		t.Fatalf("The stub returned bad JSON: %v", err)
+	}
+	if assert.Len(t, bidResp.SeatBid, 1) && assert.Len(t, bidResp.SeatBid[0].Bid, 2, "The stub should bid on every imp") {
+		assert.Equal(t, "1", bidResp.SeatBid[0].Bid[0].ImpID)
+		assert.Equal(t, 2.5, bidResp.SeatBid[0].Bid[0].Price)
+		assert.Equal(t, uint64(300), bidResp.SeatBid[0].Bid[0].W)
+	}
+
+	resp, err = client.Get("http://bidder.example.com?tag=1")
+	if err != nil {
+		t.Fatalf("The stub shouldn't fail: %v", err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode, "Requests which aren't OpenRTB should get no bids")
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// Account represents a publisher account configuration. Accounts are loaded through the stored_requests
+// AccountFetcher, using the backends configured under the "accounts" key, and hold per-publisher overrides
+// of the host-wide settings.
+type Account struct {
+	ID       string `json:"id"`
+	Disabled bool   `json:"disabled"`
+	// CacheTTL overrides the host's cache.default_ttl_seconds. Zero values fall back to the host defaults.
+	CacheTTL DefaultTTLs `json:"cache_ttl"`
+	GDPR     AccountGDPR `json:"gdpr"`
+	// DefaultTargeting is used for requests which don't define request.ext.prebid.targeting themselves.
+	DefaultTargeting *openrtb_ext.ExtRequestTargeting `json:"default_targeting"`
+	// EnabledBidders restricts the auction to these bidders. If empty, all bidders are allowed.
+	EnabledBidders []string         `json:"enabled_bidders"`
+	Analytics      AccountAnalytics `json:"analytics"`
+	// EventsEnabled adds win and impression notification URLs for the /event endpoint to every bid.
+	EventsEnabled bool `json:"events_enabled"`
+	// VASTTrackingBidders lists the bidders whose cached VAST gets an <Impression> element calling the /event endpoint.
+	// "*" matches every bidder.
+	VASTTrackingBidders []string          `json:"vast_tracking_bidders"`
+	CookieSync          AccountCookieSync `json:"cookie_sync"`
+}
+
+// AccountCookieSync represents account-specific /cookie_sync configuration
+type AccountCookieSync struct {
+	// DefaultCoopSync overrides the host's user_sync.coop_sync.default for this account's requests. If nil, the host default applies.
+	DefaultCoopSync *bool `json:"default_coop_sync"`
+}
+
+// AccountGDPR represents account-specific GDPR configuration
+type AccountGDPR struct {
+	// Enabled can be set to false to stop GDPR enforcement for this account. If nil, the host behavior applies.
+	Enabled *bool `json:"enabled"`
+}
+
+// AccountAnalytics represents account-specific analytics configuration
+type AccountAnalytics struct {
+	// Disabled stops the auction, AMP and video endpoints from logging this account's traffic to the analytics modules.
+	Disabled bool `json:"disabled"`
+}
+
+// BidderEnabled returns true if the account allows the given bidder to take part in its auctions.
+func (a *Account) BidderEnabled(bidder string) bool {
+	if len(a.EnabledBidders) == 0 {
+		return true
+	}
+	for _, enabled := range a.EnabledBidders {
+		if enabled == bidder {
+			return true
+		}
+	}
+	return false
+}
+
+// VASTTrackingEnabled returns true if the given bidder's VAST should be modified to call the /event endpoint on impressions.
+func (a *Account) VASTTrackingEnabled(bidder string) bool {
+	for _, tracked := range a.VASTTrackingBidders {
+		if tracked == "*" || tracked == bidder {
+			return true
+		}
+	}
+	return false
+}
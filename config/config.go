@@ -38,6 +38,12 @@ type Configuration struct {
 	CategoryMapping StoredRequestsSlim `mapstructure:"category_mapping"`
 	// Note that StoredVideo refers to stored video requests, and has nothing to do with caching video creatives.
 	StoredVideo StoredRequestsSlim `mapstructure:"stored_video_req"`
+	// Accounts configures the backends used to load per-publisher Account configuration.
+	// Stored queries and http responses should use the "account" type. See config/accounts.go for the data format.
+	Accounts StoredRequestsSlim `mapstructure:"accounts"`
+	// StoredResponses configures the backends used to load Stored Auction and Bid Responses.
+	// Stored queries should use the "response" type, and the %RESPONSE_ID_LIST% wildcard.
+	StoredResponses StoredRequestsSlim `mapstructure:"stored_responses"`
 
 	// Adapters should have a key for every openrtb_ext.BidderName, converted to lower-case.
 	// Se also: https://github.com/spf13/viper/issues/371#issuecomment-335388559
@@ -48,6 +54,10 @@ type Configuration struct {
 	GDPR                 GDPR               `mapstructure:"gdpr"`
 	CurrencyConverter    CurrencyConverter  `mapstructure:"currency_converter"`
 	DefReqConfig         DefReqConfig       `mapstructure:"default_request"`
+	PriceFloors          PriceFloors        `mapstructure:"price_floors"`
+	VTrack               VTrack             `mapstructure:"vtrack"`
+	Event                Event              `mapstructure:"event"`
+	UserSync             UserSync           `mapstructure:"user_sync"`
 
 	VideoStoredRequestRequired bool `mapstructure:"video_stored_request_required"`
 
@@ -57,6 +67,10 @@ type Configuration struct {
 	// Array of blacklisted accounts that is used to create the hash table BlacklistedAcctMap so Account.ID's can be instantly accessed.
 	BlacklistedAccts   []string `mapstructure:"blacklisted_accts,flow"`
 	BlacklistedAcctMap map[string]bool
+	// AccountRequired rejects requests whose publisher ID doesn't match a known Account.
+	AccountRequired bool `mapstructure:"account_required"`
+	// HostSChainNode is the host company's node, which gets added to the end of the supply chain sent to each bidder.
+	HostSChainNode *openrtb_ext.ExtRequestPrebidSChainSChainNode `mapstructure:"host_schain_node"`
 }
 
 type HTTPClient struct {
@@ -86,12 +100,21 @@ func (cfg *Configuration) validate() configErrors {
 	var errs configErrors
 	errs = cfg.AuctionTimeouts.validate(errs)
 	errs = cfg.StoredRequests.validate(errs)
+	errs = cfg.CategoryMapping.InMemoryCache.validateCategoryMapping(errs)
 	errs = cfg.Metrics.validate(errs)
 	if cfg.MaxRequestSize < 0 {
 		errs = append(errs, fmt.Errorf("cfg.max_request_size must be >= 0. Got %d", cfg.MaxRequestSize))
 	}
+	errs = cfg.Analytics.validate(errs)
+	errs = cfg.HostCookie.validate(errs)
 	errs = cfg.GDPR.validate(errs)
 	errs = cfg.CurrencyConverter.validate(errs)
+	errs = cfg.PriceFloors.validate(errs)
+	errs = cfg.VTrack.validate(errs)
+	errs = cfg.Event.validate(errs)
+	errs = cfg.UserSync.validate(errs)
+	errs = cfg.UserSync.UIDStore.validate(&cfg.HostCookie, errs)
+	errs = validateHostSChainNode(cfg.HostSChainNode, errs)
 	errs = validateAdapters(cfg.Adapters, errs)
 	return errs
 }
@@ -129,6 +152,7 @@ type GDPR struct {
 	HostVendorID            int          `mapstructure:"host_vendor_id"`
 	UsersyncIfAmbiguous     bool         `mapstructure:"usersync_if_ambiguous"`
 	Timeouts                GDPRTimeouts `mapstructure:"timeouts_ms"`
+	TCF2                    TCF2         `mapstructure:"tcf2"`
 	NonStandardPublishers   []string     `mapstructure:"non_standard_publishers,flow"`
 	NonStandardPublisherMap map[string]int
 }
@@ -153,8 +177,27 @@ func (t *GDPRTimeouts) ActiveTimeout() time.Duration {
 	return time.Duration(t.ActiveVendorlistFetch) * time.Millisecond
 }
 
+// TCF2 defines which parts of a TCF 2.0 consent string are enforced. A purpose which isn't enforced
+// is treated as though the user and the vendor had established a legal basis for it.
+type TCF2 struct {
+	Purpose1        TCF2Purpose `mapstructure:"purpose1"`
+	Purpose2        TCF2Purpose `mapstructure:"purpose2"`
+	Purpose7        TCF2Purpose `mapstructure:"purpose7"`
+	SpecialFeature1 TCF2Purpose `mapstructure:"special_feature1"`
+}
+
+// TCF2Purpose toggles the enforcement of a single TCF 2.0 purpose or special feature.
+type TCF2Purpose struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
 type Analytics struct {
 	File FileLogs `mapstructure:"file"`
+	HTTP HTTPLogs `mapstructure:"http"`
+}
+
+func (cfg *Analytics) validate(errs configErrors) configErrors {
+	return cfg.HTTP.validate(errs)
 }
 
 type CurrencyConverter struct {
@@ -169,11 +212,222 @@ func (cfg *CurrencyConverter) validate(errs configErrors) configErrors {
 	return errs
 }
 
+// PriceFloors configures the price floors module, which sets imp.bidfloor from the rules in request.ext.prebid.floors
+// and rejects bids which come in below the floor.
+type PriceFloors struct {
+	Enabled bool             `mapstructure:"enabled"`
+	Fetch   PriceFloorsFetch `mapstructure:"fetch"`
+}
+
+// PriceFloorsFetch configures how floor rules files are loaded from request.ext.prebid.floors.floorendpoint.url
+type PriceFloorsFetch struct {
+	// TimeoutMS bounds the fetch of a rules file which hasn't been loaded yet.
+	TimeoutMS int `mapstructure:"timeout_ms"`
+	// RefreshRateSeconds is how long a fetched rules file is used before it gets fetched again.
+	RefreshRateSeconds int `mapstructure:"refresh_rate_seconds"`
+	// AllowedURLs are the only rules files which requests may name. Requests for any other URL are rejected.
+	AllowedURLs []string `mapstructure:"allowed_urls"`
+}
+
+func (cfg *PriceFloors) validate(errs configErrors) configErrors {
+	if cfg.Fetch.TimeoutMS < 0 {
+		errs = append(errs, fmt.Errorf("price_floors.fetch.timeout_ms must be >= 0. Got %d", cfg.Fetch.TimeoutMS))
+	}
+	if cfg.Fetch.RefreshRateSeconds < 0 {
+		errs = append(errs, fmt.Errorf("price_floors.fetch.refresh_rate_seconds must be >= 0. Got %d", cfg.Fetch.RefreshRateSeconds))
+	}
+	return errs
+}
+
+// VTrack configures the /vtrack endpoint, which adds impression trackers to VAST before putting it in Prebid Cache.
+type VTrack struct {
+	// TimeoutMS bounds the call to Prebid Cache.
+	TimeoutMS int `mapstructure:"timeout_ms"`
+}
+
+func (cfg *VTrack) validate(errs configErrors) configErrors {
+	if cfg.TimeoutMS <= 0 {
+		errs = append(errs, fmt.Errorf("vtrack.timeout_ms must be > 0. Got %d", cfg.TimeoutMS))
+	}
+	return errs
+}
+
+// Event configures the /event endpoint, which logs win and impression notifications.
+type Event struct {
+	// AccountTimeoutMS limits how long an /event request waits for its account's config.
+	AccountTimeoutMS int `mapstructure:"account_timeout_ms"`
+}
+
+func (cfg *Event) validate(errs configErrors) configErrors {
+	if cfg.AccountTimeoutMS <= 0 {
+		errs = append(errs, fmt.Errorf("event.account_timeout_ms must be > 0. Got %d", cfg.AccountTimeoutMS))
+	}
+	return errs
+}
+
+// UserSync configures the /cookie_sync endpoint.
+type UserSync struct {
+	Cooperative UserSyncCooperative `mapstructure:"coop_sync"`
+	UIDStore    UIDStore            `mapstructure:"uid_store"`
+	// AccountTimeoutMS limits how long a /cookie_sync request waits for its account's config.
+	AccountTimeoutMS int `mapstructure:"account_timeout_ms"`
+}
+
+// UserSyncCooperative configures cooperative syncing, which syncs bidders that a /cookie_sync request didn't ask for.
+type UserSyncCooperative struct {
+	// EnabledByDefault turns on coop syncing for requests which name their bidders, but don't say whether to coop sync.
+	// Accounts can override it. Requests which don't name any bidders always coop sync, unless they say otherwise.
+	EnabledByDefault bool `mapstructure:"default"`
+	// PriorityGroups orders the coop bidders. The bidders in the first group are synced first, in a random order,
+	// then the bidders in the second group, and so on. Bidders which aren't in a group are synced last.
+	PriorityGroups [][]string `mapstructure:"priority_groups"`
+}
+
+func (cfg *UserSync) validate(errs configErrors) configErrors {
+	seen := make(map[string]bool)
+	for _, group := range cfg.Cooperative.PriorityGroups {
+		for _, bidder := range group {
+			if _, ok := openrtb_ext.BidderMap[bidder]; !ok {
+				errs = append(errs, fmt.Errorf("user_sync.coop_sync.priority_groups contains unknown bidder: %s", bidder))
+			} else if seen[bidder] {
+				errs = append(errs, fmt.Errorf("user_sync.coop_sync.priority_groups contains bidder %s more than once", bidder))
+			}
+			seen[bidder] = true
+		}
+	}
+	if cfg.AccountTimeoutMS <= 0 {
+		errs = append(errs, fmt.Errorf("user_sync.account_timeout_ms must be > 0. Got %d", cfg.AccountTimeoutMS))
+	}
+	return errs
+}
+
+const (
+	UIDStoreTypeFile     = "file"
+	UIDStoreTypePostgres = "postgres"
+)
+
+const (
+	// UIDStoreKeyHostCookie keys the UID store by the value of the host_cookie.cookie_name cookie.
+	UIDStoreKeyHostCookie = "host_cookie"
+	// UIDStoreKeyUserID keys the UID store by the user.id of auctions, and the fpid query param of /setuid.
+	// Since anyone can choose an fpid, /setuid only writes to the store if the fpid is signed with the fpid_secret.
+	UIDStoreKeyUserID = "user_id"
+)
+
+// UIDStore configures a server-side store for the IDs which bidders give users. It's used in auctions for
+// the users who don't have a uids cookie, like app and Safari users. /setuid writes to it as well as to the cookie.
+type UIDStore struct {
+	// Type is "file" or "postgres". If it's empty, UIDs are only kept in the uids cookie.
+	Type string `mapstructure:"type"`
+	// KeySource says which first-party ID users are identified by: "host_cookie" or "user_id".
+	KeySource string `mapstructure:"key_source"`
+	// FPIDSecret signs the fpid query param of /setuid when the store is keyed by user ID. See usersync.SignFPID.
+	FPIDSecret string           `mapstructure:"fpid_secret"`
+	TimeoutMS  int              `mapstructure:"timeout_ms"`
+	File       UIDStoreFile     `mapstructure:"file"`
+	Postgres   UIDStorePostgres `mapstructure:"postgres"`
+}
+
+type UIDStoreFile struct {
+	Directory string `mapstructure:"directory"`
+}
+
+type UIDStorePostgres struct {
+	Connection PostgresConnection `mapstructure:"connection"`
+	Table      string             `mapstructure:"table"`
+}
+
+func (cfg *UIDStore) validate(hostCookie *HostCookie, errs configErrors) configErrors {
+	switch cfg.Type {
+	case "":
+		return errs
+	case UIDStoreTypeFile:
+		if cfg.File.Directory == "" {
+			errs = append(errs, fmt.Errorf("user_sync.uid_store.file.directory is required for the file UID store"))
+		}
+	case UIDStoreTypePostgres:
+		if cfg.Postgres.Connection.Database == "" || cfg.Postgres.Table == "" {
+			errs = append(errs, fmt.Errorf("user_sync.uid_store.postgres.connection.dbname and user_sync.uid_store.postgres.table are required for the postgres UID store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("user_sync.uid_store.type must be empty, %s or %s. Got %s", UIDStoreTypeFile, UIDStoreTypePostgres, cfg.Type))
+	}
+
+	switch cfg.KeySource {
+	case UIDStoreKeyHostCookie:
+		if hostCookie.CookieName == "" {
+			errs = append(errs, fmt.Errorf("user_sync.uid_store.key_source host_cookie requires host_cookie.cookie_name"))
+		}
+	case UIDStoreKeyUserID:
+		if cfg.FPIDSecret == "" {
+			errs = append(errs, fmt.Errorf("user_sync.uid_store.key_source user_id requires user_sync.uid_store.fpid_secret"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("user_sync.uid_store.key_source must be %s or %s. Got %s", UIDStoreKeyHostCookie, UIDStoreKeyUserID, cfg.KeySource))
+	}
+
+	if cfg.TimeoutMS <= 0 {
+		errs = append(errs, fmt.Errorf("user_sync.uid_store.timeout_ms must be positive. Got %d", cfg.TimeoutMS))
+	}
+	return errs
+}
+
+func validateHostSChainNode(node *openrtb_ext.ExtRequestPrebidSChainSChainNode, errs configErrors) configErrors {
+	if node == nil {
+		return errs
+	}
+	if node.ASI == "" {
+		errs = append(errs, fmt.Errorf("host_schain_node.asi must be set when host_schain_node is defined"))
+	}
+	if node.SID == "" {
+		errs = append(errs, fmt.Errorf("host_schain_node.sid must be set when host_schain_node is defined"))
+	}
+	return errs
+}
+
 // FileLogs Corresponding config for FileLogger as a PBS Analytics Module
 type FileLogs struct {
 	Filename string `mapstructure:"filename"`
 }
 
+// HTTPLogs Corresponding config for HTTPLogger as a PBS Analytics Module. The module is disabled if Endpoint is empty.
+type HTTPLogs struct {
+	// Endpoint receives each batch of events as a POST of gzipped JSON.
+	Endpoint string `mapstructure:"endpoint"`
+	// BufferSize is the number of events which can wait to be batched. Events are dropped while the buffer is full.
+	BufferSize int `mapstructure:"buffer_size"`
+	// MaxBatchEvents and MaxBatchBytes flush a batch once it holds this many events, or this much uncompressed JSON.
+	MaxBatchEvents int `mapstructure:"max_batch_events"`
+	MaxBatchBytes  int `mapstructure:"max_batch_bytes"`
+	// FlushIntervalMS flushes whatever is in the current batch on a fixed ticker with this period, so no event
+	// waits longer than one interval before being sent.
+	FlushIntervalMS int `mapstructure:"flush_interval_ms"`
+	// TimeoutMS bounds each POST to the Endpoint.
+	TimeoutMS int `mapstructure:"timeout_ms"`
+}
+
+func (cfg *HTTPLogs) validate(errs configErrors) configErrors {
+	if cfg.Endpoint == "" {
+		return errs
+	}
+	if cfg.BufferSize <= 0 {
+		errs = append(errs, fmt.Errorf("analytics.http.buffer_size must be > 0. Got %d", cfg.BufferSize))
+	}
+	if cfg.MaxBatchEvents <= 0 {
+		errs = append(errs, fmt.Errorf("analytics.http.max_batch_events must be > 0. Got %d", cfg.MaxBatchEvents))
+	}
+	if cfg.MaxBatchBytes <= 0 {
+		errs = append(errs, fmt.Errorf("analytics.http.max_batch_bytes must be > 0. Got %d", cfg.MaxBatchBytes))
+	}
+	if cfg.FlushIntervalMS <= 0 {
+		errs = append(errs, fmt.Errorf("analytics.http.flush_interval_ms must be > 0. Got %d", cfg.FlushIntervalMS))
+	}
+	if cfg.TimeoutMS < 0 {
+		errs = append(errs, fmt.Errorf("analytics.http.timeout_ms must be >= 0. Got %d", cfg.TimeoutMS))
+	}
+	return errs
+}
+
 type HostCookie struct {
 	Domain       string `mapstructure:"domain"`
 	Family       string `mapstructure:"family"`
@@ -183,12 +437,34 @@ type HostCookie struct {
 	OptOutCookie Cookie `mapstructure:"optout_cookie"`
 	// Cookie timeout in days
 	TTL int64 `mapstructure:"ttl_days"`
+	// MaxCookieSizeBytes limits the size of the uids cookie. If it would be bigger, UIDs are evicted until it fits.
+	// 0 means there's no limit.
+	MaxCookieSizeBytes int `mapstructure:"max_cookie_size_bytes"`
+	// PriorityFamilies lists the bidder families whose UIDs should be evicted last, most important first.
+	PriorityFamilies []string `mapstructure:"priority_families"`
+	// SameSiteNone adds the SameSite=None and Secure attributes to the uids cookie, so that browsers keep sending it
+	// on cross-site requests. Browsers which mishandle SameSite=None get the cookie without them.
+	// Like Secure, only set it if Prebid Server is served over HTTPS.
+	SameSiteNone bool `mapstructure:"same_site_none"`
+	// Secure adds the Secure attribute to the uids cookie for every browser.
+	// Only set it if Prebid Server is served over HTTPS, since browsers ignore Secure cookies sent over HTTP.
+	Secure bool `mapstructure:"secure"`
 }
 
+// MinCookieSizeBytes is the smallest max_cookie_size_bytes allowed, since smaller cookies can't hold many UIDs.
+const MinCookieSizeBytes = 500
+
 func (cfg *HostCookie) TTLDuration() time.Duration {
 	return time.Duration(cfg.TTL) * time.Hour * 24
 }
 
+func (cfg *HostCookie) validate(errs configErrors) configErrors {
+	if cfg.MaxCookieSizeBytes != 0 && cfg.MaxCookieSizeBytes < MinCookieSizeBytes {
+		errs = append(errs, fmt.Errorf("host_cookie.max_cookie_size_bytes must be 0 or at least %d. Got %d", MinCookieSizeBytes, cfg.MaxCookieSizeBytes))
+	}
+	return errs
+}
+
 const (
 	dummyHost        string = "dummyhost.com"
 	dummyPublisherID string = "12"
@@ -213,8 +489,13 @@ type Adapter struct {
 	//
 	// For more info on templates, see: https://golang.org/pkg/text/template/
 	UserSyncURL string `mapstructure:"usersync_url"`
-	PlatformID  string `mapstructure:"platform_id"` // needed for Facebook
-	XAPI        struct {
+	// UserSyncIframeURL and UserSyncRedirectURL are optional, for Bidders which support both types of sync.
+	// If set, /cookie_sync can return a sync of that type when the request doesn't allow the Bidder's usual type.
+	// They're templates, just like UserSyncURL, and are only used if UserSyncURL is defined.
+	UserSyncIframeURL   string `mapstructure:"usersync_iframe_url"`
+	UserSyncRedirectURL string `mapstructure:"usersync_redirect_url"`
+	PlatformID          string `mapstructure:"platform_id"` // needed for Facebook
+	XAPI                struct {
 		Username string `mapstructure:"username"`
 		Password string `mapstructure:"password"`
 		Tracker  string `mapstructure:"tracker"`
@@ -291,6 +572,8 @@ func validateAdapters(adapterMap map[string]Adapter, errs configErrors) configEr
 
 			// Verify that valid user_sync URLs are specified in the config
 			errs = validateAdapterUserSyncURL(adapter.UserSyncURL, adapterName, errs)
+			errs = validateAdapterUserSyncURL(adapter.UserSyncIframeURL, adapterName, errs)
+			errs = validateAdapterUserSyncURL(adapter.UserSyncRedirectURL, adapterName, errs)
 		}
 	}
 	return errs
@@ -358,10 +641,10 @@ type Cache struct {
 
 // Default TTLs to use to cache bids for different types of imps.
 type DefaultTTLs struct {
-	Banner int `mapstructure:"banner"`
-	Video  int `mapstructure:"video"`
-	Native int `mapstructure:"native"`
-	Audio  int `mapstructure:"audio"`
+	Banner int `mapstructure:"banner" json:"banner"`
+	Video  int `mapstructure:"video" json:"video"`
+	Native int `mapstructure:"native" json:"native"`
+	Audio  int `mapstructure:"audio" json:"audio"`
 }
 
 type Cookie struct {
@@ -525,6 +808,10 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("host_cookie.optout_cookie.name", "")
 	v.SetDefault("host_cookie.value", "")
 	v.SetDefault("host_cookie.ttl_days", 90)
+	v.SetDefault("host_cookie.max_cookie_size_bytes", 0)
+	v.SetDefault("host_cookie.priority_families", []string{})
+	v.SetDefault("host_cookie.same_site_none", false)
+	v.SetDefault("host_cookie.secure", false)
 	v.SetDefault("http_client.max_idle_connections", 400)
 	v.SetDefault("http_client.max_idle_connections_per_host", 10)
 	v.SetDefault("http_client.idle_connection_timeout_seconds", 60)
@@ -543,7 +830,26 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("datacache.ttl_seconds", 0)
 	v.SetDefault("category_mapping.filesystem.enabled", true)
 	v.SetDefault("category_mapping.filesystem.directorypath", "./static/category-mapping")
+	v.SetDefault("category_mapping.postgres.connection.dbname", "")
+	v.SetDefault("category_mapping.postgres.connection.host", "")
+	v.SetDefault("category_mapping.postgres.connection.port", 0)
+	v.SetDefault("category_mapping.postgres.connection.user", "")
+	v.SetDefault("category_mapping.postgres.connection.password", "")
+	v.SetDefault("category_mapping.postgres.fetcher.query", "")
+	v.SetDefault("category_mapping.postgres.initialize_caches.timeout_ms", 0)
+	v.SetDefault("category_mapping.postgres.initialize_caches.query", "")
+	v.SetDefault("category_mapping.postgres.poll_for_updates.refresh_rate_seconds", 0)
+	v.SetDefault("category_mapping.postgres.poll_for_updates.timeout_ms", 0)
+	v.SetDefault("category_mapping.postgres.poll_for_updates.query", "")
 	v.SetDefault("category_mapping.http.endpoint", "")
+	v.SetDefault("category_mapping.in_memory_cache.type", "unbounded")
+	v.SetDefault("category_mapping.in_memory_cache.ttl_seconds", 0)
+	v.SetDefault("category_mapping.in_memory_cache.category_cache_size_bytes", 0)
+	v.SetDefault("category_mapping.cache_events.enabled", false)
+	v.SetDefault("category_mapping.cache_events.endpoint", "/storedrequests/categorymapping")
+	v.SetDefault("category_mapping.http_events.endpoint", "")
+	v.SetDefault("category_mapping.http_events.refresh_rate_seconds", 0)
+	v.SetDefault("category_mapping.http_events.timeout_ms", 0)
 	v.SetDefault("stored_requests.filesystem", false)
 	v.SetDefault("stored_requests.directorypath", "./stored_requests/data/by_id")
 	v.SetDefault("stored_requests.postgres.connection.dbname", "")
@@ -560,17 +866,23 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("stored_requests.postgres.poll_for_updates.timeout_ms", 0)
 	v.SetDefault("stored_requests.postgres.poll_for_updates.query", "")
 	v.SetDefault("stored_requests.postgres.poll_for_updates.amp_query", "")
+	v.SetDefault("stored_requests.postgres.save.request_query", "")
+	v.SetDefault("stored_requests.postgres.save.imp_query", "")
 	v.SetDefault("stored_requests.http.endpoint", "")
 	v.SetDefault("stored_requests.http.amp_endpoint", "")
 	v.SetDefault("stored_requests.in_memory_cache.type", "none")
 	v.SetDefault("stored_requests.in_memory_cache.ttl_seconds", 0)
 	v.SetDefault("stored_requests.in_memory_cache.request_cache_size_bytes", 0)
 	v.SetDefault("stored_requests.in_memory_cache.imp_cache_size_bytes", 0)
+	v.SetDefault("stored_requests.in_memory_cache.account_cache_size_bytes", 0)
 	v.SetDefault("stored_requests.cache_events_api", false)
 	v.SetDefault("stored_requests.http_events.endpoint", "")
 	v.SetDefault("stored_requests.http_events.amp_endpoint", "")
 	v.SetDefault("stored_requests.http_events.refresh_rate_seconds", 0)
 	v.SetDefault("stored_requests.http_events.timeout_ms", 0)
+	v.SetDefault("stored_requests.merge_strategy", "replace")
+	v.SetDefault("stored_requests.write_api.enabled", false)
+	v.SetDefault("stored_requests.write_api.secret", "")
 	// stored_video is short for stored_video_requests.
 	// PBS is not in the business of storing video content beyond the normal prebid cache system.
 	v.SetDefault("stored_video_req.filesystem.enabled", false)
@@ -596,6 +908,50 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("stored_video_req.http_events.endpoint", "")
 	v.SetDefault("stored_video_req.http_events.refresh_rate_seconds", 0)
 	v.SetDefault("stored_video_req.http_events.timeout_ms", 0)
+	v.SetDefault("accounts.filesystem.enabled", false)
+	v.SetDefault("accounts.filesystem.directorypath", "./stored_requests/data/by_id")
+	v.SetDefault("accounts.postgres.connection.dbname", "")
+	v.SetDefault("accounts.postgres.connection.host", "")
+	v.SetDefault("accounts.postgres.connection.port", 0)
+	v.SetDefault("accounts.postgres.connection.user", "")
+	v.SetDefault("accounts.postgres.connection.password", "")
+	v.SetDefault("accounts.postgres.fetcher.query", "")
+	v.SetDefault("accounts.postgres.initialize_caches.timeout_ms", 0)
+	v.SetDefault("accounts.postgres.initialize_caches.query", "")
+	v.SetDefault("accounts.postgres.poll_for_updates.refresh_rate_seconds", 0)
+	v.SetDefault("accounts.postgres.poll_for_updates.timeout_ms", 0)
+	v.SetDefault("accounts.postgres.poll_for_updates.query", "")
+	v.SetDefault("accounts.http.endpoint", "")
+	v.SetDefault("accounts.in_memory_cache.type", "none")
+	v.SetDefault("accounts.in_memory_cache.ttl_seconds", 0)
+	v.SetDefault("accounts.in_memory_cache.account_cache_size_bytes", 0)
+	v.SetDefault("accounts.cache_events.enabled", false)
+	v.SetDefault("accounts.cache_events.endpoint", "/storedrequests/accounts")
+	v.SetDefault("accounts.http_events.endpoint", "")
+	v.SetDefault("accounts.http_events.refresh_rate_seconds", 0)
+	v.SetDefault("accounts.http_events.timeout_ms", 0)
+	v.SetDefault("stored_responses.filesystem.enabled", false)
+	v.SetDefault("stored_responses.filesystem.directorypath", "./stored_requests/data/by_id")
+	v.SetDefault("stored_responses.postgres.connection.dbname", "")
+	v.SetDefault("stored_responses.postgres.connection.host", "")
+	v.SetDefault("stored_responses.postgres.connection.port", 0)
+	v.SetDefault("stored_responses.postgres.connection.user", "")
+	v.SetDefault("stored_responses.postgres.connection.password", "")
+	v.SetDefault("stored_responses.postgres.fetcher.query", "")
+	v.SetDefault("stored_responses.postgres.initialize_caches.timeout_ms", 0)
+	v.SetDefault("stored_responses.postgres.initialize_caches.query", "")
+	v.SetDefault("stored_responses.postgres.poll_for_updates.refresh_rate_seconds", 0)
+	v.SetDefault("stored_responses.postgres.poll_for_updates.timeout_ms", 0)
+	v.SetDefault("stored_responses.postgres.poll_for_updates.query", "")
+	v.SetDefault("stored_responses.http.endpoint", "")
+	v.SetDefault("stored_responses.in_memory_cache.type", "none")
+	v.SetDefault("stored_responses.in_memory_cache.ttl_seconds", 0)
+	v.SetDefault("stored_responses.cache_events.enabled", false)
+	v.SetDefault("stored_responses.cache_events.endpoint", "/storedrequests/responses")
+	v.SetDefault("stored_responses.http_events.endpoint", "")
+	v.SetDefault("stored_responses.http_events.refresh_rate_seconds", 0)
+	v.SetDefault("stored_responses.http_events.timeout_ms", 0)
+	v.SetDefault("account_required", false)
 
 	for _, bidder := range openrtb_ext.BidderMap {
 		setBidderDefaults(v, strings.ToLower(string(bidder)))
@@ -651,14 +1007,44 @@ func SetupViper(v *viper.Viper, filename string) {
 
 	v.SetDefault("max_request_size", 1024*256)
 	v.SetDefault("analytics.file.filename", "")
+	v.SetDefault("analytics.http.endpoint", "")
+	v.SetDefault("analytics.http.buffer_size", 10000)
+	v.SetDefault("analytics.http.max_batch_events", 1000)
+	v.SetDefault("analytics.http.max_batch_bytes", 1048576)
+	v.SetDefault("analytics.http.flush_interval_ms", 10000)
+	v.SetDefault("analytics.http.timeout_ms", 5000)
 	v.SetDefault("amp_timeout_adjustment_ms", 0)
 	v.SetDefault("gdpr.host_vendor_id", 0)
 	v.SetDefault("gdpr.usersync_if_ambiguous", false)
 	v.SetDefault("gdpr.timeouts_ms.init_vendorlist_fetches", 0)
 	v.SetDefault("gdpr.timeouts_ms.active_vendorlist_fetch", 0)
 	v.SetDefault("gdpr.non_standard_publishers", []string{""})
+	v.SetDefault("gdpr.tcf2.purpose1.enabled", true)
+	v.SetDefault("gdpr.tcf2.purpose2.enabled", true)
+	v.SetDefault("gdpr.tcf2.purpose7.enabled", true)
+	v.SetDefault("gdpr.tcf2.special_feature1.enabled", true)
 	v.SetDefault("currency_converter.fetch_url", "https://cdn.jsdelivr.net/gh/prebid/currency-file@1/latest.json")
 	v.SetDefault("currency_converter.fetch_interval_seconds", 1800) // fetch currency rates every 30 minutes
+	v.SetDefault("price_floors.enabled", false)
+	v.SetDefault("price_floors.fetch.timeout_ms", 100)
+	v.SetDefault("price_floors.fetch.refresh_rate_seconds", 300)
+	v.SetDefault("price_floors.fetch.allowed_urls", []string{})
+	v.SetDefault("vtrack.timeout_ms", 2000)
+	v.SetDefault("event.account_timeout_ms", 50)
+	v.SetDefault("user_sync.coop_sync.default", false)
+	v.SetDefault("user_sync.coop_sync.priority_groups", [][]string{})
+	v.SetDefault("user_sync.account_timeout_ms", 50)
+	v.SetDefault("user_sync.uid_store.type", "")
+	v.SetDefault("user_sync.uid_store.key_source", UIDStoreKeyHostCookie)
+	v.SetDefault("user_sync.uid_store.fpid_secret", "")
+	v.SetDefault("user_sync.uid_store.timeout_ms", 50)
+	v.SetDefault("user_sync.uid_store.file.directory", "")
+	v.SetDefault("user_sync.uid_store.postgres.connection.dbname", "")
+	v.SetDefault("user_sync.uid_store.postgres.connection.host", "")
+	v.SetDefault("user_sync.uid_store.postgres.connection.port", 0)
+	v.SetDefault("user_sync.uid_store.postgres.connection.user", "")
+	v.SetDefault("user_sync.uid_store.postgres.connection.password", "")
+	v.SetDefault("user_sync.uid_store.postgres.table", "")
 	v.SetDefault("default_request.type", "")
 	v.SetDefault("default_request.file.name", "")
 	v.SetDefault("default_request.alias_info", false)
@@ -676,6 +1062,8 @@ func setBidderDefaults(v *viper.Viper, bidder string) {
 	adapterCfgPrefix := "adapters."
 	v.SetDefault(adapterCfgPrefix+bidder+".endpoint", "")
 	v.SetDefault(adapterCfgPrefix+bidder+".usersync_url", "")
+	v.SetDefault(adapterCfgPrefix+bidder+".usersync_iframe_url", "")
+	v.SetDefault(adapterCfgPrefix+bidder+".usersync_redirect_url", "")
 	v.SetDefault(adapterCfgPrefix+bidder+".platform_id", "")
 	v.SetDefault(adapterCfgPrefix+bidder+".xapi.username", "")
 	v.SetDefault(adapterCfgPrefix+bidder+".xapi.password", "")
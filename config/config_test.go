@@ -22,10 +22,24 @@ func TestDefaults(t *testing.T) {
 	cmpInts(t, "auction_timeouts_ms.max", int(cfg.AuctionTimeouts.Max), 0)
 	cmpInts(t, "max_request_size", int(cfg.MaxRequestSize), 1024*256)
 	cmpInts(t, "host_cookie.ttl_days", int(cfg.HostCookie.TTL), 90)
+	cmpBools(t, "host_cookie.same_site_none", cfg.HostCookie.SameSiteNone, false)
+	cmpBools(t, "host_cookie.secure", cfg.HostCookie.Secure, false)
 	cmpStrings(t, "datacache.type", cfg.DataCache.Type, "dummy")
 	cmpStrings(t, "adapters.pubmatic.endpoint", cfg.Adapters[string(openrtb_ext.BidderPubmatic)].Endpoint, "http://hbopenbid.pubmatic.com/translator?source=prebid-server")
 	cmpInts(t, "currency_converter.fetch_interval_seconds", cfg.CurrencyConverter.FetchIntervalSeconds, 1800)
 	cmpStrings(t, "currency_converter.fetch_url", cfg.CurrencyConverter.FetchURL, "https://cdn.jsdelivr.net/gh/prebid/currency-file@1/latest.json")
+	cmpBools(t, "price_floors.enabled", cfg.PriceFloors.Enabled, false)
+	cmpInts(t, "price_floors.fetch.timeout_ms", cfg.PriceFloors.Fetch.TimeoutMS, 100)
+	cmpInts(t, "price_floors.fetch.refresh_rate_seconds", cfg.PriceFloors.Fetch.RefreshRateSeconds, 300)
+	cmpInts(t, "vtrack.timeout_ms", cfg.VTrack.TimeoutMS, 2000)
+	cmpInts(t, "event.account_timeout_ms", cfg.Event.AccountTimeoutMS, 50)
+	cmpInts(t, "user_sync.account_timeout_ms", cfg.UserSync.AccountTimeoutMS, 50)
+	cmpStrings(t, "analytics.http.endpoint", cfg.Analytics.HTTP.Endpoint, "")
+	cmpInts(t, "analytics.http.buffer_size", cfg.Analytics.HTTP.BufferSize, 10000)
+	cmpInts(t, "analytics.http.max_batch_events", cfg.Analytics.HTTP.MaxBatchEvents, 1000)
+	cmpInts(t, "analytics.http.max_batch_bytes", cfg.Analytics.HTTP.MaxBatchBytes, 1048576)
+	cmpInts(t, "analytics.http.flush_interval_ms", cfg.Analytics.HTTP.FlushIntervalMS, 10000)
+	cmpInts(t, "analytics.http.timeout_ms", cfg.Analytics.HTTP.TimeoutMS, 5000)
 }
 
 var fullConfig = []byte(`
@@ -33,6 +47,9 @@ gdpr:
   host_vendor_id: 15
   usersync_if_ambiguous: true
   non_standard_publishers: ["siteID","fake-site-id","appID","agltb3B1Yi1pbmNyDAsSA0FwcBiJkfIUDA"]
+  tcf2:
+    purpose7:
+      enabled: false
 host_cookie:
   cookie_name: userid
   family: prebid
@@ -161,6 +178,10 @@ func TestFullConfig(t *testing.T) {
 	cmpInts(t, "http_client.idle_connection_timeout_seconds", cfg.Client.IdleConnTimeout, 30)
 	cmpInts(t, "gdpr.host_vendor_id", cfg.GDPR.HostVendorID, 15)
 	cmpBools(t, "gdpr.usersync_if_ambiguous", cfg.GDPR.UsersyncIfAmbiguous, true)
+	cmpBools(t, "gdpr.tcf2.purpose1.enabled", cfg.GDPR.TCF2.Purpose1.Enabled, true)
+	cmpBools(t, "gdpr.tcf2.purpose2.enabled", cfg.GDPR.TCF2.Purpose2.Enabled, true)
+	cmpBools(t, "gdpr.tcf2.purpose7.enabled", cfg.GDPR.TCF2.Purpose7.Enabled, false)
+	cmpBools(t, "gdpr.tcf2.special_feature1.enabled", cfg.GDPR.TCF2.SpecialFeature1.Enabled, true)
 
 	//Assert the NonStandardPublishers was correctly unmarshalled
 	cmpStrings(t, "gdpr.non_standard_publishers", cfg.GDPR.NonStandardPublishers[0], "siteID")
@@ -217,6 +238,15 @@ func TestFullConfig(t *testing.T) {
 
 func TestValidConfig(t *testing.T) {
 	cfg := Configuration{
+		VTrack: VTrack{
+			TimeoutMS: 1000,
+		},
+		Event: Event{
+			AccountTimeoutMS: 50,
+		},
+		UserSync: UserSync{
+			AccountTimeoutMS: 50,
+		},
 		StoredRequests: StoredRequests{
 			Files: true,
 			InMemoryCache: InMemoryCache{
@@ -247,6 +277,18 @@ func TestInvalidAdapterUserSyncURLConfig(t *testing.T) {
 	assert.Error(t, err, "invalid user_sync URL in config should return an error")
 }
 
+func TestInvalidAdapterSyncTypeURLs(t *testing.T) {
+	adapters := map[string]Adapter{
+		"appnexus": {
+			Endpoint:            "http://ib.adnxs.com/some/endpoint",
+			UserSyncURL:         "http://ib.adnxs.com/getuid",
+			UserSyncIframeURL:   "http//ib.adnxs.com/iframe",
+			UserSyncRedirectURL: "http:\\\\ib.adnxs.com/redirect",
+		},
+	}
+	assert.Len(t, validateAdapters(adapters, nil), 2, "invalid iframe and redirect sync URLs should both return errors")
+}
+
 func TestNegativeRequestSize(t *testing.T) {
 	cfg := newDefaultConfig(t)
 	cfg.MaxRequestSize = -1
@@ -292,6 +334,107 @@ func TestOverflowedCurrencyConverterFetchInterval(t *testing.T) {
 	assert.NotNil(t, err, "cfg.currency_converter.fetch_interval_seconds prevent values over %d, but it doesn't", 0xffff)
 }
 
+func TestNegativePriceFloorsFetch(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.PriceFloors.Fetch = PriceFloorsFetch{
+		TimeoutMS:          -1,
+		RefreshRateSeconds: -1,
+	}
+	err := cfg.validate()
+	assert.Len(t, err, 2, "price_floors.fetch should prevent negative values, but it doesn't")
+}
+
+func TestInvalidCoopSyncPriorityGroups(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.UserSync.Cooperative.PriorityGroups = [][]string{{"appnexus", "rubicon"}, {"notabidder", "appnexus"}}
+	errs := cfg.validate()
+	if assert.Len(t, errs, 2) {
+		assert.EqualError(t, errs[0], "user_sync.coop_sync.priority_groups contains unknown bidder: notabidder")
+		assert.EqualError(t, errs[1], "user_sync.coop_sync.priority_groups contains bidder appnexus more than once")
+	}
+}
+
+func TestInvalidUserSyncAccountTimeout(t *testing.T) {
+	for _, timeout := range []int{-1, 0} {
+		cfg := newDefaultConfig(t)
+		cfg.UserSync.AccountTimeoutMS = timeout
+		err := cfg.validate()
+		assert.Len(t, err, 1, "user_sync.account_timeout_ms should prevent a value of %d, but it doesn't", timeout)
+	}
+}
+
+func TestInvalidMaxCookieSize(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.HostCookie.MaxCookieSizeBytes = 100
+	assertOneError(t, cfg.validate(), "host_cookie.max_cookie_size_bytes must be 0 or at least 500. Got 100")
+}
+
+func TestInvalidUIDStore(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.UserSync.UIDStore = UIDStore{
+		Type:      UIDStoreTypeFile,
+		KeySource: UIDStoreKeyHostCookie,
+	}
+	errs := cfg.validate()
+	if assert.Len(t, errs, 3) {
+		assert.EqualError(t, errs[0], "user_sync.uid_store.file.directory is required for the file UID store")
+		assert.EqualError(t, errs[1], "user_sync.uid_store.key_source host_cookie requires host_cookie.cookie_name")
+		assert.EqualError(t, errs[2], "user_sync.uid_store.timeout_ms must be positive. Got 0")
+	}
+
+	cfg.UserSync.UIDStore = UIDStore{Type: "redis", KeySource: "ip", TimeoutMS: 50}
+	errs = cfg.validate()
+	if assert.Len(t, errs, 2) {
+		assert.EqualError(t, errs[0], "user_sync.uid_store.type must be empty, file or postgres. Got redis")
+		assert.EqualError(t, errs[1], "user_sync.uid_store.key_source must be host_cookie or user_id. Got ip")
+	}
+
+	cfg.UserSync.UIDStore = UIDStore{Type: UIDStoreTypeFile, KeySource: UIDStoreKeyUserID, TimeoutMS: 50, File: UIDStoreFile{Directory: "/tmp/uids"}}
+	assertOneError(t, cfg.validate(), "user_sync.uid_store.key_source user_id requires user_sync.uid_store.fpid_secret")
+}
+
+func TestInvalidVTrackTimeout(t *testing.T) {
+	for _, timeout := range []int{-1, 0} {
+		cfg := newDefaultConfig(t)
+		cfg.VTrack.TimeoutMS = timeout
+		err := cfg.validate()
+		assert.Len(t, err, 1, "vtrack.timeout_ms should prevent a value of %d, but it doesn't", timeout)
+	}
+}
+
+func TestInvalidEventAccountTimeout(t *testing.T) {
+	for _, timeout := range []int{-1, 0} {
+		cfg := newDefaultConfig(t)
+		cfg.Event.AccountTimeoutMS = timeout
+		err := cfg.validate()
+		assert.Len(t, err, 1, "event.account_timeout_ms should prevent a value of %d, but it doesn't", timeout)
+	}
+}
+
+func TestInvalidHTTPAnalytics(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.Analytics.HTTP = HTTPLogs{
+		Endpoint:        "http://analytics.example.com",
+		BufferSize:      0,
+		MaxBatchEvents:  -1,
+		MaxBatchBytes:   100,
+		FlushIntervalMS: 100,
+		TimeoutMS:       -1,
+	}
+	err := cfg.validate()
+	assert.Len(t, err, 3, "analytics.http should prevent non-positive sizes and negative timeouts, but it doesn't")
+
+	cfg.Analytics.HTTP.Endpoint = ""
+	assert.Empty(t, cfg.validate(), "analytics.http shouldn't be validated when it's disabled")
+}
+
+func TestInvalidHostSChainNode(t *testing.T) {
+	cfg := newDefaultConfig(t)
+	cfg.HostSChainNode = &openrtb_ext.ExtRequestPrebidSChainSChainNode{HP: 1}
+	err := cfg.validate()
+	assert.Len(t, err, 2, "host_schain_node should require an asi and sid, but it doesn't")
+}
+
 func TestLimitTimeout(t *testing.T) {
 	doTimeoutTest(t, 10, 15, 10, 0)
 	doTimeoutTest(t, 10, 0, 10, 0)
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/openrtb_ext"
 )
 
 // StoredRequests configures the backend used to store requests on the server.
@@ -35,6 +36,33 @@ type StoredRequests struct {
 	// HTTPEvents configures an instance of stored_requests/events/http/http.go.
 	// If non-nil, the server will use those endpoints to populate and update the cache.
 	HTTPEvents HTTPEventsConfig `mapstructure:"http_events"`
+	// MergeStrategy chooses how HTTP requests are merged into Stored Requests and Imps which don't choose for themselves.
+	// The strategies are defined in openrtb_ext/imp.go.
+	MergeStrategy openrtb_ext.MergeStrategy `mapstructure:"merge_strategy"`
+	// WriteAPI configures an admin endpoint which validates Stored Requests and Imps, and then saves them
+	// to the filesystem and/or Postgres. It's defined in endpoints/openrtb2/stored_data_writer.go.
+	WriteAPI StoredRequestsWriteAPI `mapstructure:"write_api"`
+}
+
+// StoredRequestsWriteAPI configures the admin endpoint which saves Stored Requests and Imps.
+type StoredRequestsWriteAPI struct {
+	// Enabled should be true to add the /storedrequests/save endpoint to the admin server.
+	Enabled bool `mapstructure:"enabled"`
+	// Secret must be sent as a Bearer token in the Authorization header of every save.
+	Secret string `mapstructure:"secret"`
+}
+
+func (cfg *StoredRequestsWriteAPI) validate(storedRequests *StoredRequests, errs configErrors) configErrors {
+	if !cfg.Enabled {
+		return errs
+	}
+	if cfg.Secret == "" {
+		errs = append(errs, errors.New("stored_requests.write_api.secret must be set if stored_requests.write_api.enabled=true"))
+	}
+	if !storedRequests.Files && !storedRequests.Postgres.SaveQueries.enabled() {
+		errs = append(errs, errors.New("stored_requests.write_api.enabled=true requires stored_requests.filesystem=true or stored_requests.postgres.save queries to save to"))
+	}
+	return errs
 }
 
 // StoredRequestsSlim struct defines options for stored requests from a single endpoint
@@ -135,8 +163,14 @@ func (cfg *StoredRequests) validate(errs configErrors) configErrors {
 			errs = append(errs, errors.New("stored_requests.postgres.initialize_caches.query must be empty if stored_requests.in_memory_cache=none"))
 		}
 	}
+	switch cfg.MergeStrategy {
+	case "", openrtb_ext.MergeReplace, openrtb_ext.MergeDeep:
+	default:
+		errs = append(errs, fmt.Errorf("stored_requests.merge_strategy must be %s or %s. Got %s", openrtb_ext.MergeReplace, openrtb_ext.MergeDeep, cfg.MergeStrategy))
+	}
 	errs = cfg.InMemoryCache.validate(errs)
 	errs = cfg.Postgres.validate(errs)
+	errs = cfg.WriteAPI.validate(cfg, errs)
 	return errs
 }
 
@@ -156,16 +190,44 @@ type PostgresConfig struct {
 	FetcherQueries      PostgresFetcherQueries   `mapstructure:"fetcher"`
 	CacheInitialization PostgresCacheInitializer `mapstructure:"initialize_caches"`
 	PollUpdates         PostgresUpdatePolling    `mapstructure:"poll_for_updates"`
+	SaveQueries         PostgresSaveQueries      `mapstructure:"save"`
 }
 
 func (cfg *PostgresConfig) validate(errs configErrors) configErrors {
 	if cfg.ConnectionInfo.Database == "" {
+		if cfg.SaveQueries.enabled() {
+			errs = append(errs, errors.New("stored_requests.postgres.save queries require a stored_requests.postgres.connection"))
+		}
 		return errs
 	}
 
+	errs = cfg.SaveQueries.validate(errs)
 	return cfg.PollUpdates.validate(errs)
 }
 
+// PostgresSaveQueries are used by the Stored Request write API to save data to Postgres.
+// Each query is run once per ID, with the ID as $1 and the JSON as $2.
+type PostgresSaveQueries struct {
+	// RequestQuery saves a Stored Request. It should be an upsert, like:
+	//
+	//	INSERT INTO stored_requests (id, requestData) VALUES ($1, $2)
+	//	  ON CONFLICT (id) DO UPDATE SET requestData = EXCLUDED.requestData
+	RequestQuery string `mapstructure:"request_query"`
+	// ImpQuery is the same as RequestQuery, but saves a Stored Imp.
+	ImpQuery string `mapstructure:"imp_query"`
+}
+
+func (cfg *PostgresSaveQueries) enabled() bool {
+	return cfg.RequestQuery != "" || cfg.ImpQuery != ""
+}
+
+func (cfg *PostgresSaveQueries) validate(errs configErrors) configErrors {
+	if cfg.enabled() && (cfg.RequestQuery == "" || cfg.ImpQuery == "") {
+		errs = append(errs, errors.New("stored_requests.postgres.save.request_query and stored_requests.postgres.save.imp_query must be set together"))
+	}
+	return errs
+}
+
 // PostgresConnection has options which put types to the Postgres Connection string. See:
 // https://godoc.org/github.com/lib/pq#hdr-Connection_String_Parameters
 type PostgresConnection struct {
@@ -265,6 +327,18 @@ type PostgresFetcherQueriesSlim struct {
 	//     WHERE id in ($2, $3, $4, ...)
 	//
 	// ... where the number of "$x" args depends on how many IDs are nested within the HTTP request.
+	//
+	// Account queries should use %ACCOUNT_ID_LIST% instead, e.g.:
+	//   SELECT id, config, 'account' as type FROM accounts WHERE id in %ACCOUNT_ID_LIST%
+	//
+	// Stored Response queries should use %RESPONSE_ID_LIST%, e.g.:
+	//   SELECT id, data, 'response' as type FROM stored_responses WHERE id in %RESPONSE_ID_LIST%
+	//
+	// Category Mapping queries should use %CATEGORY_MAPPING_ID_LIST%, e.g.:
+	//   SELECT id, mapping, 'category' as type FROM category_mappings WHERE id in %CATEGORY_MAPPING_ID_LIST%
+	//
+	// The ID of an ad server's mapping is its name, e.g. "freewheel". The ID of a publisher's own mapping
+	// is the ad server's name and the publisher ID, joined by an underscore, e.g. "freewheel_123".
 	QueryTemplate string `mapstructure:"query"`
 }
 
@@ -419,6 +493,10 @@ func resolve(template string, numReqs int, numImps int) (query string) {
 
 	query = strings.Replace(template, "%REQUEST_ID_LIST%", makeIdList(0, numReqs), -1)
 	query = strings.Replace(query, "%IMP_ID_LIST%", makeIdList(numReqs, numImps), -1)
+	// Account, Stored Response and Category Mapping queries pass their IDs in the "request" slot
+	query = strings.Replace(query, "%ACCOUNT_ID_LIST%", makeIdList(0, numReqs), -1)
+	query = strings.Replace(query, "%RESPONSE_ID_LIST%", makeIdList(0, numReqs), -1)
+	query = strings.Replace(query, "%CATEGORY_MAPPING_ID_LIST%", makeIdList(0, numReqs), -1)
 	return
 }
 
@@ -471,6 +549,12 @@ type InMemoryCache struct {
 	RequestCacheSize int `mapstructure:"request_cache_size_bytes"`
 	// ImpCacheSize is the max number of bytes allowed in the cache for Stored Imps. Values <= 0 will have no limit
 	ImpCacheSize int `mapstructure:"imp_cache_size_bytes"`
+	// AccountCacheSize is the max number of bytes allowed in the cache for Accounts. For "lru" caches,
+	// values <= 0 disable the Account cache.
+	AccountCacheSize int `mapstructure:"account_cache_size_bytes"`
+	// CategoryCacheSize is the max number of bytes allowed in the cache for Category Mappings. For "lru" caches,
+	// values <= 0 disable the Category Mapping cache.
+	CategoryCacheSize int `mapstructure:"category_cache_size_bytes"`
 }
 
 func (cfg *InMemoryCache) validate(errs configErrors) configErrors {
@@ -487,6 +571,12 @@ func (cfg *InMemoryCache) validate(errs configErrors) configErrors {
 		if cfg.ImpCacheSize != 0 {
 			errs = append(errs, fmt.Errorf("stored_requests.in_memory_cache.imp_cache_size_bytes must be 0 for unbounded caches. Got %d", cfg.ImpCacheSize))
 		}
+		if cfg.AccountCacheSize != 0 {
+			errs = append(errs, fmt.Errorf("stored_requests.in_memory_cache.account_cache_size_bytes must be 0 for unbounded caches. Got %d", cfg.AccountCacheSize))
+		}
+		if cfg.CategoryCacheSize != 0 {
+			errs = append(errs, fmt.Errorf("stored_requests.in_memory_cache.category_cache_size_bytes must be 0 for unbounded caches. Got %d", cfg.CategoryCacheSize))
+		}
 	case "lru":
 		if cfg.RequestCacheSize <= 0 {
 			errs = append(errs, fmt.Errorf("stored_requests.in_memory_cache.request_cache_size_bytes must be >= 0 when stored_requests.in_memory_cache.type=lru. Got %d", cfg.RequestCacheSize))
@@ -499,3 +589,26 @@ func (cfg *InMemoryCache) validate(errs configErrors) configErrors {
 	}
 	return errs
 }
+
+// validateCategoryMapping checks the in_memory_cache of the category_mapping config, which only uses
+// the Category Mapping cache.
+func (cfg *InMemoryCache) validateCategoryMapping(errs configErrors) configErrors {
+	switch cfg.Type {
+	case "", "none":
+		// No errors for no config options
+	case "unbounded":
+		if cfg.TTL != 0 {
+			errs = append(errs, fmt.Errorf("category_mapping.in_memory_cache.ttl_seconds must be 0 for unbounded caches. Got %d", cfg.TTL))
+		}
+		if cfg.CategoryCacheSize != 0 {
+			errs = append(errs, fmt.Errorf("category_mapping.in_memory_cache.category_cache_size_bytes must be 0 for unbounded caches. Got %d", cfg.CategoryCacheSize))
+		}
+	case "lru":
+		if cfg.CategoryCacheSize <= 0 {
+			errs = append(errs, fmt.Errorf("category_mapping.in_memory_cache.category_cache_size_bytes must be > 0 when category_mapping.in_memory_cache.type=lru. Got %d", cfg.CategoryCacheSize))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("category_mapping.in_memory_cache.type %s is invalid", cfg.Type))
+	}
+	return errs
+}
@@ -32,6 +32,21 @@ func TestQueryMakerMultilists(t *testing.T) {
 	assertStringsEqual(t, madeQuery, "SELECT id, config FROM table WHERE id in ($1, $2, $3) UNION ALL SELECT id, config FROM other_table WHERE id in ($1, $2, $3)")
 }
 
+func TestAccountQueryMaker(t *testing.T) {
+	madeQuery := buildQuery("SELECT id, config, 'account' as type FROM accounts WHERE id in %ACCOUNT_ID_LIST%", 1, 0)
+	assertStringsEqual(t, madeQuery, "SELECT id, config, 'account' as type FROM accounts WHERE id in ($1)")
+}
+
+func TestResponseQueryMaker(t *testing.T) {
+	madeQuery := buildQuery("SELECT id, data, 'response' as type FROM stored_responses WHERE id in %RESPONSE_ID_LIST%", 2, 0)
+	assertStringsEqual(t, madeQuery, "SELECT id, data, 'response' as type FROM stored_responses WHERE id in ($1, $2)")
+}
+
+func TestCategoryMappingQueryMaker(t *testing.T) {
+	madeQuery := buildQuery("SELECT id, mapping, 'category' as type FROM category_mappings WHERE id in %CATEGORY_MAPPING_ID_LIST%", 1, 0)
+	assertStringsEqual(t, madeQuery, "SELECT id, mapping, 'category' as type FROM category_mappings WHERE id in ($1)")
+}
+
 func TestQueryMakerNegative(t *testing.T) {
 	query := buildQuery(sampleQueryTemplate, -1, -2)
 	expected := buildQuery(sampleQueryTemplate, 0, 0)
@@ -114,6 +129,50 @@ func TestInMemoryCacheValidation(t *testing.T) {
 	}).validate(nil))
 }
 
+func TestCategoryMappingCacheValidation(t *testing.T) {
+	assertNoErrs(t, (&InMemoryCache{
+		Type: "unbounded",
+	}).validateCategoryMapping(nil))
+	assertNoErrs(t, (&InMemoryCache{
+		Type:              "lru",
+		TTL:               300,
+		CategoryCacheSize: 1000,
+	}).validateCategoryMapping(nil))
+	assertErrsExist(t, (&InMemoryCache{
+		Type: "lru",
+	}).validateCategoryMapping(nil))
+	assertErrsExist(t, (&InMemoryCache{
+		Type:              "unbounded",
+		CategoryCacheSize: 1000,
+	}).validateCategoryMapping(nil))
+	assertErrsExist(t, (&InMemoryCache{
+		Type: "unrecognized",
+	}).validateCategoryMapping(nil))
+}
+
+func TestMergeStrategyValidation(t *testing.T) {
+	noCache := InMemoryCache{Type: "none"}
+	assertNoErrs(t, (&StoredRequests{InMemoryCache: noCache}).validate(nil))
+	assertNoErrs(t, (&StoredRequests{InMemoryCache: noCache, MergeStrategy: "replace"}).validate(nil))
+	assertNoErrs(t, (&StoredRequests{InMemoryCache: noCache, MergeStrategy: "deep"}).validate(nil))
+	assertErrsExist(t, (&StoredRequests{InMemoryCache: noCache, MergeStrategy: "append"}).validate(nil))
+}
+
+func TestWriteAPIValidation(t *testing.T) {
+	noCache := InMemoryCache{Type: "none"}
+	withSecret := StoredRequestsWriteAPI{Enabled: true, Secret: "shh"}
+	saveQueries := PostgresSaveQueries{RequestQuery: "INSERT INTO stored_requests", ImpQuery: "INSERT INTO stored_imps"}
+	db := PostgresConnection{Database: "db"}
+
+	assertNoErrs(t, (&StoredRequests{InMemoryCache: noCache, WriteAPI: StoredRequestsWriteAPI{Secret: "unused"}}).validate(nil))
+	assertNoErrs(t, (&StoredRequests{InMemoryCache: noCache, Files: true, WriteAPI: withSecret}).validate(nil))
+	assertNoErrs(t, (&StoredRequests{InMemoryCache: noCache, Postgres: PostgresConfig{ConnectionInfo: db, SaveQueries: saveQueries}, WriteAPI: withSecret}).validate(nil))
+	assertErrsExist(t, (&StoredRequests{InMemoryCache: noCache, Files: true, WriteAPI: StoredRequestsWriteAPI{Enabled: true}}).validate(nil))
+	assertErrsExist(t, (&StoredRequests{InMemoryCache: noCache, WriteAPI: withSecret}).validate(nil))
+	assertErrsExist(t, (&StoredRequests{InMemoryCache: noCache, Postgres: PostgresConfig{SaveQueries: saveQueries}, WriteAPI: withSecret}).validate(nil))
+	assertErrsExist(t, (&StoredRequests{InMemoryCache: noCache, Postgres: PostgresConfig{ConnectionInfo: db, SaveQueries: PostgresSaveQueries{RequestQuery: "INSERT INTO stored_requests"}}}).validate(nil))
+}
+
 func assertErrsExist(t *testing.T, err configErrors) {
 	t.Helper()
 	if len(err) == 0 {
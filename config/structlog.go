@@ -14,8 +14,11 @@ type logMsg func(string, ...interface{})
 var mapregex = regexp.MustCompile(`mapstructure:"([^"]+)"`)
 var blacklistregexp = []*regexp.Regexp{
 	regexp.MustCompile("password"),
+	regexp.MustCompile("secret"),
 }
 
+const redacted = "<REDACTED>"
+
 // LogGeneral will log nearly any sort of value, but requires the name of the root object to be in the
 // prefix if you want that name to be logged. Structs will append .<fieldname> recursively to the prefix
 // to document deeper structure.
@@ -53,7 +56,7 @@ func logStructWithLogger(v reflect.Value, prefix string, logger logMsg) {
 		if allowedName(fieldname) {
 			logGeneralWithLogger(v.Field(i), extendPrefix(prefix, fieldname), logger)
 		} else {
-			logger("%s.%s: <REDACTED>", prefix, fieldname)
+			logger("%s.%s: %s", prefix, fieldname, redacted)
 		}
 	}
 }
@@ -64,7 +67,7 @@ func logMapWithLogger(v reflect.Value, prefix string, logger logMsg) {
 	}
 	for _, k := range v.MapKeys() {
 		if k.Kind() == reflect.String && !allowedName(k.String()) {
-			logger("%s: <REDACTED>", extendMapPrefix(prefix, k.String()))
+			logger("%s: %s", extendMapPrefix(prefix, k.String()), redacted)
 		} else {
 			// Use Sprintf("%v", k.Interface) to handle non-string keys. Should not be possible to have a key
 			// too complex to represent by %v.
@@ -75,6 +78,67 @@ func logMapWithLogger(v reflect.Value, prefix string, logger logMsg) {
 	}
 }
 
+// Redact returns a copy of v which can be marshalled to JSON. Struct fields are keyed by their mapstructure tags,
+// so the keys match the config files. Like the startup log, it redacts the values of blacklisted names.
+func Redact(v interface{}) interface{} {
+	return redactValue(reflect.ValueOf(v))
+}
+
+func redactValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		fields := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			fieldname := t.Field(i).Name
+			if match := mapregex.FindStringSubmatch(string(t.Field(i).Tag)); len(match) > 0 && len(match[1]) > 0 {
+				fieldname = strings.Split(match[1], ",")[0]
+			}
+			if allowedName(fieldname) {
+				fields[fieldname] = redactValue(v.Field(i))
+			} else {
+				fields[fieldname] = redacted
+			}
+		}
+		return fields
+	case reflect.Map:
+		entries := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			key := fmt.Sprintf("%v", k)
+			if allowedName(key) {
+				entries[key] = redactValue(v.MapIndex(k))
+			} else {
+				entries[key] = redacted
+			}
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = redactValue(v.Index(i))
+		}
+		return values
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	default:
+		return v.String()
+	}
+}
+
 func fieldNameByTag(f reflect.StructField) string {
 	match := mapregex.FindStringSubmatch(string(f.Tag))
 	if len(match) == 0 || len(match[1]) == 0 {
@@ -56,3 +56,35 @@ func TestBasic(t *testing.T) {
 		t.Errorf("Did not log properly.\ndesired:%s\nfound:%s\nsource: %v", expected, result, testCfg)
 	}
 }
+
+func TestRedact(t *testing.T) {
+	testCfg := &testStruct{
+		Myint:    5,
+		Mystring: "foobar",
+		Sub: innerStruct{
+			int1:     3,
+			password: "secret",
+		},
+		Caps: map[string]string{
+			"Alabama":       "Montgomery",
+			"client_secret": "hunter2",
+		},
+	}
+
+	expected := map[string]interface{}{
+		"this_int": int64(5),
+		"mystring": "foobar",
+		"Flag":     false,
+		"sub": map[string]interface{}{
+			"int1":     int64(3),
+			"password": "<REDACTED>",
+		},
+		"Caps": map[string]interface{}{
+			"Alabama":       "Montgomery",
+			"client_secret": "<REDACTED>",
+		},
+	}
+	if result := Redact(testCfg); !reflect.DeepEqual(expected, result) {
+		t.Errorf("Did not redact properly.\ndesired: %v\nfound: %v", expected, result)
+	}
+}
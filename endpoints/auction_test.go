@@ -413,6 +413,14 @@ func (m *auctionMockPermissions) PersonalInfoAllowed(ctx context.Context, bidder
 	return m.allowPI, nil
 }
 
+func (m *auctionMockPermissions) AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (gdpr.AuctionPermissions, error) {
+	return gdpr.AuctionPermissions{
+		AllowBidRequest: true,
+		AllowUserIDs:    m.allowPI,
+		AllowPreciseGeo: m.allowPI,
+	}, nil
+}
+
 func TestBidSizeValidate(t *testing.T) {
 	bids := make(pbs.PBSBidSlice, 0)
 	// bid1 will be rejected due to undefined size when adunit has multiple sizes
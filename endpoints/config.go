@@ -0,0 +1,47 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// NewConfigEndpoint returns the effective config of the PBS server, with secrets redacted.
+func NewConfigEndpoint(cfg *config.Configuration) http.HandlerFunc {
+	return newRedactedJSONEndpoint("/config", config.Redact(cfg))
+}
+
+// NewBiddersConfigEndpoint returns the resolved config of each bidder, with secrets redacted.
+// Bidders which aren't in the config files are listed with their default settings.
+func NewBiddersConfigEndpoint(cfg *config.Configuration) http.HandlerFunc {
+	bidders := make(map[string]interface{}, len(openrtb_ext.BidderMap))
+	for name := range openrtb_ext.BidderMap {
+		bidders[name] = config.Redact(cfg.Adapters[strings.ToLower(name)])
+	}
+	// Legacy adapters like districtm only exist in the config.
+	for name, adapter := range cfg.Adapters {
+		if _, ok := bidders[name]; !ok {
+			bidders[name] = config.Redact(adapter)
+		}
+	}
+	return newRedactedJSONEndpoint("/config/bidders", bidders)
+}
+
+func newRedactedJSONEndpoint(path string, value interface{}) http.HandlerFunc {
+	jsonOutput, err := json.Marshal(value)
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err != nil {
+			glog.Errorf("%s Critical error when trying to marshal the config: %v", path, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonOutput)
+	}
+}
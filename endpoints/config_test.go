@@ -0,0 +1,60 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigEndpoint(t *testing.T) {
+	cfg := &config.Configuration{
+		Host: "prebid.example.com",
+		StoredRequests: config.StoredRequests{
+			Postgres: config.PostgresConfig{
+				ConnectionInfo: config.PostgresConnection{Username: "pbs", Password: "hunter2"},
+			},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewConfigEndpoint(cfg)(w, httptest.NewRequest("GET", "/config", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("/config returned invalid JSON: %v", err)
+	}
+	assert.Equal(t, "prebid.example.com", body["host"], "Fields should be keyed by their config names")
+	assert.NotContains(t, w.Body.String(), "hunter2", "Passwords should be redacted")
+}
+
+func TestBiddersConfigEndpoint(t *testing.T) {
+	rubicon := config.Adapter{Endpoint: "http://rubicon.example.com"}
+	rubicon.XAPI.Username = "rubicon"
+	rubicon.XAPI.Password = "hunter2"
+	cfg := &config.Configuration{
+		Adapters: map[string]config.Adapter{
+			"appnexus":  {Endpoint: "http://appnexus.example.com"},
+			"districtm": {Endpoint: "http://districtm.example.com"},
+			"rubicon":   rubicon,
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewBiddersConfigEndpoint(cfg)(w, httptest.NewRequest("GET", "/config/bidders", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body map[string]map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("/config/bidders returned invalid JSON: %v", err)
+	}
+	assert.Equal(t, "http://appnexus.example.com", body["appnexus"]["endpoint"])
+	assert.Equal(t, "http://districtm.example.com", body["districtm"]["endpoint"], "Bidders which only exist in the config should be listed")
+	assert.Contains(t, body, "pubmatic", "Bidders without config should be listed")
+	assert.NotContains(t, w.Body.String(), "hunter2", "Passwords should be redacted")
+}
@@ -9,37 +9,45 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/golang/glog"
 	"github.com/julienschmidt/httprouter"
+	accountService "github.com/prebid/prebid-server/account"
 	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/privacy/ccpa"
+	"github.com/prebid/prebid-server/stored_requests"
 	"github.com/prebid/prebid-server/usersync"
 )
 
-func NewCookieSyncEndpoint(syncers map[openrtb_ext.BidderName]usersync.Usersyncer, cfg *config.Configuration, syncPermissions gdpr.Permissions, metrics pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule) httprouter.Handle {
+func NewCookieSyncEndpoint(syncers map[openrtb_ext.BidderName]usersync.Usersyncer, cfg *config.Configuration, syncPermissions gdpr.Permissions, metrics pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, accounts stored_requests.AccountFetcher) httprouter.Handle {
 	deps := &cookieSyncDeps{
 		syncers:         syncers,
+		cfg:             cfg,
 		hostCookie:      &cfg.HostCookie,
 		gDPR:            &cfg.GDPR,
 		syncPermissions: syncPermissions,
 		metrics:         metrics,
 		pbsAnalytics:    pbsAnalytics,
+		accounts:        accounts,
 	}
 	return deps.Endpoint
 }
 
 type cookieSyncDeps struct {
 	syncers         map[openrtb_ext.BidderName]usersync.Usersyncer
+	cfg             *config.Configuration
 	hostCookie      *config.HostCookie
 	gDPR            *config.GDPR
 	syncPermissions gdpr.Permissions
 	metrics         pbsmetrics.MetricsEngine
 	pbsAnalytics    analytics.PBSAnalyticsModule
+	accounts        stored_requests.AccountFetcher
 }
 
 func (deps *cookieSyncDeps) Endpoint(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -91,6 +99,13 @@ func (deps *cookieSyncDeps) Endpoint(w http.ResponseWriter, r *http.Request, _ h
 		http.Error(w, "gdpr_consent is required if gdpr=1", http.StatusBadRequest)
 		return
 	}
+	ccpaPolicy := ccpa.Policy{Value: parsedReq.USPrivacy}
+	if err := ccpaPolicy.Validate(); err != nil {
+		co.Status = http.StatusBadRequest
+		co.Errors = append(co.Errors, fmt.Errorf("us_privacy %v", err))
+		http.Error(w, "us_privacy "+err.Error(), http.StatusBadRequest)
+		return
+	}
 	// If GDPR is ambiguous, lets untangle it here.
 	if parsedReq.GDPR == nil {
 		var gdpr = 1
@@ -100,28 +115,41 @@ func (deps *cookieSyncDeps) Endpoint(w http.ResponseWriter, r *http.Request, _ h
 		parsedReq.GDPR = &gdpr
 	}
 
-	if len(biddersJSON) == 0 {
-		parsedReq.Bidders = make([]string, 0, len(deps.syncers))
-		for bidder := range deps.syncers {
-			parsedReq.Bidders = append(parsedReq.Bidders, string(bidder))
-		}
+	requested := make(map[string]bool, len(parsedReq.Bidders))
+	for _, bidder := range parsedReq.Bidders {
+		requested[bidder] = true
+	}
+	if deps.coopSync(r.Context(), parsedReq, len(biddersJSON) == 0) {
+		parsedReq.Bidders = append(parsedReq.Bidders, coopBidders(deps.syncers, requested, deps.cfg.UserSync.Cooperative.PriorityGroups)...)
 	}
+	skipped := &cookieSyncSkips{requested: requested}
 
-	parsedReq.filterExistingSyncs(deps.syncers, userSyncCookie)
+	skipped.add(parsedReq.filterUnsupported(deps.syncers), skipUnsupported)
+	skipped.add(parsedReq.filterExistingSyncs(deps.syncers, userSyncCookie), skipAlreadySynced)
 	adapterSyncs := make(map[openrtb_ext.BidderName]bool)
 	for _, b := range parsedReq.Bidders {
 		// assume all bidders will be GDPR blocked
 		adapterSyncs[openrtb_ext.BidderName(b)] = true
 	}
-	parsedReq.filterForGDPR(deps.syncPermissions)
+	skipped.add(parsedReq.filterForGDPR(deps.syncPermissions), skipGDPR)
 	for _, b := range parsedReq.Bidders {
 		// surviving bidders are not GDPR blocked
 		adapterSyncs[openrtb_ext.BidderName(b)] = false
 	}
+	ccpaBlocked := ccpaPolicy.ShouldEnforce()
+	if ccpaBlocked {
+		skipped.add(parsedReq.Bidders, skipCCPA)
+		parsedReq.Bidders = nil
+	}
 	for b, g := range adapterSyncs {
-		deps.metrics.RecordAdapterCookieSync(b, g)
+		source := pbsmetrics.CookieSyncSourceCoop
+		if requested[string(b)] {
+			source = pbsmetrics.CookieSyncSourceRequest
+		}
+		deps.metrics.RecordAdapterCookieSync(b, source, g, ccpaBlocked && !g)
 	}
-	parsedReq.filterToLimit()
+	skipped.add(parsedReq.filterForSyncTypes(deps.syncers), skipSyncTypeFiltered)
+	skipped.add(parsedReq.filterToLimit(requested), skipLimit)
 
 	csResp := cookieSyncResponse{
 		Status:       cookieSyncStatus(userSyncCookie.LiveSyncCount()),
@@ -129,7 +157,9 @@ func (deps *cookieSyncDeps) Endpoint(w http.ResponseWriter, r *http.Request, _ h
 	}
 	for i := 0; i < len(parsedReq.Bidders); i++ {
 		bidder := parsedReq.Bidders[i]
-		syncInfo, err := deps.syncers[openrtb_ext.BidderName(bidder)].GetUsersyncInfo(gdprToString(parsedReq.GDPR), parsedReq.Consent)
+		syncer := deps.syncers[openrtb_ext.BidderName(bidder)]
+		syncType, _ := parsedReq.FilterSettings.pickSyncType(bidder, syncer.SupportedSyncTypes())
+		syncInfo, err := syncer.GetUsersyncInfoForType(syncType, gdprToString(parsedReq.GDPR), parsedReq.Consent)
 		if err == nil {
 			newSync := &usersync.CookieSyncBidders{
 				BidderCode:   bidder,
@@ -139,8 +169,10 @@ func (deps *cookieSyncDeps) Endpoint(w http.ResponseWriter, r *http.Request, _ h
 			csResp.BidderStatus = append(csResp.BidderStatus, newSync)
 		} else {
 			glog.Errorf("Failed to get usersync info for %s: %v", bidder, err)
+			skipped.add([]string{bidder}, skipError)
 		}
 	}
+	csResp.Skipped = skipped.list
 
 	if len(csResp.BidderStatus) > 0 {
 		co.BidderStatus = append(co.BidderStatus, csResp.BidderStatus...)
@@ -176,64 +208,266 @@ func cookieSyncStatus(syncCount int) string {
 	return "ok"
 }
 
+// coopSync says whether the request should sync the bidders which it didn't ask for, so that the user gets synced
+// with them too. The request decides if it says so. Otherwise its account decides, and then the host. Requests which
+// don't name any bidders coop sync unless one of those says they shouldn't.
+func (deps *cookieSyncDeps) coopSync(ctx context.Context, req *cookieSyncRequest, biddersOmitted bool) bool {
+	if req.CoopSync != nil {
+		return *req.CoopSync
+	}
+	if req.Account != "" {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(deps.cfg.UserSync.AccountTimeoutMS)*time.Millisecond)
+		defer cancel()
+		// Account errors aren't fatal here. The request is synced with the host's defaults instead.
+		if account, errs := accountService.GetAccount(ctx, deps.cfg, deps.accounts, req.Account); len(errs) == 0 && account.CookieSync.DefaultCoopSync != nil {
+			return *account.CookieSync.DefaultCoopSync
+		}
+	}
+	return biddersOmitted || deps.cfg.UserSync.Cooperative.EnabledByDefault
+}
+
+// coopBidders returns the bidders which have syncers but weren't requested. They're ordered by the host's
+// priority groups and shuffled within each group. Bidders which aren't in any group come last, in a random order.
+func coopBidders(syncers map[openrtb_ext.BidderName]usersync.Usersyncer, requested map[string]bool, priorityGroups [][]string) []string {
+	bidders := make([]string, 0, len(syncers))
+	added := make(map[string]bool, len(syncers))
+	addShuffled := func(group []string) {
+		start := len(bidders)
+		for _, bidder := range group {
+			if _, ok := syncers[openrtb_ext.BidderName(bidder)]; ok && !requested[bidder] && !added[bidder] {
+				bidders = append(bidders, bidder)
+				added[bidder] = true
+			}
+		}
+		shuffled := bidders[start:]
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+	}
+
+	for _, group := range priorityGroups {
+		addShuffled(group)
+	}
+	ungrouped := make([]string, 0, len(syncers))
+	for bidder := range syncers {
+		ungrouped = append(ungrouped, string(bidder))
+	}
+	addShuffled(ungrouped)
+	return bidders
+}
+
 type cookieSyncRequest struct {
-	Bidders []string `json:"bidders"`
-	GDPR    *int     `json:"gdpr"`
-	Consent string   `json:"gdpr_consent"`
-	Limit   int      `json:"limit"`
+	Bidders        []string                  `json:"bidders"`
+	GDPR           *int                      `json:"gdpr"`
+	Consent        string                    `json:"gdpr_consent"`
+	USPrivacy      string                    `json:"us_privacy"`
+	Limit          int                       `json:"limit"`
+	Account        string                    `json:"account"`
+	CoopSync       *bool                     `json:"coopSync"`
+	FilterSettings *cookieSyncFilterSettings `json:"filterSettings"`
 }
 
-func (req *cookieSyncRequest) filterExistingSyncs(valid map[openrtb_ext.BidderName]usersync.Usersyncer, cookie *usersync.PBSCookie) {
+// filter removes the bidders which keep returns false for, and returns them.
+func (req *cookieSyncRequest) filter(keep func(bidder string) bool) []string {
+	var removed []string
 	for i := 0; i < len(req.Bidders); i++ {
-		thisBidder := req.Bidders[i]
-		if syncer, isValid := valid[openrtb_ext.BidderName(thisBidder)]; !isValid || cookie.HasLiveSync(syncer.FamilyName()) {
+		if !keep(req.Bidders[i]) {
+			removed = append(removed, req.Bidders[i])
 			req.Bidders = append(req.Bidders[:i], req.Bidders[i+1:]...)
 			i--
 		}
 	}
+	return removed
+}
+
+func (req *cookieSyncRequest) filterUnsupported(valid map[openrtb_ext.BidderName]usersync.Usersyncer) []string {
+	return req.filter(func(bidder string) bool {
+		_, isValid := valid[openrtb_ext.BidderName(bidder)]
+		return isValid
+	})
+}
+
+func (req *cookieSyncRequest) filterExistingSyncs(valid map[openrtb_ext.BidderName]usersync.Usersyncer, cookie *usersync.PBSCookie) []string {
+	return req.filter(func(bidder string) bool {
+		return !cookie.HasLiveSync(valid[openrtb_ext.BidderName(bidder)].FamilyName())
+	})
 }
 
-func (req *cookieSyncRequest) filterForGDPR(permissions gdpr.Permissions) {
+func (req *cookieSyncRequest) filterForGDPR(permissions gdpr.Permissions) []string {
 	if req.GDPR != nil && *req.GDPR == 0 {
-		return
+		return nil
 	}
 
 	if allowSync, err := permissions.HostCookiesAllowed(context.Background(), req.Consent); err != nil || !allowSync {
+		removed := req.Bidders
 		req.Bidders = nil
-		return
+		return removed
 	}
 
-	for i := 0; i < len(req.Bidders); i++ {
-		if allowSync, err := permissions.BidderSyncAllowed(context.Background(), openrtb_ext.BidderName(req.Bidders[i]), req.Consent); err != nil || !allowSync {
-			req.Bidders = append(req.Bidders[:i], req.Bidders[i+1:]...)
-			i--
-		}
-	}
+	return req.filter(func(bidder string) bool {
+		allowSync, err := permissions.BidderSyncAllowed(context.Background(), openrtb_ext.BidderName(bidder), req.Consent)
+		return err == nil && allowSync
+	})
+}
+
+// filterForSyncTypes removes the bidders which can't run any of the types of sync allowed by the filter settings.
+func (req *cookieSyncRequest) filterForSyncTypes(syncers map[openrtb_ext.BidderName]usersync.Usersyncer) []string {
+	return req.filter(func(bidder string) bool {
+		_, ok := req.FilterSettings.pickSyncType(bidder, syncers[openrtb_ext.BidderName(bidder)].SupportedSyncTypes())
+		return ok
+	})
 }
 
-// filterToLimit will enforce a max limit on cookiesyncs supplied, picking a random subset of syncs to get to the limit if over.
-func (req *cookieSyncRequest) filterToLimit() {
+// filterToLimit will enforce a max limit on cookiesyncs supplied, and returns the bidders which were dropped.
+// Requested bidders are kept before coop ones. If there are too many of them, a random subset of them is kept.
+func (req *cookieSyncRequest) filterToLimit(requested map[string]bool) []string {
 	if req.Limit <= 0 {
-		return
+		return nil
 	}
 	if req.Limit >= len(req.Bidders) {
-		return
+		return nil
+	}
+
+	ordered := make([]string, 0, len(req.Bidders))
+	for _, bidder := range req.Bidders {
+		if requested[bidder] {
+			ordered = append(ordered, bidder)
+		}
+	}
+	rand.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	for _, bidder := range req.Bidders {
+		if !requested[bidder] {
+			ordered = append(ordered, bidder)
+		}
 	}
+	req.Bidders = ordered[:req.Limit]
+	return ordered[req.Limit:]
+}
+
+// cookieSyncFilterSettings says which bidders may run each type of sync. It works like
+// userSync.filterSettings in Prebid.js: image syncs are allowed unless a filter says otherwise,
+// but iframe syncs are only allowed for the bidders which a filter includes.
+//
+// If a request has no filter settings, every type of sync is allowed.
+type cookieSyncFilterSettings struct {
+	Iframe *cookieSyncFilter `json:"iframe"`
+	Image  *cookieSyncFilter `json:"image"`
+}
 
-	// Modified Fisher and Yates' shuffle. We don't need the bidder list shuffled, so we stop shuffling once the final values beyond limit have been set.
-	// We also don't bother saving the values that should go into the entries beyond limit, as they will be discarded.
-	for i := len(req.Bidders) - 1; i >= req.Limit; i-- {
-		j := rand.Intn(i + 1)
-		if i != j {
-			req.Bidders[j] = req.Bidders[i]
-			// Don't complete the swap as the new value for req.Bidders[i] will be discarded below, and will never again be accessed as part of the swapping.
+// pickSyncType returns the first of the syncTypes which the filter settings allow for the bidder,
+// or false if none of them are allowed.
+func (s *cookieSyncFilterSettings) pickSyncType(bidder string, syncTypes []usersync.SyncType) (usersync.SyncType, bool) {
+	for _, syncType := range syncTypes {
+		if s.allows(syncType, bidder) {
+			return syncType, true
 		}
 	}
-	req.Bidders = req.Bidders[:req.Limit]
-	return
+	return "", false
+}
+
+func (s *cookieSyncFilterSettings) allows(syncType usersync.SyncType, bidder string) bool {
+	if s == nil {
+		return true
+	}
+	switch syncType {
+	case usersync.SyncTypeIframe:
+		return s.Iframe != nil && s.Iframe.allows(bidder)
+	case usersync.SyncTypeRedirect:
+		return s.Image == nil || s.Image.allows(bidder)
+	}
+	return false
+}
+
+type cookieSyncFilter struct {
+	// allBidders is true if the filter's bidders are "*". Otherwise, they're in bidders.
+	allBidders bool
+	bidders    map[string]bool
+	exclude    bool
+}
+
+func (f *cookieSyncFilter) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Bidders json.RawMessage `json:"bidders"`
+		Filter  string          `json:"filter"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch raw.Filter {
+	case "", "include":
+		f.exclude = false
+	case "exclude":
+		f.exclude = true
+	default:
+		return fmt.Errorf(`filterSettings filter must be "include" or "exclude". Got "%s"`, raw.Filter)
+	}
+
+	if len(raw.Bidders) == 0 {
+		f.allBidders = true
+		return nil
+	}
+	var all string
+	if err := json.Unmarshal(raw.Bidders, &all); err == nil {
+		if all != "*" {
+			return fmt.Errorf(`filterSettings bidders must be "*" or an array of bidders. Got "%s"`, all)
+		}
+		f.allBidders = true
+		return nil
+	}
+	var bidders []string
+	if err := json.Unmarshal(raw.Bidders, &bidders); err != nil {
+		return errors.New(`filterSettings bidders must be "*" or an array of bidders`)
+	}
+	f.bidders = make(map[string]bool, len(bidders))
+	for _, bidder := range bidders {
+		f.bidders[bidder] = true
+	}
+	return nil
+}
+
+func (f *cookieSyncFilter) allows(bidder string) bool {
+	listed := f.allBidders || f.bidders[bidder]
+	return listed != f.exclude
 }
 
 type cookieSyncResponse struct {
 	Status       string                        `json:"status"`
 	BidderStatus []*usersync.CookieSyncBidders `json:"bidder_status"`
+	Skipped      []cookieSyncSkip              `json:"skipped,omitempty"`
+}
+
+// cookieSyncSkip says why a requested bidder won't be synced.
+type cookieSyncSkip struct {
+	Bidder string     `json:"bidder"`
+	Reason skipReason `json:"reason"`
+}
+
+type skipReason string
+
+const (
+	skipUnsupported      skipReason = "unsupported_bidder"
+	skipAlreadySynced    skipReason = "already_synced"
+	skipGDPR             skipReason = "gdpr"
+	skipCCPA             skipReason = "ccpa"
+	skipSyncTypeFiltered skipReason = "sync_type_filtered"
+	skipLimit            skipReason = "limit"
+	skipError            skipReason = "error"
+)
+
+// cookieSyncSkips collects the reasons why requested bidders were skipped.
+// Coop bidders aren't reported, because the request didn't ask for them.
+type cookieSyncSkips struct {
+	requested map[string]bool
+	list      []cookieSyncSkip
+}
+
+func (s *cookieSyncSkips) add(bidders []string, reason skipReason) {
+	for _, bidder := range bidders {
+		if s.requested[bidder] {
+			s.list = append(s.list, cookieSyncSkip{Bidder: bidder, Reason: reason})
+		}
+	}
 }
@@ -2,6 +2,7 @@ package endpoints
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,17 +12,22 @@ import (
 
 	"github.com/buger/jsonparser"
 	"github.com/julienschmidt/httprouter"
+	"github.com/prebid/prebid-server/adapters"
 	"github.com/prebid/prebid-server/adapters/appnexus"
 	"github.com/prebid/prebid-server/adapters/audienceNetwork"
 	"github.com/prebid/prebid-server/adapters/lifestreet"
 	"github.com/prebid/prebid-server/adapters/pubmatic"
+	"github.com/prebid/prebid-server/analytics"
 	analyticsConf "github.com/prebid/prebid-server/analytics/config"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
 	metricsConf "github.com/prebid/prebid-server/pbsmetrics/config"
+	"github.com/prebid/prebid-server/stored_requests"
 	"github.com/prebid/prebid-server/usersync"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestCookieSyncNoCookies(t *testing.T) {
@@ -65,6 +71,27 @@ func TestGDPRConsentRequired(t *testing.T) {
 	assert.Equal(t, "gdpr_consent is required if gdpr=1\n", rr.Body.String())
 }
 
+func TestCookieSyncCCPAPreventsBidders(t *testing.T) {
+	rr := doPost(`{"bidders":["appnexus", "pubmatic"],"us_privacy":"1NYN"}`, nil, true, syncersForTest())
+	assert.Equal(t, rr.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, parseSyncs(t, rr.Body.Bytes()))
+	assert.Equal(t, "no_cookie", parseStatus(t, rr.Body.Bytes()))
+}
+
+func TestCookieSyncCCPANotOptedOut(t *testing.T) {
+	rr := doPost(`{"bidders":["appnexus", "pubmatic"],"us_privacy":"1NNN"}`, nil, true, syncersForTest())
+	assert.Equal(t, rr.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.ElementsMatch(t, []string{"appnexus", "pubmatic"}, parseSyncs(t, rr.Body.Bytes()))
+}
+
+func TestCookieSyncCCPAInvalid(t *testing.T) {
+	rr := doPost(`{"bidders":["appnexus", "pubmatic"],"us_privacy":"1NY"}`, nil, true, syncersForTest())
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "us_privacy must contain 4 characters\n", rr.Body.String())
+}
+
 func TestCookieSyncHasCookies(t *testing.T) {
 	rr := doPost(`{"bidders":["appnexus", "audienceNetwork", "random"]}`, map[string]string{
 		"adnxs":           "1234",
@@ -117,6 +144,146 @@ func TestCookieSyncWithLargeLimit(t *testing.T) {
 	assert.Equal(t, "no_cookie", parseStatus(t, rr.Body.Bytes()))
 }
 
+func TestCookieSyncFilterSettings(t *testing.T) {
+	rr := doPostWithSyncers(`{"gdpr":0,"bidders":["appnexus","pubmatic","lifestreet"],"filterSettings":{"iframe":{"bidders":["appnexus","pubmatic"]},"image":{"bidders":["appnexus"],"filter":"exclude"}}}`, nil, multiTypeSyncersForTest())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, map[string]string{
+		"appnexus":   "iframe",
+		"pubmatic":   "iframe",
+		"lifestreet": "redirect",
+	}, parseSyncTypes(t, rr.Body.Bytes()))
+	assert.Empty(t, parseSkipped(t, rr.Body.Bytes()))
+}
+
+func TestCookieSyncFilterSettingsPreferBidderType(t *testing.T) {
+	rr := doPostWithSyncers(`{"gdpr":0,"bidders":["appnexus","pubmatic"],"filterSettings":{"image":{"bidders":"*"}}}`, nil, multiTypeSyncersForTest())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, map[string]string{"appnexus": "redirect"}, parseSyncTypes(t, rr.Body.Bytes()))
+	assert.Equal(t, map[string]string{"pubmatic": "sync_type_filtered"}, parseSkipped(t, rr.Body.Bytes()), "iframes should be off unless filterSettings.iframe allows them")
+}
+
+func TestCookieSyncNoFilterSettings(t *testing.T) {
+	rr := doPostWithSyncers(`{"gdpr":0,"bidders":["appnexus","pubmatic"]}`, nil, multiTypeSyncersForTest())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, map[string]string{"appnexus": "redirect", "pubmatic": "iframe"}, parseSyncTypes(t, rr.Body.Bytes()))
+}
+
+func TestCookieSyncBadFilterSettings(t *testing.T) {
+	rr := doPost(`{"bidders":["appnexus"],"filterSettings":{"iframe":{"bidders":"*","filter":"only"}}}`, nil, true, syncersForTest())
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "JSON parsing failed: filterSettings filter must be \"include\" or \"exclude\". Got \"only\"\n", rr.Body.String())
+
+	rr = doPost(`{"bidders":["appnexus"],"filterSettings":{"image":{"bidders":"appnexus"}}}`, nil, true, syncersForTest())
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCookieSyncSkipped(t *testing.T) {
+	rr := doPost(`{"bidders":["appnexus","random","lifestreet","pubmatic"],"limit":1}`, map[string]string{"adnxs": "1234"}, true, map[openrtb_ext.BidderName]usersync.Usersyncer{
+		openrtb_ext.BidderLifestreet: nil,
+		openrtb_ext.BidderPubmatic:   nil,
+	})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	syncs := parseSyncs(t, rr.Body.Bytes())
+	if assert.Len(t, syncs, 1) {
+		skipped := parseSkipped(t, rr.Body.Bytes())
+		assert.Equal(t, "already_synced", skipped["appnexus"])
+		assert.Equal(t, "unsupported_bidder", skipped["random"])
+		if syncs[0] == "lifestreet" {
+			assert.Equal(t, "limit", skipped["pubmatic"])
+		} else {
+			assert.Equal(t, "limit", skipped["lifestreet"])
+		}
+		assert.Len(t, skipped, 3)
+	}
+}
+
+func TestCookieSyncSkippedForGDPR(t *testing.T) {
+	rr := doPost(`{"gdpr":1,"gdpr_consent":"BOONs2HOONs2HABABBENAGgAAAAPrABACGA","bidders":["appnexus","pubmatic"]}`, nil, true, map[openrtb_ext.BidderName]usersync.Usersyncer{
+		openrtb_ext.BidderPubmatic: nil,
+	})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"pubmatic"}, parseSyncs(t, rr.Body.Bytes()))
+	assert.Equal(t, map[string]string{"appnexus": "gdpr"}, parseSkipped(t, rr.Body.Bytes()), "Coop bidders shouldn't be reported")
+}
+
+func TestCookieSyncCoopSync(t *testing.T) {
+	rr := doPost(`{"bidders":["appnexus"],"coopSync":true,"limit":2}`, nil, true, syncersForTest())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	syncs := parseSyncs(t, rr.Body.Bytes())
+	assert.Len(t, syncs, 2)
+	assert.Contains(t, syncs, "appnexus", "Requested bidders should be synced before coop ones")
+
+	rr = doPost(`{"bidders":["appnexus"]}`, nil, true, syncersForTest())
+	assert.Equal(t, []string{"appnexus"}, parseSyncs(t, rr.Body.Bytes()), "Coop syncing should be off by default when bidders are requested")
+
+	rr = doPost(`{"coopSync":false}`, nil, true, syncersForTest())
+	assert.Empty(t, parseSyncs(t, rr.Body.Bytes()), "Coop syncing can be turned off when no bidders are requested")
+}
+
+func TestCookieSyncCoopPriorityGroups(t *testing.T) {
+	cfg := &config.Configuration{}
+	cfg.UserSync.Cooperative.PriorityGroups = [][]string{{"pubmatic", "lifestreet"}, {"appnexus"}}
+	rr := doPostWithConfig(`{"limit":3}`, cfg, &metricsConf.DummyMetricsEngine{})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.ElementsMatch(t, []string{"pubmatic", "lifestreet", "appnexus"}, parseSyncs(t, rr.Body.Bytes()), "Bidders in earlier priority groups should be synced first")
+}
+
+func TestCoopBiddersOrder(t *testing.T) {
+	groups := [][]string{{"pubmatic", "lifestreet"}, {"appnexus"}}
+	bidders := coopBidders(syncersForTest(), map[string]bool{"lifestreet": true}, groups)
+	assert.Equal(t, []string{"pubmatic", "appnexus", "audienceNetwork"}, bidders, "Requested bidders should be left out, and ungrouped bidders should come last")
+}
+
+func TestCookieSyncCoopDefaults(t *testing.T) {
+	cfg := &config.Configuration{}
+	cfg.UserSync.AccountTimeoutMS = 50
+	hostDefault := &config.Configuration{}
+	hostDefault.UserSync.AccountTimeoutMS = 50
+	hostDefault.UserSync.Cooperative.EnabledByDefault = true
+	rr := doPostWithConfig(`{"bidders":["appnexus"]}`, hostDefault, &metricsConf.DummyMetricsEngine{})
+	assert.Len(t, parseSyncs(t, rr.Body.Bytes()), 4, "The host default should apply when the request doesn't say")
+
+	rr = doPostWithConfig(`{"bidders":["appnexus"],"account":"coop-acct"}`, cfg, &metricsConf.DummyMetricsEngine{})
+	assert.Len(t, parseSyncs(t, rr.Body.Bytes()), 4, "The account default should override the host's")
+
+	rr = doPostWithConfig(`{"account":"no-coop-acct"}`, cfg, &metricsConf.DummyMetricsEngine{})
+	assert.Empty(t, parseSyncs(t, rr.Body.Bytes()), "The account can turn coop syncing off when no bidders are requested")
+
+	rr = doPostWithConfig(`{"bidders":["appnexus"],"account":"coop-acct","coopSync":false}`, cfg, &metricsConf.DummyMetricsEngine{})
+	assert.Equal(t, []string{"appnexus"}, parseSyncs(t, rr.Body.Bytes()), "The request should override the account default")
+
+	rr = doPostWithConfig(`{"bidders":["appnexus"],"account":"unknown-acct"}`, hostDefault, &metricsConf.DummyMetricsEngine{})
+	assert.Len(t, parseSyncs(t, rr.Body.Bytes()), 4, "Unknown accounts should get the host default")
+}
+
+func TestCookieSyncCoopMetrics(t *testing.T) {
+	metrics := &pbsmetrics.MetricsEngineMock{}
+	metrics.On("RecordCookieSync", mock.Anything).Return()
+	metrics.On("RecordAdapterCookieSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	doPostWithConfig(`{"bidders":["appnexus"],"coopSync":true}`, &config.Configuration{}, metrics)
+	metrics.AssertCalled(t, "RecordAdapterCookieSync", openrtb_ext.BidderAppnexus, pbsmetrics.CookieSyncSourceRequest, false, false)
+	metrics.AssertCalled(t, "RecordAdapterCookieSync", openrtb_ext.BidderPubmatic, pbsmetrics.CookieSyncSourceCoop, false, false)
+	metrics.AssertNumberOfCalls(t, "RecordAdapterCookieSync", 4)
+}
+
+func doPostWithConfig(body string, cfg *config.Configuration, metrics pbsmetrics.MetricsEngine) *httptest.ResponseRecorder {
+	syncers := syncersForTest()
+	endpoint := NewCookieSyncEndpoint(syncers, cfg, mockPermissions(true, syncers), metrics, analyticsForTest(), cookieSyncAccountFetcher{})
+	req, _ := http.NewRequest("POST", "/cookie_sync", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	endpoint(rr, req, nil)
+	return rr
+}
+
+func doPostWithSyncers(body string, existingSyncs map[string]string, syncers map[openrtb_ext.BidderName]usersync.Usersyncer) *httptest.ResponseRecorder {
+	endpoint := NewCookieSyncEndpoint(syncers, &config.Configuration{}, mockPermissions(true, syncers), &metricsConf.DummyMetricsEngine{}, analyticsForTest(), cookieSyncAccountFetcher{})
+	req, _ := http.NewRequest("POST", "/cookie_sync", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	endpoint(rr, req, nil)
+	return rr
+}
+
 func doPost(body string, existingSyncs map[string]string, gdprHostConsent bool, gdprBidders map[openrtb_ext.BidderName]usersync.Usersyncer) *httptest.ResponseRecorder {
 	return doConfigurablePost(body, existingSyncs, gdprHostConsent, gdprBidders, config.GDPR{})
 }
@@ -140,7 +307,25 @@ func doConfigurablePost(body string, existingSyncs map[string]string, gdprHostCo
 }
 
 func testableEndpoint(perms gdpr.Permissions, cfgGDPR config.GDPR) httprouter.Handle {
-	return NewCookieSyncEndpoint(syncersForTest(), &config.Configuration{GDPR: cfgGDPR}, perms, &metricsConf.DummyMetricsEngine{}, analyticsConf.NewPBSAnalytics(&config.Analytics{}))
+	return NewCookieSyncEndpoint(syncersForTest(), &config.Configuration{GDPR: cfgGDPR}, perms, &metricsConf.DummyMetricsEngine{}, analyticsForTest(), cookieSyncAccountFetcher{})
+}
+
+type cookieSyncAccountFetcher struct{}
+
+func (cookieSyncAccountFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	switch accountID {
+	case "coop-acct":
+		return json.RawMessage(`{"cookie_sync":{"default_coop_sync":true}}`), nil
+	case "no-coop-acct":
+		return json.RawMessage(`{"cookie_sync":{"default_coop_sync":false}}`), nil
+	}
+	return nil, []error{stored_requests.NotFoundError{ID: accountID, DataType: "Account"}}
+}
+
+// analyticsForTest returns the analytics modules for an empty config, which don't log anything.
+func analyticsForTest() analytics.PBSAnalyticsModule {
+	modules, _ := analyticsConf.NewPBSAnalytics(&config.Analytics{}, nil, nil)
+	return modules
 }
 
 func syncersForTest() map[openrtb_ext.BidderName]usersync.Usersyncer {
@@ -152,6 +337,18 @@ func syncersForTest() map[openrtb_ext.BidderName]usersync.Usersyncer {
 	}
 }
 
+// multiTypeSyncersForTest has a bidder which supports both types of sync, one which only supports iframes,
+// and one which only supports redirects.
+func multiTypeSyncersForTest() map[openrtb_ext.BidderName]usersync.Usersyncer {
+	return map[openrtb_ext.BidderName]usersync.Usersyncer{
+		openrtb_ext.BidderAppnexus: adapters.WithSyncURLs(appnexus.NewAppnexusSyncer(template.Must(template.New("sync").Parse("someurl.com"))), map[adapters.SyncType]*template.Template{
+			adapters.SyncTypeIframe: template.Must(template.New("sync").Parse("someurl.com/iframe")),
+		}),
+		openrtb_ext.BidderLifestreet: lifestreet.NewLifestreetSyncer(template.Must(template.New("sync").Parse("anotherurl.com"))),
+		openrtb_ext.BidderPubmatic:   pubmatic.NewPubmaticSyncer(template.Must(template.New("sync").Parse("thaturl.com"))),
+	}
+}
+
 func parseStatus(t *testing.T, responseBody []byte) string {
 	t.Helper()
 	val, err := jsonparser.GetString(responseBody, "status")
@@ -177,6 +374,34 @@ func parseSyncs(t *testing.T, response []byte) []string {
 	return syncs
 }
 
+// parseSyncTypes returns the type of sync for each bidder in the response
+func parseSyncTypes(t *testing.T, response []byte) map[string]string {
+	t.Helper()
+	var parsed cookieSyncResponse
+	if err := json.Unmarshal(response, &parsed); err != nil {
+		t.Fatalf("Failed to parse the response: %v", err)
+	}
+	syncTypes := make(map[string]string, len(parsed.BidderStatus))
+	for _, status := range parsed.BidderStatus {
+		syncTypes[status.BidderCode] = status.UsersyncInfo.Type
+	}
+	return syncTypes
+}
+
+// parseSkipped returns the reason for each bidder which was skipped
+func parseSkipped(t *testing.T, response []byte) map[string]string {
+	t.Helper()
+	var parsed cookieSyncResponse
+	if err := json.Unmarshal(response, &parsed); err != nil {
+		t.Fatalf("Failed to parse the response: %v", err)
+	}
+	skipped := make(map[string]string, len(parsed.Skipped))
+	for _, skip := range parsed.Skipped {
+		skipped[skip.Bidder] = string(skip.Reason)
+	}
+	return skipped
+}
+
 func mockPermissions(allowHost bool, allowedBidders map[openrtb_ext.BidderName]usersync.Usersyncer) gdpr.Permissions {
 	return &gdprPerms{
 		allowHost:      allowHost,
@@ -201,3 +426,11 @@ func (g *gdprPerms) BidderSyncAllowed(ctx context.Context, bidder openrtb_ext.Bi
 func (g *gdprPerms) PersonalInfoAllowed(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (bool, error) {
 	return true, nil
 }
+
+func (g *gdprPerms) AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (gdpr.AuctionPermissions, error) {
+	return gdpr.AuctionPermissions{
+		AllowBidRequest: true,
+		AllowUserIDs:    true,
+		AllowPreciseGeo: true,
+	}, nil
+}
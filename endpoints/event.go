@@ -0,0 +1,108 @@
+package endpoints
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	accountService "github.com/prebid/prebid-server/account"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// trackingPixel is a 1x1 transparent PNG.
+var trackingPixel = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 0x49, 0x48, 0x44, 0x52,
+	0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4,
+	0x89, 0x00, 0x00, 0x00, 0x0d, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x60, 0x00, 0x02, 0x00,
+	0x00, 0x05, 0x00, 0x01, 0xe9, 0xfa, 0xdc, 0xd8, 0x00, 0x00, 0x00, 0x00, 0x49, 0x45, 0x4e, 0x44,
+	0xae, 0x42, 0x60, 0x82,
+}
+
+// NewEventEndpoint returns the handler for GET /event, which records win and impression notifications
+// for the bids in an auction response. The URLs are built by the exchange under bid.ext.prebid.events.
+//
+// Events for disabled accounts, or for unknown accounts when the host requires them, are rejected.
+func NewEventEndpoint(cfg *config.Configuration, accounts stored_requests.AccountFetcher, pbsAnalytics analytics.PBSAnalyticsModule) httprouter.Handle {
+	return httprouter.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		eventRequest, err := parseEventRequest(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Invalid request: %s\n", err.Error())))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(cfg.Event.AccountTimeoutMS)*time.Millisecond)
+		defer cancel()
+
+		account, errs := accountService.GetAccount(ctx, cfg, accounts, eventRequest.AccountID)
+		if len(errs) > 0 {
+			status := http.StatusInternalServerError
+			switch errortypes.DecodeError(errs[0]) {
+			case errortypes.BlacklistedAcctCode, errortypes.AcctRequiredCode:
+				status = http.StatusUnauthorized
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(fmt.Sprintf("Invalid request: %s\n", errs[0].Error())))
+			return
+		}
+		if !account.Analytics.Disabled {
+			pbsAnalytics.LogNotificationEventObject(&analytics.NotificationEvent{
+				Request: eventRequest,
+				Account: account,
+			})
+		}
+
+		if eventRequest.Format == analytics.Blank {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		w.Write(trackingPixel)
+	})
+}
+
+// parseEventRequest reads the event from the query string.
+func parseEventRequest(r *http.Request) (*analytics.EventRequest, error) {
+	query := r.URL.Query()
+	eventRequest := &analytics.EventRequest{
+		Type:      analytics.EventType(query.Get(analytics.TypeParameter)),
+		BidID:     query.Get(analytics.BidIDParameter),
+		AccountID: query.Get(analytics.AccountIDParameter),
+		Bidder:    query.Get(analytics.BidderParameter),
+		Format:    analytics.ResponseFormat(query.Get(analytics.FormatParameter)),
+	}
+
+	switch eventRequest.Type {
+	case analytics.Win, analytics.Imp:
+	case "":
+		return nil, fmt.Errorf("parameter '%s' is required", analytics.TypeParameter)
+	default:
+		return nil, fmt.Errorf("parameter '%s' must be either '%s' or '%s'", analytics.TypeParameter, analytics.Win, analytics.Imp)
+	}
+	if eventRequest.BidID == "" {
+		return nil, fmt.Errorf("parameter '%s' is required", analytics.BidIDParameter)
+	}
+	if eventRequest.AccountID == "" {
+		return nil, fmt.Errorf("parameter '%s' is required", analytics.AccountIDParameter)
+	}
+	switch eventRequest.Format {
+	case "", analytics.Image, analytics.Blank:
+	default:
+		return nil, fmt.Errorf("parameter '%s' must be either '%s' or '%s'", analytics.FormatParameter, analytics.Image, analytics.Blank)
+	}
+	if ts := query.Get(analytics.TimestampParameter); ts != "" {
+		timestamp, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil || timestamp < 0 {
+			return nil, fmt.Errorf("parameter '%s' must be a positive integer", analytics.TimestampParameter)
+		}
+		eventRequest.Timestamp = timestamp
+	}
+	return eventRequest, nil
+}
@@ -0,0 +1,143 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventEndpoint(t *testing.T) {
+	testCases := []struct {
+		description    string
+		url            string
+		accountRequire bool
+		expectStatus   int
+		expectLogged   *analytics.EventRequest
+	}{
+		{
+			description:  "Win event",
+			url:          "/event?t=win&b=bid-id&a=events-acct&bidder=appnexus&ts=1000",
+			expectStatus: http.StatusOK,
+			expectLogged: &analytics.EventRequest{Type: analytics.Win, BidID: "bid-id", AccountID: "events-acct", Bidder: "appnexus", Timestamp: 1000},
+		},
+		{
+			description:  "Imp event with a blank response",
+			url:          "/event?t=imp&b=bid-id&a=events-acct&f=b",
+			expectStatus: http.StatusNoContent,
+			expectLogged: &analytics.EventRequest{Type: analytics.Imp, BidID: "bid-id", AccountID: "events-acct", Format: analytics.Blank},
+		},
+		{
+			description:  "Unknown account",
+			url:          "/event?t=imp&b=bid-id&a=unknown-acct",
+			expectStatus: http.StatusOK,
+			expectLogged: &analytics.EventRequest{Type: analytics.Imp, BidID: "bid-id", AccountID: "unknown-acct"},
+		},
+		{
+			description:    "Unknown account, required",
+			url:            "/event?t=imp&b=bid-id&a=unknown-acct",
+			accountRequire: true,
+			expectStatus:   http.StatusUnauthorized,
+		},
+		{
+			description:  "Disabled account",
+			url:          "/event?t=imp&b=bid-id&a=disabled-acct",
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			description:  "Analytics disabled for the account",
+			url:          "/event?t=win&b=bid-id&a=no-analytics-acct",
+			expectStatus: http.StatusOK,
+		},
+		{
+			description:  "Missing type",
+			url:          "/event?b=bid-id&a=events-acct",
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Invalid type",
+			url:          "/event?t=click&b=bid-id&a=events-acct",
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Missing bid ID",
+			url:          "/event?t=win&a=events-acct",
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Missing account",
+			url:          "/event?t=win&b=bid-id",
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Invalid timestamp",
+			url:          "/event?t=win&b=bid-id&a=events-acct&ts=yesterday",
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Invalid format",
+			url:          "/event?t=win&b=bid-id&a=events-acct&f=x",
+			expectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		logger := &eventLogger{}
+		cfg := &config.Configuration{AccountRequired: test.accountRequire, Event: config.Event{AccountTimeoutMS: 50}}
+		endpoint := NewEventEndpoint(cfg, eventAccountFetcher{}, logger)
+
+		request := httptest.NewRequest("GET", test.url, nil)
+		recorder := httptest.NewRecorder()
+		endpoint(recorder, request, nil)
+
+		assert.Equal(t, test.expectStatus, recorder.Code, test.description)
+		if test.expectStatus == http.StatusOK {
+			assert.Equal(t, "image/png", recorder.Header().Get("Content-Type"), test.description)
+			assert.Equal(t, trackingPixel, recorder.Body.Bytes(), test.description)
+		}
+		if test.expectLogged == nil {
+			assert.Empty(t, logger.events, test.description)
+		} else if assert.Len(t, logger.events, 1, test.description) {
+			assert.Equal(t, test.expectLogged, logger.events[0].Request, test.description)
+		}
+	}
+}
+
+type eventAccountFetcher struct{}
+
+func (eventAccountFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	switch accountID {
+	case "events-acct":
+		return json.RawMessage(`{"events_enabled":true}`), nil
+	case "disabled-acct":
+		return json.RawMessage(`{"disabled":true}`), nil
+	case "no-analytics-acct":
+		return json.RawMessage(`{"analytics":{"disabled":true}}`), nil
+	}
+	return nil, []error{stored_requests.NotFoundError{ID: accountID, DataType: "Account"}}
+}
+
+// eventLogger records the NotificationEvents it receives, and ignores everything else
+type eventLogger struct {
+	events []*analytics.NotificationEvent
+}
+
+func (l *eventLogger) LogAuctionObject(ao *analytics.AuctionObject) {}
+
+func (l *eventLogger) LogVideoObject(vo *analytics.VideoObject) {}
+
+func (l *eventLogger) LogCookieSyncObject(cso *analytics.CookieSyncObject) {}
+
+func (l *eventLogger) LogSetUIDObject(so *analytics.SetUIDObject) {}
+
+func (l *eventLogger) LogAmpObject(ao *analytics.AmpObject) {}
+
+func (l *eventLogger) LogNotificationEventObject(ne *analytics.NotificationEvent) {
+	l.events = append(l.events, ne)
+}
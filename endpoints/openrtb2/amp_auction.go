@@ -16,6 +16,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/julienschmidt/httprouter"
 	"github.com/mxmCherry/openrtb"
+	accountService "github.com/prebid/prebid-server/account"
 	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/errortypes"
@@ -41,7 +42,9 @@ func NewAmpEndpoint(
 	ex exchange.Exchange,
 	validator openrtb_ext.BidderParamValidator,
 	requestsById stored_requests.Fetcher,
+	accounts stored_requests.AccountFetcher,
 	categories stored_requests.CategoryFetcher,
+	uidStore usersync.UIDStore,
 	cfg *config.Configuration,
 	met pbsmetrics.MetricsEngine,
 	pbsAnalytics analytics.PBSAnalyticsModule,
@@ -50,7 +53,7 @@ func NewAmpEndpoint(
 	bidderMap map[string]openrtb_ext.BidderName,
 ) (httprouter.Handle, error) {
 
-	if ex == nil || validator == nil || requestsById == nil || cfg == nil || met == nil {
+	if ex == nil || validator == nil || requestsById == nil || accounts == nil || cfg == nil || met == nil {
 		return nil, errors.New("NewAmpEndpoint requires non-nil arguments.")
 	}
 
@@ -61,7 +64,9 @@ func NewAmpEndpoint(
 		validator,
 		requestsById,
 		empty_fetcher.EmptyFetcher{},
+		accounts,
 		categories,
+		uidStore,
 		cfg,
 		met,
 		pbsAnalytics,
@@ -97,10 +102,13 @@ func (deps *endpointDeps) AmpAuction(w http.ResponseWriter, r *http.Request, _ h
 		CookieFlag:    pbsmetrics.CookieFlagUnknown,
 		RequestStatus: pbsmetrics.RequestStatusOK,
 	}
+	var account *config.Account
 	defer func() {
 		deps.metricsEngine.RecordRequest(labels)
 		deps.metricsEngine.RecordRequestTime(labels, time.Since(start))
-		deps.analytics.LogAmpObject(&ao)
+		if account == nil || !account.Analytics.Disabled {
+			deps.analytics.LogAmpObject(&ao)
+		}
 	}()
 
 	isSafari := checkSafari(r)
@@ -121,7 +129,7 @@ func (deps *endpointDeps) AmpAuction(w http.ResponseWriter, r *http.Request, _ h
 	w.Header().Set("AMP-Access-Control-Allow-Source-Origin", origin)
 	w.Header().Set("Access-Control-Expose-Headers", "AMP-Access-Control-Allow-Source-Origin")
 
-	req, errL := deps.parseAmpRequest(r)
+	req, errL := deps.parseAmpRequest(r, &ao.Details)
 
 	if fatalError(errL) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -149,6 +157,7 @@ func (deps *endpointDeps) AmpAuction(w http.ResponseWriter, r *http.Request, _ h
 		labels.CookieFlag = pbsmetrics.CookieFlagYes
 	}
 	labels.PubID = effectivePubID(req.Site.Publisher)
+	ao.AccountID = labels.PubID
 	// Blacklist account now that we have resolved the value
 	if _, found := deps.cfg.BlacklistedAcctMap[labels.PubID]; found {
 		errL = append(errL, &errortypes.BlacklistedAcct{Message: fmt.Sprintf("Prebid-server has blacklisted Account ID: %s, pleaase reach out to the prebid server host.", labels.PubID)})
@@ -161,7 +170,19 @@ func (deps *endpointDeps) AmpAuction(w http.ResponseWriter, r *http.Request, _ h
 		return
 	}
 
-	response, err := deps.ex.HoldAuction(ctx, req, usersyncs, labels, &deps.categories)
+	account, acctErrs := accountService.GetAccount(ctx, deps.cfg, deps.accounts, labels.PubID)
+	if len(acctErrs) > 0 {
+		errL = append(errL, acctErrs...)
+		w.WriteHeader(http.StatusBadRequest)
+		for _, err := range errL {
+			w.Write([]byte(fmt.Sprintf("Invalid request format: %s\n", err.Error())))
+		}
+		ao.Errors = append(ao.Errors, errL...)
+		labels.RequestStatus = pbsmetrics.RequestStatusBadInput
+		return
+	}
+
+	response, err := deps.ex.HoldAuction(ctx, req, deps.userIDs(ctx, r, req, usersyncs), labels, account, &deps.categories, &ao.Details)
 	ao.AuctionResponse = response
 
 	if err != nil {
@@ -245,9 +266,9 @@ func (deps *endpointDeps) AmpAuction(w http.ResponseWriter, r *http.Request, _ h
 // possible, it will return errors with messages that suggest improvements.
 //
 // If the errors list has at least one element, then no guarantees are made about the returned request.
-func (deps *endpointDeps) parseAmpRequest(httpRequest *http.Request) (req *openrtb.BidRequest, errs []error) {
+func (deps *endpointDeps) parseAmpRequest(httpRequest *http.Request, details *analytics.AuctionDetails) (req *openrtb.BidRequest, errs []error) {
 	// Load the stored request for the AMP ID.
-	req, errs = deps.loadRequestJSONForAmp(httpRequest)
+	req, errs = deps.loadRequestJSONForAmp(httpRequest, details)
 	if len(errs) > 0 {
 		return
 	}
@@ -272,7 +293,9 @@ func (deps *endpointDeps) parseAmpRequest(httpRequest *http.Request) (req *openr
 }
 
 // Load the stored OpenRTB request for an incoming AMP request, or return the errors found.
-func (deps *endpointDeps) loadRequestJSONForAmp(httpRequest *http.Request) (req *openrtb.BidRequest, errs []error) {
+// If the stored request is versioned, the version is chosen by the request's query string.
+// The chosen version, and the stored request's lineage if it inherits from others, are recorded in details.
+func (deps *endpointDeps) loadRequestJSONForAmp(httpRequest *http.Request, details *analytics.AuctionDetails) (req *openrtb.BidRequest, errs []error) {
 	req = &openrtb.BidRequest{}
 	errs = nil
 
@@ -288,17 +311,19 @@ func (deps *endpointDeps) loadRequestJSONForAmp(httpRequest *http.Request) (req
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(storedRequestTimeoutMillis)*time.Millisecond)
 	defer cancel()
 
-	storedRequests, _, errs := deps.storedReqFetcher.FetchRequests(ctx, []string{ampID}, nil)
+	stored, errs := deps.resolveStoredData(ctx, []string{ampID}, nil, httpRequest.URL.RawQuery)
 	if len(errs) > 0 {
 		return nil, errs
 	}
-	if len(storedRequests) == 0 {
+	requestJSON, ok := stored.requests[ampID]
+	if !ok || len(requestJSON) == 0 {
 		errs = []error{fmt.Errorf("No AMP config found for tag_id '%s'", ampID)}
 		return
 	}
+	details.StoredRequestVersions = stored.versions
+	details.StoredRequestLineage = stored.lineage
 
 	// The fetched config becomes the entire OpenRTB request
-	requestJSON := storedRequests[ampID]
 	if err := json.Unmarshal(requestJSON, req); err != nil {
 		errs = []error{err}
 		return
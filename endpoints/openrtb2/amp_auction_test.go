@@ -15,11 +15,12 @@ import (
 	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
 
 	"github.com/mxmCherry/openrtb"
-	analyticsConf "github.com/prebid/prebid-server/analytics/config"
+	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/exchange"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/usersync/uidstores/empty_store"
 	metrics "github.com/rcrowley/go-metrics"
 	"github.com/stretchr/testify/assert"
 )
@@ -47,9 +48,11 @@ func TestGoodAmpRequests(t *testing.T) {
 		newParamsValidator(t),
 		&mockAmpStoredReqFetcher{goodRequests},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -101,9 +104,11 @@ func TestAMPPageInfo(t *testing.T) {
 		newParamsValidator(t),
 		&mockAmpStoredReqFetcher{stored},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -133,9 +138,11 @@ func TestAMPSiteExt(t *testing.T) {
 		newParamsValidator(t),
 		&mockAmpStoredReqFetcher{stored},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		nil,
 		nil,
 		openrtb_ext.BidderMap,
@@ -156,6 +163,40 @@ func TestAMPSiteExt(t *testing.T) {
 	assert.JSONEq(t, `{"amp":1}`, string(exchange.lastRequest.Site.Ext))
 }
 
+func TestAMPStoredRequestVersions(t *testing.T) {
+	stored := map[string]json.RawMessage{
+		"1":   json.RawMessage(`{"versions":[{"version":"v1","weight":1,"data":` + validRequest(t, "site.json") + `}]}`),
+		"bad": json.RawMessage(`{"versions":[]}`),
+	}
+	exchange := &mockAmpExchange{}
+	endpoint, _ := NewAmpEndpoint(
+		exchange,
+		newParamsValidator(t),
+		&mockAmpStoredReqFetcher{stored},
+		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
+		&config.Configuration{MaxRequestSize: maxSize},
+		pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()),
+		analyticsForTest(),
+		nil,
+		nil,
+		openrtb_ext.BidderMap,
+	)
+
+	recorder := httptest.NewRecorder()
+	endpoint(recorder, httptest.NewRequest("GET", "/openrtb2/auction/amp?tag_id=1", nil), nil)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	if assert.NotNil(t, exchange.lastRequest) {
+		assert.NotNil(t, exchange.lastRequest.Site, "The chosen version should become the request")
+	}
+
+	recorder = httptest.NewRecorder()
+	endpoint(recorder, httptest.NewRequest("GET", "/openrtb2/auction/amp?tag_id=bad", nil), nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "Stored Request bad has invalid versions: versions must not be empty")
+}
+
 // TestBadRequests makes sure we return 400's on bad requests.
 func TestAmpBadRequests(t *testing.T) {
 	files := fetchFiles(t, "sample-requests/invalid-whole")
@@ -173,9 +214,11 @@ func TestAmpBadRequests(t *testing.T) {
 		newParamsValidator(t),
 		&mockAmpStoredReqFetcher{badRequests},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -204,9 +247,11 @@ func TestAmpDebug(t *testing.T) {
 		newParamsValidator(t),
 		&mockAmpStoredReqFetcher{requests},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -277,9 +322,11 @@ func TestQueryParamOverrides(t *testing.T) {
 		newParamsValidator(t),
 		&mockAmpStoredReqFetcher{requests},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -405,9 +452,11 @@ func (s formatOverrideSpec) execute(t *testing.T) {
 		newParamsValidator(t),
 		&mockAmpStoredReqFetcher{requests},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -453,7 +502,7 @@ type mockAmpExchange struct {
 	lastRequest *openrtb.BidRequest
 }
 
-func (m *mockAmpExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, categoriesFetcher *stored_requests.CategoryFetcher) (*openrtb.BidResponse, error) {
+func (m *mockAmpExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, auctionDetails *analytics.AuctionDetails) (*openrtb.BidResponse, error) {
 	m.lastRequest = bidRequest
 
 	response := &openrtb.BidResponse{
@@ -20,13 +20,16 @@ import (
 	"github.com/mxmCherry/openrtb"
 	"github.com/mxmCherry/openrtb/native"
 	nativeRequests "github.com/mxmCherry/openrtb/native/request"
+	accountService "github.com/prebid/prebid-server/account"
 	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/floors"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/prebid/prebid-server/prebid"
+	"github.com/prebid/prebid-server/privacy/ccpa"
 	"github.com/prebid/prebid-server/stored_requests"
 	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
 	"github.com/prebid/prebid-server/usersync"
@@ -35,9 +38,9 @@ import (
 
 const storedRequestTimeoutMillis = 50
 
-func NewEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, categories stored_requests.CategoryFetcher, cfg *config.Configuration, met pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName) (httprouter.Handle, error) {
+func NewEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, accounts stored_requests.AccountFetcher, categories stored_requests.CategoryFetcher, uidStore usersync.UIDStore, cfg *config.Configuration, met pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName) (httprouter.Handle, error) {
 
-	if ex == nil || validator == nil || requestsById == nil || cfg == nil || met == nil {
+	if ex == nil || validator == nil || requestsById == nil || accounts == nil || cfg == nil || met == nil {
 		return nil, errors.New("NewEndpoint requires non-nil arguments.")
 	}
 	defRequest := defReqJSON != nil && len(defReqJSON) > 0
@@ -47,7 +50,9 @@ func NewEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamValidato
 		validator,
 		requestsById,
 		empty_fetcher.EmptyFetcher{},
+		accounts,
 		categories,
+		uidStore,
 		cfg,
 		met,
 		pbsAnalytics,
@@ -62,7 +67,9 @@ type endpointDeps struct {
 	paramsValidator  openrtb_ext.BidderParamValidator
 	storedReqFetcher stored_requests.Fetcher
 	videoFetcher     stored_requests.Fetcher
+	accounts         stored_requests.AccountFetcher
 	categories       stored_requests.CategoryFetcher
+	uidStore         usersync.UIDStore
 	cfg              *config.Configuration
 	metricsEngine    pbsmetrics.MetricsEngine
 	analytics        analytics.PBSAnalyticsModule
@@ -94,10 +101,13 @@ func (deps *endpointDeps) Auction(w http.ResponseWriter, r *http.Request, _ http
 		CookieFlag:    pbsmetrics.CookieFlagUnknown,
 		RequestStatus: pbsmetrics.RequestStatusOK,
 	}
+	var account *config.Account
 	defer func() {
 		deps.metricsEngine.RecordRequest(labels)
 		deps.metricsEngine.RecordRequestTime(labels, time.Since(start))
-		deps.analytics.LogAuctionObject(&ao)
+		if account == nil || !account.Analytics.Disabled {
+			deps.analytics.LogAuctionObject(&ao)
+		}
 	}()
 
 	isSafari := checkSafari(r)
@@ -105,7 +115,7 @@ func (deps *endpointDeps) Auction(w http.ResponseWriter, r *http.Request, _ http
 		labels.Browser = pbsmetrics.BrowserSafari
 	}
 
-	req, errL := deps.parseRequest(r)
+	req, errL := deps.parseRequest(r, &ao.Details)
 
 	if fatalError(errL) && writeError(errL, w) {
 		labels.RequestStatus = pbsmetrics.RequestStatusBadInput
@@ -135,6 +145,7 @@ func (deps *endpointDeps) Auction(w http.ResponseWriter, r *http.Request, _ http
 		}
 		labels.PubID = effectivePubID(req.Site.Publisher)
 	}
+	ao.AccountID = labels.PubID
 	// Blacklist account now that we have resolved the value
 	if _, found := deps.cfg.BlacklistedAcctMap[labels.PubID]; found {
 		errL = append(errL, &errortypes.BlacklistedAcct{Message: fmt.Sprintf("Prebid-server has blacklisted Account ID: %s, pleaase reach out to the prebid server host.", labels.PubID)})
@@ -143,7 +154,15 @@ func (deps *endpointDeps) Auction(w http.ResponseWriter, r *http.Request, _ http
 		return
 	}
 
-	response, err := deps.ex.HoldAuction(ctx, req, usersyncs, labels, &deps.categories)
+	account, acctErrs := accountService.GetAccount(ctx, deps.cfg, deps.accounts, labels.PubID)
+	if len(acctErrs) > 0 {
+		errL = append(errL, acctErrs...)
+		writeError(errL, w)
+		labels.RequestStatus = pbsmetrics.RequestStatusBadInput
+		return
+	}
+
+	response, err := deps.ex.HoldAuction(ctx, req, deps.userIDs(ctx, r, req, usersyncs), labels, account, &deps.categories, &ao.Details)
 	ao.Request = req
 	ao.Response = response
 	if err != nil {
@@ -177,12 +196,15 @@ func (deps *endpointDeps) Auction(w http.ResponseWriter, r *http.Request, _ http
 //   - A context which times out appropriately, given the request.
 //   - A cancellation function which should be called if the auction finishes early.
 //
+// It also records the versions of any versioned Stored Requests and Imps which were chosen for the request,
+// and any conflicts found while merging them, in details.
+//
 // If the errors list is empty, then the returned request will be valid according to the OpenRTB 2.5 spec.
 // In case of "strong recommendations" in the spec, it tends to be restrictive. If a better workaround is
 // possible, it will return errors with messages that suggest improvements.
 //
 // If the errors list has at least one element, then no guarantees are made about the returned request.
-func (deps *endpointDeps) parseRequest(httpRequest *http.Request) (req *openrtb.BidRequest, errs []error) {
+func (deps *endpointDeps) parseRequest(httpRequest *http.Request, details *analytics.AuctionDetails) (req *openrtb.BidRequest, errs []error) {
 	req = &openrtb.BidRequest{}
 	errs = nil
 
@@ -209,7 +231,7 @@ func (deps *endpointDeps) parseRequest(httpRequest *http.Request) (req *openrtb.
 	defer cancel()
 
 	// Fetch the Stored Request data and merge it into the HTTP request.
-	if requestJson, errs = deps.processStoredRequests(ctx, requestJson); len(errs) > 0 {
+	if requestJson, errs = deps.processStoredRequests(ctx, requestJson, details); len(errs) > 0 {
 		return
 	}
 
@@ -250,8 +272,15 @@ func parseTimeout(requestJson []byte, defaultTimeout time.Duration) time.Duratio
 }
 
 func (deps *endpointDeps) validateRequest(req *openrtb.BidRequest) []error {
+	return deps.validateRequestData(req, false)
+}
+
+// validateRequestData validates a request. If isStored is true, it's the data from a Stored Request or Imp
+// rather than a whole request, so the fields which HTTP requests usually fill in aren't required:
+// the request's id and imps, a site or app, and the site's id or page.
+func (deps *endpointDeps) validateRequestData(req *openrtb.BidRequest, isStored bool) []error {
 	errL := []error{}
-	if req.ID == "" {
+	if req.ID == "" && !isStored {
 		return []error{errors.New("request missing required field: \"id\"")}
 	}
 
@@ -259,7 +288,7 @@ func (deps *endpointDeps) validateRequest(req *openrtb.BidRequest) []error {
 		return []error{fmt.Errorf("request.tmax must be nonnegative. Got %d", req.TMax)}
 	}
 
-	if len(req.Imp) < 1 {
+	if len(req.Imp) < 1 && !isStored {
 		return []error{errors.New("request.imp must contain at least one element.")}
 	}
 
@@ -276,14 +305,24 @@ func (deps *endpointDeps) validateRequest(req *openrtb.BidRequest) []error {
 		if err := validateBidAdjustmentFactors(bidExt.Prebid.BidAdjustmentFactors, aliases); err != nil {
 			return []error{err}
 		}
+
+		if err := validateSChains(bidExt.Prebid.SChains); err != nil {
+			return []error{err}
+		}
+
+		if bidExt.Prebid.Floors != nil && bidExt.Prebid.Floors.Data != nil {
+			if err := floors.ValidateData(bidExt.Prebid.Floors.Data); err != nil {
+				return []error{fmt.Errorf("request.ext.prebid.floors.data is invalid: %v", err)}
+			}
+		}
 	}
 
-	if (req.Site == nil && req.App == nil) || (req.Site != nil && req.App != nil) {
+	if (req.Site == nil && req.App == nil && !isStored) || (req.Site != nil && req.App != nil) {
 		errL = append(errL, errors.New("request.site or request.app must be defined, but not both."))
 		return errL
 	}
 
-	if err := deps.validateSite(req.Site); err != nil {
+	if err := deps.validateSite(req.Site, isStored); err != nil {
 		errL = append(errL, err)
 		return errL
 	}
@@ -303,6 +342,11 @@ func (deps *endpointDeps) validateRequest(req *openrtb.BidRequest) []error {
 		return errL
 	}
 
+	if err := validateSource(req.Source); err != nil {
+		errL = append(errL, err)
+		return errL
+	}
+
 	impIDs := make(map[string]int, len(req.Imp))
 	for index := range req.Imp {
 		imp := &req.Imp[index]
@@ -336,6 +380,67 @@ func validateBidAdjustmentFactors(adjustmentFactors map[string]float64, aliases
 	return nil
 }
 
+func validateSChains(sChains []*openrtb_ext.ExtRequestPrebidSChain) error {
+	bidders := make(map[string]struct{})
+	for i, sChain := range sChains {
+		if sChain == nil {
+			return fmt.Errorf("request.ext.prebid.schains[%d] must be an object", i)
+		}
+		if len(sChain.Bidders) == 0 {
+			return fmt.Errorf("request.ext.prebid.schains[%d].bidders must contain at least one bidder", i)
+		}
+		for _, bidder := range sChain.Bidders {
+			if _, ok := bidders[bidder]; ok {
+				return fmt.Errorf("request.ext.prebid.schains contains multiple schains for bidder %s; it must contain no more than one per bidder.", bidder)
+			}
+			bidders[bidder] = struct{}{}
+		}
+		if err := validateSChain(&sChain.SChain, fmt.Sprintf("request.ext.prebid.schains[%d].schain", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateSource(source *openrtb.Source) error {
+	if source == nil || len(source.Ext) == 0 {
+		return nil
+	}
+	var sourceExt openrtb_ext.ExtSource
+	if err := json.Unmarshal(source.Ext, &sourceExt); err != nil {
+		return fmt.Errorf("request.source.ext is invalid: %v", err)
+	}
+	if sourceExt.SChain != nil {
+		return validateSChain(sourceExt.SChain, "request.source.ext.schain")
+	}
+	return nil
+}
+
+// validateSChain checks the fields which the supply chain spec requires.
+func validateSChain(sChain *openrtb_ext.ExtRequestPrebidSChainSChain, path string) error {
+	if sChain.Ver == "" {
+		return fmt.Errorf("%s.ver is required", path)
+	}
+	if sChain.Complete != 0 && sChain.Complete != 1 {
+		return fmt.Errorf("%s.complete must be either 0 or 1. Got %d", path, sChain.Complete)
+	}
+	for i, node := range sChain.Nodes {
+		if node == nil {
+			return fmt.Errorf("%s.nodes[%d] must be an object", path, i)
+		}
+		if node.ASI == "" {
+			return fmt.Errorf("%s.nodes[%d].asi is required", path, i)
+		}
+		if node.SID == "" {
+			return fmt.Errorf("%s.nodes[%d].sid is required", path, i)
+		}
+		if node.HP != 0 && node.HP != 1 {
+			return fmt.Errorf("%s.nodes[%d].hp must be either 0 or 1. Got %d", path, i, node.HP)
+		}
+	}
+	return nil
+}
+
 func (deps *endpointDeps) validateImp(imp *openrtb.Imp, aliases map[string]string, index int) []error {
 	if imp.ID == "" {
 		return []error{fmt.Errorf("request.imp[%d] missing required field: \"id\"", index)}
@@ -773,12 +878,12 @@ func (deps *endpointDeps) validateAliases(aliases map[string]string) error {
 	return nil
 }
 
-func (deps *endpointDeps) validateSite(site *openrtb.Site) error {
+func (deps *endpointDeps) validateSite(site *openrtb.Site, isStored bool) error {
 	if site == nil {
 		return nil
 	}
 
-	if site.ID == "" && site.Page == "" {
+	if site.ID == "" && site.Page == "" && !isStored {
 		return errors.New("request.site should include at least one of request.site.id or request.site.page.")
 	}
 	if len(site.Ext) > 0 {
@@ -883,6 +988,9 @@ func validateRegs(regs *openrtb.Regs) error {
 		if regsExt.GDPR != nil && (*regsExt.GDPR < 0 || *regsExt.GDPR > 1) {
 			return errors.New("request.regs.ext.gdpr must be either 0 or 1.")
 		}
+		if err := (ccpa.Policy{Value: regsExt.USPrivacy}).Validate(); err != nil {
+			return fmt.Errorf("request.regs.ext.us_privacy %s.", err.Error())
+		}
 	}
 	return nil
 }
@@ -971,7 +1079,9 @@ func getJsonSyntaxError(testJSON []byte) (bool, string) {
 	return false, ""
 }
 
-func (deps *endpointDeps) processStoredRequests(ctx context.Context, requestJson []byte) ([]byte, []error) {
+// processStoredRequests merges any Stored Requests and Imps into the request JSON. The versions it chose,
+// the lineage of any which inherit from others, and any conflicts found while merging, are recorded in the auction details.
+func (deps *endpointDeps) processStoredRequests(ctx context.Context, requestJson []byte, details *analytics.AuctionDetails) ([]byte, []error) {
 	// Parse the Stored Request IDs from the BidRequest and Imps.
 	storedBidRequestId, hasStoredBidRequest, err := getStoredRequestId(requestJson)
 	if err != nil {
@@ -982,26 +1092,35 @@ func (deps *endpointDeps) processStoredRequests(ctx context.Context, requestJson
 		return nil, errs
 	}
 
-	// Fetch the Stored Request data
+	// Fetch the Stored Request data, with the versions and ancestors of any which have them resolved.
 	var storedReqIds []string
 	if hasStoredBidRequest {
 		storedReqIds = []string{storedBidRequestId}
 	}
-	storedRequests, storedImps, errs := deps.storedReqFetcher.FetchRequests(ctx, storedReqIds, impIds)
+	stored, errs := deps.resolveStoredData(ctx, storedReqIds, impIds, storedVersionKey(requestJson))
 	if len(errs) != 0 {
 		return nil, errs
 	}
+	storedBidRequest := stored.requests[storedBidRequestId]
+	resolvedImps := stored.imps
+	details.StoredRequestVersions = stored.versions
+	details.StoredRequestLineage = stored.lineage
 
 	// Apply the Stored BidRequest, if it exists
 	resolvedRequest := requestJson
+	requestStrategy := deps.cfg.StoredRequests.MergeStrategy
+	var conflicts []string
 	if hasStoredBidRequest {
-		resolvedRequest, err = jsonpatch.MergePatch(storedRequests[storedBidRequestId], requestJson)
+		var requestConflicts []string
+		requestStrategy = stored_requests.ChooseMergeStrategy(storedBidRequest, requestStrategy)
+		resolvedRequest, requestConflicts, err = stored_requests.Merge(requestStrategy, storedBidRequest, requestJson)
+		conflicts = append(conflicts, requestConflicts...)
 		if err != nil {
 			hasErr, Err := getJsonSyntaxError(requestJson)
 			if hasErr {
 				err = fmt.Errorf("Invalid JSON in Incoming Request: %s", Err)
 			} else {
-				hasErr, Err = getJsonSyntaxError(storedRequests[storedBidRequestId])
+				hasErr, Err = getJsonSyntaxError(storedBidRequest)
 				if hasErr {
 					err = fmt.Errorf("Invalid JSON in Stored Request with ID %s: %s", storedBidRequestId, Err)
 					err = fmt.Errorf("ext.prebid.storedrequest.id refers to Stored Request %s which contains Invalid JSON: %s", storedBidRequestId, Err)
@@ -1032,14 +1151,20 @@ func (deps *endpointDeps) processStoredRequests(ctx context.Context, requestJson
 	// Apply any Stored Imps, if they exist. Since the JSON Merge Patch overrides arrays,
 	// and Prebid Server defers to the HTTP Request to resolve conflicts, it's safe to
 	// assume that the request.imp data did not change when applying the Stored BidRequest.
+	// A deep merge keeps the Stored BidRequest's imps though, so the resolved imps are merged back into them below.
 	for i := 0; i < len(impIds); i++ {
-		resolvedImp, err := jsonpatch.MergePatch(storedImps[impIds[i]], imps[idIndices[i]])
+		storedImp := resolvedImps[impIds[i]]
+		impStrategy := stored_requests.ChooseMergeStrategy(storedImp, deps.cfg.StoredRequests.MergeStrategy)
+		resolvedImp, impConflicts, err := stored_requests.Merge(impStrategy, storedImp, imps[idIndices[i]])
+		for _, conflict := range impConflicts {
+			conflicts = append(conflicts, fmt.Sprintf("imp[%d].%s", idIndices[i], conflict))
+		}
 		if err != nil {
 			hasErr, Err := getJsonSyntaxError(imps[idIndices[i]])
 			if hasErr {
 				err = fmt.Errorf("Invalid JSON in Imp[%d] of Incoming Request: %s", i, Err)
 			} else {
-				hasErr, Err = getJsonSyntaxError(storedImps[impIds[i]])
+				hasErr, Err = getJsonSyntaxError(resolvedImps[impIds[i]])
 				if hasErr {
 					err = fmt.Errorf("imp.ext.prebid.storedrequest.id %s: Stored Imp has Invalid JSON: %s", impIds[i], Err)
 				}
@@ -1053,15 +1178,31 @@ func (deps *endpointDeps) processStoredRequests(ctx context.Context, requestJson
 		if err != nil {
 			return nil, []error{err}
 		}
-		resolvedRequest, err = jsonparser.Set(resolvedRequest, newImpJson, "imp")
+		if requestStrategy == openrtb_ext.MergeDeep {
+			var impConflicts []string
+			resolvedRequest, impConflicts, err = stored_requests.Merge(requestStrategy, resolvedRequest, []byte(`{"imp":`+string(newImpJson)+`}`))
+			conflicts = append(conflicts, impConflicts...)
+		} else {
+			resolvedRequest, err = jsonparser.Set(resolvedRequest, newImpJson, "imp")
+		}
 		if err != nil {
 			return nil, []error{err}
 		}
 	}
+	details.StoredRequestConflicts = conflicts
 
 	return resolvedRequest, nil
 }
 
+// storedVersionKey returns the key which picks the versions of Stored Requests and Imps for a request.
+// It's the request's ID, if the HTTP request has one, so that retries of an auction use the same versions.
+func storedVersionKey(requestJson []byte) string {
+	if id, err := jsonparser.GetString(requestJson, "id"); err == nil && id != "" {
+		return id
+	}
+	return string(requestJson)
+}
+
 // parseImpInfo parses the request JSON and returns several things about the Imps
 //
 // 1. A list of the JSON for every Imp.
@@ -1156,7 +1297,7 @@ func writeError(errs []error, w http.ResponseWriter) bool {
 func fatalError(errL []error) bool {
 	for _, err := range errL {
 		errCode := errortypes.DecodeError(err)
-		if errCode != errortypes.BidderTemporarilyDisabledCode || errCode == errortypes.BlacklistedAppCode || errCode == errortypes.BlacklistedAcctCode {
+		if errCode != errortypes.BidderTemporarilyDisabledCode || errCode == errortypes.BlacklistedAppCode || errCode == errortypes.BlacklistedAcctCode || errCode == errortypes.AcctRequiredCode {
 			return true
 		}
 	}
@@ -1179,3 +1320,26 @@ func effectivePubID(pub *openrtb.Publisher) string {
 	}
 	return pbsmetrics.PublisherUnknown
 }
+
+// userIDs returns the bidders' IDs for the user. They come from the uids cookie, or from the UID store
+// if the cookie has none. Users who have opted out never get IDs from the store.
+func (deps *endpointDeps) userIDs(ctx context.Context, httpReq *http.Request, req *openrtb.BidRequest, cookie *usersync.PBSCookie) exchange.IdFetcher {
+	if !cookie.AllowSyncs() || cookie.LiveSyncCount() > 0 {
+		return cookie
+	}
+	var userID string
+	if req.User != nil {
+		userID = req.User.ID
+	}
+	key := usersync.UIDStoreKey(httpReq, userID, &deps.cfg.HostCookie, &deps.cfg.UserSync.UIDStore)
+	if key == "" {
+		return cookie
+	}
+
+	storeCtx, cancel := context.WithTimeout(ctx, time.Duration(deps.cfg.UserSync.UIDStore.TimeoutMS)*time.Millisecond)
+	defer cancel()
+	if uids := usersync.LoadStoredUIDs(storeCtx, deps.uidStore, key); len(uids) > 0 {
+		return uids
+	}
+	return cookie
+}
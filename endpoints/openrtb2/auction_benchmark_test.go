@@ -10,13 +10,13 @@ import (
 	"github.com/prebid/prebid-server/currencies"
 	metrics "github.com/rcrowley/go-metrics"
 
-	analyticsConf "github.com/prebid/prebid-server/analytics/config"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/exchange"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
+	"github.com/prebid/prebid-server/usersync/uidstores/empty_store"
 )
 
 // dummyServer returns the header bidding test ad. This response was scraped from a real appnexus server response.
@@ -78,13 +78,16 @@ func BenchmarkOpenrtbEndpoint(b *testing.B) {
 			infos,
 			gdpr.AlwaysAllow{},
 			currencies.NewRateConverterDefault(),
+			empty_fetcher.EmptyFetcher{},
 		),
 		paramValidator,
 		empty_fetcher.EmptyFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		nil,
@@ -22,6 +22,7 @@ import (
 	"github.com/buger/jsonparser"
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
 	analyticsConf "github.com/prebid/prebid-server/analytics/config"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/errortypes"
@@ -29,6 +30,8 @@ import (
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
+	"github.com/prebid/prebid-server/usersync"
+	"github.com/prebid/prebid-server/usersync/uidstores/empty_store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -98,7 +101,7 @@ func TestExplicitUserId(t *testing.T) {
 	// NewMetrics() will create a new go_metrics MetricsEngine, bypassing the need for a crafted configuration set to support it.
 	// As a side effect this gives us some coverage of the go_metrics piece of the metrics engine.
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	endpoint, _ := NewEndpoint(ex, newParamsValidator(t), empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, cfg, theMetrics, analyticsConf.NewPBSAnalytics(&config.Analytics{}), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
+	endpoint, _ := NewEndpoint(ex, newParamsValidator(t), empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, cfg, theMetrics, analyticsForTest(), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
 
 	endpoint(httptest.NewRecorder(), request, nil)
 
@@ -283,9 +286,11 @@ func (gr *getResponseFromDirectory) doRequest(t *testing.T, requestData []byte)
 		newParamsValidator(t),
 		&mockStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize, BlacklistedApps: []string{"spam_app"}, BlacklistedAppMap: map[string]bool{"spam_app": true}, BlacklistedAccts: []string{"bad_acct"}, BlacklistedAcctMap: map[string]bool{"bad_acct": true}},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		disabledBidders,
 		aliasJSON,
 		bidderMap,
@@ -322,7 +327,7 @@ func doBadAliasRequest(t *testing.T, filename string, expectMsg string) {
 	// NewMetrics() will create a new go_metrics MetricsEngine, bypassing the need for a crafted configuration set to support it.
 	// As a side effect this gives us some coverage of the go_metrics piece of the metrics engine.
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	endpoint, _ := NewEndpoint(&nobidExchange{}, newParamsValidator(t), &mockStoredReqFetcher{}, empty_fetcher.EmptyFetcher{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsConf.NewPBSAnalytics(&config.Analytics{}), disabledBidders, aliasJSON, bidderMap)
+	endpoint, _ := NewEndpoint(&nobidExchange{}, newParamsValidator(t), &mockStoredReqFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsForTest(), disabledBidders, aliasJSON, bidderMap)
 
 	request := httptest.NewRequest("POST", "/openrtb2/auction", bytes.NewReader(requestData))
 	recorder := httptest.NewRecorder()
@@ -341,6 +346,12 @@ func newParamsValidator(t *testing.T) openrtb_ext.BidderParamValidator {
 	return paramValidator
 }
 
+// analyticsForTest returns the analytics modules for an empty config, which don't log anything.
+func analyticsForTest() analytics.PBSAnalyticsModule {
+	modules, _ := analyticsConf.NewPBSAnalytics(&config.Analytics{}, nil, nil)
+	return modules
+}
+
 func assertResponseCode(t *testing.T, filename string, actual int, expected int, msg string) {
 	t.Helper()
 	if actual != expected {
@@ -386,7 +397,7 @@ func TestNilExchange(t *testing.T) {
 	// NewMetrics() will create a new go_metrics MetricsEngine, bypassing the need for a crafted configuration set to support it.
 	// As a side effect this gives us some coverage of the go_metrics piece of the metrics engine.
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	_, err := NewEndpoint(nil, newParamsValidator(t), empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsConf.NewPBSAnalytics(&config.Analytics{}), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
+	_, err := NewEndpoint(nil, newParamsValidator(t), empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsForTest(), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
 	if err == nil {
 		t.Errorf("NewEndpoint should return an error when given a nil Exchange.")
 	}
@@ -397,7 +408,7 @@ func TestNilValidator(t *testing.T) {
 	// NewMetrics() will create a new go_metrics MetricsEngine, bypassing the need for a crafted configuration set to support it.
 	// As a side effect this gives us some coverage of the go_metrics piece of the metrics engine.
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	_, err := NewEndpoint(&nobidExchange{}, nil, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsConf.NewPBSAnalytics(&config.Analytics{}), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
+	_, err := NewEndpoint(&nobidExchange{}, nil, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsForTest(), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
 	if err == nil {
 		t.Errorf("NewEndpoint should return an error when given a nil BidderParamValidator.")
 	}
@@ -408,7 +419,7 @@ func TestExchangeError(t *testing.T) {
 	// NewMetrics() will create a new go_metrics MetricsEngine, bypassing the need for a crafted configuration set to support it.
 	// As a side effect this gives us some coverage of the go_metrics piece of the metrics engine.
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	endpoint, _ := NewEndpoint(&brokenExchange{}, newParamsValidator(t), empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsConf.NewPBSAnalytics(&config.Analytics{}), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
+	endpoint, _ := NewEndpoint(&brokenExchange{}, newParamsValidator(t), empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsForTest(), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
 	request := httptest.NewRequest("POST", "/openrtb2/auction", strings.NewReader(validRequest(t, "site.json")))
 	recorder := httptest.NewRecorder()
 	endpoint(recorder, request, nil)
@@ -466,7 +477,7 @@ func TestImplicitIPs(t *testing.T) {
 	// NewMetrics() will create a new go_metrics MetricsEngine, bypassing the need for a crafted configuration set to support it.
 	// As a side effect this gives us some coverage of the go_metrics piece of the metrics engine.
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	endpoint, _ := NewEndpoint(ex, newParamsValidator(t), &mockStoredReqFetcher{}, empty_fetcher.EmptyFetcher{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsConf.NewPBSAnalytics(&config.Analytics{}), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
+	endpoint, _ := NewEndpoint(ex, newParamsValidator(t), &mockStoredReqFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsForTest(), map[string]string{}, []byte{}, openrtb_ext.BidderMap)
 
 	httpReq := httptest.NewRequest("POST", "/openrtb2/auction", strings.NewReader(validRequest(t, "site.json")))
 	httpReq.Header.Set("X-Forwarded-For", "123.456.78.90")
@@ -536,10 +547,10 @@ func TestStoredRequests(t *testing.T) {
 	// NewMetrics() will create a new go_metrics MetricsEngine, bypassing the need for a crafted configuration set to support it.
 	// As a side effect this gives us some coverage of the go_metrics piece of the metrics engine.
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	edep := &endpointDeps{&nobidExchange{}, newParamsValidator(t), &mockStoredReqFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsConf.NewPBSAnalytics(&config.Analytics{}), map[string]string{}, false, []byte{}, openrtb_ext.BidderMap}
+	edep := &endpointDeps{&nobidExchange{}, newParamsValidator(t), &mockStoredReqFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, theMetrics, analyticsForTest(), map[string]string{}, false, []byte{}, openrtb_ext.BidderMap}
 
 	for i, requestData := range testStoredRequests {
-		newRequest, errList := edep.processStoredRequests(context.Background(), json.RawMessage(requestData))
+		newRequest, errList := edep.processStoredRequests(context.Background(), json.RawMessage(requestData), &analytics.AuctionDetails{})
 		if len(errList) != 0 {
 			for _, err := range errList {
 				if err != nil {
@@ -556,6 +567,74 @@ func TestStoredRequests(t *testing.T) {
 	}
 }
 
+// TestStoredRequestVersions makes sure that versioned Stored Requests and Imps are resolved, and their versions reported.
+func TestStoredRequestVersions(t *testing.T) {
+	fetcher := &versionedStoredReqFetcher{
+		requests: map[string]json.RawMessage{
+			"versioned": json.RawMessage(`{"versions":[{"version":"v2","weight":1,"data":{"tmax":500}},{"version":"v1","weight":0,"data":{"tmax":100}}]}`),
+			"plain":     json.RawMessage(`{"tmax":300}`),
+		},
+		imps: map[string]json.RawMessage{
+			"imp": json.RawMessage(`{"versions":[{"version":"a","weight":1,"data":{"banner":{"format":[{"w":300,"h":250}]}}}]}`),
+			"bad": json.RawMessage(`{"versions":[{"version":"a","weight":0,"data":{}}]}`),
+		},
+	}
+	edep := &endpointDeps{&nobidExchange{}, newParamsValidator(t), fetcher, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, &pbsmetrics.MetricsEngineMock{}, analyticsForTest(), map[string]string{}, false, []byte{}, openrtb_ext.BidderMap}
+
+	details := &analytics.AuctionDetails{}
+	resolved, errs := edep.processStoredRequests(context.Background(), []byte(`{"id":"req","ext":{"prebid":{"storedrequest":{"id":"versioned"}}},"imp":[{"id":"1","ext":{"prebid":{"storedrequest":{"id":"imp"}}}}]}`), details)
+	assert.Empty(t, errs)
+	assert.JSONEq(t, `{"id":"req","tmax":500,"ext":{"prebid":{"storedrequest":{"id":"versioned"}}},"imp":[{"id":"1","banner":{"format":[{"w":300,"h":250}]},"ext":{"prebid":{"storedrequest":{"id":"imp"}}}}]}`, string(resolved))
+	assert.Equal(t, &openrtb_ext.ExtStoredRequestVersions{
+		Requests: map[string]string{"versioned": "v2"},
+		Imps:     map[string]string{"imp": "a"},
+	}, details.StoredRequestVersions)
+
+	details = &analytics.AuctionDetails{}
+	_, errs = edep.processStoredRequests(context.Background(), []byte(`{"id":"req","ext":{"prebid":{"storedrequest":{"id":"plain"}}},"imp":[{"id":"1"}]}`), details)
+	assert.Empty(t, errs)
+	assert.Nil(t, details.StoredRequestVersions, "Stored data without versions shouldn't be reported")
+
+	_, errs = edep.processStoredRequests(context.Background(), []byte(`{"id":"req","imp":[{"id":"1","ext":{"prebid":{"storedrequest":{"id":"bad"}}}}]}`), &analytics.AuctionDetails{})
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "Stored Imp bad has invalid versions: at least one version must have a weight > 0")
+	}
+}
+
+// TestStoredRequestMergeStrategies makes sure that Stored Requests and Imps can choose a deep merge, and its conflicts are reported.
+func TestStoredRequestMergeStrategies(t *testing.T) {
+	fetcher := &versionedStoredReqFetcher{
+		requests: map[string]json.RawMessage{
+			"deep": json.RawMessage(`{"tmax":500,"bcat":["IAB1"],"ext":{"prebid":{"storedrequest":{"merge":"deep"}}},"imp":[{"id":"1","bidfloor":1}]}`),
+		},
+		imps: map[string]json.RawMessage{
+			"deep":    json.RawMessage(`{"banner":{"format":[{"w":300,"h":250}]},"ext":{"prebid":{"storedrequest":{"merge":"deep"}}}}`),
+			"replace": json.RawMessage(`{"banner":{"format":[{"w":300,"h":250}]}}`),
+		},
+	}
+	edep := &endpointDeps{&nobidExchange{}, newParamsValidator(t), fetcher, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, &pbsmetrics.MetricsEngineMock{}, analyticsForTest(), map[string]string{}, false, []byte{}, openrtb_ext.BidderMap}
+
+	details := &analytics.AuctionDetails{}
+	resolved, errs := edep.processStoredRequests(context.Background(), []byte(`{"id":"req","tmax":300,"bcat":["IAB2"],"ext":{"prebid":{"storedrequest":{"id":"deep"}}},"imp":[{"id":"1","banner":{"format":[{"w":728,"h":90}]},"ext":{"prebid":{"storedrequest":{"id":"deep"}}}},{"id":"2","banner":{"format":[{"w":728,"h":90}]},"ext":{"prebid":{"storedrequest":{"id":"replace"}}}}]}`), details)
+	assert.Empty(t, errs)
+	assert.JSONEq(t, `{
+		"id":"req",
+		"tmax":300,
+		"bcat":["IAB1","IAB2"],
+		"ext":{"prebid":{"storedrequest":{"id":"deep","merge":"deep"}}},
+		"imp":[
+			{"id":"1","bidfloor":1,"banner":{"format":[{"w":728,"h":90},{"w":300,"h":250}]},"ext":{"prebid":{"storedrequest":{"id":"deep","merge":"deep"}}}},
+			{"id":"2","banner":{"format":[{"w":728,"h":90}]},"ext":{"prebid":{"storedrequest":{"id":"replace"}}}}
+		]
+	}`, string(resolved))
+	assert.Equal(t, []string{"tmax: the request's 300 replaced the stored 500"}, details.StoredRequestConflicts)
+
+	edep.cfg = &config.Configuration{MaxRequestSize: maxSize, StoredRequests: config.StoredRequests{MergeStrategy: openrtb_ext.MergeDeep}}
+	resolved, errs = edep.processStoredRequests(context.Background(), []byte(`{"id":"req","imp":[{"id":"2","banner":{"format":[{"w":728,"h":90}]},"ext":{"prebid":{"storedrequest":{"id":"replace"}}}}]}`), &analytics.AuctionDetails{})
+	assert.Empty(t, errs)
+	assert.JSONEq(t, `{"id":"req","imp":[{"id":"2","banner":{"format":[{"w":728,"h":90},{"w":300,"h":250}]},"ext":{"prebid":{"storedrequest":{"id":"replace"}}}}]}`, string(resolved), "The host's merge strategy should be the default")
+}
+
 // TestOversizedRequest makes sure we behave properly when the request size exceeds the configured max.
 func TestOversizedRequest(t *testing.T) {
 	reqBody := validRequest(t, "site.json")
@@ -565,9 +644,11 @@ func TestOversizedRequest(t *testing.T) {
 		&mockStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: int64(len(reqBody) - 1)},
 		pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()),
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		false,
 		[]byte{},
@@ -597,9 +678,11 @@ func TestRequestSizeEdgeCase(t *testing.T) {
 		&mockStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: int64(len(reqBody))},
 		pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()),
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		false,
 		[]byte{},
@@ -627,9 +710,11 @@ func TestNoEncoding(t *testing.T) {
 		newParamsValidator(t),
 		&mockStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()),
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -702,9 +787,11 @@ func TestContentType(t *testing.T) {
 		newParamsValidator(t),
 		&mockStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()),
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		[]byte{},
 		openrtb_ext.BidderMap,
@@ -732,11 +819,13 @@ func TestDisabledBidder(t *testing.T) {
 		&mockStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{
 			MaxRequestSize: int64(len(reqBody)),
 		},
 		pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()),
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{"unknownbidder": "The biddder 'unknownbidder' has been disabled."},
 		false,
 		[]byte{},
@@ -767,9 +856,11 @@ func TestValidateImpExtDisabledBidder(t *testing.T) {
 		&mockStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: int64(8096)},
 		pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()),
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{"unknownbidder": "The biddder 'unknownbidder' has been disabled."},
 		false,
 		[]byte{},
@@ -803,7 +894,7 @@ type nobidExchange struct {
 	gotRequest *openrtb.BidRequest
 }
 
-func (e *nobidExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, categoriesFetcher *stored_requests.CategoryFetcher) (*openrtb.BidResponse, error) {
+func (e *nobidExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, auctionDetails *analytics.AuctionDetails) (*openrtb.BidResponse, error) {
 	e.gotRequest = bidRequest
 	return &openrtb.BidResponse{
 		ID:    bidRequest.ID,
@@ -814,7 +905,7 @@ func (e *nobidExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.Bid
 
 type brokenExchange struct{}
 
-func (e *brokenExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, categoriesFetcher *stored_requests.CategoryFetcher) (*openrtb.BidResponse, error) {
+func (e *brokenExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, auctionDetails *analytics.AuctionDetails) (*openrtb.BidResponse, error) {
 	return nil, errors.New("Critical, unrecoverable error.")
 }
 
@@ -1170,11 +1261,20 @@ func (cf mockStoredReqFetcher) FetchRequests(ctx context.Context, requestIDs []s
 	return testStoredRequestData, testStoredImpData, nil
 }
 
+type versionedStoredReqFetcher struct {
+	requests map[string]json.RawMessage
+	imps     map[string]json.RawMessage
+}
+
+func (f *versionedStoredReqFetcher) FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage, errs []error) {
+	return f.requests, f.imps, nil
+}
+
 type mockExchange struct {
 	lastRequest *openrtb.BidRequest
 }
 
-func (m *mockExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, categoriesFetcher *stored_requests.CategoryFetcher) (*openrtb.BidResponse, error) {
+func (m *mockExchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, auctionDetails *analytics.AuctionDetails) (*openrtb.BidResponse, error) {
 	m.lastRequest = bidRequest
 	return &openrtb.BidResponse{
 		SeatBid: []openrtb.SeatBid{{
@@ -1199,6 +1299,56 @@ func blankAdapterConfig(bidderList []openrtb_ext.BidderName, disabledBidders []s
 	return adapters
 }
 
+// TestUserIDsFromStore makes sure the UID store is only used for users without synced cookies.
+func TestUserIDsFromStore(t *testing.T) {
+	deps := &endpointDeps{
+		uidStore: mockUIDStore{"user-id": {"adnxs": "stored-id"}},
+		cfg: &config.Configuration{
+			UserSync: config.UserSync{
+				UIDStore: config.UIDStore{Type: config.UIDStoreTypeFile, KeySource: config.UIDStoreKeyUserID, TimeoutMS: 50},
+			},
+		},
+	}
+	httpReq := httptest.NewRequest("POST", "/openrtb2/auction", nil)
+	req := &openrtb.BidRequest{User: &openrtb.User{ID: "user-id"}}
+
+	ids := deps.userIDs(context.Background(), httpReq, req, usersync.NewPBSCookie())
+	id, exists := ids.GetId(openrtb_ext.BidderAppnexus)
+	assert.True(t, exists, "Users without synced cookies should get their UIDs from the store")
+	assert.Equal(t, "stored-id", id)
+
+	cookie := usersync.NewPBSCookie()
+	cookie.TrySync("rubicon", "cookie-id")
+	ids = deps.userIDs(context.Background(), httpReq, req, cookie)
+	_, exists = ids.GetId(openrtb_ext.BidderAppnexus)
+	assert.False(t, exists, "Users with synced cookies shouldn't use the store")
+
+	ids = deps.userIDs(context.Background(), httpReq, &openrtb.BidRequest{}, usersync.NewPBSCookie())
+	_, exists = ids.GetId(openrtb_ext.BidderAppnexus)
+	assert.False(t, exists, "Users without a user.id shouldn't use the store")
+
+	optedOut := usersync.NewPBSCookie()
+	optedOut.SetPreference(false)
+	ids = deps.userIDs(context.Background(), httpReq, req, optedOut)
+	_, exists = ids.GetId(openrtb_ext.BidderAppnexus)
+	assert.False(t, exists, "Users who opted out shouldn't use the store")
+}
+
+// mockUIDStore keeps UIDs in memory
+type mockUIDStore map[string]map[string]string
+
+func (store mockUIDStore) GetUIDs(ctx context.Context, key string) (map[string]string, error) {
+	return store[key], nil
+}
+
+func (store mockUIDStore) SetUID(ctx context.Context, key string, familyName string, uid string) error {
+	return nil
+}
+
+func (store mockUIDStore) DeleteUIDs(ctx context.Context, key string) error {
+	return nil
+}
+
 func getBidderInfos(cfg map[string]config.Adapter, biddersNames []openrtb_ext.BidderName) adapters.BidderInfos {
 	biddersInfos := make(adapters.BidderInfos)
 	for _, name := range biddersNames {
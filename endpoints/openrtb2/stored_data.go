@@ -0,0 +1,161 @@
+package openrtb2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// maxStoredRequestDepth is the number of ancestors which a Stored Request or Imp may inherit from.
+const maxStoredRequestDepth = 5
+
+// storedData holds the Stored Requests and Imps for an HTTP request. The versions of any versioned data have been
+// chosen, and any data which inherits from a parent has had its ancestors merged in.
+type storedData struct {
+	requests map[string]json.RawMessage
+	imps     map[string]json.RawMessage
+	// versions and lineage are nil unless some data was versioned, or inherited from a parent.
+	versions *openrtb_ext.ExtStoredRequestVersions
+	lineage  *openrtb_ext.ExtStoredRequestLineage
+}
+
+// storedChain tracks one kind of stored data, either Stored Requests or Stored Imps, while it's being resolved.
+type storedChain struct {
+	kind     string
+	chosen   map[string]json.RawMessage
+	versions map[string]string
+	lineage  map[string][]string
+}
+
+// resolveStoredData fetches the Stored Requests and Imps with the given IDs, and chooses their versions with versionKey.
+//
+// Stored data may inherit from a parent by setting ext.prebid.storedrequest.id, like an HTTP request does.
+// Stored Requests inherit from Stored Requests, and Stored Imps from Stored Imps. Each level of parents
+// is fetched in one batch, and the data is merged into its parent with the parent's merge strategy.
+func (deps *endpointDeps) resolveStoredData(ctx context.Context, requestIDs []string, impIDs []string, versionKey string) (*storedData, []error) {
+	requests := &storedChain{kind: "Stored Request", chosen: make(map[string]json.RawMessage, len(requestIDs))}
+	imps := &storedChain{kind: "Stored Imp", chosen: make(map[string]json.RawMessage, len(impIDs))}
+
+	// Ancestors beyond the depth limit aren't fetched. resolve reports the error for the data which needs them.
+	fetchRequestIDs, fetchImpIDs := requestIDs, impIDs
+	for depth := 0; depth <= maxStoredRequestDepth && (len(fetchRequestIDs) > 0 || len(fetchImpIDs) > 0); depth++ {
+		fetchedRequests, fetchedImps, errs := deps.storedReqFetcher.FetchRequests(ctx, fetchRequestIDs, fetchImpIDs)
+		if len(errs) != 0 {
+			return nil, errs
+		}
+		if fetchRequestIDs, errs = requests.choose(fetchRequestIDs, fetchedRequests, versionKey); len(errs) != 0 {
+			return nil, errs
+		}
+		if fetchImpIDs, errs = imps.choose(fetchImpIDs, fetchedImps, versionKey); len(errs) != 0 {
+			return nil, errs
+		}
+	}
+
+	resolved := &storedData{
+		requests: make(map[string]json.RawMessage, len(requestIDs)),
+		imps:     make(map[string]json.RawMessage, len(impIDs)),
+	}
+	for _, id := range requestIDs {
+		data, err := requests.resolve(id, deps.cfg.StoredRequests.MergeStrategy)
+		if err != nil {
+			return nil, []error{err}
+		}
+		resolved.requests[id] = data
+	}
+	for _, id := range impIDs {
+		data, err := imps.resolve(id, deps.cfg.StoredRequests.MergeStrategy)
+		if err != nil {
+			return nil, []error{err}
+		}
+		resolved.imps[id] = data
+	}
+	if requests.versions != nil || imps.versions != nil {
+		resolved.versions = &openrtb_ext.ExtStoredRequestVersions{Requests: requests.versions, Imps: imps.versions}
+	}
+	if requests.lineage != nil || imps.lineage != nil {
+		resolved.lineage = &openrtb_ext.ExtStoredRequestLineage{Requests: requests.lineage, Imps: imps.lineage}
+	}
+	return resolved, nil
+}
+
+// choose picks the versions of the fetched data. It returns the IDs of any parents which haven't been fetched yet.
+// The Fetcher's maps may be shared, so they're left alone.
+func (c *storedChain) choose(ids []string, fetched map[string]json.RawMessage, versionKey string) ([]string, []error) {
+	var parents []string
+	for _, id := range ids {
+		data, version, err := stored_requests.ChooseVersion(id, fetched[id], versionKey)
+		if err != nil {
+			return nil, []error{fmt.Errorf("%s %s has invalid versions: %v", c.kind, id, err)}
+		}
+		c.chosen[id] = data
+		if version != "" {
+			if c.versions == nil {
+				c.versions = make(map[string]string)
+			}
+			c.versions[id] = version
+		}
+	}
+	for _, id := range ids {
+		if parent := storedParentID(c.chosen[id]); parent != "" && !containsString(parents, parent) {
+			if _, ok := c.chosen[parent]; !ok {
+				parents = append(parents, parent)
+			}
+		}
+	}
+	return parents, nil
+}
+
+// resolve merges the chosen data for the ID into its ancestors, and records its lineage if it has any.
+func (c *storedChain) resolve(id string, hostStrategy openrtb_ext.MergeStrategy) (json.RawMessage, error) {
+	lineage := []string{id}
+	for parent := storedParentID(c.chosen[id]); parent != ""; parent = storedParentID(c.chosen[parent]) {
+		if containsString(lineage, parent) {
+			return nil, fmt.Errorf("%s %s inherits from itself: %s -> %s", c.kind, id, strings.Join(lineage, " -> "), parent)
+		}
+		if len(lineage) > maxStoredRequestDepth {
+			return nil, fmt.Errorf("%s %s can't inherit from more than %d ancestors", c.kind, id, maxStoredRequestDepth)
+		}
+		if _, ok := c.chosen[parent]; !ok {
+			return nil, fmt.Errorf("%s %s inherits from %s, which wasn't found", c.kind, lineage[len(lineage)-1], parent)
+		}
+		lineage = append(lineage, parent)
+	}
+	if len(lineage) == 1 {
+		return c.chosen[id], nil
+	}
+
+	// Children are meant to override their parents, so conflicts between them aren't reported.
+	data := c.chosen[lineage[len(lineage)-1]]
+	for i := len(lineage) - 2; i >= 0; i-- {
+		merged, _, err := stored_requests.Merge(stored_requests.ChooseMergeStrategy(data, hostStrategy), data, c.chosen[lineage[i]])
+		if err != nil {
+			return nil, fmt.Errorf("%s %s can't be merged into its parent %s: %v", c.kind, lineage[i], lineage[i+1], err)
+		}
+		data = merged
+	}
+	if c.lineage == nil {
+		c.lineage = make(map[string][]string)
+	}
+	c.lineage[id] = lineage
+	return data, nil
+}
+
+// storedParentID returns the ID of the Stored Request or Imp which the stored data inherits from, if any.
+func storedParentID(data json.RawMessage) string {
+	parent, _ := jsonparser.GetString(data, "ext", "prebid", "storedrequest", "id")
+	return parent
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package openrtb2
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
+	"github.com/prebid/prebid-server/usersync/uidstores/empty_store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveStoredDataInheritance(t *testing.T) {
+	fetcher := &inheritingStoredReqFetcher{
+		requests: map[string]json.RawMessage{
+			"site":    json.RawMessage(`{"site":{"page":"site.com"},"ext":{"prebid":{"storedrequest":{"id":"account"}}}}`),
+			"account": json.RawMessage(`{"tmax":500,"site":{"page":"account.com","publisher":{"id":"pub"}}}`),
+		},
+		imps: map[string]json.RawMessage{
+			"banner":  json.RawMessage(`{"banner":{"format":[{"w":300,"h":250}]},"ext":{"prebid":{"storedrequest":{"id":"bidders"}}}}`),
+			"bidders": json.RawMessage(`{"versions":[{"version":"v1","weight":1,"data":{"ext":{"appnexus":{"placementId":1}}}}]}`),
+		},
+	}
+	deps := storedDataDeps(t, fetcher)
+
+	stored, errs := deps.resolveStoredData(context.Background(), []string{"site"}, []string{"banner", "bidders"}, "key")
+	if !assert.Empty(t, errs) {
+		return
+	}
+	assert.JSONEq(t, `{"tmax":500,"site":{"page":"site.com","publisher":{"id":"pub"}},"ext":{"prebid":{"storedrequest":{"id":"account"}}}}`, string(stored.requests["site"]))
+	assert.JSONEq(t, `{"banner":{"format":[{"w":300,"h":250}]},"ext":{"appnexus":{"placementId":1},"prebid":{"storedrequest":{"id":"bidders"}}}}`, string(stored.imps["banner"]))
+	assert.JSONEq(t, `{"ext":{"appnexus":{"placementId":1}}}`, string(stored.imps["bidders"]))
+	assert.Equal(t, &openrtb_ext.ExtStoredRequestLineage{
+		Requests: map[string][]string{"site": {"site", "account"}},
+		Imps:     map[string][]string{"banner": {"banner", "bidders"}},
+	}, stored.lineage)
+	assert.Equal(t, &openrtb_ext.ExtStoredRequestVersions{Imps: map[string]string{"bidders": "v1"}}, stored.versions)
+	assert.Equal(t, [][]string{{"site"}, {"account"}}, fetcher.requestBatches, "Each level of parents should be fetched in one batch")
+	assert.Equal(t, [][]string{{"banner", "bidders"}, nil}, fetcher.impBatches, "Parents which were already fetched shouldn't be fetched again")
+}
+
+func TestResolveStoredDataErrors(t *testing.T) {
+	fetcher := &inheritingStoredReqFetcher{
+		requests: map[string]json.RawMessage{
+			"a":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"b"}}}}`),
+			"b":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"a"}}}}`),
+			"self": json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"self"}}}}`),
+			"0":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"1"}}}}`),
+			"1":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"2"}}}}`),
+			"2":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"3"}}}}`),
+			"3":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"4"}}}}`),
+			"4":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"5"}}}}`),
+			"5":    json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"id":"6"}}}}`),
+			"6":    json.RawMessage(`{}`),
+		},
+	}
+	deps := storedDataDeps(t, fetcher)
+
+	_, errs := deps.resolveStoredData(context.Background(), []string{"a"}, nil, "key")
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "Stored Request a inherits from itself: a -> b -> a")
+	}
+	_, errs = deps.resolveStoredData(context.Background(), []string{"self"}, nil, "key")
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "Stored Request self inherits from itself: self -> self")
+	}
+	_, errs = deps.resolveStoredData(context.Background(), []string{"1"}, nil, "key")
+	assert.Empty(t, errs, "Stored Requests should be able to inherit from 5 ancestors")
+	_, errs = deps.resolveStoredData(context.Background(), []string{"0"}, nil, "key")
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "Stored Request 0 can't inherit from more than 5 ancestors")
+	}
+}
+
+func TestProcessStoredRequestsLineage(t *testing.T) {
+	fetcher := &inheritingStoredReqFetcher{
+		requests: map[string]json.RawMessage{
+			"site":    json.RawMessage(`{"site":{"page":"site.com"},"ext":{"prebid":{"storedrequest":{"id":"account"}}}}`),
+			"account": json.RawMessage(`{"tmax":500}`),
+		},
+	}
+	deps := storedDataDeps(t, fetcher)
+
+	details := &analytics.AuctionDetails{}
+	resolved, errs := deps.processStoredRequests(context.Background(), []byte(`{"id":"req","imp":[{"id":"1"}],"ext":{"prebid":{"storedrequest":{"id":"site"}}}}`), details)
+	assert.Empty(t, errs)
+	assert.JSONEq(t, `{"id":"req","tmax":500,"site":{"page":"site.com"},"imp":[{"id":"1"}],"ext":{"prebid":{"storedrequest":{"id":"site"}}}}`, string(resolved))
+	assert.Equal(t, &openrtb_ext.ExtStoredRequestLineage{Requests: map[string][]string{"site": {"site", "account"}}}, details.StoredRequestLineage)
+}
+
+func storedDataDeps(t *testing.T, fetcher stored_requests.Fetcher) *endpointDeps {
+	return &endpointDeps{&nobidExchange{}, newParamsValidator(t), fetcher, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_fetcher.EmptyFetcher{}, empty_store.EmptyStore{}, &config.Configuration{MaxRequestSize: maxSize}, &pbsmetrics.MetricsEngineMock{}, analyticsForTest(), map[string]string{}, false, []byte{}, openrtb_ext.BidderMap}
+}
+
+// inheritingStoredReqFetcher only returns the data which was asked for, and records the IDs in each call.
+type inheritingStoredReqFetcher struct {
+	requests       map[string]json.RawMessage
+	imps           map[string]json.RawMessage
+	requestBatches [][]string
+	impBatches     [][]string
+}
+
+func (f *inheritingStoredReqFetcher) FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage, errs []error) {
+	f.requestBatches = append(f.requestBatches, requestIDs)
+	f.impBatches = append(f.impBatches, impIDs)
+	requestData = make(map[string]json.RawMessage, len(requestIDs))
+	for _, id := range requestIDs {
+		if data, ok := f.requests[id]; ok {
+			requestData[id] = data
+		} else {
+			errs = append(errs, stored_requests.NotFoundError{ID: id, DataType: "Request"})
+		}
+	}
+	impData = make(map[string]json.RawMessage, len(impIDs))
+	for _, id := range impIDs {
+		if data, ok := f.imps[id]; ok {
+			impData[id] = data
+		} else {
+			errs = append(errs, stored_requests.NotFoundError{ID: id, DataType: "Imp"})
+		}
+	}
+	return
+}
@@ -0,0 +1,298 @@
+package openrtb2
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
+)
+
+// storedDataSaveTimeout bounds the fetches and writes made while saving Stored Requests and Imps.
+const storedDataSaveTimeout = 5 * time.Second
+
+// storedImpProbeID is the imp ID used while validating a Stored Imp, since HTTP requests give imps their IDs.
+const storedImpProbeID = "stored-imp"
+
+// NewStoredDataWriteEndpoint returns an admin endpoint which validates Stored Requests and Imps, and then saves them
+// with the writer. Saves must be authorized by the secret in the config's stored_requests.write_api.
+//
+// Each Stored Request and Imp gets the same validation that an auction would give it. Data which inherits from a parent
+// is merged into the parent first, and every version of versioned data is checked. Nothing is saved unless everything is valid.
+func NewStoredDataWriteEndpoint(validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, writer stored_requests.Writer, cfg *config.Configuration, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName) (http.HandlerFunc, error) {
+	if validator == nil || requestsById == nil || writer == nil || cfg == nil {
+		return nil, errors.New("NewStoredDataWriteEndpoint requires non-nil arguments.")
+	}
+	if cfg.StoredRequests.WriteAPI.Secret == "" {
+		return nil, errors.New("NewStoredDataWriteEndpoint requires stored_requests.write_api.secret")
+	}
+	defRequest := defReqJSON != nil && len(defReqJSON) > 0
+
+	return (&storedDataWriter{
+		deps: &endpointDeps{
+			nil,
+			validator,
+			requestsById,
+			empty_fetcher.EmptyFetcher{},
+			nil,
+			nil,
+			nil,
+			cfg,
+			nil,
+			nil,
+			disabledBidders,
+			defRequest,
+			defReqJSON,
+			bidderMap},
+		writer: writer,
+		secret: cfg.StoredRequests.WriteAPI.Secret,
+	}).Save, nil
+}
+
+type storedDataWriter struct {
+	deps   *endpointDeps
+	writer stored_requests.Writer
+	secret string
+}
+
+// storedDataSave is the body of a save. It's the same as the body of a save to the cache events API.
+type storedDataSave struct {
+	Requests map[string]json.RawMessage `json:"requests"`
+	Imps     map[string]json.RawMessage `json:"imps"`
+}
+
+// storedDataErrors describes everything which is wrong with each invalid Stored Request and Imp in a save.
+type storedDataErrors struct {
+	Requests map[string][]string `json:"requests,omitempty"`
+	Imps     map[string][]string `json:"imps,omitempty"`
+}
+
+func (w *storedDataWriter) Save(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !w.authorized(r) {
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		writeStoredDataSaveError(rw, http.StatusUnauthorized, "Saves must be authorized with the write API's secret")
+		return
+	}
+
+	save, err := w.parseSave(r)
+	if err != nil {
+		writeStoredDataSaveError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), storedDataSaveTimeout)
+	defer cancel()
+
+	if invalid := w.deps.validateStoredData(ctx, save); invalid != nil {
+		body, err := json.Marshal(struct {
+			Errors *storedDataErrors `json:"errors"`
+		}{invalid})
+		if err != nil {
+			glog.Errorf("/storedrequests/save Critical error when trying to marshal the validation errors: %v", err)
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write(body)
+		return
+	}
+
+	if err := w.writer.SaveRequests(ctx, save.Requests, save.Imps); err != nil {
+		glog.Errorf("/storedrequests/save Failed to save Stored Requests: %v", err)
+		writeStoredDataSaveError(rw, http.StatusInternalServerError, fmt.Sprintf("Failed to save: %v", err))
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (w *storedDataWriter) authorized(r *http.Request) bool {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(w.secret)) == 1
+}
+
+func (w *storedDataWriter) parseSave(r *http.Request) (*storedDataSave, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, w.deps.cfg.MaxRequestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > w.deps.cfg.MaxRequestSize {
+		return nil, fmt.Errorf("The save is bigger than the max request size of %d bytes", w.deps.cfg.MaxRequestSize)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	save := &storedDataSave{}
+	if err := decoder.Decode(save); err != nil {
+		return nil, fmt.Errorf("Invalid save: %v", err)
+	}
+	if len(save.Requests) == 0 && len(save.Imps) == 0 {
+		return nil, errors.New("The save must include at least one Stored Request or Imp")
+	}
+	return save, nil
+}
+
+func writeStoredDataSaveError(rw http.ResponseWriter, status int, msg string) {
+	rw.WriteHeader(status)
+	rw.Write([]byte(msg))
+}
+
+// validateStoredData runs the checks which an auction would run on each Stored Request and Imp in the save.
+// It returns nil if they're all valid.
+func (deps *endpointDeps) validateStoredData(ctx context.Context, save *storedDataSave) *storedDataErrors {
+	invalid := &storedDataErrors{}
+	for id := range save.Requests {
+		if messages := deps.validateStoredVersions(ctx, save, id, true); len(messages) > 0 {
+			if invalid.Requests == nil {
+				invalid.Requests = make(map[string][]string)
+			}
+			invalid.Requests[id] = messages
+		}
+	}
+	for id := range save.Imps {
+		if messages := deps.validateStoredVersions(ctx, save, id, false); len(messages) > 0 {
+			if invalid.Imps == nil {
+				invalid.Imps = make(map[string][]string)
+			}
+			invalid.Imps[id] = messages
+		}
+	}
+	if invalid.Requests == nil && invalid.Imps == nil {
+		return nil
+	}
+	return invalid
+}
+
+// validateStoredVersions validates every version of the Stored Request or Imp with the given ID.
+// Errors in a version are prefixed with its name.
+func (deps *endpointDeps) validateStoredVersions(ctx context.Context, save *storedDataSave, id string, isRequest bool) []string {
+	data := save.Imps[id]
+	if isRequest {
+		data = save.Requests[id]
+	}
+	versions, err := stored_requests.Versions(data)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid versions: %v", err)}
+	}
+
+	var messages []string
+	for _, version := range versions {
+		for _, err := range deps.validateStoredVersion(ctx, save, id, version.Data, isRequest) {
+			if version.Version != "" {
+				messages = append(messages, fmt.Sprintf("version %s: %v", version.Version, err))
+			} else {
+				messages = append(messages, err.Error())
+			}
+		}
+	}
+	return messages
+}
+
+// validateStoredVersion validates one version of a Stored Request or Imp. It resolves the data through a request
+// which uses it, just like an auction would, and then validates the result as stored data.
+func (deps *endpointDeps) validateStoredVersion(ctx context.Context, save *storedDataSave, id string, data json.RawMessage, isRequest bool) []error {
+	fetcher := &savedDataFetcher{requests: save.Requests, imps: save.Imps, backend: deps.storedReqFetcher}
+	storedRequest, err := json.Marshal(openrtb_ext.ExtStoredRequest{ID: id})
+	if err != nil {
+		return []error{err}
+	}
+	var probe []byte
+	if isRequest {
+		fetcher.requests = withStoredData(save.Requests, id, data)
+		probe = []byte(fmt.Sprintf(`{"ext":{"prebid":{"storedrequest":%s}}}`, storedRequest))
+	} else {
+		fetcher.imps = withStoredData(save.Imps, id, data)
+		probe = []byte(fmt.Sprintf(`{"imp":[{"id":"%s","ext":{"prebid":{"storedrequest":%s}}}]}`, storedImpProbeID, storedRequest))
+	}
+
+	probeDeps := *deps
+	probeDeps.storedReqFetcher = fetcher
+	resolved, errs := probeDeps.processStoredRequests(ctx, probe, &analytics.AuctionDetails{})
+	if len(errs) > 0 {
+		return errs
+	}
+	req := &openrtb.BidRequest{}
+	if err := json.Unmarshal(resolved, req); err != nil {
+		return []error{err}
+	}
+
+	// Warnings, like bids for disabled bidders, don't stop an auction, so they shouldn't stop a save either.
+	var fatalErrs []error
+	for _, err := range probeDeps.validateRequestData(req, true) {
+		if fatalError([]error{err}) {
+			fatalErrs = append(fatalErrs, err)
+		}
+	}
+	return fatalErrs
+}
+
+// withStoredData returns a copy of the stored data, with the data for the ID replaced.
+func withStoredData(stored map[string]json.RawMessage, id string, data json.RawMessage) map[string]json.RawMessage {
+	replaced := make(map[string]json.RawMessage, len(stored))
+	for storedID, storedData := range stored {
+		replaced[storedID] = storedData
+	}
+	replaced[id] = data
+	return replaced
+}
+
+// savedDataFetcher returns the Stored Requests and Imps in a save ahead of the backend's,
+// so that the data in the save is validated against each other before any of it is written.
+type savedDataFetcher struct {
+	requests map[string]json.RawMessage
+	imps     map[string]json.RawMessage
+	backend  stored_requests.Fetcher
+}
+
+func (f *savedDataFetcher) FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (map[string]json.RawMessage, map[string]json.RawMessage, []error) {
+	requestData, unsavedRequestIDs := splitSavedData(requestIDs, f.requests)
+	impData, unsavedImpIDs := splitSavedData(impIDs, f.imps)
+	if len(unsavedRequestIDs) == 0 && len(unsavedImpIDs) == 0 {
+		return requestData, impData, nil
+	}
+
+	fetchedRequests, fetchedImps, errs := f.backend.FetchRequests(ctx, unsavedRequestIDs, unsavedImpIDs)
+	for id, data := range fetchedRequests {
+		requestData[id] = data
+	}
+	for id, data := range fetchedImps {
+		impData[id] = data
+	}
+	return requestData, impData, errs
+}
+
+// splitSavedData returns the data for the IDs which are in the save, and the IDs which aren't.
+func splitSavedData(ids []string, saved map[string]json.RawMessage) (map[string]json.RawMessage, []string) {
+	data := make(map[string]json.RawMessage, len(ids))
+	var unsaved []string
+	for _, id := range ids {
+		if savedData, ok := saved[id]; ok {
+			data[id] = savedData
+		} else {
+			unsaved = append(unsaved, id)
+		}
+	}
+	return data, unsaved
+}
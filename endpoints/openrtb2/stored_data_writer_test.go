@@ -0,0 +1,165 @@
+package openrtb2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+const storedDataSecret = "shh"
+
+func TestStoredDataSave(t *testing.T) {
+	writer := &storedDataRecorder{}
+	endpoint := newStoredDataWriteEndpoint(t, &inheritingStoredReqFetcher{}, writer)
+
+	body := `{
+		"requests": {"site": {"site": {"page": "site.com"}, "tmax": 500}},
+		"imps": {"banner": {"banner": {"format": [{"w": 300, "h": 250}]}, "ext": {"appnexus": {"placementId": 1}}}}
+	}`
+	recorder := httptest.NewRecorder()
+	endpoint(recorder, newStoredDataSave(body, "Bearer "+storedDataSecret))
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code, recorder.Body.String())
+	if assert.Len(t, writer.saves, 1) {
+		assert.JSONEq(t, `{"site": {"page": "site.com"}, "tmax": 500}`, string(writer.saves[0].Requests["site"]))
+		assert.JSONEq(t, `{"banner": {"format": [{"w": 300, "h": 250}]}, "ext": {"appnexus": {"placementId": 1}}}`, string(writer.saves[0].Imps["banner"]))
+	}
+}
+
+func TestStoredDataSaveUnauthorized(t *testing.T) {
+	writer := &storedDataRecorder{}
+	endpoint := newStoredDataWriteEndpoint(t, &inheritingStoredReqFetcher{}, writer)
+
+	for _, authorization := range []string{"", storedDataSecret, "Bearer wrong", "Basic " + storedDataSecret} {
+		recorder := httptest.NewRecorder()
+		endpoint(recorder, newStoredDataSave(`{"requests":{"site":{"site":{"page":"site.com"}}}}`, authorization))
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code, "Authorization %q shouldn't be accepted", authorization)
+	}
+	assert.Empty(t, writer.saves)
+}
+
+func TestStoredDataSaveBadBody(t *testing.T) {
+	writer := &storedDataRecorder{}
+	endpoint := newStoredDataWriteEndpoint(t, &inheritingStoredReqFetcher{}, writer)
+
+	for _, body := range []string{`{`, `{}`, `{"accounts":{"a":{}}}`} {
+		recorder := httptest.NewRecorder()
+		endpoint(recorder, newStoredDataSave(body, "Bearer "+storedDataSecret))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "The save %s should be rejected", body)
+	}
+	assert.Empty(t, writer.saves)
+}
+
+func TestStoredDataSaveInvalid(t *testing.T) {
+	writer := &storedDataRecorder{}
+	endpoint := newStoredDataWriteEndpoint(t, &inheritingStoredReqFetcher{}, writer)
+
+	body := `{
+		"requests": {
+			"ok": {"site": {"page": "site.com"}},
+			"bad-tmax": {"tmax": -1},
+			"site-and-app": {"site": {"page": "site.com"}, "app": {"id": "app"}}
+		},
+		"imps": {
+			"no-media-type": {"ext": {"appnexus": {"placementId": 1}}},
+			"versioned": {"versions": [
+				{"version": "v1", "weight": 1, "data": {"banner": {"format": [{"w": 300, "h": 250}]}, "ext": {"appnexus": {"placementId": 1}}}},
+				{"version": "v2", "weight": 0, "data": {"ext": {"appnexus": {"placementId": 1}}}}
+			]},
+			"bad-versions": {"versions": []}
+		}
+	}`
+	recorder := httptest.NewRecorder()
+	endpoint(recorder, newStoredDataSave(body, "Bearer "+storedDataSecret))
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"errors": {
+		"requests": {
+			"bad-tmax": ["request.tmax must be nonnegative. Got -1"],
+			"site-and-app": ["request.site or request.app must be defined, but not both."]
+		},
+		"imps": {
+			"no-media-type": ["request.imp[0] must contain at least one of \"banner\", \"video\", \"audio\", or \"native\""],
+			"versioned": ["version v2: request.imp[0] must contain at least one of \"banner\", \"video\", \"audio\", or \"native\""],
+			"bad-versions": ["invalid versions: versions must not be empty"]
+		}
+	}}`, recorder.Body.String())
+	assert.Empty(t, writer.saves, "Nothing should be saved if anything is invalid")
+}
+
+func TestStoredDataSaveBidderParams(t *testing.T) {
+	writer := &storedDataRecorder{}
+	endpoint := newStoredDataWriteEndpoint(t, &inheritingStoredReqFetcher{}, writer)
+
+	recorder := httptest.NewRecorder()
+	endpoint(recorder, newStoredDataSave(`{"imps":{"bad-params":{"banner":{"format":[{"w":300,"h":250}]},"ext":{"appnexus":{"placementId":"abc"}}}}}`, "Bearer "+storedDataSecret))
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var response struct {
+		Errors storedDataErrors `json:"errors"`
+	}
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response)) && assert.Len(t, response.Errors.Imps["bad-params"], 1) {
+		assert.True(t, strings.HasPrefix(response.Errors.Imps["bad-params"][0], "request.imp[0].ext.appnexus failed validation."), response.Errors.Imps["bad-params"][0])
+	}
+	assert.Empty(t, writer.saves)
+}
+
+func TestStoredDataSaveInheritance(t *testing.T) {
+	fetcher := &inheritingStoredReqFetcher{
+		imps: map[string]json.RawMessage{
+			"bidders": json.RawMessage(`{"ext":{"appnexus":{"placementId":1}}}`),
+		},
+	}
+	writer := &storedDataRecorder{}
+	endpoint := newStoredDataWriteEndpoint(t, fetcher, writer)
+
+	body := `{"imps": {
+		"from-backend": {"banner": {"format": [{"w": 300, "h": 250}]}, "ext": {"prebid": {"storedrequest": {"id": "bidders"}}}},
+		"from-save": {"video": {"mimes": ["video/mp4"]}, "ext": {"prebid": {"storedrequest": {"id": "saved-bidders"}}}},
+		"saved-bidders": {"banner": {"format": [{"w": 728, "h": 90}]}, "ext": {"appnexus": {"placementId": 2}}}
+	}}`
+	recorder := httptest.NewRecorder()
+	endpoint(recorder, newStoredDataSave(body, "Bearer "+storedDataSecret))
+	assert.Equal(t, http.StatusNoContent, recorder.Code, "Stored Imps should be validated after they're merged into their parents. Got %s", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	endpoint(recorder, newStoredDataSave(`{"imps":{"orphan":{"banner":{"format":[{"w":300,"h":250}]},"ext":{"prebid":{"storedrequest":{"id":"missing"}}}}}}`, "Bearer "+storedDataSecret))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `{"errors":{"imps":{"orphan":["Stored Imp with ID=\"missing\" not found."]}}}`, recorder.Body.String())
+	assert.Len(t, writer.saves, 1)
+}
+
+func newStoredDataWriteEndpoint(t *testing.T, fetcher *inheritingStoredReqFetcher, writer *storedDataRecorder) http.HandlerFunc {
+	cfg := &config.Configuration{MaxRequestSize: maxSize}
+	cfg.StoredRequests.WriteAPI = config.StoredRequestsWriteAPI{Enabled: true, Secret: storedDataSecret}
+	endpoint, err := NewStoredDataWriteEndpoint(newParamsValidator(t), fetcher, writer, cfg, map[string]string{}, nil, openrtb_ext.BidderMap)
+	if err != nil {
+		t.Fatalf("Failed to create the endpoint: %v", err)
+	}
+	return endpoint
+}
+
+func newStoredDataSave(body string, authorization string) *http.Request {
+	req := httptest.NewRequest("POST", "/storedrequests/save", strings.NewReader(body))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req
+}
+
+// storedDataRecorder is a stored_requests.Writer which records its saves.
+type storedDataRecorder struct {
+	saves []storedDataSave
+}
+
+func (w *storedDataRecorder) SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error {
+	w.saves = append(w.saves, storedDataSave{Requests: requests, Imps: imps})
+	return nil
+}
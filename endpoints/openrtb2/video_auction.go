@@ -14,11 +14,13 @@ import (
 
 	"github.com/buger/jsonparser"
 	jsonpatch "github.com/evanphx/json-patch"
+	uuid "github.com/gofrs/uuid"
 	"github.com/prebid/prebid-server/errortypes"
 
 	"github.com/golang/glog"
 	"github.com/julienschmidt/httprouter"
 	"github.com/mxmCherry/openrtb"
+	accountService "github.com/prebid/prebid-server/account"
 	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/exchange"
@@ -30,14 +32,14 @@ import (
 
 var defaultRequestTimeout int64 = 5000
 
-func NewVideoEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, videoFetcher stored_requests.Fetcher, categories stored_requests.CategoryFetcher, cfg *config.Configuration, met pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName) (httprouter.Handle, error) {
+func NewVideoEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamValidator, requestsById stored_requests.Fetcher, videoFetcher stored_requests.Fetcher, accounts stored_requests.AccountFetcher, categories stored_requests.CategoryFetcher, uidStore usersync.UIDStore, cfg *config.Configuration, met pbsmetrics.MetricsEngine, pbsAnalytics analytics.PBSAnalyticsModule, disabledBidders map[string]string, defReqJSON []byte, bidderMap map[string]openrtb_ext.BidderName) (httprouter.Handle, error) {
 
-	if ex == nil || validator == nil || requestsById == nil || cfg == nil || met == nil {
+	if ex == nil || validator == nil || requestsById == nil || accounts == nil || cfg == nil || met == nil {
 		return nil, errors.New("NewVideoEndpoint requires non-nil arguments.")
 	}
 	defRequest := defReqJSON != nil && len(defReqJSON) > 0
 
-	return httprouter.Handle((&endpointDeps{ex, validator, requestsById, videoFetcher, categories, cfg, met, pbsAnalytics, disabledBidders, defRequest, defReqJSON, bidderMap}).VideoAuctionEndpoint), nil
+	return httprouter.Handle((&endpointDeps{ex, validator, requestsById, videoFetcher, accounts, categories, uidStore, cfg, met, pbsAnalytics, disabledBidders, defRequest, defReqJSON, bidderMap}).VideoAuctionEndpoint), nil
 }
 
 /*
@@ -65,7 +67,7 @@ func NewVideoEndpoint(ex exchange.Exchange, validator openrtb_ext.BidderParamVal
 */
 func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 
-	ao := analytics.AuctionObject{
+	vo := analytics.VideoObject{
 		Status: http.StatusOK,
 		Errors: make([]error, 0),
 	}
@@ -79,10 +81,13 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 		CookieFlag:    pbsmetrics.CookieFlagUnknown,
 		RequestStatus: pbsmetrics.RequestStatusOK,
 	}
+	var account *config.Account
 	defer func() {
 		deps.metricsEngine.RecordRequest(labels)
 		deps.metricsEngine.RecordRequestTime(labels, time.Since(start))
-		deps.analytics.LogAuctionObject(&ao)
+		if account == nil || !account.Analytics.Disabled {
+			deps.analytics.LogVideoObject(&vo)
+		}
 	}()
 
 	isSafari := checkSafari(r)
@@ -97,39 +102,58 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 	requestJson, err := ioutil.ReadAll(lr)
 	if err != nil {
 		errL := []error{err}
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
 		return
 	}
 
 	resolvedRequest := requestJson
+	versionKey := storedVersionKey(requestJson)
+	var requestVersions map[string]string
 
 	//load additional data - stored simplified req
 	storedRequestId, err := getVideoStoredRequestId(requestJson)
 
 	if err != nil && deps.cfg.VideoStoredRequestRequired {
 		errL := []error{err}
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
 		return
 	}
 	if err == nil {
-		storedRequest, errs := deps.loadStoredVideoRequest(context.Background(), storedRequestId)
+		storedRequest, errs := deps.loadStoredVideoRequest(r.Context(), storedRequestId)
 		if len(errs) > 0 {
-			handleError(labels, w, errs, ao)
+			handleError(labels, w, errs, &vo)
+			return
+		}
+		storedRequest, version, err := stored_requests.ChooseVersion(storedRequestId, storedRequest, versionKey)
+		if err != nil {
+			errL := []error{fmt.Errorf("storedrequestid refers to Stored Request %s which has invalid versions: %v", storedRequestId, err)}
+			handleError(labels, w, errL, &vo)
 			return
 		}
+		if version != "" {
+			requestVersions = map[string]string{storedRequestId: version}
+		}
 
 		//merge incoming req with stored video req
 		resolvedRequest, err = jsonpatch.MergePatch(storedRequest, requestJson)
 		if err != nil {
 			errL := []error{err}
-			handleError(labels, w, errL, ao)
+			handleError(labels, w, errL, &vo)
 			return
 		}
 	}
 	//unmarshal and validate combined result
 	videoBidReq, errL, podErrors := deps.parseVideoRequest(resolvedRequest)
+	vo.VideoRequest = videoBidReq
 	if len(errL) > 0 {
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
+		return
+	}
+
+	output, err := videoOutput(r, videoBidReq)
+	if err != nil {
+		errL := []error{err}
+		handleError(labels, w, errL, &vo)
 		return
 	}
 
@@ -138,6 +162,7 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 		if err := json.Unmarshal(deps.defReqJSON, bidReq); err != nil {
 			err = fmt.Errorf("Invalid JSON in Default Request Settings: %s", err)
 			errL = []error{err}
+			handleError(labels, w, errL, &vo)
 			return
 		}
 	}
@@ -152,7 +177,10 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 	}
 
 	//create impressions array
-	imps, podErrors := deps.createImpressions(videoBidReq, podErrors)
+	imps, impVersions, podErrors := deps.createImpressions(r.Context(), videoBidReq, podErrors, versionKey)
+	if requestVersions != nil || impVersions != nil {
+		vo.Details.StoredRequestVersions = &openrtb_ext.ExtStoredRequestVersions{Requests: requestVersions, Imps: impVersions}
+	}
 
 	if len(podErrors) == initialPodNumber {
 		resPodErr := make([]string, 0)
@@ -161,23 +189,28 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 		}
 		err := errors.New(fmt.Sprintf("all pods are incorrect: %s", strings.Join(resPodErr, "; ")))
 		errL = append(errL, err)
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
 		return
 	}
 
 	bidReq.Imp = imps
-	bidReq.ID = "bid_id" //TODO: look at prebid.js
+	bidReq.ID, err = videoRequestID(videoBidReq)
+	if err != nil {
+		errL := []error{err}
+		handleError(labels, w, errL, &vo)
+		return
+	}
 
 	// Populate any "missing" OpenRTB fields with info from other sources, (e.g. HTTP request headers).
 	deps.setFieldsImplicitly(r, bidReq) // move after merge
 
 	errL = deps.validateRequest(bidReq)
 	if len(errL) > 0 {
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
 		return
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 	timeout := deps.cfg.AuctionTimeouts.LimitAuctionTimeout(time.Duration(bidReq.TMax) * time.Millisecond)
 	if timeout > 0 {
 		var cancel context.CancelFunc
@@ -198,37 +231,61 @@ func (deps *endpointDeps) VideoAuctionEndpoint(w http.ResponseWriter, r *http.Re
 		}
 		labels.PubID = effectivePubID(bidReq.Site.Publisher)
 	}
+	vo.AccountID = labels.PubID
 	// Blacklist account now that we have resolved the value
 	if _, found := deps.cfg.BlacklistedAcctMap[labels.PubID]; found {
 		errL := []error{&errortypes.BlacklistedAcct{Message: fmt.Sprintf("Prebid-server has blacklisted Account ID: %s, pleaase reach out to the prebid server host.", labels.PubID)}}
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
+		return
+	}
+
+	account, acctErrs := accountService.GetAccount(ctx, deps.cfg, deps.accounts, labels.PubID)
+	if len(acctErrs) > 0 {
+		handleError(labels, w, acctErrs, &vo)
+		return
 	}
+
 	//execute auction logic
-	response, err := deps.ex.HoldAuction(ctx, bidReq, usersyncs, labels, &deps.categories)
-	ao.Request = bidReq
-	ao.Response = response
+	response, err := deps.ex.HoldAuction(ctx, bidReq, deps.userIDs(ctx, r, bidReq, usersyncs), labels, account, &deps.categories, &vo.Details)
+	vo.Request = bidReq
+	vo.Response = response
 	if err != nil {
 		errL := []error{err}
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
+		return
+	}
+
+	if output != openrtb_ext.VideoOutputJSON {
+		tracking := &vastTracking{externalURL: deps.cfg.ExternalURL, account: account, auctionStart: start}
+		vastResp, warnings, err := buildVASTResponse(response, &videoBidReq.PodConfig, podErrors, output, tracking)
+		if err != nil {
+			errL := []error{err}
+			handleError(labels, w, errL, &vo)
+			return
+		}
+		vo.Errors = append(vo.Errors, warnings...)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(vastResp)
 		return
 	}
 
 	//build simplified response
-	bidResp, err := buildVideoResponse(response, podErrors)
+	bidResp, err := buildVideoResponse(response, &videoBidReq.PodConfig, podErrors)
 	if err != nil {
 		errL := []error{err}
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
 		return
 	}
 	if bidReq.Test == 1 {
 		bidResp.Ext = response.Ext
 	}
+	vo.VideoResponse = bidResp
 
 	resp, err := json.Marshal(bidResp)
 	//resp, err := json.Marshal(response)
 	if err != nil {
 		errL := []error{err}
-		handleError(labels, w, errL, ao)
+		handleError(labels, w, errL, &vo)
 		return
 	}
 
@@ -244,47 +301,51 @@ func cleanupVideoBidRequest(videoReq *openrtb_ext.BidRequestVideo, podErrors []P
 	return videoReq
 }
 
-func handleError(labels pbsmetrics.Labels, w http.ResponseWriter, errL []error, ao analytics.AuctionObject) {
+func handleError(labels pbsmetrics.Labels, w http.ResponseWriter, errL []error, vo *analytics.VideoObject) {
 	labels.RequestStatus = pbsmetrics.RequestStatusErr
 	var errors string
 	var foundBlacklisted bool = false
 	for _, er := range errL {
 		erVal := errortypes.DecodeError(er)
-		if erVal == errortypes.BlacklistedAppCode || erVal == errortypes.BlacklistedAcctCode {
+		if erVal == errortypes.BlacklistedAppCode || erVal == errortypes.BlacklistedAcctCode || erVal == errortypes.AcctRequiredCode {
 			foundBlacklisted = true
 		}
 		errors = fmt.Sprintf("%s %s", errors, er.Error())
 	}
 	if foundBlacklisted {
 		w.WriteHeader(http.StatusServiceUnavailable)
-		ao.Status = http.StatusServiceUnavailable
+		vo.Status = http.StatusServiceUnavailable
 	} else {
 		w.WriteHeader(http.StatusInternalServerError)
-		ao.Status = http.StatusInternalServerError
+		vo.Status = http.StatusInternalServerError
 	}
 	fmt.Fprintf(w, "Critical error while running the video endpoint: %v", errors)
 	glog.Errorf("/openrtb2/video Critical error: %v", errors)
-	ao.Errors = append(ao.Errors, errL...)
+	vo.Errors = append(vo.Errors, errL...)
 }
 
-func (deps *endpointDeps) createImpressions(videoReq *openrtb_ext.BidRequestVideo, podErrors []PodError) ([]openrtb.Imp, []PodError) {
+// createImpressions splits each pod into impressions. Each pod's impressions are made from the pod's stored imp,
+// and the pod's bidders override the bidder params in the stored imp. It also returns the versions of any versioned stored imps.
+func (deps *endpointDeps) createImpressions(ctx context.Context, videoReq *openrtb_ext.BidRequestVideo, podErrors []PodError, versionKey string) ([]openrtb.Imp, map[string]string, []PodError) {
 	videoDur := videoReq.PodConfig.DurationRangeSec
 	minDuration, maxDuration := minMax(videoDur)
 	reqExactDur := videoReq.PodConfig.RequireExactDuration
 	videoData := videoReq.Video
 
+	storedImps, impVersions, versionErrs := deps.loadStoredImps(ctx, videoReq.PodConfig.Pods, versionKey)
+
 	finalImpsArray := make([]openrtb.Imp, 0)
 	for ind, pod := range videoReq.PodConfig.Pods {
 
-		//load stored impression
-		storedImpressionId := string(pod.ConfigId)
-		storedImp, errs := deps.loadStoredImp(storedImpressionId)
-		if errs != nil {
-			err := fmt.Sprintf("unable to load configid %s, Pod id: %d", storedImpressionId, pod.PodId)
+		storedImp, err := podImp(pod, storedImps)
+		if versionErr, ok := versionErrs[pod.ConfigId]; ok {
+			err = fmt.Errorf("configid %s has invalid versions: %v, Pod id: %d", pod.ConfigId, versionErr, pod.PodId)
+		}
+		if err != nil {
 			podErr := PodError{}
 			podErr.PodId = pod.PodId
 			podErr.PodIndex = ind
-			podErr.ErrMsgs = append(podErr.ErrMsgs, err)
+			podErr.ErrMsgs = append(podErr.ErrMsgs, err.Error())
 			podErrors = append(podErrors, podErr)
 			continue
 		}
@@ -319,7 +380,7 @@ func (deps *endpointDeps) createImpressions(videoReq *openrtb_ext.BidRequestVide
 		finalImpsArray = append(finalImpsArray, impsArray...)
 
 	}
-	return finalImpsArray, podErrors
+	return finalImpsArray, impVersions, podErrors
 }
 
 func max(a, b int) int {
@@ -338,20 +399,93 @@ func createImpressionTemplate(imp openrtb.Imp, video openrtb_ext.SimplifiedVideo
 	return imp
 }
 
-func (deps *endpointDeps) loadStoredImp(storedImpId string) (openrtb.Imp, []error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(storedRequestTimeoutMillis)*time.Millisecond)
+// loadStoredImps fetches the stored imps of all the pods at once. Imps which couldn't be loaded are missing
+// from the result. Versioned imps are resolved to the version chosen for versionKey, and the chosen versions
+// are returned too. Imps with invalid versions are reported in the error map instead.
+func (deps *endpointDeps) loadStoredImps(ctx context.Context, pods []openrtb_ext.Pod, versionKey string) (map[string]json.RawMessage, map[string]string, map[string]error) {
+	storedImpIds := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		if pod.ConfigId != "" {
+			storedImpIds = append(storedImpIds, pod.ConfigId)
+		}
+	}
+	if len(storedImpIds) == 0 {
+		return nil, nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(storedRequestTimeoutMillis)*time.Millisecond)
 	defer cancel()
 
+	_, storedImps, _ := deps.storedReqFetcher.FetchRequests(ctx, []string{}, storedImpIds)
+
+	// The Fetcher's map may be shared, so the chosen versions go in a new one.
+	resolvedImps := make(map[string]json.RawMessage, len(storedImps))
+	var versions map[string]string
+	var errs map[string]error
+	for id, data := range storedImps {
+		chosen, version, err := stored_requests.ChooseVersion(id, data, versionKey)
+		if err != nil {
+			if errs == nil {
+				errs = make(map[string]error)
+			}
+			errs[id] = err
+			continue
+		}
+		resolvedImps[id] = chosen
+		if version != "" {
+			if versions == nil {
+				versions = make(map[string]string)
+			}
+			versions[id] = version
+		}
+	}
+	return resolvedImps, versions, errs
+}
+
+// podImp returns the imp which the pod's impressions are based on. It's the pod's stored imp, if it has one,
+// with the pod's bidder params.
+func podImp(pod openrtb_ext.Pod, storedImps map[string]json.RawMessage) (openrtb.Imp, error) {
 	impr := openrtb.Imp{}
-	_, imp, err := deps.storedReqFetcher.FetchRequests(ctx, []string{}, []string{storedImpId})
+	if pod.ConfigId != "" {
+		storedImp, ok := storedImps[pod.ConfigId]
+		if !ok {
+			return impr, fmt.Errorf("unable to load configid %s, Pod id: %d", pod.ConfigId, pod.PodId)
+		}
+		if err := json.Unmarshal(storedImp, &impr); err != nil {
+			return impr, fmt.Errorf("unable to parse configid %s, Pod id: %d: %v", pod.ConfigId, pod.PodId, err)
+		}
+	}
+	if len(pod.Bidders) == 0 {
+		return impr, nil
+	}
+
+	bidders := make(map[string]json.RawMessage, len(pod.Bidders))
+	if len(impr.Ext) > 0 {
+		if err := json.Unmarshal(impr.Ext, &bidders); err != nil {
+			return impr, fmt.Errorf("unable to parse the ext of configid %s, Pod id: %d: %v", pod.ConfigId, pod.PodId, err)
+		}
+	}
+	for bidder, params := range pod.Bidders {
+		bidders[bidder] = params
+	}
+	ext, err := json.Marshal(bidders)
 	if err != nil {
 		return impr, err
 	}
+	impr.Ext = ext
+	return impr, nil
+}
 
-	if err := json.Unmarshal(imp[storedImpId], &impr); err != nil {
-		return impr, []error{err}
+// videoRequestID returns the request's own ID, or a new one if it doesn't have an ID.
+func videoRequestID(videoReq *openrtb_ext.BidRequestVideo) (string, error) {
+	if videoReq.ID != "" {
+		return videoReq.ID, nil
 	}
-	return impr, nil
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate a request ID: %v", err)
+	}
+	return id.String(), nil
 }
 
 func minMax(array []int) (int, int) {
@@ -368,17 +502,48 @@ func minMax(array []int) (int, int) {
 	return min, max
 }
 
-func buildVideoResponse(bidresponse *openrtb.BidResponse, podErrors []PodError) (*openrtb_ext.BidResponseVideo, error) {
+// buildVideoResponse returns the targeting keys for the bids which selectPodBids chose in each pod.
+func buildVideoResponse(bidresponse *openrtb.BidResponse, podConfig *openrtb_ext.PodConfig, podErrors []PodError) (*openrtb_ext.BidResponseVideo, error) {
+	adPods, podBids, err := selectPodBids(bidresponse, podConfig)
+	if err != nil {
+		return nil, err
+	}
+	for _, adPod := range adPods {
+		for _, bid := range podBids[adPod.PodId] {
+			adPod.Targeting = append(adPod.Targeting, bid.targeting)
+		}
+	}
+
+	// If there were incorrect pods, we put them back to response with error message
+	if len(podErrors) > 0 {
+		for _, podEr := range podErrors {
+			adPodEr := &openrtb_ext.AdPod{
+				PodId:  int64(podEr.PodId),
+				Errors: podEr.ErrMsgs,
+			}
+			adPods = append(adPods, adPodEr)
+		}
+	}
+
+	return &openrtb_ext.BidResponseVideo{AdPods: adPods}, nil
+}
+
+// selectPodBids groups the cached bids into their ad pods. If the pod is in the podConfig,
+// only the bids chosen by optimizeAdPod are kept, and the others are listed in the pod's Excluded bids.
+func selectPodBids(bidresponse *openrtb.BidResponse, podConfig *openrtb_ext.PodConfig) ([]*openrtb_ext.AdPod, map[int64][]podBid, error) {
 
 	adPods := make([]*openrtb_ext.AdPod, 0)
+	podBids := make(map[int64][]podBid)
 	anyBidsReturned := false
-	for _, seatBid := range bidresponse.SeatBid {
-		for _, bid := range seatBid.Bid {
+	for seatInd := range bidresponse.SeatBid {
+		seatBid := &bidresponse.SeatBid[seatInd]
+		for bidInd := range seatBid.Bid {
+			bid := &seatBid.Bid[bidInd]
 			anyBidsReturned = true
 
 			var tempRespBidExt openrtb_ext.ExtBid
 			if err := json.Unmarshal(bid.Ext, &tempRespBidExt); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			if tempRespBidExt.Prebid.Targeting[string(openrtb_ext.HbVastCacheKey)] == "" {
 				continue
@@ -402,8 +567,7 @@ func buildVideoResponse(bidresponse *openrtb.BidResponse, podErrors []PodError)
 				}
 				adPods = append(adPods, adPod)
 			}
-			adPod.Targeting = append(adPod.Targeting, videoTargeting)
-
+			podBids[podId] = append(podBids[podId], newPodBid(bid, seatBid.Seat, tempRespBidExt.Prebid, videoTargeting))
 		}
 	}
 
@@ -412,21 +576,17 @@ func buildVideoResponse(bidresponse *openrtb.BidResponse, podErrors []PodError)
 	if len(adPods) == 0 && anyBidsReturned {
 		//means there is a global cache error, we need to reject all bids
 		err := errors.New("caching failed for all bids")
-		return nil, err
+		return nil, nil, err
 	}
 
-	// If there were incorrect pods, we put them back to response with error message
-	if len(podErrors) > 0 {
-		for _, podEr := range podErrors {
-			adPodEr := &openrtb_ext.AdPod{
-				PodId:  int64(podEr.PodId),
-				Errors: podEr.ErrMsgs,
+	if podConfig != nil {
+		for _, adPod := range adPods {
+			if pod := findPod(adPod.PodId, podConfig.Pods); pod != nil {
+				podBids[adPod.PodId], adPod.Excluded = optimizeAdPod(podBids[adPod.PodId], pod, podConfig)
 			}
-			adPods = append(adPods, adPodEr)
 		}
 	}
-
-	return &openrtb_ext.BidResponseVideo{AdPods: adPods}, nil
+	return adPods, podBids, nil
 }
 
 func findAdPod(podInd int64, pods []*openrtb_ext.AdPod) *openrtb_ext.AdPod {
@@ -589,6 +749,11 @@ func (deps *endpointDeps) validateVideoRequest(req *openrtb_ext.BidRequestVideo)
 		err := errors.New("request missing required field: PodConfig.Pods")
 		errL = append(errL, err)
 	}
+	if len(req.PodConfig.Pods) > maxAdPods {
+		err := fmt.Errorf("request incorrect required field: PodConfig.Pods can't have more than %d pods. Got %d", maxAdPods, len(req.PodConfig.Pods))
+		errL = append(errL, err)
+		return errL, nil
+	}
 	podErrors := make([]PodError, 0, 0)
 	podIdsSet := make(map[int]bool)
 	for ind, pod := range req.PodConfig.Pods {
@@ -612,10 +777,22 @@ func (deps *endpointDeps) validateVideoRequest(req *openrtb_ext.BidRequestVideo)
 			err := fmt.Sprintf("request incorrect required field: PodConfig.Pods.AdPodDurationSec is negative, Pod index: %d", ind)
 			podErr.ErrMsgs = append(podErr.ErrMsgs, err)
 		}
-		if pod.ConfigId == "" {
+		if pod.AdPodDurationSec > maxAdPodDurationSec {
+			err := fmt.Sprintf("request incorrect required field: PodConfig.Pods.AdPodDurationSec can't be more than %d, Pod index: %d", maxAdPodDurationSec, ind)
+			podErr.ErrMsgs = append(podErr.ErrMsgs, err)
+		}
+		if pod.ConfigId == "" && len(pod.Bidders) == 0 {
 			err := fmt.Sprintf("request missing or incorrect required field: PodConfig.Pods.ConfigId, Pod index: %d", ind)
 			podErr.ErrMsgs = append(podErr.ErrMsgs, err)
 		}
+		if pod.MaxAds < 0 {
+			err := fmt.Sprintf("request incorrect field: PodConfig.Pods.MaxAds is negative, Pod index: %d", ind)
+			podErr.ErrMsgs = append(podErr.ErrMsgs, err)
+		}
+		if pod.MaxAds > maxAdPodAds {
+			err := fmt.Sprintf("request incorrect field: PodConfig.Pods.MaxAds can't be more than %d, Pod index: %d", maxAdPodAds, ind)
+			podErr.ErrMsgs = append(podErr.ErrMsgs, err)
+		}
 		if len(podErr.ErrMsgs) > 0 {
 			podErr.PodId = pod.PodId
 			podErr.PodIndex = ind
@@ -1,6 +1,7 @@
 package openrtb2
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io/ioutil"
@@ -8,14 +9,16 @@ import (
 	"strings"
 	"testing"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/mxmCherry/openrtb"
-	analyticsConf "github.com/prebid/prebid-server/analytics/config"
+	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/exchange"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/prebid/prebid-server/stored_requests"
 	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
+	"github.com/prebid/prebid-server/usersync/uidstores/empty_store"
 	metrics "github.com/rcrowley/go-metrics"
 	"github.com/stretchr/testify/assert"
 )
@@ -365,6 +368,89 @@ func TestVideoEndpointValidationsPodErrors(t *testing.T) {
 	assert.Equal(t, "request missing or incorrect required field: PodConfig.Pods.ConfigId, Pod index: 3", podErrors[1].ErrMsgs[2], "Pod error ind 1 should have missing config id")
 }
 
+func TestVideoEndpointValidationsPodLimits(t *testing.T) {
+	ex := &mockExchangeVideo{}
+	deps := mockDeps(t, ex)
+
+	pods := []openrtb_ext.Pod{
+		{
+			PodId:            1,
+			AdPodDurationSec: maxAdPodDurationSec,
+			ConfigId:         "qwerty",
+			MaxAds:           maxAdPodAds,
+		},
+		{
+			PodId:            2,
+			AdPodDurationSec: maxAdPodDurationSec + 1,
+			ConfigId:         "qwerty",
+			MaxAds:           maxAdPodAds + 1,
+		},
+	}
+
+	req := openrtb_ext.BidRequestVideo{
+		StoredRequestId: "123",
+		PodConfig: openrtb_ext.PodConfig{
+			DurationRangeSec: []int{15, 30},
+			Pods:             pods,
+		},
+		App: &openrtb.App{
+			Bundle: "pbs.com",
+		},
+		Video: openrtb_ext.SimplifiedVideo{
+			Mimes:     []string{"mp4"},
+			Protocols: []openrtb.Protocol{15},
+		},
+	}
+
+	errors, podErrors := deps.validateVideoRequest(&req)
+	assert.Len(t, errors, 0, "Errors should be empty")
+	if assert.Len(t, podErrors, 1, "Only the pod over the limits should have errors") {
+		assert.Equal(t, 1, podErrors[0].PodIndex, "The pod over the limits should have errors")
+		assert.Equal(t, []string{
+			"request incorrect required field: PodConfig.Pods.AdPodDurationSec can't be more than 600, Pod index: 1",
+			"request incorrect field: PodConfig.Pods.MaxAds can't be more than 30, Pod index: 1",
+		}, podErrors[0].ErrMsgs)
+	}
+}
+
+func TestVideoEndpointValidationsTooManyPods(t *testing.T) {
+	ex := &mockExchangeVideo{}
+	deps := mockDeps(t, ex)
+
+	pods := make([]openrtb_ext.Pod, 0, maxAdPods+1)
+	for i := 1; i <= maxAdPods+1; i++ {
+		pods = append(pods, openrtb_ext.Pod{
+			PodId:            i,
+			AdPodDurationSec: 30,
+			ConfigId:         "qwerty",
+		})
+	}
+
+	req := openrtb_ext.BidRequestVideo{
+		StoredRequestId: "123",
+		PodConfig: openrtb_ext.PodConfig{
+			DurationRangeSec: []int{15, 30},
+			Pods:             pods,
+		},
+		App: &openrtb.App{
+			Bundle: "pbs.com",
+		},
+		Video: openrtb_ext.SimplifiedVideo{
+			Mimes:     []string{"mp4"},
+			Protocols: []openrtb.Protocol{15},
+		},
+	}
+
+	errors, _ := deps.validateVideoRequest(&req)
+	if assert.Len(t, errors, 1) {
+		assert.EqualError(t, errors[0], "request incorrect required field: PodConfig.Pods can't have more than 20 pods. Got 21")
+	}
+
+	req.PodConfig.Pods = pods[:maxAdPods]
+	errors, _ = deps.validateVideoRequest(&req)
+	assert.Empty(t, errors, "Requests with the most pods allowed should be valid")
+}
+
 func TestVideoEndpointValidationsSiteAndApp(t *testing.T) {
 	ex := &mockExchangeVideo{}
 	deps := mockDeps(t, ex)
@@ -507,7 +593,7 @@ func TestVideoBuildVideoResponseMissedCacheForOneBid(t *testing.T) {
 	seatBids = append(seatBids, seatBid)
 	openRtbBidResp.SeatBid = seatBids
 
-	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, podErrors)
+	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, nil, podErrors)
 	assert.NoError(t, err, "Should be no error")
 	assert.Len(t, bidRespVideo.AdPods, 1, "AdPods length should be 1")
 	assert.Len(t, bidRespVideo.AdPods[0].Targeting, 2, "AdPod Targeting length should be 2")
@@ -544,7 +630,7 @@ func TestVideoBuildVideoResponseMissedCacheForAllBids(t *testing.T) {
 	seatBids = append(seatBids, seatBid)
 	openRtbBidResp.SeatBid = seatBids
 
-	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, podErrors)
+	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, nil, podErrors)
 	assert.Nil(t, bidRespVideo, "bid response should be nil")
 	assert.Equal(t, "caching failed for all bids", err.Error(), "error should be caching failed for all bids")
 }
@@ -583,7 +669,7 @@ func TestVideoBuildVideoResponsePodErrors(t *testing.T) {
 	podErr2.PodIndex = 2
 	podErrors = append(podErrors, podErr2)
 
-	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, podErrors)
+	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, nil, podErrors)
 	assert.NoError(t, err, "Error should be nil")
 	assert.Len(t, bidRespVideo.AdPods, 3, "AdPods length should be 3")
 	assert.Len(t, bidRespVideo.AdPods[0].Targeting, 2, "First ad pod should be correct and contain 2 targeting elements")
@@ -595,11 +681,85 @@ func TestVideoBuildVideoResponseNoBids(t *testing.T) {
 	openRtbBidResp := openrtb.BidResponse{}
 	podErrors := make([]PodError, 0, 0)
 	openRtbBidResp.SeatBid = make([]openrtb.SeatBid, 0)
-	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, podErrors)
+	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, nil, podErrors)
 	assert.NoError(t, err, "Error should be nil")
 	assert.Len(t, bidRespVideo.AdPods, 0, "AdPods length should be 0")
 }
 
+func TestPodImp(t *testing.T) {
+	storedImps := map[string]json.RawMessage{
+		"preroll": json.RawMessage(`{"ext": {"appnexus": {"placementId": 1}, "rubicon": {"zoneId": 2}}}`),
+		"broken":  json.RawMessage(`{`),
+	}
+
+	imp, err := podImp(openrtb_ext.Pod{PodId: 1, ConfigId: "preroll"}, storedImps)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"appnexus": {"placementId": 1}, "rubicon": {"zoneId": 2}}`, string(imp.Ext))
+
+	imp, err = podImp(openrtb_ext.Pod{PodId: 1, ConfigId: "preroll", Bidders: map[string]json.RawMessage{"appnexus": json.RawMessage(`{"placementId": 3}`)}}, storedImps)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"appnexus": {"placementId": 3}, "rubicon": {"zoneId": 2}}`, string(imp.Ext), "The pod's bidders should override the stored imp's")
+
+	imp, err = podImp(openrtb_ext.Pod{PodId: 1, Bidders: map[string]json.RawMessage{"appnexus": json.RawMessage(`{"placementId": 3}`)}}, storedImps)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"appnexus": {"placementId": 3}}`, string(imp.Ext), "Pods should work without a stored imp")
+
+	_, err = podImp(openrtb_ext.Pod{PodId: 2, ConfigId: "midroll"}, storedImps)
+	assert.EqualError(t, err, "unable to load configid midroll, Pod id: 2")
+
+	_, err = podImp(openrtb_ext.Pod{PodId: 3, ConfigId: "broken"}, storedImps)
+	assert.Error(t, err)
+}
+
+func TestLoadStoredImpsVersions(t *testing.T) {
+	deps := mockDeps(t, &mockExchangeVideo{})
+	deps.storedReqFetcher = &versionedStoredReqFetcher{
+		imps: map[string]json.RawMessage{
+			"preroll": json.RawMessage(`{"versions":[{"version":"v1","weight":1,"data":{"ext":{"appnexus":{"placementId":1}}}}]}`),
+			"plain":   json.RawMessage(`{"ext":{"appnexus":{"placementId":2}}}`),
+			"bad":     json.RawMessage(`{"versions":[]}`),
+		},
+	}
+	pods := []openrtb_ext.Pod{{PodId: 1, ConfigId: "preroll"}, {PodId: 2, ConfigId: "plain"}, {PodId: 3, ConfigId: "bad"}}
+
+	storedImps, versions, errs := deps.loadStoredImps(context.Background(), pods, "key")
+	assert.JSONEq(t, `{"ext":{"appnexus":{"placementId":1}}}`, string(storedImps["preroll"]), "Versioned imps should be resolved to the chosen version")
+	assert.JSONEq(t, `{"ext":{"appnexus":{"placementId":2}}}`, string(storedImps["plain"]))
+	assert.Equal(t, map[string]string{"preroll": "v1"}, versions)
+	assert.EqualError(t, errs["bad"], "versions must not be empty")
+	assert.NotContains(t, storedImps, "bad")
+
+	_, _, podErrors := deps.createImpressions(context.Background(), &openrtb_ext.BidRequestVideo{
+		PodConfig: openrtb_ext.PodConfig{DurationRangeSec: []int{15}, Pods: []openrtb_ext.Pod{{PodId: 3, ConfigId: "bad", AdPodDurationSec: 15}}},
+	}, nil, "key")
+	if assert.Len(t, podErrors, 1) {
+		assert.Equal(t, []string{"configid bad has invalid versions: versions must not be empty, Pod id: 3"}, podErrors[0].ErrMsgs)
+	}
+}
+
+func TestVideoEndpointRequestID(t *testing.T) {
+	reqData, err := ioutil.ReadFile("sample-requests/video/video_valid_sample.json")
+	if err != nil {
+		t.Fatalf("Failed to fetch a valid request: %v", err)
+	}
+	reqBody, err := jsonpatch.MergePatch(getRequestPayload(t, reqData), []byte(`{"id": "video-request-id"}`))
+	if err != nil {
+		t.Fatalf("Failed to add the request ID: %v", err)
+	}
+
+	ex := &mockExchangeVideo{}
+	mockDeps(t, ex).VideoAuctionEndpoint(httptest.NewRecorder(), httptest.NewRequest("POST", "/openrtb2/video", bytes.NewReader(reqBody)), nil)
+	if assert.NotNil(t, ex.lastRequest, "The request never made it into the Exchange.") {
+		assert.Equal(t, "video-request-id", ex.lastRequest.ID, "The request's ID should be passed through")
+	}
+
+	ex = &mockExchangeVideo{}
+	mockDeps(t, ex).VideoAuctionEndpoint(httptest.NewRecorder(), httptest.NewRequest("POST", "/openrtb2/video", bytes.NewReader(getRequestPayload(t, reqData))), nil)
+	if assert.NotNil(t, ex.lastRequest, "The request never made it into the Exchange.") {
+		assert.NotEmpty(t, ex.lastRequest.ID, "Requests without an ID should get one")
+	}
+}
+
 func TestMergeOpenRTBToVideoRequest(t *testing.T) {
 	var bidReq = &openrtb.BidRequest{}
 	var videoReq = &openrtb_ext.BidRequestVideo{}
@@ -645,9 +805,11 @@ func mockDeps(t *testing.T, ex *mockExchangeVideo) *endpointDeps {
 		&mockVideoStoredReqFetcher{},
 		&mockVideoStoredReqFetcher{},
 		empty_fetcher.EmptyFetcher{},
+		empty_fetcher.EmptyFetcher{},
+		empty_store.EmptyStore{},
 		&config.Configuration{MaxRequestSize: maxSize},
 		theMetrics,
-		analyticsConf.NewPBSAnalytics(&config.Analytics{}),
+		analyticsForTest(),
 		map[string]string{},
 		false,
 		[]byte{},
@@ -668,7 +830,7 @@ type mockExchangeVideo struct {
 	lastRequest *openrtb.BidRequest
 }
 
-func (m *mockExchangeVideo) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, categoriesFetcher *stored_requests.CategoryFetcher) (*openrtb.BidResponse, error) {
+func (m *mockExchangeVideo) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, ids exchange.IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, auctionDetails *analytics.AuctionDetails) (*openrtb.BidResponse, error) {
 	m.lastRequest = bidRequest
 	ext := []byte(`{"prebid":{"targeting":{"hb_bidder":"appnexus","hb_pb":"20.00","hb_pb_cat_dur":"20.00_395_30s","hb_size":"1x1", "hb_uuid":"837ea3b7-5598-4958-8c45-8e9ef2bf7cc1"},"type":"video"},"bidder":{"appnexus":{"brand_id":1,"auction_id":7840037870526938650,"bidder_id":2,"bid_ad_type":1,"creative_info":{"video":{"duration":30,"mimes":["video\/mp4"]}}}}}`)
 	return &openrtb.BidResponse{
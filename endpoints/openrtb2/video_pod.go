@@ -0,0 +1,235 @@
+package openrtb2
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// revenueEpsilon absorbs float rounding errors when comparing the revenue of two pod selections.
+const revenueEpsilon = 1e-9
+
+// maxAdPodDurationSec and maxAdPodAds are the largest pods a request may ask for, and maxAdPods is the most pods
+// it may have. optimizeAdPod's time and memory grow with all three, so validateVideoRequest rejects requests
+// which go over them.
+const (
+	maxAdPodDurationSec = 600
+	maxAdPodAds         = 30
+	maxAdPods           = 20
+)
+
+// podBid is a bid which competes for a place in an ad pod.
+type podBid struct {
+	bid       *openrtb.Bid
+	seat      string
+	targeting openrtb_ext.VideoTargeting
+	duration  int
+	category  string
+}
+
+// newPodBid reads the bid's duration and category. The category is the one chosen by applyCategoryMapping in
+// the exchange, which is part of the hb_pb_cat_dur targeting value.
+func newPodBid(bid *openrtb.Bid, seat string, ext *openrtb_ext.ExtBidPrebid, targeting openrtb_ext.VideoTargeting) podBid {
+	pb := podBid{
+		bid:       bid,
+		seat:      seat,
+		targeting: targeting,
+	}
+	if ext.Video != nil {
+		pb.duration = ext.Video.Duration
+	}
+
+	// hb_pb_cat_dur looks like {price}_{category}_{duration}s. Categories may contain underscores themselves.
+	parts := strings.Split(targeting.HbPbCatDur, "_")
+	if len(parts) >= 3 {
+		pb.category = strings.Join(parts[1:len(parts)-1], "_")
+		if pb.duration == 0 {
+			pb.duration, _ = strconv.Atoi(strings.TrimSuffix(parts[len(parts)-1], "s"))
+		}
+	}
+	return pb
+}
+
+// podSelection is a set of bids which fits in an ad pod.
+type podSelection struct {
+	revenue float64
+	bids    []int
+}
+
+func (selection *podSelection) with(bidIndex int, price float64) *podSelection {
+	bids := make([]int, len(selection.bids), len(selection.bids)+1)
+	copy(bids, selection.bids)
+	return &podSelection{
+		revenue: selection.revenue + price,
+		bids:    append(bids, bidIndex),
+	}
+}
+
+// betterThan prefers more revenue, and then more ads.
+func (selection *podSelection) betterThan(other *podSelection) bool {
+	if other == nil {
+		return true
+	}
+	if selection.revenue > other.revenue+revenueEpsilon {
+		return true
+	}
+	if selection.revenue < other.revenue-revenueEpsilon {
+		return false
+	}
+	return len(selection.bids) > len(other.bids)
+}
+
+// optimizeAdPod picks the bids with the highest total revenue which fit in the pod. The chosen ads can't be longer
+// than the pod altogether, or outnumber its maxads. If the pod config requires exact durations, each ad must have one
+// of the configured durations. If it requires category exclusion, the pod can only have one ad per category.
+//
+// It returns the chosen bids in their original order, and the reasons why the others were left out.
+func optimizeAdPod(bids []podBid, pod *openrtb_ext.Pod, podConfig *openrtb_ext.PodConfig) ([]podBid, []openrtb_ext.ExcludedBid) {
+	_, maxDuration := minMax(podConfig.DurationRangeSec)
+	excluded := make([]openrtb_ext.ExcludedBid, 0)
+	exclude := func(bid podBid, reason openrtb_ext.ExclusionReason) {
+		excluded = append(excluded, openrtb_ext.ExcludedBid{
+			BidID:  bid.bid.ID,
+			Seat:   bid.seat,
+			Reason: reason,
+		})
+	}
+
+	candidates := make([]podBid, 0, len(bids))
+	minDuration := 0
+	for _, bid := range bids {
+		if bid.duration < 0 {
+			exclude(bid, openrtb_ext.ExclusionInvalidDuration)
+			continue
+		}
+		if bid.duration == 0 {
+			// If we don't know how long the ad is, assume it's as long as the imp allows.
+			bid.duration = maxDuration
+		}
+		if podConfig.RequireExactDuration && !containsInt(podConfig.DurationRangeSec, bid.duration) {
+			exclude(bid, openrtb_ext.ExclusionDuration)
+		} else if bid.duration > pod.AdPodDurationSec {
+			exclude(bid, openrtb_ext.ExclusionPodDuration)
+		} else {
+			candidates = append(candidates, bid)
+			if bid.duration > 0 && (minDuration == 0 || bid.duration < minDuration) {
+				minDuration = bid.duration
+			}
+		}
+	}
+
+	maxAds := pod.MaxAds
+	if maxAds <= 0 || maxAds > maxAdPodAds {
+		maxAds = maxAdPodAds
+	}
+	if maxAds > len(candidates) {
+		maxAds = len(candidates)
+	}
+	// No selection can hold more ads than fit in the pod end to end, so there's no need to track longer ones.
+	maxCount := maxAds
+	if minDuration > 0 && pod.AdPodDurationSec/minDuration < maxCount {
+		maxCount = pod.AdPodDurationSec / minDuration
+	}
+
+	// This is a knapsack problem, where each group of bids with the same category can contribute one ad.
+	// best[count][duration] is the most valuable selection of count ads which is exactly duration seconds long.
+	best := make([][]*podSelection, maxCount+1)
+	for count := range best {
+		best[count] = make([]*podSelection, pod.AdPodDurationSec+1)
+	}
+	best[0][0] = &podSelection{}
+	for _, group := range groupPodBids(candidates, podConfig.CategoryExclusion) {
+		next := make([][]*podSelection, len(best))
+		for count := range best {
+			next[count] = make([]*podSelection, len(best[count]))
+			copy(next[count], best[count])
+		}
+		for count := 0; count < maxCount; count++ {
+			for duration, selection := range best[count] {
+				if selection == nil {
+					continue
+				}
+				for _, bidIndex := range group {
+					newDuration := duration + candidates[bidIndex].duration
+					if newDuration > pod.AdPodDurationSec {
+						continue
+					}
+					if newSelection := selection.with(bidIndex, candidates[bidIndex].bid.Price); newSelection.betterThan(next[count+1][newDuration]) {
+						next[count+1][newDuration] = newSelection
+					}
+				}
+			}
+		}
+		best = next
+	}
+
+	var winner *podSelection
+	for count := range best {
+		for _, selection := range best[count] {
+			if selection != nil && selection.betterThan(winner) {
+				winner = selection
+			}
+		}
+	}
+
+	chosen := make([]bool, len(candidates))
+	chosenCategories := make(map[string]bool, len(winner.bids))
+	for _, bidIndex := range winner.bids {
+		chosen[bidIndex] = true
+		chosenCategories[candidates[bidIndex].category] = true
+	}
+
+	selected := make([]podBid, 0, len(winner.bids))
+	for i, bid := range candidates {
+		if chosen[i] {
+			selected = append(selected, bid)
+		} else if podConfig.CategoryExclusion && bid.category != "" && chosenCategories[bid.category] {
+			exclude(bid, openrtb_ext.ExclusionCategory)
+		} else if len(winner.bids) == maxAds && maxAds < len(candidates) {
+			exclude(bid, openrtb_ext.ExclusionMaxAds)
+		} else {
+			exclude(bid, openrtb_ext.ExclusionPodDuration)
+		}
+	}
+	return selected, excluded
+}
+
+// groupPodBids groups the indices of bids which compete for the same place in a pod. With category exclusion,
+// bids with the same category compete. Bids without a category never compete with each other.
+func groupPodBids(bids []podBid, categoryExclusion bool) [][]int {
+	groups := make([][]int, 0, len(bids))
+	categoryGroups := make(map[string]int)
+	for i, bid := range bids {
+		if !categoryExclusion || bid.category == "" {
+			groups = append(groups, []int{i})
+			continue
+		}
+		if groupIndex, ok := categoryGroups[bid.category]; ok {
+			groups[groupIndex] = append(groups[groupIndex], i)
+		} else {
+			categoryGroups[bid.category] = len(groups)
+			groups = append(groups, []int{i})
+		}
+	}
+	return groups
+}
+
+func findPod(podId int64, pods []openrtb_ext.Pod) *openrtb_ext.Pod {
+	for i := range pods {
+		if int64(pods[i].PodId) == podId {
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package openrtb2
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPodBid(t *testing.T) {
+	bid := &openrtb.Bid{ID: "bid"}
+
+	pb := newPodBid(bid, "appnexus", &openrtb_ext.ExtBidPrebid{}, openrtb_ext.VideoTargeting{HbPbCatDur: "17.00_IAB1_sports_30s"})
+	assert.Equal(t, "IAB1_sports", pb.category, "Categories with underscores should be parsed whole")
+	assert.Equal(t, 30, pb.duration)
+
+	pb = newPodBid(bid, "appnexus", &openrtb_ext.ExtBidPrebid{Video: &openrtb_ext.ExtBidPrebidVideo{Duration: 25}}, openrtb_ext.VideoTargeting{HbPbCatDur: "17.00_sports_30s"})
+	assert.Equal(t, 25, pb.duration, "The bid's own duration should be preferred over its duration bucket")
+
+	pb = newPodBid(bid, "appnexus", &openrtb_ext.ExtBidPrebid{}, openrtb_ext.VideoTargeting{})
+	assert.Equal(t, "", pb.category)
+	assert.Equal(t, 0, pb.duration)
+}
+
+func TestOptimizeAdPodDuration(t *testing.T) {
+	bids := []podBid{
+		newTestPodBid("a", 5, 30, ""),
+		newTestPodBid("b", 4, 30, ""),
+		newTestPodBid("c", 8, 60, ""),
+		newTestPodBid("d", 20, 90, ""),
+	}
+	selected, excluded := optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: 60}, &openrtb_ext.PodConfig{DurationRangeSec: []int{30, 60, 90}})
+
+	assertPodBidIDs(t, []string{"a", "b"}, selected)
+	assert.ElementsMatch(t, []openrtb_ext.ExcludedBid{
+		{BidID: "c", Seat: "appnexus", Reason: openrtb_ext.ExclusionPodDuration},
+		{BidID: "d", Seat: "appnexus", Reason: openrtb_ext.ExclusionPodDuration},
+	}, excluded)
+}
+
+func TestOptimizeAdPodExactDuration(t *testing.T) {
+	bids := []podBid{
+		newTestPodBid("a", 5, 15, ""),
+		newTestPodBid("b", 10, 20, ""),
+		newTestPodBid("c", 1, 0, ""),
+	}
+	selected, excluded := optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: 60}, &openrtb_ext.PodConfig{DurationRangeSec: []int{15, 30}, RequireExactDuration: true})
+
+	assertPodBidIDs(t, []string{"a", "c"}, selected)
+	assert.Equal(t, []openrtb_ext.ExcludedBid{{BidID: "b", Seat: "appnexus", Reason: openrtb_ext.ExclusionDuration}}, excluded)
+}
+
+func TestOptimizeAdPodCategoryExclusion(t *testing.T) {
+	bids := []podBid{
+		newTestPodBid("a", 4, 30, "sports"),
+		newTestPodBid("b", 5, 30, "sports"),
+		newTestPodBid("c", 3, 30, "cars"),
+		newTestPodBid("d", 1, 30, ""),
+	}
+	podConfig := &openrtb_ext.PodConfig{DurationRangeSec: []int{30}, CategoryExclusion: true}
+	selected, excluded := optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: 120}, podConfig)
+
+	assertPodBidIDs(t, []string{"b", "c", "d"}, selected)
+	assert.Equal(t, []openrtb_ext.ExcludedBid{{BidID: "a", Seat: "appnexus", Reason: openrtb_ext.ExclusionCategory}}, excluded)
+
+	podConfig.CategoryExclusion = false
+	selected, excluded = optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: 120}, podConfig)
+	assertPodBidIDs(t, []string{"a", "b", "c", "d"}, selected)
+	assert.Empty(t, excluded)
+}
+
+func TestOptimizeAdPodMaxAds(t *testing.T) {
+	bids := []podBid{
+		newTestPodBid("a", 3, 15, ""),
+		newTestPodBid("b", 5, 15, ""),
+		newTestPodBid("c", 4, 15, ""),
+	}
+	selected, excluded := optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: 60, MaxAds: 2}, &openrtb_ext.PodConfig{DurationRangeSec: []int{15}})
+
+	assertPodBidIDs(t, []string{"b", "c"}, selected)
+	assert.Equal(t, []openrtb_ext.ExcludedBid{{BidID: "a", Seat: "appnexus", Reason: openrtb_ext.ExclusionMaxAds}}, excluded)
+}
+
+func TestOptimizeAdPodNegativeDuration(t *testing.T) {
+	bids := []podBid{
+		newTestPodBid("a", 3, 15, ""),
+		newTestPodBid("b", 5, -15, ""),
+	}
+	selected, excluded := optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: 60}, &openrtb_ext.PodConfig{DurationRangeSec: []int{15}})
+
+	assertPodBidIDs(t, []string{"a"}, selected)
+	assert.Equal(t, []openrtb_ext.ExcludedBid{{BidID: "b", Seat: "appnexus", Reason: openrtb_ext.ExclusionInvalidDuration}}, excluded)
+}
+
+func TestOptimizeAdPodDefaultMaxAds(t *testing.T) {
+	bids := make([]podBid, 0, maxAdPodAds+5)
+	for i := 0; i < maxAdPodAds+5; i++ {
+		bids = append(bids, newTestPodBid(strconv.Itoa(i), 1, 1, ""))
+	}
+	selected, excluded := optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: maxAdPodDurationSec}, &openrtb_ext.PodConfig{DurationRangeSec: []int{1}})
+
+	assert.Len(t, selected, maxAdPodAds, "Pods without maxads should be capped at the host's limit")
+	assert.Len(t, excluded, 5)
+
+	selected, _ = optimizeAdPod(bids, &openrtb_ext.Pod{PodId: 1, AdPodDurationSec: 3}, &openrtb_ext.PodConfig{DurationRangeSec: []int{1}})
+	assert.Len(t, selected, 3, "Pods should only hold as many ads as fit end to end")
+}
+
+func TestVideoBuildVideoResponseOptimizesPods(t *testing.T) {
+	extBid1 := []byte(`{"prebid":{"targeting":{"hb_pb":"17.00","hb_pb_cat_dur":"17.00_123_30s","hb_uuid":"uuid-1"}}}`)
+	extBid2 := []byte(`{"prebid":{"targeting":{"hb_pb":"12.00","hb_pb_cat_dur":"12.00_123_30s","hb_uuid":"uuid-2"}}}`)
+	extBid3 := []byte(`{"prebid":{"targeting":{"hb_pb":"10.00","hb_pb_cat_dur":"10.00_456_30s","hb_uuid":"uuid-3"}}}`)
+	openRtbBidResp := openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{{
+			Seat: "appnexus",
+			Bid: []openrtb.Bid{
+				{ID: "bid-1", ImpID: "1_0", Price: 17, Ext: extBid1},
+				{ID: "bid-2", ImpID: "1_1", Price: 12, Ext: extBid2},
+				{ID: "bid-3", ImpID: "1_2", Price: 10, Ext: extBid3},
+			},
+		}},
+	}
+	podConfig := &openrtb_ext.PodConfig{
+		DurationRangeSec:  []int{30},
+		CategoryExclusion: true,
+		Pods:              []openrtb_ext.Pod{{PodId: 1, AdPodDurationSec: 90}},
+	}
+
+	bidRespVideo, err := buildVideoResponse(&openRtbBidResp, podConfig, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, bidRespVideo.AdPods, 1) {
+		assert.Equal(t, []openrtb_ext.VideoTargeting{
+			{HbPb: "17.00", HbPbCatDur: "17.00_123_30s", HbCacheID: "uuid-1"},
+			{HbPb: "10.00", HbPbCatDur: "10.00_456_30s", HbCacheID: "uuid-3"},
+		}, bidRespVideo.AdPods[0].Targeting)
+		assert.Equal(t, []openrtb_ext.ExcludedBid{{BidID: "bid-2", Seat: "appnexus", Reason: openrtb_ext.ExclusionCategory}}, bidRespVideo.AdPods[0].Excluded)
+	}
+}
+
+func newTestPodBid(id string, price float64, duration int, category string) podBid {
+	return podBid{
+		bid:      &openrtb.Bid{ID: id, Price: price},
+		seat:     "appnexus",
+		duration: duration,
+		category: category,
+	}
+}
+
+func assertPodBidIDs(t *testing.T, expected []string, bids []podBid) {
+	t.Helper()
+	actual := make([]string, 0, len(bids))
+	for _, bid := range bids {
+		actual = append(actual, bid.bid.ID)
+	}
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,257 @@
+package openrtb2
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+const (
+	vastVersion   = "4.0"
+	vmapVersion   = "1.0"
+	vmapNamespace = "http://www.iab.net/videosuite/vmap"
+)
+
+type vastDocument struct {
+	XMLName xml.Name `xml:"VAST"`
+	Version string   `xml:"version,attr"`
+	Ads     []vastAd `xml:"Ad"`
+}
+
+// vastAd keeps the bidder's ad as-is, so that it doesn't lose any elements or attributes we don't know about.
+type vastAd struct {
+	ID       string     `xml:"id,attr,omitempty"`
+	Sequence int        `xml:"sequence,attr,omitempty"`
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXML string     `xml:",innerxml"`
+}
+
+// prebidVASTExtension keeps the metadata which the JSON response has in its targeting keys.
+type prebidVASTExtension struct {
+	XMLName          xml.Name `xml:"Extension"`
+	Type             string   `xml:"type,attr"`
+	Bidder           string   `xml:"Bidder,omitempty"`
+	PriceBucket      string   `xml:"PriceBucket,omitempty"`
+	Category         string   `xml:"Category,omitempty"`
+	Duration         int      `xml:"Duration,omitempty"`
+	CategoryDuration string   `xml:"CategoryDuration,omitempty"`
+	CacheID          string   `xml:"CacheID,omitempty"`
+}
+
+type vmapDocument struct {
+	XMLName    xml.Name             `xml:"vmap:VMAP"`
+	Namespace  string               `xml:"xmlns:vmap,attr"`
+	Version    string               `xml:"version,attr"`
+	AdBreaks   []vmapAdBreak        `xml:"vmap:AdBreak"`
+	Extensions *prebidVMAPExtension `xml:"vmap:Extensions>vmap:Extension,omitempty"`
+}
+
+// prebidVMAPExtension reports the pods which were left out of the VMAP because the request had errors in them.
+type prebidVMAPExtension struct {
+	Type      string            `xml:"type,attr"`
+	PodErrors []prebidVMAPError `xml:"PodError"`
+}
+
+type prebidVMAPError struct {
+	PodID  int      `xml:"podId,attr"`
+	Errors []string `xml:"Error"`
+}
+
+type vmapAdBreak struct {
+	TimeOffset string       `xml:"timeOffset,attr"`
+	BreakType  string       `xml:"breakType,attr"`
+	BreakID    string       `xml:"breakId,attr"`
+	AdSource   vmapAdSource `xml:"vmap:AdSource"`
+}
+
+type vmapAdSource struct {
+	ID               string       `xml:"id,attr"`
+	AllowMultipleAds bool         `xml:"allowMultipleAds,attr"`
+	FollowRedirects  bool         `xml:"followRedirects,attr"`
+	VASTAdData       vastDocument `xml:"vmap:VASTAdData>VAST"`
+}
+
+// videoOutput returns the response format which the request asked for.
+// The output query param takes precedence over ext.prebid.output.
+func videoOutput(r *http.Request, videoReq *openrtb_ext.BidRequestVideo) (openrtb_ext.VideoOutput, error) {
+	output := openrtb_ext.VideoOutput(r.URL.Query().Get("output"))
+	if output == "" && videoReq.Ext != nil {
+		output = videoReq.Ext.Prebid.Output
+	}
+	switch output {
+	case "", openrtb_ext.VideoOutputJSON:
+		return openrtb_ext.VideoOutputJSON, nil
+	case openrtb_ext.VideoOutputVAST:
+		if len(videoReq.PodConfig.Pods) != 1 {
+			return "", errors.New("vast output only supports requests with one pod. Use vmap output for several pods")
+		}
+		return output, nil
+	case openrtb_ext.VideoOutputVMAP:
+		return output, nil
+	}
+	return "", fmt.Errorf("output must be %s, %s or %s. Got %s", openrtb_ext.VideoOutputJSON, openrtb_ext.VideoOutputVAST, openrtb_ext.VideoOutputVMAP, output)
+}
+
+// vastTracking says how to add impression trackers to the bids' VAST, the same way the exchange does for cached VAST.
+type vastTracking struct {
+	externalURL  string
+	account      *config.Account
+	auctionStart time.Time
+}
+
+// buildVASTResponse returns a VAST ad pod, or a VMAP with an ad break for each pod, made from the bids which
+// selectPodBids chose. Bids whose ads aren't valid VAST are left out, and returned as warnings.
+//
+// The VMAP lists the podErrors in a Prebid extension, the way the JSON response lists them in its ad pods.
+// VAST output only ever has one pod, so a VAST response can't have any podErrors: the request fails instead.
+func buildVASTResponse(bidresponse *openrtb.BidResponse, podConfig *openrtb_ext.PodConfig, podErrors []PodError, output openrtb_ext.VideoOutput, tracking *vastTracking) ([]byte, []error, error) {
+	_, podBids, err := selectPodBids(bidresponse, podConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []error
+	var doc interface{}
+	if output == openrtb_ext.VideoOutputVAST {
+		var pod vastDocument
+		pod, warnings = buildVASTPod(podBids[int64(podConfig.Pods[0].PodId)], tracking)
+		doc = pod
+	} else {
+		vmap := vmapDocument{
+			Namespace: vmapNamespace,
+			Version:   vmapVersion,
+			AdBreaks:  make([]vmapAdBreak, 0, len(podConfig.Pods)),
+		}
+		for i, pod := range podConfig.Pods {
+			vast, podWarnings := buildVASTPod(podBids[int64(pod.PodId)], tracking)
+			warnings = append(warnings, podWarnings...)
+			if len(vast.Ads) == 0 {
+				continue
+			}
+			timeOffset := pod.TimeOffset
+			if timeOffset == "" {
+				timeOffset = fmt.Sprintf("#%d", i+1)
+			}
+			vmap.AdBreaks = append(vmap.AdBreaks, vmapAdBreak{
+				TimeOffset: timeOffset,
+				BreakType:  "linear",
+				BreakID:    fmt.Sprintf("%d", pod.PodId),
+				AdSource: vmapAdSource{
+					ID:               fmt.Sprintf("pod-%d", pod.PodId),
+					AllowMultipleAds: true,
+					FollowRedirects:  true,
+					VASTAdData:       vast,
+				},
+			})
+		}
+		if len(podErrors) > 0 {
+			vmap.Extensions = &prebidVMAPExtension{
+				Type:      "prebid",
+				PodErrors: make([]prebidVMAPError, 0, len(podErrors)),
+			}
+			for _, podErr := range podErrors {
+				vmap.Extensions.PodErrors = append(vmap.Extensions.PodErrors, prebidVMAPError{
+					PodID:  podErr.PodId,
+					Errors: podErr.ErrMsgs,
+				})
+			}
+		}
+		doc = vmap
+	}
+
+	resp, err := xml.Marshal(doc)
+	if err != nil {
+		return nil, warnings, err
+	}
+	return append([]byte(xml.Header), resp...), warnings, nil
+}
+
+// buildVASTPod sequences the bids' ads into a VAST ad pod.
+func buildVASTPod(bids []podBid, tracking *vastTracking) (vastDocument, []error) {
+	pod := vastDocument{
+		Version: vastVersion,
+		Ads:     make([]vastAd, 0, len(bids)),
+	}
+	var warnings []error
+	for _, bid := range bids {
+		ad, err := podBidVASTAd(bid, tracking)
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+		ad.Sequence = len(pod.Ads) + 1
+		pod.Ads = append(pod.Ads, ad)
+	}
+	return pod, warnings
+}
+
+// podBidVASTAd returns the first Ad in the bid's VAST, with a Prebid extension for the bid's metadata.
+// Any other Ads in the bid's VAST are alternatives to the first, so they don't belong in the pod.
+// If the account tracks the bidder's VAST, the Ad also gets an impression tracker.
+func podBidVASTAd(bid podBid, tracking *vastTracking) (vastAd, error) {
+	vast := exchange.MakeTrackedVAST(bid.bid, openrtb_ext.BidderName(bid.seat), tracking.externalURL, tracking.account, tracking.auctionStart)
+	var doc vastDocument
+	if err := xml.Unmarshal([]byte(vast), &doc); err != nil {
+		return vastAd{}, fmt.Errorf("bid %s from %s doesn't have valid VAST: %v", bid.bid.ID, bid.seat, err)
+	}
+	if len(doc.Ads) == 0 {
+		return vastAd{}, fmt.Errorf("bid %s from %s doesn't have a VAST Ad", bid.bid.ID, bid.seat)
+	}
+
+	extension, err := xml.Marshal(prebidVASTExtension{
+		Type:             "prebid",
+		Bidder:           bid.seat,
+		PriceBucket:      bid.targeting.HbPb,
+		Category:         bid.category,
+		Duration:         bid.duration,
+		CategoryDuration: bid.targeting.HbPbCatDur,
+		CacheID:          bid.targeting.HbCacheID,
+	})
+	if err != nil {
+		return vastAd{}, err
+	}
+
+	ad := doc.Ads[0]
+	if ad.ID == "" {
+		ad.ID = bid.bid.ID
+	}
+	ad.Attrs = plainAttrs(ad.Attrs)
+	ad.InnerXML = addVASTExtension(ad.InnerXML, string(extension))
+	return ad, nil
+}
+
+// plainAttrs drops the namespace declarations and namespaced attributes, which encoding/xml can't write back
+// the way it read them.
+func plainAttrs(attrs []xml.Attr) []xml.Attr {
+	plain := make([]xml.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Name.Space == "" && attr.Name.Local != "xmlns" {
+			plain = append(plain, attr)
+		}
+	}
+	return plain
+}
+
+// addVASTExtension adds the extension to the Ad's InLine or Wrapper element. VAST only allows one Extensions
+// element there, so the extension joins the ad's own Extensions if it has any.
+func addVASTExtension(adXML string, extension string) string {
+	for _, tag := range []string{"InLine", "Wrapper"} {
+		end := strings.LastIndex(adXML, "</"+tag+">")
+		if end < 0 {
+			continue
+		}
+		if extensionsEnd := strings.LastIndex(adXML[:end], "</Extensions>"); extensionsEnd >= 0 {
+			return adXML[:extensionsEnd] + extension + adXML[extensionsEnd:]
+		}
+		return adXML[:end] + "<Extensions>" + extension + "</Extensions>" + adXML[end:]
+	}
+	return adXML
+}
@@ -0,0 +1,193 @@
+package openrtb2
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVideoOutput(t *testing.T) {
+	onePod := &openrtb_ext.BidRequestVideo{PodConfig: openrtb_ext.PodConfig{Pods: []openrtb_ext.Pod{{PodId: 1}}}}
+	twoPods := &openrtb_ext.BidRequestVideo{PodConfig: openrtb_ext.PodConfig{Pods: []openrtb_ext.Pod{{PodId: 1}, {PodId: 2}}}}
+	extVMAP := &openrtb_ext.BidRequestVideo{Ext: &openrtb_ext.ExtRequestVideo{Prebid: openrtb_ext.ExtRequestVideoPrebid{Output: openrtb_ext.VideoOutputVMAP}}}
+
+	output, err := videoOutput(httptest.NewRequest("POST", "/openrtb2/video", nil), onePod)
+	assert.NoError(t, err)
+	assert.Equal(t, openrtb_ext.VideoOutputJSON, output, "JSON should be the default output")
+
+	output, err = videoOutput(httptest.NewRequest("POST", "/openrtb2/video?output=vast", nil), onePod)
+	assert.NoError(t, err)
+	assert.Equal(t, openrtb_ext.VideoOutputVAST, output)
+
+	output, err = videoOutput(httptest.NewRequest("POST", "/openrtb2/video", nil), extVMAP)
+	assert.NoError(t, err)
+	assert.Equal(t, openrtb_ext.VideoOutputVMAP, output, "ext.prebid.output should be used without a query param")
+
+	output, err = videoOutput(httptest.NewRequest("POST", "/openrtb2/video?output=json", nil), extVMAP)
+	assert.NoError(t, err)
+	assert.Equal(t, openrtb_ext.VideoOutputJSON, output, "The query param should take precedence over ext.prebid.output")
+
+	_, err = videoOutput(httptest.NewRequest("POST", "/openrtb2/video?output=vast", nil), twoPods)
+	assert.EqualError(t, err, "vast output only supports requests with one pod. Use vmap output for several pods")
+
+	_, err = videoOutput(httptest.NewRequest("POST", "/openrtb2/video?output=xml", nil), onePod)
+	assert.EqualError(t, err, "output must be json, vast or vmap. Got xml")
+}
+
+func TestBuildVASTResponse(t *testing.T) {
+	podConfig := &openrtb_ext.PodConfig{
+		DurationRangeSec: []int{15, 30},
+		Pods:             []openrtb_ext.Pod{{PodId: 1, AdPodDurationSec: 60}},
+	}
+	resp, warnings, err := buildVASTResponse(vastBidResponse(), podConfig, nil, openrtb_ext.VideoOutputVAST, &vastTracking{})
+	assert.NoError(t, err)
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0].Error(), "bid bid-3 from appnexus doesn't have valid VAST")
+	}
+
+	var doc vastDocument
+	if err := xml.Unmarshal(resp, &doc); err != nil {
+		t.Fatalf("The response isn't valid VAST: %v", err)
+	}
+	assert.Equal(t, "4.0", doc.Version)
+	if assert.Len(t, doc.Ads, 2) {
+		assert.Equal(t, "creative-1", doc.Ads[0].ID, "The bidder's Ad ID should be kept")
+		assert.Equal(t, []xml.Attr{{Name: xml.Name{Local: "conditionalAd"}, Value: "false"}}, doc.Ads[0].Attrs, "The bidder's other Ad attributes should be kept")
+		assert.Equal(t, 1, doc.Ads[0].Sequence)
+		assert.Contains(t, doc.Ads[0].InnerXML, `<Extensions><Extension type="own"></Extension><Extension type="prebid"><Bidder>appnexus</Bidder><PriceBucket>17.00</PriceBucket><Category>sports</Category><Duration>30</Duration><CategoryDuration>17.00_sports_30s</CategoryDuration><CacheID>uuid-1</CacheID></Extension></Extensions></InLine>`)
+
+		assert.Equal(t, "bid-2", doc.Ads[1].ID, "Ads without IDs should use the bid ID")
+		assert.Equal(t, 2, doc.Ads[1].Sequence)
+		assert.Contains(t, doc.Ads[1].InnerXML, `<VASTAdTagURI><![CDATA[http://example.com/nurl]]></VASTAdTagURI>`, "Bids without AdM should be wrapped")
+	}
+}
+
+func TestBuildVASTResponseTracking(t *testing.T) {
+	podConfig := &openrtb_ext.PodConfig{
+		DurationRangeSec: []int{15, 30},
+		Pods:             []openrtb_ext.Pod{{PodId: 1, AdPodDurationSec: 60}},
+	}
+	tracking := &vastTracking{
+		externalURL:  "http://pbs.example.com",
+		account:      &config.Account{ID: "acct", VASTTrackingBidders: []string{"appnexus"}},
+		auctionStart: time.Unix(1, 234*int64(time.Millisecond)),
+	}
+	resp, _, err := buildVASTResponse(vastBidResponse(), podConfig, nil, openrtb_ext.VideoOutputVAST, tracking)
+	assert.NoError(t, err)
+
+	var doc vastDocument
+	if err := xml.Unmarshal(resp, &doc); err != nil {
+		t.Fatalf("The response isn't valid VAST: %v", err)
+	}
+	if assert.Len(t, doc.Ads, 2) {
+		assert.Contains(t, doc.Ads[0].InnerXML, `<Impression><![CDATA[http://pbs.example.com/event?a=acct&b=bid-1&bidder=appnexus&f=b&t=imp&ts=1234]]></Impression>`, "Tracked bidders should get an impression tracker")
+		assert.Contains(t, doc.Ads[1].InnerXML, `<Impression><![CDATA[http://pbs.example.com/event?a=acct&b=bid-2&bidder=appnexus&f=b&t=imp&ts=1234]]></Impression>`, "Wrapped bids should get an impression tracker")
+	}
+}
+
+func TestBuildVMAPResponse(t *testing.T) {
+	podConfig := &openrtb_ext.PodConfig{
+		DurationRangeSec: []int{15, 30},
+		Pods: []openrtb_ext.Pod{
+			{PodId: 1, AdPodDurationSec: 60},
+			{PodId: 2, AdPodDurationSec: 60, TimeOffset: "end"},
+			{PodId: 3, AdPodDurationSec: 60},
+		},
+	}
+	podErrors := []PodError{{PodId: 4, PodIndex: 3, ErrMsgs: []string{"first error", "second error"}}}
+	resp, _, err := buildVASTResponse(vastBidResponse(), podConfig, podErrors, openrtb_ext.VideoOutputVMAP, &vastTracking{})
+	assert.NoError(t, err)
+
+	var doc struct {
+		AdBreaks []struct {
+			TimeOffset string       `xml:"timeOffset,attr"`
+			BreakID    string       `xml:"breakId,attr"`
+			VAST       vastDocument `xml:"AdSource>VASTAdData>VAST"`
+		} `xml:"AdBreak"`
+		PodErrors []prebidVMAPError `xml:"Extensions>Extension>PodError"`
+	}
+	if err := xml.Unmarshal(resp, &doc); err != nil {
+		t.Fatalf("The response isn't valid VMAP: %v", err)
+	}
+	assert.True(t, strings.Contains(string(resp), `<vmap:VMAP xmlns:vmap="http://www.iab.net/videosuite/vmap" version="1.0">`))
+	if assert.Len(t, doc.AdBreaks, 2, "Pods without ads shouldn't have a break") {
+		assert.Equal(t, "#1", doc.AdBreaks[0].TimeOffset, "The time offset should default to the pod's position")
+		assert.Equal(t, "1", doc.AdBreaks[0].BreakID)
+		assert.Len(t, doc.AdBreaks[0].VAST.Ads, 2)
+		assert.Equal(t, "end", doc.AdBreaks[1].TimeOffset)
+		assert.Equal(t, "2", doc.AdBreaks[1].BreakID)
+		assert.Len(t, doc.AdBreaks[1].VAST.Ads, 1)
+	}
+	assert.Equal(t, []prebidVMAPError{{PodID: 4, Errors: []string{"first error", "second error"}}}, doc.PodErrors, "Pods with errors should be reported in the VMAP")
+}
+
+func TestAddVASTExtension(t *testing.T) {
+	assert.Equal(t, "<InLine><AdSystem>a</AdSystem><Extensions><Extension/></Extensions></InLine>", addVASTExtension("<InLine><AdSystem>a</AdSystem></InLine>", "<Extension/>"))
+	assert.Equal(t, "<Wrapper><Extensions><Extension id=\"1\"/><Extension/></Extensions></Wrapper>", addVASTExtension("<Wrapper><Extensions><Extension id=\"1\"/></Extensions></Wrapper>", "<Extension/>"))
+	assert.Equal(t, "<Unknown/>", addVASTExtension("<Unknown/>", "<Extension/>"), "Ads without InLine or Wrapper should be left alone")
+}
+
+func TestVideoEndpointVMAPOutput(t *testing.T) {
+	ex := &mockExchangeVideo{}
+	reqData, err := ioutil.ReadFile("sample-requests/video/video_valid_sample.json")
+	if err != nil {
+		t.Fatalf("Failed to fetch a valid request: %v", err)
+	}
+	reqBody := string(getRequestPayload(t, reqData))
+	req := httptest.NewRequest("POST", "/openrtb2/video?output=vmap", strings.NewReader(reqBody))
+	recorder := httptest.NewRecorder()
+
+	deps := mockDeps(t, ex)
+	deps.VideoAuctionEndpoint(recorder, req, nil)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, 2, strings.Count(recorder.Body.String(), "<vmap:AdBreak "), "There should be a break for each pod in the request")
+}
+
+func vastBidResponse() *openrtb.BidResponse {
+	return &openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{{
+			Seat: "appnexus",
+			Bid: []openrtb.Bid{
+				{
+					ID:    "bid-1",
+					ImpID: "1_0",
+					Price: 17,
+					AdM:   `<VAST version="3.0"><Ad id="creative-1" conditionalAd="false"><InLine><AdSystem>appnexus</AdSystem><Extensions><Extension type="own"></Extension></Extensions></InLine></Ad></VAST>`,
+					Ext:   []byte(`{"prebid":{"targeting":{"hb_pb":"17.00","hb_pb_cat_dur":"17.00_sports_30s","hb_uuid":"uuid-1"}}}`),
+				},
+				{
+					ID:    "bid-2",
+					ImpID: "1_1",
+					Price: 12,
+					NURL:  "http://example.com/nurl",
+					Ext:   []byte(`{"prebid":{"targeting":{"hb_pb":"12.00","hb_pb_cat_dur":"12.00_cars_15s","hb_uuid":"uuid-2"}}}`),
+				},
+				{
+					ID:    "bid-3",
+					ImpID: "1_2",
+					Price: 10,
+					AdM:   "not vast",
+					Ext:   []byte(`{"prebid":{"targeting":{"hb_pb":"10.00","hb_pb_cat_dur":"10.00_news_15s","hb_uuid":"uuid-3"}}}`),
+				},
+				{
+					ID:    "bid-4",
+					ImpID: "2_0",
+					Price: 9,
+					AdM:   `<VAST version="4.0"><Ad><Wrapper><VASTAdTagURI>http://example.com/vast</VASTAdTagURI></Wrapper></Ad></VAST>`,
+					Ext:   []byte(`{"prebid":{"targeting":{"hb_pb":"9.00","hb_pb_cat_dur":"9.00_sports_30s","hb_uuid":"uuid-4"}}}`),
+				},
+			},
+		}},
+	}
+}
@@ -2,20 +2,32 @@ package endpoints
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/golang/glog"
 	"github.com/julienschmidt/httprouter"
 	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/privacy/ccpa"
 	"github.com/prebid/prebid-server/usersync"
 )
 
-func NewSetUIDEndpoint(cfg config.HostCookie, perms gdpr.Permissions, pbsanalytics analytics.PBSAnalyticsModule, metrics pbsmetrics.MetricsEngine) httprouter.Handle {
+// NewSetUIDEndpoint returns the handler for GET /setuid. It saves a bidder's ID for the user in the uids cookie,
+// and in the UID store if the request has a key for it.
+//
+// The syncers map the families which are evicted from the cookie back to their bidders for the metrics.
+// Evicted families which no syncer uses aren't counted.
+func NewSetUIDEndpoint(syncers map[openrtb_ext.BidderName]usersync.Usersyncer, cfg config.HostCookie, perms gdpr.Permissions, pbsanalytics analytics.PBSAnalyticsModule, metrics pbsmetrics.MetricsEngine, uidStore usersync.UIDStore, uidStoreCfg config.UIDStore) httprouter.Handle {
 	cookieTTL := time.Duration(cfg.TTL) * 24 * time.Hour
+	familyBidders := make(map[string]openrtb_ext.BidderName, len(syncers))
+	for bidder, syncer := range syncers {
+		familyBidders[syncer.FamilyName()] = bidder
+	}
 	return httprouter.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		so := analytics.SetUIDObject{
 			Status: http.StatusOK,
@@ -34,6 +46,16 @@ func NewSetUIDEndpoint(cfg config.HostCookie, perms gdpr.Permissions, pbsanalyti
 
 		query := r.URL.Query()
 		bidder := query.Get("bidder")
+		if bidder == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`"bidder" query param is required`))
+			metrics.RecordUserIDSet(pbsmetrics.UserLabels{
+				Action: pbsmetrics.RequestActionErr,
+			})
+			so.Status = http.StatusBadRequest
+			return
+		}
+
 		if shouldReturn, status, body := preventSyncsGDPR(query.Get("gdpr"), query.Get("gdpr_consent"), perms); shouldReturn {
 			w.WriteHeader(status)
 			w.Write([]byte(body))
@@ -45,14 +67,14 @@ func NewSetUIDEndpoint(cfg config.HostCookie, perms gdpr.Permissions, pbsanalyti
 			return
 		}
 
-		if bidder == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(`"bidder" query param is required`))
+		if shouldReturn, status, body := preventSyncsCCPA(query.Get("us_privacy")); shouldReturn {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
 			metrics.RecordUserIDSet(pbsmetrics.UserLabels{
-				Action: pbsmetrics.RequestActionErr,
+				Action: pbsmetrics.RequestActionCCPA,
 				Bidder: openrtb_ext.BidderName(bidder),
 			})
-			so.Status = http.StatusBadRequest
+			so.Status = status
 			return
 		}
 		so.Bidder = bidder
@@ -74,9 +96,28 @@ func NewSetUIDEndpoint(cfg config.HostCookie, perms gdpr.Permissions, pbsanalyti
 			}
 			metrics.RecordUserIDSet(labels)
 			so.Success = true
+
+			fpid := query.Get("fpid")
+			if uidStoreCfg.KeySource == config.UIDStoreKeyUserID && fpid != "" && !usersync.ValidFPID(fpid, query.Get("fpid_sig"), uidStoreCfg.FPIDSecret) {
+				so.Errors = append(so.Errors, errors.New("fpid_sig isn't a valid signature for the fpid, so the UID wasn't stored"))
+				fpid = ""
+			}
+			if key := usersync.UIDStoreKey(r, fpid, &cfg, &uidStoreCfg); key != "" {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(uidStoreCfg.TimeoutMS)*time.Millisecond)
+				if err := uidStore.SetUID(ctx, key, bidder, uid); err != nil {
+					glog.Errorf("Failed to save the UID for %s in the UID store: %v", bidder, err)
+					so.Errors = append(so.Errors, err)
+				}
+				cancel()
+			}
 		}
 
-		pc.SetCookieOnResponse(w, cfg.Domain, cookieTTL)
+		so.Evicted = pc.SetCookieOnResponse(w, usersync.UseSameSiteNone(r, &cfg), &cfg, cookieTTL)
+		for _, family := range so.Evicted {
+			if bidder, ok := familyBidders[family]; ok {
+				metrics.RecordUserIDEvicted(bidder)
+			}
+		}
 	})
 }
 
@@ -109,3 +150,14 @@ func preventSyncsGDPR(gdprEnabled string, gdprConsent string, perms gdpr.Permiss
 		return true, http.StatusBadRequest, "the gdpr query param must be either 0 or 1. You gave " + gdprEnabled
 	}
 }
+
+func preventSyncsCCPA(usPrivacy string) (bool, int, string) {
+	policy := ccpa.Policy{Value: usPrivacy}
+	if err := policy.Validate(); err != nil {
+		return true, http.StatusBadRequest, "us_privacy " + err.Error()
+	}
+	if policy.ShouldEnforce() {
+		return true, http.StatusOK, "The us_privacy string prevents cookies from being saved"
+	}
+	return false, 0, ""
+}
@@ -6,16 +6,21 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/prebid/prebid-server/usersync"
+	"github.com/prebid/prebid-server/usersync/uidstores/empty_store"
 
 	"github.com/prebid/prebid-server/openrtb_ext"
 
-	analyticsConf "github.com/prebid/prebid-server/analytics/config"
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/gdpr"
+	"github.com/prebid/prebid-server/pbsmetrics"
 	metricsConf "github.com/prebid/prebid-server/pbsmetrics/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestNormalSet(t *testing.T) {
@@ -41,6 +46,79 @@ func TestMergeSet(t *testing.T) {
 	})
 }
 
+func TestEvictions(t *testing.T) {
+	longUID := strings.Repeat("x", 300)
+	req := makeRequest("/setuid?bidder=pubmatic&uid=123", map[string]string{"adnxs": longUID, "rubicon": longUID})
+	metrics := &pbsmetrics.MetricsEngineMock{}
+	metrics.On("RecordUserIDSet", mock.Anything).Return()
+	metrics.On("RecordUserIDEvicted", mock.Anything).Return()
+
+	cfg := config.HostCookie{MaxCookieSizeBytes: 600, PriorityFamilies: []string{"pubmatic"}}
+	endpoint := NewSetUIDEndpoint(syncersForTest(), cfg, &mockPermsSetUID{allowHost: true, allowPI: true}, analyticsForTest(), metrics, empty_store.EmptyStore{}, config.UIDStore{})
+	response := httptest.NewRecorder()
+	endpoint(response, req, nil)
+
+	assertIntsMatch(t, http.StatusOK, response.Code)
+	assertHasSyncs(t, response, map[string]string{
+		"pubmatic": "123",
+	})
+	assert.True(t, len(response.Header().Get("Set-Cookie")) <= 600, "The cookie should fit in the max size")
+	metrics.AssertCalled(t, "RecordUserIDEvicted", openrtb_ext.BidderAppnexus)
+	// None of the test syncers use the rubicon family, so its eviction shouldn't be counted
+	metrics.AssertNumberOfCalls(t, "RecordUserIDEvicted", 1)
+}
+
+func TestSameSiteNone(t *testing.T) {
+	cfg := config.HostCookie{SameSiteNone: true}
+	endpoint := NewSetUIDEndpoint(syncersForTest(), cfg, &mockPermsSetUID{allowHost: true, allowPI: true}, analyticsForTest(), &metricsConf.DummyMetricsEngine{}, empty_store.EmptyStore{}, config.UIDStore{})
+
+	req := makeRequest("/setuid?bidder=pubmatic&uid=123", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/80.0.3987.87 Safari/537.36")
+	response := httptest.NewRecorder()
+	endpoint(response, req, nil)
+	assert.Contains(t, response.Header().Get("Set-Cookie"), "; Secure; SameSite=None")
+
+	req = makeRequest("/setuid?bidder=pubmatic&uid=123", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/60.0.3112.113 Safari/537.36")
+	response = httptest.NewRecorder()
+	endpoint(response, req, nil)
+	assert.NotContains(t, response.Header().Get("Set-Cookie"), "SameSite", "Browsers which reject SameSite=None should get the legacy cookie")
+}
+
+func TestUIDStoreWrites(t *testing.T) {
+	store := &mockUIDStore{uids: map[string]map[string]string{"fp-id": {"rubicon": "def"}}}
+	storeCfg := config.UIDStore{KeySource: config.UIDStoreKeyHostCookie, TimeoutMS: 50}
+	endpoint := NewSetUIDEndpoint(syncersForTest(), config.HostCookie{CookieName: "host-id"}, &mockPermsSetUID{allowHost: true, allowPI: true}, analyticsForTest(), &metricsConf.DummyMetricsEngine{}, store, storeCfg)
+
+	req := makeRequest("/setuid?bidder=pubmatic&uid=123", nil)
+	req.AddCookie(&http.Cookie{Name: "host-id", Value: "fp-id"})
+	endpoint(httptest.NewRecorder(), req, nil)
+	assert.Equal(t, map[string]string{"rubicon": "def", "pubmatic": "123"}, store.uids["fp-id"], "/setuid should write through to the store")
+
+	req = makeRequest("/setuid?bidder=rubicon", nil)
+	req.AddCookie(&http.Cookie{Name: "host-id", Value: "fp-id"})
+	endpoint(httptest.NewRecorder(), req, nil)
+	assert.Equal(t, map[string]string{"pubmatic": "123"}, store.uids["fp-id"], "Unsyncs should remove the UID from the store")
+
+	endpoint(httptest.NewRecorder(), makeRequest("/setuid?bidder=rubicon&uid=456", nil), nil)
+	assert.Len(t, store.uids, 1, "Requests without a key shouldn't be stored")
+}
+
+func TestUIDStoreWritesNeedSignedFPIDs(t *testing.T) {
+	store := &mockUIDStore{uids: map[string]map[string]string{"victim": {"rubicon": "def"}}}
+	storeCfg := config.UIDStore{KeySource: config.UIDStoreKeyUserID, FPIDSecret: "secret", TimeoutMS: 50}
+	endpoint := NewSetUIDEndpoint(syncersForTest(), config.HostCookie{}, &mockPermsSetUID{allowHost: true, allowPI: true}, analyticsForTest(), &metricsConf.DummyMetricsEngine{}, store, storeCfg)
+
+	endpoint(httptest.NewRecorder(), makeRequest("/setuid?bidder=rubicon&uid=attacker&fpid=victim", nil), nil)
+	assert.Equal(t, map[string]string{"rubicon": "def"}, store.uids["victim"], "Unsigned fpids shouldn't be stored")
+
+	endpoint(httptest.NewRecorder(), makeRequest("/setuid?bidder=rubicon&uid=attacker&fpid=victim&fpid_sig="+usersync.SignFPID("victim", "other-secret"), nil), nil)
+	assert.Equal(t, map[string]string{"rubicon": "def"}, store.uids["victim"], "fpids signed with the wrong secret shouldn't be stored")
+
+	endpoint(httptest.NewRecorder(), makeRequest("/setuid?bidder=pubmatic&uid=123&fpid=victim&fpid_sig="+usersync.SignFPID("victim", "secret"), nil), nil)
+	assert.Equal(t, map[string]string{"rubicon": "def", "pubmatic": "123"}, store.uids["victim"], "Signed fpids should be stored")
+}
+
 func TestGDPRPrevention(t *testing.T) {
 	response := doRequest(makeRequest("/setuid?bidder=pubmatic&uid=123", nil), false, false)
 	assertIntsMatch(t, http.StatusOK, response.Code)
@@ -70,6 +148,21 @@ func TestExplicitGDPRPrevention(t *testing.T) {
 	assertNoCookie(t, response)
 }
 
+func TestCCPAPrevention(t *testing.T) {
+	response := doRequest(makeRequest("/setuid?bidder=pubmatic&uid=123&us_privacy=1NYN", nil), true, false)
+	assertIntsMatch(t, http.StatusOK, response.Code)
+	assertStringsMatch(t, "The us_privacy string prevents cookies from being saved", response.Body.String())
+	assertNoCookie(t, response)
+}
+
+func TestCCPAAllowsSync(t *testing.T) {
+	response := doRequest(makeRequest("/setuid?bidder=pubmatic&uid=123&us_privacy=1NNN", nil), true, false)
+	assertIntsMatch(t, http.StatusOK, response.Code)
+	assertHasSyncs(t, response, map[string]string{
+		"pubmatic": "123",
+	})
+}
+
 func assertNoCookie(t *testing.T, resp *httptest.ResponseRecorder) {
 	t.Helper()
 	assertStringsMatch(t, "", resp.Header().Get("Set-Cookie"))
@@ -77,8 +170,10 @@ func assertNoCookie(t *testing.T, resp *httptest.ResponseRecorder) {
 
 func TestBadRequests(t *testing.T) {
 	assertBadRequest(t, "/setuid?uid=123", `"bidder" query param is required`)
+	assertBadRequest(t, "/setuid?uid=123&us_privacy=1NYN", `"bidder" query param is required`)
 	assertBadRequest(t, "/setuid?bidder=appnexus&uid=123&gdpr=2", "the gdpr query param must be either 0 or 1. You gave 2")
 	assertBadRequest(t, "/setuid?bidder=appnexus&uid=123&gdpr=1", "gdpr_consent is required when gdpr=1")
+	assertBadRequest(t, "/setuid?bidder=appnexus&uid=123&us_privacy=2NYN", "us_privacy must specify version 1")
 }
 
 func TestOptedOut(t *testing.T) {
@@ -127,7 +222,7 @@ func doRequest(req *http.Request, gdprAllowsHostCookies bool, gdprReturnsError b
 		allowPI:   true,
 	}
 	cfg := config.Configuration{}
-	endpoint := NewSetUIDEndpoint(cfg.HostCookie, perms, analyticsConf.NewPBSAnalytics(&cfg.Analytics), metricsConf.NewMetricsEngine(&cfg, openrtb_ext.BidderList()))
+	endpoint := NewSetUIDEndpoint(syncersForTest(), cfg.HostCookie, perms, analyticsForTest(), metricsConf.NewMetricsEngine(&cfg, openrtb_ext.BidderList()), empty_store.EmptyStore{}, config.UIDStore{})
 	response := httptest.NewRecorder()
 	endpoint(response, req, nil)
 	return response
@@ -196,3 +291,37 @@ func (g *mockPermsSetUID) BidderSyncAllowed(ctx context.Context, bidder openrtb_
 func (g *mockPermsSetUID) PersonalInfoAllowed(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (bool, error) {
 	return g.allowPI, nil
 }
+
+func (g *mockPermsSetUID) AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (gdpr.AuctionPermissions, error) {
+	return gdpr.AuctionPermissions{
+		AllowBidRequest: true,
+		AllowUserIDs:    g.allowPI,
+		AllowPreciseGeo: g.allowPI,
+	}, nil
+}
+
+// mockUIDStore keeps UIDs in memory
+type mockUIDStore struct {
+	uids map[string]map[string]string
+}
+
+func (s *mockUIDStore) GetUIDs(ctx context.Context, key string) (map[string]string, error) {
+	return s.uids[key], nil
+}
+
+func (s *mockUIDStore) SetUID(ctx context.Context, key string, familyName string, uid string) error {
+	if uid == "" {
+		delete(s.uids[key], familyName)
+		return nil
+	}
+	if s.uids[key] == nil {
+		s.uids[key] = make(map[string]string)
+	}
+	s.uids[key][familyName] = uid
+	return nil
+}
+
+func (s *mockUIDStore) DeleteUIDs(ctx context.Context, key string) error {
+	delete(s.uids, key)
+	return nil
+}
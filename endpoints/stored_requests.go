@@ -0,0 +1,100 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// storedDataTimeout bounds the fetches made by the stored data endpoints.
+const storedDataTimeout = 500 * time.Millisecond
+
+// NewStoredDataCachesEndpoint returns the hits, misses and entry counts of the Stored Data caches, grouped by
+// the Fetcher which uses them. Fetchers without an in-memory cache are left out.
+func NewStoredDataCachesEndpoint(fetchers map[string]stored_requests.CacheInspector) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		stats := make(map[string]map[string]stored_requests.CacheStats, len(fetchers))
+		for name, fetcher := range fetchers {
+			if fetcherStats := fetcher.CacheStats(); len(fetcherStats) > 0 {
+				stats[name] = fetcherStats
+			}
+		}
+
+		jsonOutput, err := json.Marshal(stats)
+		if err != nil {
+			glog.Errorf("/storedrequests/caches Critical error when trying to marshal the cache stats: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonOutput)
+	}
+}
+
+// NewStoredRequestEndpoint returns a Stored Request as the given endpoint's Fetcher sees it, through its caches.
+// The endpoint is chosen by the "endpoint" query param, and defaults to "auction".
+func NewStoredRequestEndpoint(fetchers map[string]stored_requests.Fetcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoint := r.URL.Query().Get("endpoint")
+		if endpoint == "" {
+			endpoint = "auction"
+		}
+		fetcher, ok := fetchers[endpoint]
+		if !ok {
+			writeStoredDataError(w, http.StatusBadRequest, fmt.Sprintf("Unknown endpoint %q", endpoint))
+			return
+		}
+		writeStoredData(w, r, fetcher, true)
+	}
+}
+
+// NewStoredImpEndpoint returns a Stored Imp as PBS sees it, through its caches.
+func NewStoredImpEndpoint(fetcher stored_requests.Fetcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeStoredData(w, r, fetcher, false)
+	}
+}
+
+func writeStoredData(w http.ResponseWriter, r *http.Request, fetcher stored_requests.Fetcher, isRequest bool) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeStoredDataError(w, http.StatusBadRequest, "The id query param is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), storedDataTimeout)
+	defer cancel()
+
+	var data map[string]json.RawMessage
+	var errs []error
+	if isRequest {
+		data, _, errs = fetcher.FetchRequests(ctx, []string{id}, nil)
+	} else {
+		_, data, errs = fetcher.FetchRequests(ctx, nil, []string{id})
+	}
+	for _, err := range errs {
+		if _, ok := err.(stored_requests.NotFoundError); !ok {
+			writeStoredDataError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	value, ok := data[id]
+	if !ok {
+		writeStoredDataError(w, http.StatusNotFound, fmt.Sprintf("No stored data found for id %s", id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(value)
+}
+
+func writeStoredDataError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	w.Write([]byte(msg))
+}
@@ -0,0 +1,99 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoredDataCachesEndpoint(t *testing.T) {
+	fetchers := map[string]stored_requests.CacheInspector{
+		"auction": mockCacheInspector{"requests": {Entries: 2, Hits: 5, Misses: 1}},
+		"amp":     mockCacheInspector{},
+	}
+
+	w := httptest.NewRecorder()
+	NewStoredDataCachesEndpoint(fetchers)(w, httptest.NewRequest("GET", "/storedrequests/caches", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"auction":{"requests":{"entries":2,"hits":5,"misses":1}}}`, w.Body.String())
+}
+
+func TestStoredRequestEndpoint(t *testing.T) {
+	fetchers := map[string]stored_requests.Fetcher{
+		"auction": &mockStoredFetcher{requests: map[string]json.RawMessage{"1": json.RawMessage(`{"id":"auction"}`)}},
+		"amp":     &mockStoredFetcher{requests: map[string]json.RawMessage{"1": json.RawMessage(`{"id":"amp"}`)}},
+		"video":   &mockStoredFetcher{err: errors.New("connection refused")},
+	}
+	endpoint := NewStoredRequestEndpoint(fetchers)
+
+	testCases := []struct {
+		url          string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/storedrequests/request?id=1", http.StatusOK, `{"id":"auction"}`},
+		{"/storedrequests/request?id=1&endpoint=amp", http.StatusOK, `{"id":"amp"}`},
+		{"/storedrequests/request?id=2", http.StatusNotFound, "No stored data found for id 2"},
+		{"/storedrequests/request", http.StatusBadRequest, "The id query param is required"},
+		{"/storedrequests/request?id=1&endpoint=legacy", http.StatusBadRequest, `Unknown endpoint "legacy"`},
+		{"/storedrequests/request?id=1&endpoint=video", http.StatusInternalServerError, "connection refused"},
+	}
+
+	for _, test := range testCases {
+		w := httptest.NewRecorder()
+		endpoint(w, httptest.NewRequest("GET", test.url, nil))
+		assert.Equal(t, test.expectedCode, w.Code, "Bad status for %s", test.url)
+		assert.Equal(t, test.expectedBody, w.Body.String(), "Bad body for %s", test.url)
+	}
+}
+
+func TestStoredImpEndpoint(t *testing.T) {
+	endpoint := NewStoredImpEndpoint(&mockStoredFetcher{imps: map[string]json.RawMessage{"1": json.RawMessage(`{"id":"imp"}`)}})
+
+	w := httptest.NewRecorder()
+	endpoint(w, httptest.NewRequest("GET", "/storedrequests/imp?id=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"id":"imp"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	endpoint(w, httptest.NewRequest("GET", "/storedrequests/imp?id=2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+type mockCacheInspector map[string]stored_requests.CacheStats
+
+func (m mockCacheInspector) CacheStats() map[string]stored_requests.CacheStats {
+	return m
+}
+
+type mockStoredFetcher struct {
+	requests map[string]json.RawMessage
+	imps     map[string]json.RawMessage
+	err      error
+}
+
+func (f *mockStoredFetcher) FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (map[string]json.RawMessage, map[string]json.RawMessage, []error) {
+	if f.err != nil {
+		return nil, nil, []error{f.err}
+	}
+	var errs []error
+	for _, id := range requestIDs {
+		if _, ok := f.requests[id]; !ok {
+			errs = append(errs, stored_requests.NotFoundError{ID: id, DataType: "Request"})
+		}
+	}
+	for _, id := range impIDs {
+		if _, ok := f.imps[id]; !ok {
+			errs = append(errs, stored_requests.NotFoundError{ID: id, DataType: "Imp"})
+		}
+	}
+	return f.requests, f.imps, errs
+}
@@ -0,0 +1,161 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	accountService "github.com/prebid/prebid-server/account"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/events"
+	"github.com/prebid/prebid-server/prebid_cache_client"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// vtrackRequest is the body of a /vtrack request. It has the same shape as a Prebid Cache put request,
+// with the bid and bidder added to each value so that its VAST can be tracked.
+type vtrackRequest struct {
+	Puts []vtrackPut `json:"puts"`
+}
+
+type vtrackPut struct {
+	Type       prebid_cache_client.PayloadType `json:"type"`
+	Value      json.RawMessage                 `json:"value"`
+	TTLSeconds int64                           `json:"ttlseconds,omitempty"`
+	Key        string                          `json:"key,omitempty"`
+	BidID      string                          `json:"bidid"`
+	Bidder     string                          `json:"bidder"`
+	Timestamp  int64                           `json:"timestamp,omitempty"`
+}
+
+type vtrackResponse struct {
+	Responses []vtrackResponseItem `json:"responses"`
+}
+
+type vtrackResponseItem struct {
+	UUID string `json:"uuid"`
+}
+
+// NewVTrackEndpoint returns the handler for POST /vtrack. It takes a Prebid Cache put request, adds an
+// <Impression> tracker which calls the /event endpoint to the VAST of each bidder which the account tracks,
+// and puts the result in Prebid Cache. The account is given by the "a" query parameter.
+func NewVTrackEndpoint(cfg *config.Configuration, accounts stored_requests.AccountFetcher, cache prebid_cache_client.Client) httprouter.Handle {
+	return httprouter.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		accountID := r.URL.Query().Get(analytics.AccountIDParameter)
+		if accountID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Invalid request: parameter '%s' is required\n", analytics.AccountIDParameter)))
+			return
+		}
+
+		req, err := parseVTrackRequest(r, cfg.MaxRequestSize)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Invalid request: %s\n", err.Error())))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.VTrack.TimeoutMS)*time.Millisecond)
+		defer cancel()
+
+		account, errs := accountService.GetAccount(ctx, cfg, accounts, accountID)
+		if len(errs) > 0 {
+			status := http.StatusInternalServerError
+			switch errortypes.DecodeError(errs[0]) {
+			case errortypes.BlacklistedAcctCode, errortypes.AcctRequiredCode:
+				status = http.StatusUnauthorized
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(fmt.Sprintf("Invalid request: %s\n", errs[0].Error())))
+			return
+		}
+
+		toCache, err := makeVTrackCacheables(cfg.ExternalURL, account, req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Invalid request: %s\n", err.Error())))
+			return
+		}
+
+		ids, errs := cache.PutJson(ctx, toCache)
+		if len(errs) > 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fmt.Sprintf("Error putting values in the cache: %s\n", errs[0].Error())))
+			return
+		}
+
+		resp := vtrackResponse{Responses: make([]vtrackResponseItem, len(ids))}
+		for i, id := range ids {
+			resp.Responses[i].UUID = id
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func parseVTrackRequest(r *http.Request, maxSize int64) (*vtrackRequest, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the request body: %v", err)
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("request body is bigger than the max request size of %d bytes", maxSize)
+	}
+	var req vtrackRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse the request body: %v", err)
+	}
+	if len(req.Puts) == 0 {
+		return nil, errors.New("request body must contain at least one put")
+	}
+	for i, put := range req.Puts {
+		switch put.Type {
+		case prebid_cache_client.TypeXML, prebid_cache_client.TypeJSON:
+		default:
+			return nil, fmt.Errorf("puts[%d].type must be either '%s' or '%s'", i, prebid_cache_client.TypeXML, prebid_cache_client.TypeJSON)
+		}
+		if len(put.Value) == 0 {
+			return nil, fmt.Errorf("puts[%d].value is required", i)
+		}
+	}
+	return &req, nil
+}
+
+// makeVTrackCacheables turns the puts into Cacheables, adding impression trackers to the VAST of tracked bidders.
+func makeVTrackCacheables(externalURL string, account *config.Account, req *vtrackRequest) ([]prebid_cache_client.Cacheable, error) {
+	toCache := make([]prebid_cache_client.Cacheable, len(req.Puts))
+	for i, put := range req.Puts {
+		toCache[i] = prebid_cache_client.Cacheable{
+			Type:       put.Type,
+			Data:       put.Value,
+			TTLSeconds: put.TTLSeconds,
+			Key:        put.Key,
+		}
+		if put.Type != prebid_cache_client.TypeXML || !account.VASTTrackingEnabled(put.Bidder) {
+			continue
+		}
+		if put.BidID == "" {
+			return nil, fmt.Errorf("puts[%d].bidid is required to track the VAST of bidder %s", i, put.Bidder)
+		}
+		var vast string
+		if err := json.Unmarshal(put.Value, &vast); err != nil {
+			return nil, fmt.Errorf("puts[%d].value must be a string of VAST XML", i)
+		}
+		trackerURL := events.ImpressionTrackerURL(externalURL, put.BidID, put.Bidder, account.ID, put.Timestamp)
+		modified, _ := events.ModifyVAST(vast, trackerURL)
+		data, err := json.Marshal(modified)
+		if err != nil {
+			return nil, err
+		}
+		toCache[i].Data = data
+	}
+	return toCache, nil
+}
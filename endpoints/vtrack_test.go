@@ -0,0 +1,154 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/prebid_cache_client"
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/stretchr/testify/assert"
+)
+
+const vtrackVAST = `<VAST version=\"3.0\"><Ad><Wrapper><Impression></Impression></Wrapper></Ad></VAST>`
+
+func TestVTrackEndpoint(t *testing.T) {
+	trackedVAST := `<VAST version="3.0"><Ad><Wrapper><Impression><![CDATA[http://pbs.example.com/event?a=vtrack-acct&b=bid-id&bidder=appnexus&f=b&t=imp&ts=1000]]></Impression><Impression></Impression></Wrapper></Ad></VAST>`
+	untrackedVAST := `<VAST version="3.0"><Ad><Wrapper><Impression></Impression></Wrapper></Ad></VAST>`
+
+	testCases := []struct {
+		description  string
+		url          string
+		body         string
+		cacheErr     error
+		expectStatus int
+		expectCached []string
+	}{
+		{
+			description:  "Tracked bidder",
+			url:          "/vtrack?a=vtrack-acct",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + `","bidid":"bid-id","bidder":"appnexus","timestamp":1000}]}`,
+			expectStatus: http.StatusOK,
+			expectCached: []string{trackedVAST},
+		},
+		{
+			description:  "Untracked bidder",
+			url:          "/vtrack?a=vtrack-acct",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + `","bidid":"bid-id","bidder":"rubicon"}]}`,
+			expectStatus: http.StatusOK,
+			expectCached: []string{untrackedVAST},
+		},
+		{
+			description:  "Account which tracks no bidders",
+			url:          "/vtrack?a=unknown-acct",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + `","bidid":"bid-id","bidder":"appnexus"}]}`,
+			expectStatus: http.StatusOK,
+			expectCached: []string{untrackedVAST},
+		},
+		{
+			description:  "Missing account",
+			url:          "/vtrack",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + `","bidid":"bid-id","bidder":"appnexus"}]}`,
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Disabled account",
+			url:          "/vtrack?a=disabled-acct",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + `","bidid":"bid-id","bidder":"appnexus"}]}`,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			description:  "No puts",
+			url:          "/vtrack?a=vtrack-acct",
+			body:         `{"puts":[]}`,
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Invalid type",
+			url:          "/vtrack?a=vtrack-acct",
+			body:         `{"puts":[{"type":"html","value":"<div></div>","bidid":"bid-id","bidder":"appnexus"}]}`,
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Tracked bidder without a bid ID",
+			url:          "/vtrack?a=vtrack-acct",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + `","bidder":"appnexus"}]}`,
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Body over the max request size",
+			url:          "/vtrack?a=vtrack-acct",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + strings.Repeat(" ", 1024) + `","bidid":"bid-id","bidder":"appnexus"}]}`,
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			description:  "Cache error",
+			url:          "/vtrack?a=vtrack-acct",
+			body:         `{"puts":[{"type":"xml","value":"` + vtrackVAST + `","bidid":"bid-id","bidder":"appnexus"}]}`,
+			cacheErr:     errors.New("cache is down"),
+			expectStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		cache := &vtrackCache{err: test.cacheErr}
+		cfg := &config.Configuration{
+			ExternalURL:    "http://pbs.example.com",
+			MaxRequestSize: 1024,
+			VTrack:         config.VTrack{TimeoutMS: 1000},
+		}
+		endpoint := NewVTrackEndpoint(cfg, vtrackAccountFetcher{}, cache)
+
+		request := httptest.NewRequest("POST", test.url, strings.NewReader(test.body))
+		recorder := httptest.NewRecorder()
+		endpoint(recorder, request, nil)
+
+		assert.Equal(t, test.expectStatus, recorder.Code, test.description)
+		if test.expectStatus != http.StatusOK {
+			continue
+		}
+		if assert.Len(t, cache.values, len(test.expectCached), test.description) {
+			for i, expected := range test.expectCached {
+				var cached string
+				assert.NoError(t, json.Unmarshal(cache.values[i].Data, &cached), test.description)
+				assert.Equal(t, expected, cached, test.description)
+			}
+		}
+		assert.JSONEq(t, `{"responses":[{"uuid":"uuid-0"}]}`, recorder.Body.String(), test.description)
+	}
+}
+
+type vtrackAccountFetcher struct{}
+
+func (vtrackAccountFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	switch accountID {
+	case "vtrack-acct":
+		return json.RawMessage(`{"vast_tracking_bidders":["appnexus"]}`), nil
+	case "disabled-acct":
+		return json.RawMessage(`{"disabled":true}`), nil
+	}
+	return nil, []error{stored_requests.NotFoundError{ID: accountID, DataType: "Account"}}
+}
+
+// vtrackCache records the values it's asked to store
+type vtrackCache struct {
+	values []prebid_cache_client.Cacheable
+	err    error
+}
+
+func (c *vtrackCache) PutJson(ctx context.Context, values []prebid_cache_client.Cacheable) ([]string, []error) {
+	if c.err != nil {
+		return make([]string, len(values)), []error{c.err}
+	}
+	c.values = values
+	ids := make([]string, len(values))
+	for i := range values {
+		ids[i] = "uuid-" + string('0'+rune(i))
+	}
+	return ids, nil
+}
@@ -11,6 +11,8 @@ const (
 	FailedToRequestBidsCode
 	BidderTemporarilyDisabledCode
 	BlacklistedAcctCode
+	AcctRequiredCode
+	BidBelowFloorCode
 )
 
 // We should use this code for any Error interface that is not in this package
@@ -85,6 +87,22 @@ func (err *BlacklistedAcct) Code() int {
 	return BlacklistedAcctCode
 }
 
+// AcctRequired should be used when the account_required config option has been set and a request
+// doesn't come with a known Account ID
+//
+// These errors will be written to  http.ResponseWriter before canceling execution
+type AcctRequired struct {
+	Message string
+}
+
+func (err *AcctRequired) Error() string {
+	return err.Message
+}
+
+func (err *AcctRequired) Code() int {
+	return AcctRequiredCode
+}
+
 // BadServerResponse should be used when returning errors which are caused by bad/unexpected behavior on the remote server.
 //
 // For example:
@@ -138,6 +156,19 @@ func (err *BidderTemporarilyDisabled) Code() int {
 	return BidderTemporarilyDisabledCode
 }
 
+// BidBelowFloor is used when a bid is thrown out because its price is lower than the imp's price floor.
+type BidBelowFloor struct {
+	Message string
+}
+
+func (err *BidBelowFloor) Error() string {
+	return err.Message
+}
+
+func (err *BidBelowFloor) Code() int {
+	return BidBelowFloorCode
+}
+
 // DecodeError provides the error code for an error, as defined above
 func DecodeError(err error) int {
 	if ce, ok := err.(Coder); ok {
@@ -0,0 +1,70 @@
+// Package events adds Prebid Server's event notifications to creatives, so that the /event endpoint
+// hears about them when they render.
+package events
+
+import (
+	"strings"
+
+	"github.com/prebid/prebid-server/analytics"
+)
+
+// ImpressionTrackerURL returns the /event URL which a VAST <Impression> element should call for a bid.
+func ImpressionTrackerURL(externalURL, bidID, bidder, accountID string, timestamp int64) string {
+	return analytics.EventURL(externalURL, &analytics.EventRequest{
+		Type:      analytics.Imp,
+		BidID:     bidID,
+		AccountID: accountID,
+		Bidder:    bidder,
+		Timestamp: timestamp,
+		Format:    analytics.Blank,
+	})
+}
+
+// ModifyVAST adds an <Impression> element which calls trackerURL to the first Ad in the VAST XML.
+//
+// The element goes in front of the Ad's existing Impressions. If it has none, the element goes at the end of
+// its Wrapper or InLine. The boolean is false, and the VAST is returned unchanged, if there's nowhere to put it.
+func ModifyVAST(vast string, trackerURL string) (string, bool) {
+	tracker := "<Impression><![CDATA[" + trackerURL + "]]></Impression>"
+
+	adStart := indexOfElement(vast, "Ad")
+	if adStart == -1 {
+		return vast, false
+	}
+	adEnd := strings.Index(vast[adStart:], "</Ad>")
+	if adEnd == -1 {
+		return vast, false
+	}
+	adEnd += adStart
+	ad := vast[adStart:adEnd]
+
+	insertAt := indexOfElement(ad, "Impression")
+	if insertAt == -1 {
+		if insertAt = strings.Index(ad, "</Wrapper>"); insertAt == -1 {
+			insertAt = strings.Index(ad, "</InLine>")
+		}
+	}
+	if insertAt == -1 {
+		return vast, false
+	}
+	insertAt += adStart
+	return vast[:insertAt] + tracker + vast[insertAt:], true
+}
+
+// indexOfElement returns the index of the first opening tag for the named element, or -1 if there isn't one.
+func indexOfElement(xml string, name string) int {
+	offset := 0
+	for {
+		i := strings.Index(xml[offset:], "<"+name)
+		if i == -1 {
+			return -1
+		}
+		i += offset
+		next := i + len(name) + 1
+		// Make sure this isn't a different element whose name starts the same way
+		if next < len(xml) && (xml[next] == '>' || xml[next] == ' ' || xml[next] == '/' || xml[next] == '\t' || xml[next] == '\n' || xml[next] == '\r') {
+			return i
+		}
+		offset = next
+	}
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const tracker = `<Impression><![CDATA[http://pbs.example.com/event]]></Impression>`
+
+func TestModifyVAST(t *testing.T) {
+	testCases := []struct {
+		description  string
+		vast         string
+		expectVAST   string
+		expectResult bool
+	}{
+		{
+			description:  "Wrapper with an Impression",
+			vast:         `<VAST version="3.0"><Ad><Wrapper><AdSystem>prebid.org wrapper</AdSystem><Impression></Impression><Creatives></Creatives></Wrapper></Ad></VAST>`,
+			expectVAST:   `<VAST version="3.0"><Ad><Wrapper><AdSystem>prebid.org wrapper</AdSystem>` + tracker + `<Impression></Impression><Creatives></Creatives></Wrapper></Ad></VAST>`,
+			expectResult: true,
+		},
+		{
+			description:  "InLine with an Impression that has an id",
+			vast:         `<VAST version="3.0"><Ad id="1"><InLine><AdTitle>ad</AdTitle><Impression id="x"><![CDATA[http://other.com]]></Impression></InLine></Ad></VAST>`,
+			expectVAST:   `<VAST version="3.0"><Ad id="1"><InLine><AdTitle>ad</AdTitle>` + tracker + `<Impression id="x"><![CDATA[http://other.com]]></Impression></InLine></Ad></VAST>`,
+			expectResult: true,
+		},
+		{
+			description:  "InLine without an Impression",
+			vast:         `<VAST version="3.0"><Ad><InLine><AdTitle>ad</AdTitle><Creatives></Creatives></InLine></Ad></VAST>`,
+			expectVAST:   `<VAST version="3.0"><Ad><InLine><AdTitle>ad</AdTitle><Creatives></Creatives>` + tracker + `</InLine></Ad></VAST>`,
+			expectResult: true,
+		},
+		{
+			description:  "Only the first Ad is modified",
+			vast:         `<VAST version="3.0"><Ad><InLine></InLine></Ad><Ad><InLine></InLine></Ad></VAST>`,
+			expectVAST:   `<VAST version="3.0"><Ad><InLine>` + tracker + `</InLine></Ad><Ad><InLine></InLine></Ad></VAST>`,
+			expectResult: true,
+		},
+		{
+			description:  "Elements which only start like Impression are skipped",
+			vast:         `<VAST version="3.0"><Ad><InLine><ImpressionX></ImpressionX></InLine></Ad></VAST>`,
+			expectVAST:   `<VAST version="3.0"><Ad><InLine><ImpressionX></ImpressionX>` + tracker + `</InLine></Ad></VAST>`,
+			expectResult: true,
+		},
+		{
+			description:  "No Ad",
+			vast:         `<VAST version="3.0"></VAST>`,
+			expectVAST:   `<VAST version="3.0"></VAST>`,
+			expectResult: false,
+		},
+		{
+			description:  "No Wrapper or InLine",
+			vast:         `<VAST version="3.0"><Ad></Ad></VAST>`,
+			expectVAST:   `<VAST version="3.0"><Ad></Ad></VAST>`,
+			expectResult: false,
+		},
+		{
+			description:  "Not VAST",
+			vast:         `<div>banner</div>`,
+			expectVAST:   `<div>banner</div>`,
+			expectResult: false,
+		},
+	}
+
+	for _, test := range testCases {
+		vast, ok := ModifyVAST(test.vast, "http://pbs.example.com/event")
+		assert.Equal(t, test.expectResult, ok, test.description)
+		assert.Equal(t, test.expectVAST, vast, test.description)
+	}
+}
+
+func TestImpressionTrackerURL(t *testing.T) {
+	url := ImpressionTrackerURL("http://pbs.example.com", "bid-id", "appnexus", "acct", 1234)
+	assert.Equal(t, "http://pbs.example.com/event?a=acct&b=bid-id&bidder=appnexus&f=b&t=imp&ts=1234", url)
+}
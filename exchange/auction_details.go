@@ -0,0 +1,116 @@
+package exchange
+
+import (
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// auctionRecorder fills in the analytics.AuctionDetails for an auction, so that the analytics modules can see
+// what each bidder did. Its methods are safe to call on a nil auctionRecorder, which records nothing.
+type auctionRecorder struct {
+	details *analytics.AuctionDetails
+	bids    map[*openrtb.Bid]*analytics.BidDetails
+}
+
+// newAuctionRecorder returns an auctionRecorder which fills in details, or nil if details is nil.
+func newAuctionRecorder(details *analytics.AuctionDetails) *auctionRecorder {
+	if details == nil {
+		return nil
+	}
+	if details.Bidders == nil {
+		details.Bidders = make(map[openrtb_ext.BidderName]*analytics.BidderDetails)
+	}
+	return &auctionRecorder{
+		details: details,
+		bids:    make(map[*openrtb.Bid]*analytics.BidDetails),
+	}
+}
+
+// recordBidder records a bidder's response, before any of its bids have been rejected.
+func (r *auctionRecorder) recordBidder(bidder openrtb_ext.BidderName, seatBid *pbsOrtbSeatBid, extra *seatResponseExtra) {
+	if r == nil {
+		return
+	}
+	bidderDetails := &analytics.BidderDetails{RequestSent: true}
+	if extra != nil {
+		bidderDetails.ResponseTimeMillis = extra.ResponseTimeMillis
+	}
+	r.details.Bidders[bidder] = bidderDetails
+	r.addBids(bidderDetails, seatBid)
+}
+
+// recordStoredBids records the bids which came from stored auction responses.
+func (r *auctionRecorder) recordStoredBids(adapterBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid) {
+	if r == nil {
+		return
+	}
+	for bidder, seatBid := range adapterBids {
+		bidderDetails, ok := r.details.Bidders[bidder]
+		if !ok {
+			bidderDetails = &analytics.BidderDetails{}
+			r.details.Bidders[bidder] = bidderDetails
+		}
+		r.addBids(bidderDetails, seatBid)
+	}
+}
+
+func (r *auctionRecorder) addBids(bidderDetails *analytics.BidderDetails, seatBid *pbsOrtbSeatBid) {
+	if seatBid == nil {
+		return
+	}
+	for _, bid := range seatBid.bids {
+		if _, ok := r.bids[bid.bid]; ok {
+			continue
+		}
+		bidDetails := &analytics.BidDetails{
+			Bid:    bid.bid,
+			Type:   bid.bidType,
+			Status: analytics.BidLost,
+		}
+		bidderDetails.Bids = append(bidderDetails.Bids, bidDetails)
+		r.bids[bid.bid] = bidDetails
+	}
+}
+
+// rejectBid records why a bid was taken out of the auction.
+func (r *auctionRecorder) rejectBid(bid *pbsOrtbBid, status analytics.BidStatus) {
+	if r == nil {
+		return
+	}
+	if bidDetails, ok := r.bids[bid.bid]; ok {
+		bidDetails.Status = status
+	}
+}
+
+// recordWinners marks the winning bid for each imp. The others keep the status they already have.
+func (r *auctionRecorder) recordWinners(auc *auction) {
+	if r == nil {
+		return
+	}
+	for _, winner := range auc.winningBids {
+		if bidDetails, ok := r.bids[winner.bid]; ok {
+			bidDetails.Status = analytics.BidWon
+		}
+	}
+}
+
+// recordErrors records each bidder's errors, once nothing else will be added to them.
+func (r *auctionRecorder) recordErrors(adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra) {
+	if r == nil {
+		return
+	}
+	for bidder, extra := range adapterExtra {
+		bidderDetails, ok := r.details.Bidders[bidder]
+		if !ok || extra == nil {
+			continue
+		}
+		bidderDetails.Errors = extra.Errors
+		for _, err := range extra.Errors {
+			if err.Code == errortypes.TimeoutCode {
+				bidderDetails.TimedOut = true
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuctionRecorder(t *testing.T) {
+	winner := &pbsOrtbBid{bid: &openrtb.Bid{ID: "winner", ImpID: "imp-1", Price: 2}, bidType: openrtb_ext.BidTypeBanner}
+	loser := &pbsOrtbBid{bid: &openrtb.Bid{ID: "loser", ImpID: "imp-1", Price: 1}, bidType: openrtb_ext.BidTypeBanner}
+	belowFloor := &pbsOrtbBid{bid: &openrtb.Bid{ID: "below-floor", ImpID: "imp-1", Price: 0.1}, bidType: openrtb_ext.BidTypeBanner}
+	stored := &pbsOrtbBid{bid: &openrtb.Bid{ID: "stored", ImpID: "imp-2", Price: 1}, bidType: openrtb_ext.BidTypeVideo}
+
+	details := &analytics.AuctionDetails{}
+	recorder := newAuctionRecorder(details)
+
+	adapterBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {bids: []*pbsOrtbBid{winner, belowFloor}},
+		openrtb_ext.BidderRubicon:  {bids: []*pbsOrtbBid{loser}},
+		openrtb_ext.BidderOpenx:    nil,
+	}
+	adapterExtra := map[openrtb_ext.BidderName]*seatResponseExtra{
+		openrtb_ext.BidderAppnexus: {ResponseTimeMillis: 100},
+		openrtb_ext.BidderRubicon:  {ResponseTimeMillis: 200},
+		openrtb_ext.BidderOpenx: {ResponseTimeMillis: 300, Errors: []openrtb_ext.ExtBidderError{
+			{Code: errortypes.TimeoutCode, Message: "timed out"},
+		}},
+	}
+	for bidder, seatBid := range adapterBids {
+		recorder.recordBidder(bidder, seatBid, adapterExtra[bidder])
+	}
+	recorder.rejectBid(belowFloor, analytics.BidRejectedBelowFloor)
+	adapterBids[openrtb_ext.BidderAppnexus].bids = []*pbsOrtbBid{winner}
+
+	adapterBids[openrtb_ext.BidderPubmatic] = &pbsOrtbSeatBid{bids: []*pbsOrtbBid{stored}}
+	recorder.recordStoredBids(adapterBids)
+
+	recorder.recordWinners(newAuction(adapterBids, 2))
+	recorder.recordErrors(adapterExtra)
+
+	assert.Len(t, details.Bidders, 4)
+	appnexus := details.Bidders[openrtb_ext.BidderAppnexus]
+	if assert.NotNil(t, appnexus) {
+		assert.True(t, appnexus.RequestSent)
+		assert.Equal(t, 100, appnexus.ResponseTimeMillis)
+		assert.False(t, appnexus.TimedOut)
+		assert.Equal(t, []*analytics.BidDetails{
+			{Bid: winner.bid, Type: openrtb_ext.BidTypeBanner, Status: analytics.BidWon},
+			{Bid: belowFloor.bid, Type: openrtb_ext.BidTypeBanner, Status: analytics.BidRejectedBelowFloor},
+		}, appnexus.Bids)
+	}
+	rubicon := details.Bidders[openrtb_ext.BidderRubicon]
+	if assert.NotNil(t, rubicon) && assert.Len(t, rubicon.Bids, 1) {
+		assert.Equal(t, analytics.BidLost, rubicon.Bids[0].Status)
+	}
+	openx := details.Bidders[openrtb_ext.BidderOpenx]
+	if assert.NotNil(t, openx) {
+		assert.True(t, openx.TimedOut)
+		assert.Empty(t, openx.Bids)
+		assert.Len(t, openx.Errors, 1)
+	}
+	pubmatic := details.Bidders[openrtb_ext.BidderPubmatic]
+	if assert.NotNil(t, pubmatic) && assert.Len(t, pubmatic.Bids, 1) {
+		assert.False(t, pubmatic.RequestSent, "Bidders from stored auction responses weren't called")
+		assert.Equal(t, analytics.BidWon, pubmatic.Bids[0].Status)
+	}
+}
+
+func TestAuctionRecorderCategoryRejections(t *testing.T) {
+	categoriesFetcher, err := newCategoryFetcher("./test/category-mapping")
+	if err != nil {
+		t.Errorf("Failed to create a category Fetcher: %v", err)
+	}
+
+	requestExt := newExtRequest()
+	requestExt.Prebid.Targeting.DurationRangeSec = []int{15, 30}
+	targData := &targetData{
+		priceGranularity: requestExt.Prebid.Targeting.PriceGranularity,
+		includeWinners:   true,
+	}
+
+	mapped := &pbsOrtbBid{&openrtb.Bid{ID: "mapped", ImpID: "imp-1", Price: 10, Cat: []string{"IAB1-3"}}, "video", nil, &openrtb_ext.ExtBidPrebidVideo{Duration: 30}}
+	tooLong := &pbsOrtbBid{&openrtb.Bid{ID: "too-long", ImpID: "imp-2", Price: 10, Cat: []string{"IAB1-4"}}, "video", nil, &openrtb_ext.ExtBidPrebidVideo{Duration: 40}}
+	unmapped := &pbsOrtbBid{&openrtb.Bid{ID: "unmapped", ImpID: "imp-3", Price: 10, Cat: []string{"IAB1-2000"}}, "video", nil, &openrtb_ext.ExtBidPrebidVideo{Duration: 30}}
+	adapterBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {bids: []*pbsOrtbBid{mapped, tooLong, unmapped}, currency: "USD"},
+	}
+
+	details := &analytics.AuctionDetails{}
+	recorder := newAuctionRecorder(details)
+	recorder.recordBidder(openrtb_ext.BidderAppnexus, adapterBids[openrtb_ext.BidderAppnexus], nil)
+
+	_, adapterBids, err = applyCategoryMapping(nil, requestExt, adapterBids, categoriesFetcher, targData, recorder)
+	assert.NoError(t, err)
+	recorder.recordWinners(newAuction(adapterBids, 3))
+
+	statuses := make(map[string]analytics.BidStatus)
+	for _, bid := range details.Bidders[openrtb_ext.BidderAppnexus].Bids {
+		statuses[bid.Bid.ID] = bid.Status
+	}
+	assert.Equal(t, map[string]analytics.BidStatus{
+		"mapped":   analytics.BidWon,
+		"too-long": analytics.BidRejectedDuration,
+		"unmapped": analytics.BidRejectedCategory,
+	}, statuses)
+}
+
+func TestNilAuctionRecorder(t *testing.T) {
+	recorder := newAuctionRecorder(nil)
+	bid := &pbsOrtbBid{bid: &openrtb.Bid{ID: "bid"}}
+
+	assert.Nil(t, recorder)
+	recorder.recordBidder(openrtb_ext.BidderAppnexus, &pbsOrtbSeatBid{bids: []*pbsOrtbBid{bid}}, nil)
+	recorder.rejectBid(bid, analytics.BidRejectedBelowFloor)
+	recorder.recordStoredBids(nil)
+	recorder.recordWinners(&auction{})
+	recorder.recordErrors(nil)
+}
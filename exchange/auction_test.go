@@ -22,7 +22,7 @@ func TestMakeVASTGiven(t *testing.T) {
 	bid := &openrtb.Bid{
 		AdM: expect,
 	}
-	vast := makeVAST(bid)
+	vast := MakeVAST(bid)
 	assert.Equal(t, expect, vast)
 }
 
@@ -36,7 +36,7 @@ func TestMakeVASTNurl(t *testing.T) {
 	bid := &openrtb.Bid{
 		NURL: url,
 	}
-	vast := makeVAST(bid)
+	vast := MakeVAST(bid)
 	assert.Equal(t, expect, vast)
 }
 
@@ -188,7 +188,7 @@ func runCacheSpec(t *testing.T, fileDisplayName string, specData *cacheSpec) {
 		winningBidsByBidder: winningBidsByBidder,
 		roundedPrices:       roundedPrices,
 	}
-	_ = testAuction.doCache(ctx, cache, targData, &specData.BidRequest, 60, &specData.DefaultTTLs, bidCategory)
+	_ = testAuction.doCache(ctx, cache, targData, &specData.BidRequest, 60, &specData.DefaultTTLs, bidCategory, nil)
 
 	if len(specData.ExpectedCacheables) > len(cache.items) {
 		t.Errorf("%s:  [CACHE_ERROR] Less elements were cached than expected \n", fileDisplayName)
@@ -38,7 +38,10 @@ type adaptedBidder interface {
 	//
 	// Any errors will be user-facing in the API.
 	// Error messages should help publishers understand what might account for "bad" bids.
-	requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error)
+	//
+	// If storedBidResponses is non-empty, its bodies stand in for the Bidder's HTTP responses to the imps
+	// whose IDs key them, and no HTTP calls will be made.
+	requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo, storedBidResponses map[string]json.RawMessage) (*pbsOrtbSeatBid, []error)
 }
 
 // pbsOrtbBid is a Bid returned by an adaptedBidder.
@@ -88,27 +91,43 @@ type bidderAdapter struct {
 	Client *http.Client
 }
 
-func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo) (*pbsOrtbSeatBid, []error) {
-	reqData, errs := bidder.Bidder.MakeRequests(request, reqInfo)
+func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64, conversions currencies.Conversions, reqInfo *adapters.ExtraRequestInfo, storedBidResponses map[string]json.RawMessage) (*pbsOrtbSeatBid, []error) {
+	var reqData []*adapters.RequestData
+	var errs []error
+	var responseChannel chan *httpCallInfo
 
-	if len(reqData) == 0 {
-		// If the adapter failed to generate both requests and errors, this is an error.
-		if len(errs) == 0 {
-			errs = append(errs, &errortypes.FailedToRequestBids{Message: "The adapter failed to generate any bid requests, but also failed to generate an error explaining why"})
+	if len(storedBidResponses) > 0 {
+		reqData = make([]*adapters.RequestData, 0, len(storedBidResponses))
+		responseChannel = make(chan *httpCallInfo, len(storedBidResponses))
+		for _, imp := range request.Imp {
+			if body, ok := storedBidResponses[imp.ID]; ok {
+				httpInfo := storedCallInfo(request, imp, body)
+				reqData = append(reqData, httpInfo.request)
+				responseChannel <- httpInfo
+			}
 		}
-		return nil, errs
-	}
-
-	// Make any HTTP requests in parallel.
-	// If the bidder only needs to make one, save some cycles by just using the current one.
-	responseChannel := make(chan *httpCallInfo, len(reqData))
-	if len(reqData) == 1 {
-		responseChannel <- bidder.doRequest(ctx, reqData[0])
 	} else {
-		for _, oneReqData := range reqData {
-			go func(data *adapters.RequestData) {
-				responseChannel <- bidder.doRequest(ctx, data)
-			}(oneReqData) // Method arg avoids a race condition on oneReqData
+		reqData, errs = bidder.Bidder.MakeRequests(request, reqInfo)
+
+		if len(reqData) == 0 {
+			// If the adapter failed to generate both requests and errors, this is an error.
+			if len(errs) == 0 {
+				errs = append(errs, &errortypes.FailedToRequestBids{Message: "The adapter failed to generate any bid requests, but also failed to generate an error explaining why"})
+			}
+			return nil, errs
+		}
+
+		// Make any HTTP requests in parallel.
+		// If the bidder only needs to make one, save some cycles by just using the current one.
+		responseChannel = make(chan *httpCallInfo, len(reqData))
+		if len(reqData) == 1 {
+			responseChannel <- bidder.doRequest(ctx, reqData[0])
+		} else {
+			for _, oneReqData := range reqData {
+				go func(data *adapters.RequestData) {
+					responseChannel <- bidder.doRequest(ctx, data)
+				}(oneReqData) // Method arg avoids a race condition on oneReqData
+			}
 		}
 	}
 
@@ -158,6 +177,10 @@ func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.Bi
 					for i := 0; i < len(bidResponse.Bids); i++ {
 						if bidResponse.Bids[i].Bid != nil {
 							bidResponse.Bids[i].Bid.Price = bidResponse.Bids[i].Bid.Price * bidAdjustment * conversionRate
+							// Stored bids can be shared by many imps, so they always answer the imp which asked for them
+							if httpInfo.storedImpID != "" {
+								bidResponse.Bids[i].Bid.ImpID = httpInfo.storedImpID
+							}
 						}
 						seatBid.bids = append(seatBid.bids, &pbsOrtbBid{
 							bid:      bidResponse.Bids[i].Bid,
@@ -178,6 +201,26 @@ func (bidder *bidderAdapter) requestBid(ctx context.Context, request *openrtb.Bi
 	return seatBid, errs
 }
 
+// storedCallInfo builds the call info for the imp's Stored Bid Response, as though the Bidder's server had returned it.
+// The request body is the OpenRTB request for that imp alone, since some Bidders read it back when unpacking their bids.
+func storedCallInfo(request *openrtb.BidRequest, imp openrtb.Imp, body json.RawMessage) *httpCallInfo {
+	impRequest := *request
+	impRequest.Imp = []openrtb.Imp{imp}
+	reqBody, _ := json.Marshal(&impRequest)
+	return &httpCallInfo{
+		storedImpID: imp.ID,
+		request: &adapters.RequestData{
+			Method: "POST",
+			Body:   reqBody,
+		},
+		response: &adapters.ResponseData{
+			StatusCode: http.StatusOK,
+			Body:       body,
+			Headers:    http.Header{},
+		},
+	}
+}
+
 // makeExt transforms information about the HTTP call into the contract class for the PBS response.
 func makeExt(httpInfo *httpCallInfo) *openrtb_ext.ExtHttpCall {
 	if httpInfo.err == nil {
@@ -250,4 +293,6 @@ type httpCallInfo struct {
 	request  *adapters.RequestData
 	response *adapters.ResponseData
 	err      error
+	// storedImpID is the imp answered by a Stored Bid Response, if this call stands in for one.
+	storedImpID string
 }
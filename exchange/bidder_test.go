@@ -61,7 +61,7 @@ func TestSingleBidder(t *testing.T) {
 	}
 	bidder := adaptBidder(bidderImpl, server.Client())
 	currencyConverter := currencies.NewRateConverterDefault()
-	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", bidAdjustment, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", bidAdjustment, currencyConverter.Rates(), &adapters.ExtraRequestInfo{}, nil)
 
 	// Make sure the goodSingleBidder was called with the expected arguments.
 	if bidderImpl.httpResponse == nil {
@@ -104,6 +104,44 @@ func TestSingleBidder(t *testing.T) {
 	}
 }
 
+// TestStoredBidResponses makes sure that Stored Bid Responses are passed to the Bidder without any HTTP calls.
+func TestStoredBidResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("The Bidder's server shouldn't be called when it has Stored Bid Responses.")
+	}))
+	defer server.Close()
+
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method: "POST",
+			Uri:    server.URL,
+		},
+		bidResponse: &adapters.BidderResponse{
+			Bids: []*adapters.TypedBid{
+				{
+					Bid:     &openrtb.Bid{Price: 2.0},
+					BidType: openrtb_ext.BidTypeBanner,
+				},
+			},
+		},
+	}
+	bidder := adaptBidder(bidderImpl, server.Client())
+	storedBody := json.RawMessage(`{"seatbid":[]}`)
+	request := &openrtb.BidRequest{ID: "req-id", Imp: []openrtb.Imp{{ID: "imp-1"}, {ID: "imp-2"}}}
+	seatBid, errs := bidder.requestBid(context.Background(), request, "test", 1.0, currencies.NewConstantRates(), &adapters.ExtraRequestInfo{}, map[string]json.RawMessage{"imp-2": storedBody})
+
+	assert.Empty(t, errs, "Stored Bid Responses shouldn't produce errors")
+	assert.Nil(t, bidderImpl.bidRequest, "MakeRequests shouldn't be called for Stored Bid Responses")
+	if assert.NotNil(t, bidderImpl.httpResponse, "MakeBids should be called with the Stored Bid Response") {
+		assert.Equal(t, http.StatusOK, bidderImpl.httpResponse.StatusCode, "Stored Bid Responses should look like successful calls")
+		assert.JSONEq(t, string(storedBody), string(bidderImpl.httpResponse.Body), "MakeBids got the wrong body")
+	}
+	if assert.NotNil(t, seatBid) && assert.Len(t, seatBid.bids, 1) {
+		assert.Equal(t, 2.0, seatBid.bids[0].bid.Price, "The Bidder's bids should be returned")
+		assert.Equal(t, "imp-2", seatBid.bids[0].bid.ImpID, "Stored bids should answer the imp which asked for them")
+	}
+}
+
 // TestMultiBidder makes sure all the requests get sent, and the responses processed.
 // Because this is done in parallel, it should be run under the race detector.
 func TestMultiBidder(t *testing.T) {
@@ -146,7 +184,7 @@ func TestMultiBidder(t *testing.T) {
 	}
 	bidder := adaptBidder(bidderImpl, server.Client())
 	currencyConverter := currencies.NewRateConverterDefault()
-	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	seatBid, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{}, nil)
 
 	if seatBid == nil {
 		t.Fatalf("SeatBid should exist, because bids exist.")
@@ -515,6 +553,7 @@ func TestMultiCurrencies(t *testing.T) {
 			1,
 			currencyConverter.Rates(),
 			&adapters.ExtraRequestInfo{},
+			nil,
 		)
 
 		// Verify:
@@ -659,6 +698,7 @@ func TestMultiCurrencies_RateConverterNotSet(t *testing.T) {
 			1,
 			currencyConverter.Rates(),
 			&adapters.ExtraRequestInfo{},
+			nil,
 		)
 
 		// Verify:
@@ -831,6 +871,7 @@ func TestMultiCurrencies_RequestCurrencyPick(t *testing.T) {
 			1,
 			currencyConverter.Rates(),
 			&adapters.ExtraRequestInfo{},
+			nil,
 		)
 
 		// Verify:
@@ -943,6 +984,7 @@ func TestServerCallDebugging(t *testing.T) {
 		1.0,
 		currencyConverter.Rates(),
 		&adapters.ExtraRequestInfo{},
+		nil,
 	)
 
 	if len(bids.httpCalls) != 1 {
@@ -965,7 +1007,7 @@ func TestServerCallDebugging(t *testing.T) {
 func TestErrorReporting(t *testing.T) {
 	bidder := adaptBidder(&bidRejector{}, nil)
 	currencyConverter := currencies.NewRateConverterDefault()
-	bids, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{})
+	bids, errs := bidder.requestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0, currencyConverter.Rates(), &adapters.ExtraRequestInfo{}, nil)
 	if bids != nil {
 		t.Errorf("There should be no seatbid if no http requests are returned.")
 	}
@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/events"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// eventTracking builds the /event URLs which get added to each bid under bid.ext.prebid.events,
+// and the <Impression> trackers which get added to cached VAST.
+type eventTracking struct {
+	externalURL        string
+	accountID          string
+	auctionTimestampMs int64
+	// bidEvents is true if bids should get bid.ext.prebid.events
+	bidEvents bool
+	account   *config.Account
+}
+
+// newEventTracking returns the eventTracking for an auction, or nil if neither the account nor the request
+// has enabled events. Requests without a known account can't be tracked, because the events are keyed by account.
+func newEventTracking(externalURL string, account *config.Account, requestExt openrtb_ext.ExtRequest, auctionStart time.Time) *eventTracking {
+	if account == nil || account.ID == "" || account.ID == pbsmetrics.PublisherUnknown {
+		return nil
+	}
+	bidEvents := account.EventsEnabled || requestExt.Prebid.Events != nil
+	if !bidEvents && len(account.VASTTrackingBidders) == 0 {
+		return nil
+	}
+	return &eventTracking{
+		externalURL:        externalURL,
+		accountID:          account.ID,
+		auctionTimestampMs: auctionStart.UnixNano() / int64(time.Millisecond),
+		bidEvents:          bidEvents,
+		account:            account,
+	}
+}
+
+// makeBidExtEvents returns the event URLs for a bid. It is safe to call on a nil eventTracking.
+func (ev *eventTracking) makeBidExtEvents(bidID string, bidder openrtb_ext.BidderName) *openrtb_ext.ExtBidPrebidEvents {
+	if ev == nil || !ev.bidEvents {
+		return nil
+	}
+	return &openrtb_ext.ExtBidPrebidEvents{
+		Win: ev.makeEventURL(analytics.Win, bidID, bidder),
+		Imp: ev.makeEventURL(analytics.Imp, bidID, bidder),
+	}
+}
+
+func (ev *eventTracking) makeEventURL(eventType analytics.EventType, bidID string, bidder openrtb_ext.BidderName) string {
+	return analytics.EventURL(ev.externalURL, &analytics.EventRequest{
+		Type:      eventType,
+		BidID:     bidID,
+		AccountID: ev.accountID,
+		Bidder:    string(bidder),
+		Timestamp: ev.auctionTimestampMs,
+		Format:    analytics.Image,
+	})
+}
+
+// modifyVAST adds an impression tracker to the VAST if the account tracks this bidder's VAST.
+// It is safe to call on a nil eventTracking.
+func (ev *eventTracking) modifyVAST(vast string, bidID string, bidder openrtb_ext.BidderName) string {
+	if ev == nil || !ev.account.VASTTrackingEnabled(string(bidder)) {
+		return vast
+	}
+	trackerURL := events.ImpressionTrackerURL(ev.externalURL, bidID, string(bidder), ev.accountID, ev.auctionTimestampMs)
+	modified, _ := events.ModifyVAST(vast, trackerURL)
+	return modified
+}
+
+// MakeTrackedVAST returns the bid's VAST with the same impression tracker as the VAST which the exchange caches.
+// Endpoints which return a bid's VAST themselves use it, so that those impressions get tracked too.
+func MakeTrackedVAST(bid *openrtb.Bid, bidder openrtb_ext.BidderName, externalURL string, account *config.Account, auctionStart time.Time) string {
+	return newEventTracking(externalURL, account, openrtb_ext.ExtRequest{}, auctionStart).modifyVAST(MakeVAST(bid), bid.ID, bidder)
+}
@@ -0,0 +1,135 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEventTracking(t *testing.T) {
+	auctionStart := time.Unix(1500000000, 0)
+	requestEvents := openrtb_ext.ExtRequest{Prebid: openrtb_ext.ExtRequestPrebid{Events: json.RawMessage(`{}`)}}
+
+	testCases := []struct {
+		description string
+		account     *config.Account
+		requestExt  openrtb_ext.ExtRequest
+		expectNil   bool
+		expectBid   bool
+	}{
+		{
+			description: "Enabled by account",
+			account:     &config.Account{ID: "acct", EventsEnabled: true},
+			expectBid:   true,
+		},
+		{
+			description: "Enabled by request",
+			account:     &config.Account{ID: "acct"},
+			requestExt:  requestEvents,
+			expectBid:   true,
+		},
+		{
+			description: "Only VAST tracking enabled",
+			account:     &config.Account{ID: "acct", VASTTrackingBidders: []string{"appnexus"}},
+		},
+		{
+			description: "Not enabled",
+			account:     &config.Account{ID: "acct"},
+			expectNil:   true,
+		},
+		{
+			description: "Unknown account",
+			account:     &config.Account{ID: "unknown", EventsEnabled: true},
+			requestExt:  requestEvents,
+			expectNil:   true,
+		},
+		{
+			description: "No account",
+			requestExt:  requestEvents,
+			expectNil:   true,
+		},
+	}
+
+	for _, test := range testCases {
+		events := newEventTracking("http://pbs.example.com", test.account, test.requestExt, auctionStart)
+		if test.expectNil {
+			assert.Nil(t, events, test.description)
+		} else if assert.NotNil(t, events, test.description) {
+			assert.Equal(t, "acct", events.accountID, test.description)
+			assert.Equal(t, int64(1500000000000), events.auctionTimestampMs, test.description)
+			assert.Equal(t, test.expectBid, events.bidEvents, test.description)
+		}
+	}
+}
+
+func TestMakeBidExtEvents(t *testing.T) {
+	events := &eventTracking{
+		externalURL:        "http://pbs.example.com",
+		accountID:          "acct",
+		auctionTimestampMs: 1234,
+		bidEvents:          true,
+	}
+
+	bidExtEvents := events.makeBidExtEvents("bid-id", openrtb_ext.BidderAppnexus)
+
+	assert.Equal(t, &openrtb_ext.ExtBidPrebidEvents{
+		Win: "http://pbs.example.com/event?a=acct&b=bid-id&bidder=appnexus&f=i&t=win&ts=1234",
+		Imp: "http://pbs.example.com/event?a=acct&b=bid-id&bidder=appnexus&f=i&t=imp&ts=1234",
+	}, bidExtEvents)
+
+	var noEvents *eventTracking
+	assert.Nil(t, noEvents.makeBidExtEvents("bid-id", openrtb_ext.BidderAppnexus), "Disabled events shouldn't add anything to the bid")
+
+	vastOnly := &eventTracking{externalURL: "http://pbs.example.com", accountID: "acct"}
+	assert.Nil(t, vastOnly.makeBidExtEvents("bid-id", openrtb_ext.BidderAppnexus), "VAST tracking alone shouldn't add anything to the bid")
+}
+
+func TestModifyVAST(t *testing.T) {
+	events := &eventTracking{
+		externalURL:        "http://pbs.example.com",
+		accountID:          "acct",
+		auctionTimestampMs: 1234,
+		account:            &config.Account{ID: "acct", VASTTrackingBidders: []string{"appnexus"}},
+	}
+	vast := `<VAST version="3.0"><Ad><InLine></InLine></Ad></VAST>`
+
+	assert.Equal(t, `<VAST version="3.0"><Ad><InLine><Impression><![CDATA[http://pbs.example.com/event?a=acct&b=bid-id&bidder=appnexus&f=b&t=imp&ts=1234]]></Impression></InLine></Ad></VAST>`,
+		events.modifyVAST(vast, "bid-id", openrtb_ext.BidderAppnexus), "Tracked bidders should get an impression tracker")
+	assert.Equal(t, vast, events.modifyVAST(vast, "bid-id", openrtb_ext.BidderRubicon), "Untracked bidders shouldn't be modified")
+
+	var noEvents *eventTracking
+	assert.Equal(t, vast, noEvents.modifyVAST(vast, "bid-id", openrtb_ext.BidderAppnexus), "Disabled events shouldn't modify the VAST")
+}
+
+func TestMakeTrackedVAST(t *testing.T) {
+	account := &config.Account{ID: "acct", VASTTrackingBidders: []string{"appnexus"}}
+	bid := &openrtb.Bid{ID: "bid-id", AdM: `<VAST version="3.0"><Ad><InLine></InLine></Ad></VAST>`}
+	auctionStart := time.Unix(1, 234*int64(time.Millisecond))
+
+	assert.Equal(t, `<VAST version="3.0"><Ad><InLine><Impression><![CDATA[http://pbs.example.com/event?a=acct&b=bid-id&bidder=appnexus&f=b&t=imp&ts=1234]]></Impression></InLine></Ad></VAST>`,
+		MakeTrackedVAST(bid, openrtb_ext.BidderAppnexus, "http://pbs.example.com", account, auctionStart), "Tracked bidders should get an impression tracker")
+	assert.Equal(t, bid.AdM, MakeTrackedVAST(bid, openrtb_ext.BidderRubicon, "http://pbs.example.com", account, auctionStart), "Untracked bidders shouldn't be modified")
+	assert.Equal(t, bid.AdM, MakeTrackedVAST(bid, openrtb_ext.BidderAppnexus, "http://pbs.example.com", nil, auctionStart), "Bids without an account shouldn't be modified")
+}
+
+func TestMakeBidWithEvents(t *testing.T) {
+	e := &exchange{}
+	events := &eventTracking{externalURL: "http://pbs.example.com", accountID: "acct", bidEvents: true}
+	bids := []*pbsOrtbBid{{bid: &openrtb.Bid{ID: "bid-id", ImpID: "imp-id", Price: 1}, bidType: openrtb_ext.BidTypeBanner}}
+
+	result, errs := e.makeBid(bids, openrtb_ext.BidderAppnexus, events)
+
+	assert.Empty(t, errs)
+	if assert.Len(t, result, 1) {
+		var bidExt openrtb_ext.ExtBid
+		assert.NoError(t, json.Unmarshal(result[0].Ext, &bidExt))
+		if assert.NotNil(t, bidExt.Prebid.Events) {
+			assert.Equal(t, "http://pbs.example.com/event?a=acct&b=bid-id&bidder=appnexus&f=i&t=win", bidExt.Prebid.Events.Win)
+		}
+	}
+}
@@ -16,9 +16,11 @@ import (
 	"github.com/golang/glog"
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/analytics"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/currencies"
 	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/floors"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
@@ -28,7 +30,8 @@ import (
 // Exchange runs Auctions. Implementations must be threadsafe, and will be shared across many goroutines.
 type Exchange interface {
 	// HoldAuction executes an OpenRTB v2.5 Auction.
-	HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, usersyncs IdFetcher, labels pbsmetrics.Labels, categoriesFetcher *stored_requests.CategoryFetcher) (*openrtb.BidResponse, error)
+	// If auctionDetails isn't nil, it gets filled in with what each bidder did, for the analytics modules.
+	HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, usersyncs IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, auctionDetails *analytics.AuctionDetails) (*openrtb.BidResponse, error)
 }
 
 // IdFetcher can find the user's ID for a specific Bidder.
@@ -46,6 +49,11 @@ type exchange struct {
 	currencyConverter   *currencies.RateConverter
 	UsersyncIfAmbiguous bool
 	defaultTTLs         config.DefaultTTLs
+	storedResponses     stored_requests.ResponseFetcher
+	priceFloors         config.PriceFloors
+	floorsFetcher       *floors.Fetcher
+	hostSChainNode      *openrtb_ext.ExtRequestPrebidSChainSChainNode
+	externalURL         string
 }
 
 // Container to pass out response ext data from the GetAllBids goroutines back into the main thread
@@ -60,7 +68,7 @@ type bidResponseWrapper struct {
 	bidder       openrtb_ext.BidderName
 }
 
-func NewExchange(client *http.Client, cache prebid_cache_client.Client, cfg *config.Configuration, metricsEngine pbsmetrics.MetricsEngine, infos adapters.BidderInfos, gDPR gdpr.Permissions, currencyConverter *currencies.RateConverter) Exchange {
+func NewExchange(client *http.Client, cache prebid_cache_client.Client, cfg *config.Configuration, metricsEngine pbsmetrics.MetricsEngine, infos adapters.BidderInfos, gDPR gdpr.Permissions, currencyConverter *currencies.RateConverter, storedResponses stored_requests.ResponseFetcher) Exchange {
 	e := new(exchange)
 
 	e.adapterMap = newAdapterMap(client, cfg, infos)
@@ -71,10 +79,20 @@ func NewExchange(client *http.Client, cache prebid_cache_client.Client, cfg *con
 	e.currencyConverter = currencyConverter
 	e.UsersyncIfAmbiguous = cfg.GDPR.UsersyncIfAmbiguous
 	e.defaultTTLs = cfg.CacheURL.DefaultTTLs
+	e.storedResponses = storedResponses
+	e.hostSChainNode = cfg.HostSChainNode
+	e.externalURL = cfg.ExternalURL
+	e.priceFloors = cfg.PriceFloors
+	if cfg.PriceFloors.Enabled {
+		e.floorsFetcher = floors.NewFetcher(client, cfg.PriceFloors.Fetch)
+	}
 	return e
 }
 
-func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, usersyncs IdFetcher, labels pbsmetrics.Labels, categoriesFetcher *stored_requests.CategoryFetcher) (*openrtb.BidResponse, error) {
+func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidRequest, usersyncs IdFetcher, labels pbsmetrics.Labels, account *config.Account, categoriesFetcher *stored_requests.CategoryFetcher, auctionDetails *analytics.AuctionDetails) (*openrtb.BidResponse, error) {
+	auctionStart := time.Now()
+	recorder := newAuctionRecorder(auctionDetails)
+
 	// Snapshot of resolved bid request for debug if test request
 	var resolvedRequest json.RawMessage
 	if bidRequest.Test == 1 {
@@ -95,19 +113,6 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 		e.me.RecordImps(impLabels)
 	}
 
-	// Slice of BidRequests, each a copy of the original cleaned to only contain bidder data for the named bidder
-	blabels := make(map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels)
-	cleanRequests, aliases, errs := cleanOpenRTBRequests(ctx, bidRequest, usersyncs, blabels, labels, e.gDPR, e.UsersyncIfAmbiguous)
-
-	// List of bidders we have requests for.
-	liveAdapters := make([]openrtb_ext.BidderName, len(cleanRequests))
-	i := 0
-	for a := range cleanRequests {
-		liveAdapters[i] = a
-		i++
-	}
-	// Randomize the list of adapters to make the auction more fair
-	randomizeList(liveAdapters)
 	// Process the request to check for targeting parameters.
 	var targData *targetData
 	shouldCacheBids := false
@@ -124,19 +129,47 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 			shouldCacheBids = requestExt.Prebid.Cache.Bids != nil
 			shouldCacheVAST = requestExt.Prebid.Cache.VastXML != nil
 		}
+	}
 
-		if requestExt.Prebid.Targeting != nil {
-			targData = &targetData{
-				priceGranularity:  requestExt.Prebid.Targeting.PriceGranularity,
-				includeWinners:    requestExt.Prebid.Targeting.IncludeWinners,
-				includeBidderKeys: requestExt.Prebid.Targeting.IncludeBidderKeys,
-			}
-			if shouldCacheBids {
-				targData.includeCacheBids = true
-			}
-			if shouldCacheVAST {
-				targData.includeCacheVast = true
-			}
+	// Imps with Stored Auction Responses are answered without calling the bidders
+	stored, errs := fetchStoredResponses(ctx, e.storedResponses, bidRequest.Imp)
+	liveRequest := prepareLiveRequest(bidRequest, stored)
+
+	// Price floors must be set on the imps before they're copied into the bidder requests
+	liveRequest, impFloors, floorErrs := e.applyFloors(ctx, liveRequest, requestExt.Prebid.Floors)
+	errs = append(errs, floorErrs...)
+
+	// Slice of BidRequests, each a copy of the original cleaned to only contain bidder data for the named bidder
+	blabels := make(map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels)
+	cleanRequests, aliases, cleanErrs := cleanOpenRTBRequests(ctx, liveRequest, usersyncs, blabels, labels, e.gDPR, e.UsersyncIfAmbiguous, account, e.hostSChainNode)
+	errs = append(errs, cleanErrs...)
+
+	// List of bidders we have requests for.
+	liveAdapters := make([]openrtb_ext.BidderName, len(cleanRequests))
+	i := 0
+	for a := range cleanRequests {
+		liveAdapters[i] = a
+		i++
+	}
+	// Randomize the list of adapters to make the auction more fair
+	randomizeList(liveAdapters)
+
+	// Fall back to the account's targeting defaults if the request didn't ask for targeting itself
+	if requestExt.Prebid.Targeting == nil && account != nil && account.DefaultTargeting != nil {
+		requestExt.Prebid.Targeting = account.DefaultTargeting
+	}
+
+	if requestExt.Prebid.Targeting != nil {
+		targData = &targetData{
+			priceGranularity:  requestExt.Prebid.Targeting.PriceGranularity,
+			includeWinners:    requestExt.Prebid.Targeting.IncludeWinners,
+			includeBidderKeys: requestExt.Prebid.Targeting.IncludeBidderKeys,
+		}
+		if shouldCacheBids {
+			targData.includeCacheBids = true
+		}
+		if shouldCacheVAST {
+			targData.includeCacheVast = true
 		}
 	}
 
@@ -148,19 +181,30 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 	// Get currency rates conversions for the auction
 	conversions := e.currencyConverter.Rates()
 
-	adapterBids, adapterExtra, anyBidsReturned := e.getAllBids(auctionCtx, cleanRequests, aliases, bidAdjustmentFactors, blabels, conversions)
+	adapterBids, adapterExtra, anyBidsReturned := e.getAllBids(auctionCtx, cleanRequests, aliases, bidAdjustmentFactors, blabels, conversions, stored.bidResponses, recorder)
+	if len(impFloors) > 0 {
+		anyBidsReturned = e.enforceFloors(impFloors, adapterBids, adapterExtra, aliases, blabels, conversions, recorder)
+	}
+
+	liveAdapters, storedBidsAdded := addStoredAuctionBids(bidRequest, stored.auctionResponses, liveAdapters, adapterBids, adapterExtra)
+	anyBidsReturned = anyBidsReturned || storedBidsAdded
+	recorder.recordStoredBids(adapterBids)
+
+	events := newEventTracking(e.externalURL, account, requestExt, auctionStart)
 
 	if anyBidsReturned {
-		bidCategory, adapterBids, err := applyCategoryMapping(ctx, requestExt, adapterBids, *categoriesFetcher, targData)
+		bidCategory, adapterBids, err := applyCategoryMapping(ctx, requestExt, adapterBids, *categoriesFetcher, targData, recorder)
 		if err != nil {
 			return nil, fmt.Errorf("Error in category mapping : %s", err.Error())
 		}
 
 		auc := newAuction(adapterBids, len(bidRequest.Imp))
+		recorder.recordWinners(auc)
 
 		if targData != nil {
 			auc.setRoundedPrices(targData.priceGranularity)
-			cacheErrs := auc.doCache(ctx, e.cache, targData, bidRequest, 60, &e.defaultTTLs, bidCategory)
+			defaultTTLs := accountTTLs(e.defaultTTLs, account)
+			cacheErrs := auc.doCache(ctx, e.cache, targData, bidRequest, 60, &defaultTTLs, bidCategory, events)
 			if len(cacheErrs) > 0 {
 				errs = append(errs, cacheErrs...)
 			}
@@ -169,7 +213,29 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 	}
 
 	// Build the response
-	return e.buildBidResponse(ctx, liveAdapters, adapterBids, bidRequest, resolvedRequest, adapterExtra, errs)
+	recorder.recordErrors(adapterExtra)
+	return e.buildBidResponse(ctx, liveAdapters, adapterBids, bidRequest, resolvedRequest, auctionDetails, adapterExtra, events, errs)
+}
+
+// accountTTLs overrides the host's default cache TTLs with any the account has set.
+func accountTTLs(hostTTLs config.DefaultTTLs, account *config.Account) config.DefaultTTLs {
+	ttls := hostTTLs
+	if account == nil {
+		return ttls
+	}
+	if account.CacheTTL.Banner > 0 {
+		ttls.Banner = account.CacheTTL.Banner
+	}
+	if account.CacheTTL.Video > 0 {
+		ttls.Video = account.CacheTTL.Video
+	}
+	if account.CacheTTL.Native > 0 {
+		ttls.Native = account.CacheTTL.Native
+	}
+	if account.CacheTTL.Audio > 0 {
+		ttls.Audio = account.CacheTTL.Audio
+	}
+	return ttls
 }
 
 func (e *exchange) makeAuctionContext(ctx context.Context, needsCache bool) (auctionCtx context.Context, cancel context.CancelFunc) {
@@ -184,7 +250,7 @@ func (e *exchange) makeAuctionContext(ctx context.Context, needsCache bool) (auc
 }
 
 // This piece sends all the requests to the bidder adapters and gathers the results.
-func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, bidAdjustments map[string]float64, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, conversions currencies.Conversions) (map[openrtb_ext.BidderName]*pbsOrtbSeatBid, map[openrtb_ext.BidderName]*seatResponseExtra, bool) {
+func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, bidAdjustments map[string]float64, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, conversions currencies.Conversions, storedBidResponses map[string]map[openrtb_ext.BidderName]json.RawMessage, recorder *auctionRecorder) (map[openrtb_ext.BidderName]*pbsOrtbSeatBid, map[openrtb_ext.BidderName]*seatResponseExtra, bool) {
 	// Set up pointers to the bid results
 	adapterBids := make(map[openrtb_ext.BidderName]*pbsOrtbSeatBid, len(cleanRequests))
 	adapterExtra := make(map[openrtb_ext.BidderName]*seatResponseExtra, len(cleanRequests))
@@ -214,7 +280,7 @@ func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext
 			}
 			var reqInfo adapters.ExtraRequestInfo
 			reqInfo.PbsEntryPoint = bidlabels.RType
-			bids, err := e.adapterMap[coreBidder].requestBid(ctx, request, aName, adjustmentFactor, conversions, &reqInfo)
+			bids, err := e.adapterMap[coreBidder].requestBid(ctx, request, aName, adjustmentFactor, conversions, &reqInfo, bidderStoredBidResponses(request, aName, storedBidResponses))
 
 			// Add in time reporting
 			elapsed := time.Since(start)
@@ -246,6 +312,7 @@ func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext
 		brw := <-chBids
 		adapterBids[brw.bidder] = brw.adapterBids
 		adapterExtra[brw.bidder] = brw.adapterExtra
+		recorder.recordBidder(brw.bidder, brw.adapterBids, brw.adapterExtra)
 
 		if !bidsFound && adapterBids[brw.bidder] != nil && len(adapterBids[brw.bidder].bids) > 0 {
 			bidsFound = true
@@ -311,7 +378,7 @@ func errsToBidderErrors(errs []error) []openrtb_ext.ExtBidderError {
 }
 
 // This piece takes all the bids supplied by the adapters and crafts an openRTB response to send back to the requester
-func (e *exchange) buildBidResponse(ctx context.Context, liveAdapters []openrtb_ext.BidderName, adapterBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, bidRequest *openrtb.BidRequest, resolvedRequest json.RawMessage, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra, errList []error) (*openrtb.BidResponse, error) {
+func (e *exchange) buildBidResponse(ctx context.Context, liveAdapters []openrtb_ext.BidderName, adapterBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, bidRequest *openrtb.BidRequest, resolvedRequest json.RawMessage, auctionDetails *analytics.AuctionDetails, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra, events *eventTracking, errList []error) (*openrtb.BidResponse, error) {
 	bidResponse := new(openrtb.BidResponse)
 
 	bidResponse.ID = bidRequest.ID
@@ -326,14 +393,14 @@ func (e *exchange) buildBidResponse(ctx context.Context, liveAdapters []openrtb_
 	for _, a := range liveAdapters {
 		//while processing every single bib, do we need to handle categories here?
 		if adapterBids[a] != nil && len(adapterBids[a].bids) > 0 {
-			sb := e.makeSeatBid(adapterBids[a], a, adapterExtra)
+			sb := e.makeSeatBid(adapterBids[a], a, adapterExtra, events)
 			seatBids = append(seatBids, *sb)
 		}
 	}
 
 	bidResponse.SeatBid = seatBids
 
-	bidResponseExt := e.makeExtBidResponse(adapterBids, adapterExtra, bidRequest, resolvedRequest, errList)
+	bidResponseExt := e.makeExtBidResponse(adapterBids, adapterExtra, bidRequest, resolvedRequest, auctionDetails, errList)
 	buffer := &bytes.Buffer{}
 	enc := json.NewEncoder(buffer)
 	enc.SetEscapeHTML(false)
@@ -343,13 +410,14 @@ func (e *exchange) buildBidResponse(ctx context.Context, liveAdapters []openrtb_
 	return bidResponse, err
 }
 
-func applyCategoryMapping(ctx context.Context, requestExt openrtb_ext.ExtRequest, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, categoriesFetcher stored_requests.CategoryFetcher, targData *targetData) (map[string]string, map[openrtb_ext.BidderName]*pbsOrtbSeatBid, error) {
+func applyCategoryMapping(ctx context.Context, requestExt openrtb_ext.ExtRequest, seatBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, categoriesFetcher stored_requests.CategoryFetcher, targData *targetData, recorder *auctionRecorder) (map[string]string, map[openrtb_ext.BidderName]*pbsOrtbSeatBid, error) {
 	res := make(map[string]string)
 
 	type bidDedupe struct {
 		bidderName openrtb_ext.BidderName
 		bidIndex   int
 		bidID      string
+		bid        *pbsOrtbBid
 	}
 
 	dedupe := make(map[string]bidDedupe)
@@ -394,6 +462,7 @@ func applyCategoryMapping(ctx context.Context, requestExt openrtb_ext.ExtRequest
 					//TODO: add metrics
 					//on receiving bids from adapters if no unique IAB category is returned  or if no ad server category is returned discard the bid
 					bidsToRemove = append(bidsToRemove, bidInd)
+					recorder.rejectBid(bid, analytics.BidRejectedCategory)
 					continue
 				} else {
 					//if unique IAB category is present then translate it to the adserver category based on mapping file
@@ -402,6 +471,7 @@ func applyCategoryMapping(ctx context.Context, requestExt openrtb_ext.ExtRequest
 						//TODO: add metrics
 						//if mapping required but no mapping file is found then discard the bid
 						bidsToRemove = append(bidsToRemove, bidInd)
+						recorder.rejectBid(bid, analytics.BidRejectedCategory)
 						continue
 					}
 				}
@@ -418,6 +488,7 @@ func applyCategoryMapping(ctx context.Context, requestExt openrtb_ext.ExtRequest
 				//if the bid is above the range of the listed durations (and outside the buffer), reject the bid
 				if duration > durationRange[len(durationRange)-1] {
 					bidsToRemove = append(bidsToRemove, bidInd)
+					recorder.rejectBid(bid, analytics.BidRejectedDuration)
 					continue
 				}
 				for _, dur := range durationRange {
@@ -433,6 +504,7 @@ func applyCategoryMapping(ctx context.Context, requestExt openrtb_ext.ExtRequest
 			if dupe, ok := dedupe[categoryDuration]; ok {
 				// 50% chance for either bid with duplicate categoryDuration values to be kept
 				if rand.Intn(100) < 50 {
+					recorder.rejectBid(dupe.bid, analytics.BidRejectedDuplicate)
 					if dupe.bidderName == bidderName {
 						// An older bid from the current bidder
 						bidsToRemove = append(bidsToRemove, dupe.bidIndex)
@@ -449,11 +521,12 @@ func applyCategoryMapping(ctx context.Context, requestExt openrtb_ext.ExtRequest
 				} else {
 					// Remove this bid
 					bidsToRemove = append(bidsToRemove, bidInd)
+					recorder.rejectBid(bid, analytics.BidRejectedDuplicate)
 					continue
 				}
 			}
 			res[bid.bid.ID] = categoryDuration
-			dedupe[categoryDuration] = bidDedupe{bidderName: bidderName, bidIndex: bidInd, bidID: bid.bid.ID}
+			dedupe[categoryDuration] = bidDedupe{bidderName: bidderName, bidIndex: bidInd, bidID: bid.bid.ID, bid: bid}
 		}
 
 		if len(bidsToRemove) > 0 {
@@ -499,7 +572,7 @@ func getPrimaryAdServer(adServerId int) (string, error) {
 }
 
 // Extract all the data from the SeatBids and build the ExtBidResponse
-func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra, req *openrtb.BidRequest, resolvedRequest json.RawMessage, errList []error) *openrtb_ext.ExtBidResponse {
+func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra, req *openrtb.BidRequest, resolvedRequest json.RawMessage, auctionDetails *analytics.AuctionDetails, errList []error) *openrtb_ext.ExtBidResponse {
 	bidResponseExt := &openrtb_ext.ExtBidResponse{
 		Errors:               make(map[openrtb_ext.BidderName][]openrtb_ext.ExtBidderError, len(adapterBids)),
 		ResponseTimeMillis:   make(map[openrtb_ext.BidderName]int, len(adapterBids)),
@@ -509,6 +582,11 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*pb
 		bidResponseExt.Debug = &openrtb_ext.ExtResponseDebug{
 			HttpCalls: make(map[openrtb_ext.BidderName][]*openrtb_ext.ExtHttpCall),
 		}
+		if auctionDetails != nil {
+			bidResponseExt.Debug.StoredRequestVersions = auctionDetails.StoredRequestVersions
+			bidResponseExt.Debug.StoredRequestConflicts = auctionDetails.StoredRequestConflicts
+			bidResponseExt.Debug.StoredRequestLineage = auctionDetails.StoredRequestLineage
+		}
 		if err := json.Unmarshal(resolvedRequest, &bidResponseExt.Debug.ResolvedRequest); err != nil {
 			glog.Errorf("Error unmarshalling bid request snapshot: %v", err)
 		}
@@ -535,7 +613,7 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*pb
 
 // Return an openrtb seatBid for a bidder
 // BuildBidResponse is responsible for ensuring nil bid seatbids are not included
-func (e *exchange) makeSeatBid(adapterBid *pbsOrtbSeatBid, adapter openrtb_ext.BidderName, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra) *openrtb.SeatBid {
+func (e *exchange) makeSeatBid(adapterBid *pbsOrtbSeatBid, adapter openrtb_ext.BidderName, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra, events *eventTracking) *openrtb.SeatBid {
 	seatBid := new(openrtb.SeatBid)
 	seatBid.Seat = adapter.String()
 	// Prebid cannot support roadblocking
@@ -558,7 +636,7 @@ func (e *exchange) makeSeatBid(adapterBid *pbsOrtbSeatBid, adapter openrtb_ext.B
 	}
 
 	var errList []error
-	seatBid.Bid, errList = e.makeBid(adapterBid.bids, adapter)
+	seatBid.Bid, errList = e.makeBid(adapterBid.bids, adapter, events)
 	if len(errList) > 0 {
 		adapterExtra[adapter].Errors = append(adapterExtra[adapter].Errors, errsToBidderErrors(errList)...)
 	}
@@ -567,7 +645,7 @@ func (e *exchange) makeSeatBid(adapterBid *pbsOrtbSeatBid, adapter openrtb_ext.B
 }
 
 // Create the Bid array inside of SeatBid
-func (e *exchange) makeBid(Bids []*pbsOrtbBid, adapter openrtb_ext.BidderName) ([]openrtb.Bid, []error) {
+func (e *exchange) makeBid(Bids []*pbsOrtbBid, adapter openrtb_ext.BidderName, events *eventTracking) ([]openrtb.Bid, []error) {
 	bids := make([]openrtb.Bid, 0, len(Bids))
 	errList := make([]error, 0, 1)
 	for _, thisBid := range Bids {
@@ -577,6 +655,7 @@ func (e *exchange) makeBid(Bids []*pbsOrtbBid, adapter openrtb_ext.BidderName) (
 				Targeting: thisBid.bidTargets,
 				Type:      thisBid.bidType,
 				Video:     thisBid.bidVideo,
+				Events:    events.makeBidExtEvents(thisBid.bid.ID, adapter),
 			},
 		}
 
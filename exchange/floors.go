@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/analytics"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/floors"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// applyFloors sets imp.bidfloor and imp.bidfloorcur on each imp which has a floor in the request's rules.
+// It returns a copy of the request with the floors set, along with the floors by imp ID.
+// If the request doesn't use price floors, it is returned as-is.
+func (e *exchange) applyFloors(ctx context.Context, bidRequest *openrtb.BidRequest, reqFloors *openrtb_ext.ExtRequestFloors) (*openrtb.BidRequest, map[string]floors.Floor, []error) {
+	if !floors.Enabled(e.priceFloors, reqFloors) {
+		return bidRequest, nil, nil
+	}
+	rules, err := floors.Rules(ctx, e.floorsFetcher, reqFloors)
+	if err != nil {
+		return bidRequest, nil, []error{err}
+	}
+	if rules == nil {
+		return bidRequest, nil, nil
+	}
+
+	flooredRequest := *bidRequest
+	flooredRequest.Imp = make([]openrtb.Imp, len(bidRequest.Imp))
+	impFloors := make(map[string]floors.Floor, len(bidRequest.Imp))
+	for i, imp := range bidRequest.Imp {
+		if floor, ok := rules.FloorForImp(&imp, bidRequest); ok {
+			imp.BidFloor = floor.Value
+			imp.BidFloorCur = floor.Currency
+			impFloors[imp.ID] = floor
+		}
+		flooredRequest.Imp[i] = imp
+	}
+	return &flooredRequest, impFloors, nil
+}
+
+// enforceFloors removes the bids whose price is below their imp's floor, once converted into the floor's currency.
+// Each rejected bid is reported as an error on its seat and recorded in the metrics.
+// It returns true if any bids remain.
+func (e *exchange) enforceFloors(impFloors map[string]floors.Floor, adapterBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra, aliases map[string]string, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, conversions currencies.Conversions, recorder *auctionRecorder) bool {
+	bidsFound := false
+	for bidderName, seatBid := range adapterBids {
+		if seatBid == nil {
+			continue
+		}
+		if len(impFloors) > 0 {
+			coreBidder := resolveBidder(string(bidderName), aliases)
+			keptBids := make([]*pbsOrtbBid, 0, len(seatBid.bids))
+			for _, bid := range seatBid.bids {
+				floor, ok := impFloors[bid.bid.ImpID]
+				if !ok {
+					keptBids = append(keptBids, bid)
+					continue
+				}
+				rate, err := conversions.GetRate(seatBid.currency, floor.Currency)
+				if err != nil {
+					addSeatError(adapterExtra, bidderName, fmt.Errorf("Unable to compare bid %s to the price floor: %v", bid.bid.ID, err))
+					keptBids = append(keptBids, bid)
+					continue
+				}
+				if bid.bid.Price*rate < floor.Value {
+					addSeatError(adapterExtra, bidderName, &errortypes.BidBelowFloor{
+						Message: fmt.Sprintf("Bid %s for imp %s was rejected because its price %f %s is below the floor of %f %s", bid.bid.ID, bid.bid.ImpID, bid.bid.Price, seatBid.currency, floor.Value, floor.Currency),
+					})
+					if labels, ok := blabels[coreBidder]; ok {
+						e.me.RecordAdapterFloorRejectedBid(*labels)
+					}
+					recorder.rejectBid(bid, analytics.BidRejectedBelowFloor)
+					continue
+				}
+				keptBids = append(keptBids, bid)
+			}
+			seatBid.bids = keptBids
+		}
+		if len(seatBid.bids) > 0 {
+			bidsFound = true
+		}
+	}
+	return bidsFound
+}
+
+func addSeatError(adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra, bidderName openrtb_ext.BidderName, err error) {
+	extra, ok := adapterExtra[bidderName]
+	if !ok || extra == nil {
+		extra = new(seatResponseExtra)
+		adapterExtra[bidderName] = extra
+	}
+	extra.Errors = append(extra.Errors, errsToBidderErrors([]error{err})...)
+}
@@ -0,0 +1,101 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/floors"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyFloors(t *testing.T) {
+	e := &exchange{priceFloors: config.PriceFloors{Enabled: true}}
+	request := &openrtb.BidRequest{
+		ID: "req-id",
+		Imp: []openrtb.Imp{
+			{ID: "imp-1", Banner: &openrtb.Banner{}},
+			{ID: "imp-2", Video: &openrtb.Video{}},
+		},
+	}
+	reqFloors := &openrtb_ext.ExtRequestFloors{
+		Data: &openrtb_ext.ExtFloorData{
+			Currency: "EUR",
+			Schema:   openrtb_ext.ExtFloorSchema{Fields: []string{"mediaType"}},
+			Values:   map[string]float64{"banner": 1.5},
+		},
+	}
+
+	flooredRequest, impFloors, errs := e.applyFloors(context.Background(), request, reqFloors)
+
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]floors.Floor{"imp-1": {Value: 1.5, Currency: "EUR"}}, impFloors)
+	if assert.Len(t, flooredRequest.Imp, 2) {
+		assert.Equal(t, 1.5, flooredRequest.Imp[0].BidFloor)
+		assert.Equal(t, "EUR", flooredRequest.Imp[0].BidFloorCur)
+		assert.Equal(t, 0.0, flooredRequest.Imp[1].BidFloor, "Imps without a matching rule shouldn't get a floor")
+	}
+	assert.Equal(t, 0.0, request.Imp[0].BidFloor, "The original request shouldn't be changed")
+}
+
+func TestApplyFloorsDisabled(t *testing.T) {
+	e := &exchange{priceFloors: config.PriceFloors{Enabled: false}}
+	request := &openrtb.BidRequest{ID: "req-id", Imp: []openrtb.Imp{{ID: "imp-1", Banner: &openrtb.Banner{}}}}
+	reqFloors := &openrtb_ext.ExtRequestFloors{
+		Data: &openrtb_ext.ExtFloorData{Default: 1.0},
+	}
+
+	flooredRequest, impFloors, errs := e.applyFloors(context.Background(), request, reqFloors)
+
+	assert.Empty(t, errs)
+	assert.Empty(t, impFloors)
+	assert.True(t, request == flooredRequest, "Requests shouldn't be copied if the host doesn't support price floors")
+}
+
+func TestEnforceFloors(t *testing.T) {
+	metricsMock := &pbsmetrics.MetricsEngineMock{}
+	metricsMock.On("RecordAdapterFloorRejectedBid", pbsmetrics.AdapterLabels{Adapter: openrtb_ext.BidderAppnexus}).Return()
+	e := &exchange{me: metricsMock}
+
+	impFloors := map[string]floors.Floor{
+		"imp-1": {Value: 1.0, Currency: "USD"},
+	}
+	adapterBids := map[openrtb_ext.BidderName]*pbsOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			currency: "EUR",
+			bids: []*pbsOrtbBid{
+				{bid: &openrtb.Bid{ID: "low", ImpID: "imp-1", Price: 0.8}},
+				{bid: &openrtb.Bid{ID: "high", ImpID: "imp-1", Price: 0.9}},
+				{bid: &openrtb.Bid{ID: "unfloored", ImpID: "imp-2", Price: 0.1}},
+			},
+		},
+	}
+	adapterExtra := map[openrtb_ext.BidderName]*seatResponseExtra{
+		openrtb_ext.BidderAppnexus: {},
+	}
+	blabels := map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{
+		openrtb_ext.BidderAppnexus: {Adapter: openrtb_ext.BidderAppnexus},
+	}
+	conversions := currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"EUR": {"USD": 1.2},
+	})
+
+	bidsFound := e.enforceFloors(impFloors, adapterBids, adapterExtra, nil, blabels, conversions, nil)
+
+	assert.True(t, bidsFound)
+	bids := adapterBids[openrtb_ext.BidderAppnexus].bids
+	if assert.Len(t, bids, 2) {
+		assert.Equal(t, "high", bids[0].bid.ID)
+		assert.Equal(t, "unfloored", bids[1].bid.ID)
+	}
+	if assert.Len(t, adapterExtra[openrtb_ext.BidderAppnexus].Errors, 1) {
+		assert.Equal(t, errortypes.BidBelowFloorCode, adapterExtra[openrtb_ext.BidderAppnexus].Errors[0].Code)
+	}
+	metricsMock.AssertNumberOfCalls(t, "RecordAdapterFloorRejectedBid", 1)
+}
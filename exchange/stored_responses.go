@@ -0,0 +1,183 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// storedResponses holds the Stored Responses which were requested through imp.ext.prebid.
+type storedResponses struct {
+	// auctionResponses maps imp IDs to the SeatBids which answer them. These imps aren't sent to any bidders.
+	auctionResponses map[string][]openrtb.SeatBid
+	// bidResponses maps imp IDs and bidders to the bodies which stand in for the bidders' HTTP responses to those imps.
+	bidResponses map[string]map[openrtb_ext.BidderName]json.RawMessage
+	// bidResponseBidders maps imp IDs to the bidders which have Stored Bid Responses for them.
+	bidResponseBidders map[string][]string
+}
+
+// fetchStoredResponses loads the storedauctionresponse and storedbidresponse IDs from each imp.ext.prebid.
+// Any IDs which can't be loaded produce errors, and are otherwise ignored.
+func fetchStoredResponses(ctx context.Context, fetcher stored_requests.ResponseFetcher, imps []openrtb.Imp) (stored storedResponses, errs []error) {
+	auctionIDs := make(map[string]string)
+	bidIDs := make(map[string]map[openrtb_ext.BidderName]string)
+	bidders := make(map[string][]string)
+	ids := make([]string, 0)
+
+	for _, imp := range imps {
+		rawPrebidExt, _, _, err := jsonparser.Get(imp.Ext, openrtb_ext.PrebidExtKey)
+		if err != nil {
+			continue
+		}
+		var prebidExt openrtb_ext.ExtImpPrebid
+		if err := json.Unmarshal(rawPrebidExt, &prebidExt); err != nil {
+			errs = append(errs, fmt.Errorf("Error decoding imp[id=%s].ext.prebid: %v", imp.ID, err))
+			continue
+		}
+		if prebidExt.StoredAuctionResponse != nil {
+			auctionIDs[imp.ID] = prebidExt.StoredAuctionResponse.ID
+			ids = append(ids, prebidExt.StoredAuctionResponse.ID)
+		}
+		for _, bidResponse := range prebidExt.StoredBidResponse {
+			if bidIDs[imp.ID] == nil {
+				bidIDs[imp.ID] = make(map[openrtb_ext.BidderName]string)
+			}
+			bidIDs[imp.ID][openrtb_ext.BidderName(bidResponse.Bidder)] = bidResponse.ID
+			bidders[imp.ID] = append(bidders[imp.ID], bidResponse.Bidder)
+			ids = append(ids, bidResponse.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	data, fetchErrs := fetcher.FetchResponses(ctx, ids)
+	errs = append(errs, fetchErrs...)
+
+	stored.auctionResponses = make(map[string][]openrtb.SeatBid, len(auctionIDs))
+	for impID, id := range auctionIDs {
+		if rawSeatBids, ok := data[id]; ok {
+			var seatBids []openrtb.SeatBid
+			if err := json.Unmarshal(rawSeatBids, &seatBids); err != nil {
+				errs = append(errs, fmt.Errorf("Error decoding Stored Auction Response %s: %v", id, err))
+				continue
+			}
+			stored.auctionResponses[impID] = seatBids
+		}
+	}
+
+	stored.bidResponseBidders = bidders
+	stored.bidResponses = make(map[string]map[openrtb_ext.BidderName]json.RawMessage, len(bidIDs))
+	for impID, impBidIDs := range bidIDs {
+		for bidder, id := range impBidIDs {
+			if body, ok := data[id]; ok {
+				if stored.bidResponses[impID] == nil {
+					stored.bidResponses[impID] = make(map[openrtb_ext.BidderName]json.RawMessage)
+				}
+				stored.bidResponses[impID][bidder] = body
+			}
+		}
+	}
+
+	return
+}
+
+// bidderStoredBidResponses returns the Stored Bid Responses which the bidder has for the imps in its request,
+// keyed by imp ID.
+func bidderStoredBidResponses(request *openrtb.BidRequest, bidder openrtb_ext.BidderName, bidResponses map[string]map[openrtb_ext.BidderName]json.RawMessage) map[string]json.RawMessage {
+	if len(bidResponses) == 0 {
+		return nil
+	}
+	var impResponses map[string]json.RawMessage
+	for _, imp := range request.Imp {
+		if body, ok := bidResponses[imp.ID][bidder]; ok {
+			if impResponses == nil {
+				impResponses = make(map[string]json.RawMessage)
+			}
+			impResponses[imp.ID] = body
+		}
+	}
+	return impResponses
+}
+
+// prepareLiveRequest returns a shallow copy of the request which is ready to be split among the bidders.
+// Imps with Stored Auction Responses are removed, and bidders with Stored Bid Responses are added to their imps
+// so that they take part even if the imp didn't name them.
+func prepareLiveRequest(bidRequest *openrtb.BidRequest, stored storedResponses) *openrtb.BidRequest {
+	if len(stored.auctionResponses) == 0 && len(stored.bidResponseBidders) == 0 {
+		return bidRequest
+	}
+	liveRequest := *bidRequest
+	liveRequest.Imp = make([]openrtb.Imp, 0, len(bidRequest.Imp))
+	for _, imp := range bidRequest.Imp {
+		if _, ok := stored.auctionResponses[imp.ID]; ok {
+			continue
+		}
+		for _, bidder := range stored.bidResponseBidders[imp.ID] {
+			if _, _, _, err := jsonparser.Get(imp.Ext, bidder); err == jsonparser.KeyPathNotFoundError {
+				ext := append(make([]byte, 0, len(imp.Ext)), imp.Ext...)
+				if newExt, err := jsonparser.Set(ext, []byte("{}"), bidder); err == nil {
+					imp.Ext = newExt
+				}
+			}
+		}
+		liveRequest.Imp = append(liveRequest.Imp, imp)
+	}
+	return &liveRequest
+}
+
+// addStoredAuctionBids adds the bids from Stored Auction Responses to the adapterBids, as though the seats had bid.
+// The new seats are appended to liveAdapters, and the function returns true if any bids were added.
+func addStoredAuctionBids(bidRequest *openrtb.BidRequest, auctionResponses map[string][]openrtb.SeatBid, liveAdapters []openrtb_ext.BidderName, adapterBids map[openrtb_ext.BidderName]*pbsOrtbSeatBid, adapterExtra map[openrtb_ext.BidderName]*seatResponseExtra) ([]openrtb_ext.BidderName, bool) {
+	bidsAdded := false
+	for _, imp := range bidRequest.Imp {
+		seatBids, ok := auctionResponses[imp.ID]
+		if !ok {
+			continue
+		}
+		bidType := storedBidType(&imp)
+		for _, seatBid := range seatBids {
+			seat := openrtb_ext.BidderName(seatBid.Seat)
+			if _, ok := adapterBids[seat]; !ok {
+				liveAdapters = append(liveAdapters, seat)
+			}
+			if adapterBids[seat] == nil {
+				adapterBids[seat] = &pbsOrtbSeatBid{currency: "USD"}
+			}
+			if adapterExtra[seat] == nil {
+				adapterExtra[seat] = &seatResponseExtra{}
+			}
+			for i := range seatBid.Bid {
+				bid := seatBid.Bid[i]
+				// Stored bids can be shared by many imps, so they always answer the imp which asked for them
+				bid.ImpID = imp.ID
+				adapterBids[seat].bids = append(adapterBids[seat].bids, &pbsOrtbBid{
+					bid:     &bid,
+					bidType: bidType,
+				})
+				bidsAdded = true
+			}
+		}
+	}
+	return liveAdapters, bidsAdded
+}
+
+// storedBidType guesses the type of a stored bid from the imp it answers.
+func storedBidType(imp *openrtb.Imp) openrtb_ext.BidType {
+	switch {
+	case imp.Video != nil:
+		return openrtb_ext.BidTypeVideo
+	case imp.Audio != nil:
+		return openrtb_ext.BidTypeAudio
+	case imp.Native != nil:
+		return openrtb_ext.BidTypeNative
+	default:
+		return openrtb_ext.BidTypeBanner
+	}
+}
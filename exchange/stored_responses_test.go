@@ -0,0 +1,84 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchStoredResponses(t *testing.T) {
+	fetcher := mockResponseFetcher{
+		"auction-resp": json.RawMessage(`[{"seat":"appnexus","bid":[{"id":"bid-1","price":1.5}]}]`),
+		"bid-resp":     json.RawMessage(`{"seatbid":[]}`),
+	}
+	imps := []openrtb.Imp{
+		{ID: "imp-1", Ext: json.RawMessage(`{"prebid":{"storedauctionresponse":{"id":"auction-resp"}}}`)},
+		{ID: "imp-2", Ext: json.RawMessage(`{"appnexus":{"placementId":1},"prebid":{"storedbidresponse":[{"bidder":"rubicon","id":"bid-resp"},{"bidder":"appnexus","id":"missing"}]}}`)},
+		{ID: "imp-3", Ext: json.RawMessage(`{"appnexus":{"placementId":1}}`)},
+	}
+
+	stored, errs := fetchStoredResponses(context.Background(), fetcher, imps)
+
+	assert.Len(t, errs, 1, "Missing Stored Responses should produce an error")
+	if assert.Len(t, stored.auctionResponses["imp-1"], 1) {
+		assert.Equal(t, "appnexus", stored.auctionResponses["imp-1"][0].Seat)
+	}
+	assert.Equal(t, map[openrtb_ext.BidderName]json.RawMessage{openrtb_ext.BidderRubicon: json.RawMessage(`{"seatbid":[]}`)}, stored.bidResponses["imp-2"], "Missing Stored Bid Responses shouldn't replace the bidder's call")
+	assert.Equal(t, []string{"rubicon", "appnexus"}, stored.bidResponseBidders["imp-2"])
+}
+
+func TestBidderStoredBidResponses(t *testing.T) {
+	bidResponses := map[string]map[openrtb_ext.BidderName]json.RawMessage{
+		"imp-1": {openrtb_ext.BidderAppnexus: json.RawMessage(`{"id":"resp-1"}`)},
+		"imp-2": {openrtb_ext.BidderAppnexus: json.RawMessage(`{"id":"resp-2"}`), openrtb_ext.BidderRubicon: json.RawMessage(`{"id":"resp-3"}`)},
+		"imp-3": {openrtb_ext.BidderAppnexus: json.RawMessage(`{"id":"resp-4"}`)},
+	}
+	request := &openrtb.BidRequest{Imp: []openrtb.Imp{{ID: "imp-1"}, {ID: "imp-2"}}}
+
+	assert.Equal(t, map[string]json.RawMessage{
+		"imp-1": json.RawMessage(`{"id":"resp-1"}`),
+		"imp-2": json.RawMessage(`{"id":"resp-2"}`),
+	}, bidderStoredBidResponses(request, openrtb_ext.BidderAppnexus, bidResponses), "Bidders should only get the responses for their own imps")
+	assert.Equal(t, map[string]json.RawMessage{
+		"imp-2": json.RawMessage(`{"id":"resp-3"}`),
+	}, bidderStoredBidResponses(request, openrtb_ext.BidderRubicon, bidResponses))
+	assert.Nil(t, bidderStoredBidResponses(request, openrtb_ext.BidderPubmatic, bidResponses))
+}
+
+func TestFetchStoredResponsesNone(t *testing.T) {
+	imps := []openrtb.Imp{{ID: "imp-1", Ext: json.RawMessage(`{"appnexus":{"placementId":1}}`)}}
+
+	// The fetcher shouldn't be called at all if no imps ask for Stored Responses
+	stored, errs := fetchStoredResponses(context.Background(), nil, imps)
+
+	assert.Empty(t, errs)
+	assert.Empty(t, stored.auctionResponses)
+	assert.Empty(t, stored.bidResponses)
+}
+
+func TestPrepareLiveRequest(t *testing.T) {
+	request := &openrtb.BidRequest{
+		ID: "req-id",
+		Imp: []openrtb.Imp{
+			{ID: "imp-1", Ext: json.RawMessage(`{"appnexus":{"placementId":1}}`)},
+			{ID: "imp-2", Ext: json.RawMessage(`{"appnexus":{"placementId":2}}`)},
+		},
+	}
+	stored := storedResponses{
+		auctionResponses:   map[string][]openrtb.SeatBid{"imp-1": {}},
+		bidResponseBidders: map[string][]string{"imp-2": {"rubicon", "appnexus"}},
+	}
+
+	liveRequest := prepareLiveRequest(request, stored)
+
+	if assert.Len(t, liveRequest.Imp, 1, "Imps with Stored Auction Responses shouldn't be sent to bidders") {
+		assert.Equal(t, "imp-2", liveRequest.Imp[0].ID)
+		assert.JSONEq(t, `{"appnexus":{"placementId":2},"rubicon":{}}`, string(liveRequest.Imp[0].Ext), "Bidders with Stored Bid Responses should be added to the imp")
+	}
+	assert.Len(t, request.Imp, 2, "The original request shouldn't be changed")
+	assert.JSONEq(t, `{"appnexus":{"placementId":2}}`, string(request.Imp[1].Ext), "The original imp shouldn't be changed")
+}
@@ -9,23 +9,29 @@ import (
 
 	"github.com/buger/jsonparser"
 	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
+	"github.com/prebid/prebid-server/privacy/ccpa"
 )
 
 // cleanOpenRTBRequests splits the input request into requests which are sanitized for each bidder. Intended behavior is:
 //
-//   1. BidRequest.Imp[].Ext will only contain the "prebid" field and a "bidder" field which has the params for the intended Bidder.
-//   2. Every BidRequest.Imp[] requested Bids from the Bidder who keys it.
-//   3. BidRequest.User.BuyerUID will be set to that Bidder's ID.
+//  1. BidRequest.Imp[].Ext will only contain the "prebid" field and a "bidder" field which has the params for the intended Bidder.
+//  2. Every BidRequest.Imp[] requested Bids from the Bidder who keys it.
+//  3. BidRequest.User.BuyerUID will be set to that Bidder's ID.
+//  4. Bidders which the account hasn't enabled are dropped.
+//  5. BidRequest.Source.Ext.SChain will be the Bidder's supply chain, with the host's node added at the end.
 func cleanOpenRTBRequests(ctx context.Context,
 	orig *openrtb.BidRequest,
 	usersyncs IdFetcher,
 	blables map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels,
 	labels pbsmetrics.Labels,
 	gDPR gdpr.Permissions,
-	usersyncIfAmbiguous bool) (requestsByBidder map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, errs []error) {
+	usersyncIfAmbiguous bool,
+	account *config.Account,
+	hostSChainNode *openrtb_ext.ExtRequestPrebidSChainSChainNode) (requestsByBidder map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, errs []error) {
 
 	impsByBidder, errs := splitImps(orig.Imp)
 	if len(errs) > 0 {
@@ -37,10 +43,18 @@ func cleanOpenRTBRequests(ctx context.Context,
 		return
 	}
 
-	requestsByBidder, errs = splitBidRequest(orig, impsByBidder, aliases, usersyncs, blables, labels)
+	sChainsByBidder, errs := parseSChains(orig)
+	if len(errs) > 0 {
+		return
+	}
+
+	requestsByBidder, errs = splitBidRequest(orig, impsByBidder, aliases, usersyncs, blables, labels, sChainsByBidder, hostSChainNode)
 
 	// Clean PI from bidrequests if not allowed per GDPR
 	gdpr := extractGDPR(orig, usersyncIfAmbiguous)
+	if account != nil && account.GDPR.Enabled != nil && !*account.GDPR.Enabled {
+		gdpr = 0
+	}
 	consent := extractConsent(orig)
 
 	// Check if it's an AMP request
@@ -52,32 +66,55 @@ func cleanOpenRTBRequests(ctx context.Context,
 		applyCOPPA = true
 	}
 
+	// Check if the user has opted out of the sale of their personal information under CCPA.
+	// The endpoints have already validated the policy, so a read error here is ignored.
+	ccpaPolicy, _ := ccpa.ReadPolicy(orig)
+	applyCCPA := ccpaPolicy.ShouldEnforce()
+
 	for bidder, bidReq := range requestsByBidder {
-		var applyGDPR bool
+		enforcement := regsEnforcement{
+			coppa: applyCOPPA,
+			ccpa:  applyCCPA,
+		}
+		coreBidder := resolveBidder(bidder.String(), aliases)
+		if account != nil && !account.BidderEnabled(coreBidder.String()) {
+			delete(requestsByBidder, bidder)
+			continue
+		}
+
 		// Fixes #820
 		if gdpr == 1 {
-			coreBidder := resolveBidder(bidder.String(), aliases)
-
 			var publisherID = labels.PubID
-			if ok, err := gDPR.PersonalInfoAllowed(ctx, coreBidder, publisherID, consent); !ok && err == nil {
-				applyGDPR = true
+			if permissions, err := gDPR.AuctionPermissions(ctx, coreBidder, publisherID, consent); err == nil {
+				// TCF 2.0 may forbid even basic ads, in which case the bidder isn't called at all
+				if !permissions.AllowBidRequest {
+					delete(requestsByBidder, bidder)
+					continue
+				}
+				enforcement.gdprIDs = !permissions.AllowUserIDs
+				enforcement.gdprGeo = !permissions.AllowPreciseGeo
 			}
 		}
 
-		if applyGDPR || applyCOPPA {
-			applyRegs(bidReq, isAMP, applyGDPR, applyCOPPA)
+		if enforcement.any() {
+			applyRegs(bidReq, isAMP, enforcement)
 		}
 	}
 
 	return
 }
 
-func splitBidRequest(req *openrtb.BidRequest, impsByBidder map[string][]openrtb.Imp, aliases map[string]string, usersyncs IdFetcher, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, labels pbsmetrics.Labels) (map[openrtb_ext.BidderName]*openrtb.BidRequest, []error) {
+func splitBidRequest(req *openrtb.BidRequest, impsByBidder map[string][]openrtb.Imp, aliases map[string]string, usersyncs IdFetcher, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, labels pbsmetrics.Labels, sChainsByBidder map[string]*openrtb_ext.ExtRequestPrebidSChainSChain, hostSChainNode *openrtb_ext.ExtRequestPrebidSChainSChainNode) (map[openrtb_ext.BidderName]*openrtb.BidRequest, []error) {
 	requestsByBidder := make(map[openrtb_ext.BidderName]*openrtb.BidRequest, len(impsByBidder))
 	explicitBuyerUIDs, err := extractBuyerUIDs(req.User)
 	if err != nil {
 		return nil, []error{err}
 	}
+	// Bidders shouldn't see the supply chains meant for other bidders
+	bidderExt := req.Ext
+	if len(sChainsByBidder) > 0 {
+		bidderExt = jsonparser.Delete(append(make([]byte, 0, len(req.Ext)), req.Ext...), openrtb_ext.PrebidExtKey, "schains")
+	}
 	for bidder, imps := range impsByBidder {
 		reqCopy := *req
 		coreBidder := resolveBidder(bidder, aliases)
@@ -97,6 +134,10 @@ func splitBidRequest(req *openrtb.BidRequest, impsByBidder map[string][]openrtb.
 			blabels[coreBidder].CookieFlag = pbsmetrics.CookieFlagYes
 		}
 		reqCopy.Imp = imps
+		reqCopy.Ext = bidderExt
+		if reqCopy.Source, err = prepareSource(req.Source, bidder, sChainsByBidder, hostSChainNode); err != nil {
+			return nil, []error{err}
+		}
 		requestsByBidder[openrtb_ext.BidderName(bidder)] = &reqCopy
 	}
 	return requestsByBidder, nil
@@ -194,6 +235,9 @@ func sanitizedImpCopy(imp *openrtb.Imp,
 	// in the sanitized imp
 	if err := json.Unmarshal(rawPrebidExt, &prebidExt); err == nil {
 		delete(prebidExt, "bidder")
+		// Bidders don't need to know which Stored Responses were asked for
+		delete(prebidExt, "storedauctionresponse")
+		delete(prebidExt, "storedbidresponse")
 
 		var err error
 		if rawPrebidExt, err = json.Marshal(prebidExt); err != nil {
@@ -303,6 +347,96 @@ func parseAliases(orig *openrtb.BidRequest) (map[string]string, []error) {
 	return aliases, nil
 }
 
+// parseSChains parses the supply chains from request.ext.prebid.schains, keyed by the bidders they're meant for.
+// The key "*" holds the supply chain for any bidders which weren't given their own.
+func parseSChains(orig *openrtb.BidRequest) (map[string]*openrtb_ext.ExtRequestPrebidSChainSChain, []error) {
+	value, dataType, _, err := jsonparser.Get(orig.Ext, openrtb_ext.PrebidExtKey, "schains")
+	if dataType != jsonparser.Array || err != nil {
+		if dataType != jsonparser.NotExist && err != jsonparser.KeyPathNotFoundError {
+			return nil, []error{err}
+		}
+		return nil, nil
+	}
+
+	var sChains []*openrtb_ext.ExtRequestPrebidSChain
+	if err := json.Unmarshal(value, &sChains); err != nil {
+		return nil, []error{err}
+	}
+	sChainsByBidder := make(map[string]*openrtb_ext.ExtRequestPrebidSChainSChain, len(sChains))
+	for _, sChain := range sChains {
+		if sChain == nil {
+			continue
+		}
+		for _, bidder := range sChain.Bidders {
+			if _, ok := sChainsByBidder[bidder]; ok {
+				return nil, []error{fmt.Errorf("request.ext.prebid.schains contains multiple schains for bidder %s; it must contain no more than one per bidder.", bidder)}
+			}
+			sChainsByBidder[bidder] = &sChain.SChain
+		}
+	}
+	return sChainsByBidder, nil
+}
+
+// prepareSource returns a copy of source with the given bidder's supply chain in source.ext.schain.
+// This will *not* mutate the source.
+//
+// The bidder's own schain from request.ext.prebid.schains is used first, then the "*" schain, and finally
+// the request's own source.ext.schain. If the host has a node, it is added to the end of the chosen chain.
+func prepareSource(source *openrtb.Source, bidder string, sChainsByBidder map[string]*openrtb_ext.ExtRequestPrebidSChainSChain, hostSChainNode *openrtb_ext.ExtRequestPrebidSChainSChainNode) (*openrtb.Source, error) {
+	sChain, ok := sChainsByBidder[bidder]
+	if !ok {
+		sChain = sChainsByBidder["*"]
+	}
+	if sChain == nil && hostSChainNode == nil {
+		return source, nil
+	}
+
+	var sourceExt map[string]json.RawMessage
+	if source != nil && len(source.Ext) > 0 {
+		if err := json.Unmarshal(source.Ext, &sourceExt); err != nil {
+			return nil, fmt.Errorf("request.source.ext is invalid: %v", err)
+		}
+	}
+	if sourceExt == nil {
+		sourceExt = make(map[string]json.RawMessage, 1)
+	}
+	if rawSChain, ok := sourceExt["schain"]; ok && sChain == nil {
+		sChain = new(openrtb_ext.ExtRequestPrebidSChainSChain)
+		if err := json.Unmarshal(rawSChain, sChain); err != nil {
+			return nil, fmt.Errorf("request.source.ext.schain is invalid: %v", err)
+		}
+	}
+
+	if hostSChainNode != nil {
+		// Requests without any supply chain still get one, holding just the host's node
+		var chainCopy openrtb_ext.ExtRequestPrebidSChainSChain
+		if sChain != nil {
+			chainCopy = *sChain
+		} else {
+			chainCopy.Ver = "1.0"
+		}
+		chainCopy.Nodes = append(append(make([]*openrtb_ext.ExtRequestPrebidSChainSChainNode, 0, len(chainCopy.Nodes)+1), chainCopy.Nodes...), hostSChainNode)
+		sChain = &chainCopy
+	}
+
+	rawSChain, err := json.Marshal(sChain)
+	if err != nil {
+		return nil, err
+	}
+	sourceExt["schain"] = rawSChain
+	rawSourceExt, err := json.Marshal(sourceExt)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceCopy openrtb.Source
+	if source != nil {
+		sourceCopy = *source
+	}
+	sourceCopy.Ext = rawSourceExt
+	return &sourceCopy, nil
+}
+
 // Quick little randomizer for a list of strings. Stuffing it in utils to keep other files clean
 func randomizeList(list []openrtb_ext.BidderName) {
 	l := len(list)
@@ -314,7 +448,23 @@ func randomizeList(list []openrtb_ext.BidderName) {
 	}
 }
 
-func applyRegs(bidRequest *openrtb.BidRequest, isAMP, applyGDPR, applyCOPPA bool) {
+// regsEnforcement lists the privacy regulations which apply to a single bidder's request
+type regsEnforcement struct {
+	gdprIDs bool // GDPR prevents user identifiers from being passed to the bidder
+	gdprGeo bool // GDPR prevents precise geolocation from being passed to the bidder
+	coppa   bool
+	ccpa    bool
+}
+
+func (e regsEnforcement) any() bool {
+	return e.gdprIDs || e.gdprGeo || e.coppa || e.ccpa
+}
+
+func applyRegs(bidRequest *openrtb.BidRequest, isAMP bool, enforcement regsEnforcement) {
+	removeIDs := enforcement.gdprIDs || enforcement.coppa || enforcement.ccpa
+	// A CCPA opt-out requires the same level of anonymization of the IP and geo as GDPR
+	anonymize := enforcement.gdprGeo || enforcement.ccpa
+
 	if bidRequest.User != nil {
 		// Need to duplicate pointer objects
 		user := *bidRequest.User
@@ -323,35 +473,72 @@ func applyRegs(bidRequest *openrtb.BidRequest, isAMP, applyGDPR, applyCOPPA bool
 		// There's no way for AMP to send a GDPR consent string yet so it's hard
 		// to know if the vendor is consented or not and therefore for AMP requests
 		// we keep the BuyerUID as is
-		if !isAMP {
+		if enforcement.gdprIDs && !isAMP {
+			bidRequest.User.BuyerUID = ""
+		}
+		// The us_privacy string is explicit about the user's opt-out, so the IDs
+		// are removed regardless of the endpoint
+		if enforcement.ccpa {
+			bidRequest.User.ID = ""
 			bidRequest.User.BuyerUID = ""
 		}
-		if applyCOPPA {
+		if enforcement.coppa {
 			bidRequest.User.ID = ""
 			bidRequest.User.Yob = 0
 			bidRequest.User.Gender = ""
 			bidRequest.User.BuyerUID = ""
 		}
-		bidRequest.User.Geo = cleanGeo(bidRequest.User.Geo, applyGDPR, applyCOPPA)
+		if removeIDs {
+			bidRequest.User.Ext = removeEIDs(bidRequest.User.Ext)
+		}
+		bidRequest.User.Geo = cleanGeo(bidRequest.User.Geo, anonymize, enforcement.coppa)
 	}
 	if bidRequest.Device != nil {
 		// Need to duplicate pointer objects
 		device := *bidRequest.Device
 		bidRequest.Device = &device
 
-		bidRequest.Device.DIDMD5 = ""
-		bidRequest.Device.DIDSHA1 = ""
-		bidRequest.Device.DPIDMD5 = ""
-		bidRequest.Device.DPIDSHA1 = ""
-		if applyCOPPA {
+		if removeIDs {
+			bidRequest.Device.DIDMD5 = ""
+			bidRequest.Device.DIDSHA1 = ""
+			bidRequest.Device.DPIDMD5 = ""
+			bidRequest.Device.DPIDSHA1 = ""
+		}
+		if enforcement.coppa || enforcement.ccpa {
 			bidRequest.Device.MACSHA1 = ""
 			bidRequest.Device.MACMD5 = ""
 			bidRequest.Device.IFA = ""
 		}
-		bidRequest.Device.IP = cleanIP(bidRequest.Device.IP)
-		bidRequest.Device.IPv6 = cleanIPV6(bidRequest.Device.IPv6, applyGDPR, applyCOPPA)
-		bidRequest.Device.Geo = cleanGeo(bidRequest.Device.Geo, applyGDPR, applyCOPPA)
+		if anonymize || enforcement.coppa {
+			bidRequest.Device.IP = cleanIP(bidRequest.Device.IP)
+		}
+		bidRequest.Device.IPv6 = cleanIPV6(bidRequest.Device.IPv6, anonymize, enforcement.coppa)
+		bidRequest.Device.Geo = cleanGeo(bidRequest.Device.Geo, anonymize, enforcement.coppa)
+	}
+}
+
+// removeEIDs drops request.user.ext.eids, leaving the rest of the extension untouched
+func removeEIDs(userExt json.RawMessage) json.RawMessage {
+	if len(userExt) == 0 {
+		return userExt
+	}
+
+	var ext map[string]json.RawMessage
+	if err := json.Unmarshal(userExt, &ext); err != nil {
+		return userExt
+	}
+	if _, ok := ext["eids"]; !ok {
+		return userExt
+	}
+
+	delete(ext, "eids")
+	if len(ext) == 0 {
+		return nil
+	}
+	if cleaned, err := json.Marshal(ext); err == nil {
+		return cleaned
 	}
+	return userExt
 }
 
 // Zero the last byte of an IP address
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
 	"github.com/stretchr/testify/assert"
@@ -31,6 +33,15 @@ func (p *permissionsMock) PersonalInfoAllowed(ctx context.Context, bidder openrt
 	return false, nil
 }
 
+func (p *permissionsMock) AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (gdpr.AuctionPermissions, error) {
+	allowPI, err := p.PersonalInfoAllowed(ctx, bidder, PublisherID, consent)
+	return gdpr.AuctionPermissions{
+		AllowBidRequest: true,
+		AllowUserIDs:    allowPI,
+		AllowPreciseGeo: allowPI,
+	}, err
+}
+
 func assertReq(t *testing.T, reqByBidders map[openrtb_ext.BidderName]*openrtb.BidRequest,
 	applyCOPPA bool, consentedVendors map[string]bool) {
 	// assert individual bidder requests
@@ -67,7 +78,7 @@ func TestCleanOpenRTBRequests(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		reqByBidders, _, err := cleanOpenRTBRequests(context.Background(), test.req, &emptyUsersync{}, map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{}, pbsmetrics.Labels{}, &permissionsMock{}, true)
+		reqByBidders, _, err := cleanOpenRTBRequests(context.Background(), test.req, &emptyUsersync{}, map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{}, pbsmetrics.Labels{}, &permissionsMock{}, true, &config.Account{}, nil)
 		if test.hasError {
 			assert.NotNil(t, err, "Error shouldn't be nil")
 		} else {
@@ -77,6 +88,58 @@ func TestCleanOpenRTBRequests(t *testing.T) {
 	}
 }
 
+func TestCleanOpenRTBRequestsCCPA(t *testing.T) {
+	testCases := []struct {
+		description string
+		usPrivacy   string
+		expectClean bool
+	}{
+		{
+			description: "Opted Out",
+			usPrivacy:   "1NYN",
+			expectClean: true,
+		},
+		{
+			description: "Not Opted Out",
+			usPrivacy:   "1NNN",
+			expectClean: false,
+		},
+		{
+			description: "Not Applicable",
+			usPrivacy:   "1---",
+			expectClean: false,
+		},
+		{
+			description: "Missing",
+			usPrivacy:   "",
+			expectClean: false,
+		},
+	}
+
+	for _, test := range testCases {
+		req := newBidRequest(t)
+		req.Regs = &openrtb.Regs{
+			Ext: json.RawMessage(`{"us_privacy":"` + test.usPrivacy + `"}`),
+		}
+
+		results, _, errs := cleanOpenRTBRequests(context.Background(), req, &emptyUsersync{}, map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{}, pbsmetrics.Labels{}, &permissionsMock{}, true, &config.Account{}, nil)
+		result := results["appnexus"]
+
+		assert.Nil(t, errs, test.description)
+		if test.expectClean {
+			assert.Empty(t, result.User.BuyerUID, test.description)
+			assert.Empty(t, result.User.ID, test.description)
+			assert.Empty(t, result.Device.DIDMD5, test.description)
+			assert.Equal(t, "132.173.230.0", result.Device.IP, test.description)
+		} else {
+			assert.NotEmpty(t, result.User.BuyerUID, test.description)
+			assert.NotEmpty(t, result.User.ID, test.description)
+			assert.NotEmpty(t, result.Device.DIDMD5, test.description)
+			assert.Equal(t, "132.173.230.74", result.Device.IP, test.description)
+		}
+	}
+}
+
 // newAdapterAliasBidRequest builds a BidRequest with aliases
 func newAdapterAliasBidRequest(t *testing.T) *openrtb.BidRequest {
 	dnt := int8(1)
@@ -340,6 +403,7 @@ func TestApplyRegs(t *testing.T) {
 			ID:       "123",
 			Yob:      2050,
 			Gender:   "Female",
+			Ext:      json.RawMessage(`{"consent":"BONV8oqONXwgmADACHENAO7pqzAAppY","eids":[{"source":"adserver.org","uids":[{"id":"111"}]}]}`),
 		},
 		Device: &openrtb.Device{
 			DIDMD5:  "teapot",
@@ -357,8 +421,7 @@ func TestApplyRegs(t *testing.T) {
 		bidReq      openrtb.BidRequest
 		cleanedIP   string
 		cleanedIPv6 string
-		applyCOPPA  bool
-		applyGDPR   bool
+		enforcement regsEnforcement
 		isAMP       bool
 		description string
 	}{
@@ -366,33 +429,61 @@ func TestApplyRegs(t *testing.T) {
 			bidReq:      bidReqOrig,
 			cleanedIP:   "12.123.56.0",
 			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0370:0",
-			applyGDPR:   true,
+			enforcement: regsEnforcement{gdprIDs: true, gdprGeo: true},
 			description: "Should clean recommended personal information for GDPR compliance",
 		},
 		{
 			bidReq:      bidReqOrig,
 			cleanedIP:   "12.123.56.0",
 			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0370:0",
-			applyGDPR:   true,
+			enforcement: regsEnforcement{gdprIDs: true, gdprGeo: true},
 			isAMP:       true,
 			description: "Should clean recommended personal information for GDPR compliance",
 		},
+		{
+			bidReq:      bidReqOrig,
+			cleanedIP:   "12.123.56.128",
+			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0370:7334",
+			enforcement: regsEnforcement{gdprIDs: true},
+			description: "Should only clean user identifiers when GDPR allows precise geolocation",
+		},
+		{
+			bidReq:      bidReqOrig,
+			cleanedIP:   "12.123.56.0",
+			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0370:0",
+			enforcement: regsEnforcement{gdprGeo: true},
+			description: "Should only clean geolocation when GDPR allows user identifiers",
+		},
 		{
 			bidReq:      bidReqOrig,
 			cleanedIP:   "12.123.56.0",
 			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0:0",
-			applyGDPR:   true,
+			enforcement: regsEnforcement{gdprIDs: true, gdprGeo: true, coppa: true},
 			isAMP:       true,
-			applyCOPPA:  true,
 			description: "Should clean recommended personal information for GDPR compliance",
 		},
 		{
 			bidReq:      bidReqOrig,
 			cleanedIP:   "12.123.56.0",
 			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0:0",
-			applyCOPPA:  true,
+			enforcement: regsEnforcement{coppa: true},
 			description: "Should clean recommended personal information for COPPA compliance",
 		},
+		{
+			bidReq:      bidReqOrig,
+			cleanedIP:   "12.123.56.0",
+			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0370:0",
+			enforcement: regsEnforcement{ccpa: true},
+			description: "Should clean recommended personal information for a CCPA opt-out",
+		},
+		{
+			bidReq:      bidReqOrig,
+			cleanedIP:   "12.123.56.0",
+			cleanedIPv6: "2001:0db8:85a3:0000:0000:8a2e:0370:0",
+			enforcement: regsEnforcement{ccpa: true},
+			isAMP:       true,
+			description: "Should clean the buyer UID of AMP requests for a CCPA opt-out",
+		},
 		{
 			bidReq:      openrtb.BidRequest{},
 			cleanedIP:   "",
@@ -404,27 +495,46 @@ func TestApplyRegs(t *testing.T) {
 	for _, test := range testCases {
 		// Make a shallow copy
 		bidReqCopy := test.bidReq
-		applyRegs(&bidReqCopy, test.isAMP, test.applyGDPR, test.applyCOPPA)
+		applyRegs(&bidReqCopy, test.isAMP, test.enforcement)
 
+		removeIDs := test.enforcement.gdprIDs || test.enforcement.coppa || test.enforcement.ccpa
 		if bidReqCopy.User != nil {
-			if test.isAMP && !test.applyCOPPA {
+			if !removeIDs || (test.isAMP && !test.enforcement.coppa && !test.enforcement.ccpa) {
 				assert.Equal(t, "abc123", bidReqCopy.User.BuyerUID, test.description)
 			} else {
 				assert.Empty(t, bidReqCopy.User.BuyerUID, test.description)
 			}
 
-			if test.applyCOPPA {
+			if removeIDs {
+				assert.JSONEq(t, `{"consent":"BONV8oqONXwgmADACHENAO7pqzAAppY"}`, string(bidReqCopy.User.Ext), test.description)
+			} else {
+				assert.Equal(t, bidReqOrig.User.Ext, bidReqCopy.User.Ext, test.description)
+			}
+
+			if test.enforcement.coppa {
 				assert.Empty(t, bidReqCopy.User.ID, test.description)
 				assert.Empty(t, bidReqCopy.User.Yob, test.description)
 				assert.Empty(t, bidReqCopy.User.Gender, test.description)
 			}
+
+			if test.enforcement.ccpa {
+				assert.Empty(t, bidReqCopy.User.ID, test.description)
+			}
 		}
 
 		if bidReqCopy.Device != nil {
-			if test.applyCOPPA {
+			if test.enforcement.coppa || test.enforcement.ccpa {
 				assert.Empty(t, bidReqCopy.Device.MACSHA1, test.description)
 			}
-			assert.Empty(t, bidReqCopy.Device.DIDMD5, test.description)
+			if (test.enforcement.gdprGeo || test.enforcement.ccpa) && !test.enforcement.coppa {
+				assert.Equal(t, 123.46, bidReqCopy.Device.Geo.Lat, test.description)
+				assert.Equal(t, 7.98, bidReqCopy.Device.Geo.Lon, test.description)
+			}
+			if removeIDs {
+				assert.Empty(t, bidReqCopy.Device.DIDMD5, test.description)
+			} else {
+				assert.Equal(t, "teapot", bidReqCopy.Device.DIDMD5, test.description)
+			}
 			assert.Equal(t, test.cleanedIP, bidReqCopy.Device.IP, test.description)
 			assert.Equal(t, test.cleanedIPv6, bidReqCopy.Device.IPv6, test.description)
 		}
@@ -438,3 +548,232 @@ func TestApplyRegs(t *testing.T) {
 		assert.Equal(t, 7.9836, bidReqOrig.Device.Geo.Lon)
 	}
 }
+
+func TestCleanOpenRTBRequestsTCF2(t *testing.T) {
+	testCases := []struct {
+		description     string
+		permissions     gdpr.AuctionPermissions
+		expectRequest   bool
+		expectBuyerUID  string
+		expectIP        string
+		expectDeviceMD5 string
+	}{
+		{
+			description:     "Full Pass-Through",
+			permissions:     gdpr.AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true, AllowPreciseGeo: true},
+			expectRequest:   true,
+			expectBuyerUID:  "their-id",
+			expectIP:        "132.173.230.74",
+			expectDeviceMD5: "some device ID hash",
+		},
+		{
+			description:     "Basic Ads Only",
+			permissions:     gdpr.AuctionPermissions{AllowBidRequest: true, AllowPreciseGeo: true},
+			expectRequest:   true,
+			expectBuyerUID:  "",
+			expectIP:        "132.173.230.74",
+			expectDeviceMD5: "",
+		},
+		{
+			description:     "No Precise Geo",
+			permissions:     gdpr.AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true},
+			expectRequest:   true,
+			expectBuyerUID:  "their-id",
+			expectIP:        "132.173.230.0",
+			expectDeviceMD5: "some device ID hash",
+		},
+		{
+			description:   "No Request",
+			permissions:   gdpr.AuctionPermissions{},
+			expectRequest: false,
+		},
+	}
+
+	for _, test := range testCases {
+		req := newBidRequest(t)
+		req.Regs = &openrtb.Regs{Ext: json.RawMessage(`{"gdpr":1}`)}
+
+		results, _, errs := cleanOpenRTBRequests(context.Background(), req, &emptyUsersync{}, map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{}, pbsmetrics.Labels{}, &fixedPermissions{test.permissions}, true, &config.Account{}, nil)
+		assert.Empty(t, errs, test.description)
+
+		result, found := results["appnexus"]
+		assert.Equal(t, test.expectRequest, found, test.description)
+		if test.expectRequest && found {
+			assert.Equal(t, test.expectBuyerUID, result.User.BuyerUID, test.description)
+			assert.Equal(t, test.expectIP, result.Device.IP, test.description)
+			assert.Equal(t, test.expectDeviceMD5, result.Device.DIDMD5, test.description)
+		}
+	}
+}
+
+func TestCleanOpenRTBRequestsAccount(t *testing.T) {
+	gdprDisabled := false
+	testCases := []struct {
+		description   string
+		account       config.Account
+		expectRequest bool
+		expectIP      string
+	}{
+		{
+			description:   "All Bidders Enabled",
+			account:       config.Account{},
+			expectRequest: true,
+			expectIP:      "132.173.230.0",
+		},
+		{
+			description:   "Bidder Enabled",
+			account:       config.Account{EnabledBidders: []string{"appnexus"}},
+			expectRequest: true,
+			expectIP:      "132.173.230.0",
+		},
+		{
+			description:   "Bidder Not Enabled",
+			account:       config.Account{EnabledBidders: []string{"rubicon"}},
+			expectRequest: false,
+		},
+		{
+			description:   "GDPR Disabled",
+			account:       config.Account{GDPR: config.AccountGDPR{Enabled: &gdprDisabled}},
+			expectRequest: true,
+			expectIP:      "132.173.230.74",
+		},
+	}
+
+	for _, test := range testCases {
+		req := newBidRequest(t)
+		req.Regs = &openrtb.Regs{Ext: json.RawMessage(`{"gdpr":1}`)}
+		permissions := gdpr.AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true}
+
+		results, _, errs := cleanOpenRTBRequests(context.Background(), req, &emptyUsersync{}, map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{}, pbsmetrics.Labels{}, &fixedPermissions{permissions}, true, &test.account, nil)
+		assert.Empty(t, errs, test.description)
+
+		result, found := results["appnexus"]
+		assert.Equal(t, test.expectRequest, found, test.description)
+		if test.expectRequest && found {
+			assert.Equal(t, test.expectIP, result.Device.IP, test.description)
+		}
+	}
+}
+
+func TestCleanOpenRTBRequestsSChain(t *testing.T) {
+	hostNode := &openrtb_ext.ExtRequestPrebidSChainSChainNode{ASI: "pbshost.com", SID: "host-sid", HP: 1}
+	testCases := []struct {
+		description  string
+		requestExt   json.RawMessage
+		sourceExt    json.RawMessage
+		hostNode     *openrtb_ext.ExtRequestPrebidSChainSChainNode
+		expectSource json.RawMessage
+		expectError  bool
+	}{
+		{
+			description:  "No SChain",
+			expectSource: nil,
+		},
+		{
+			description:  "Bidder SChain",
+			requestExt:   json.RawMessage(`{"prebid":{"schains":[{"bidders":["appnexus"],"schain":{"complete":1,"nodes":[{"asi":"directseller.com","sid":"00001","hp":1}],"ver":"1.0"}},{"bidders":["*"],"schain":{"complete":1,"nodes":[],"ver":"1.0"}}]}}`),
+			expectSource: json.RawMessage(`{"ext":{"schain":{"complete":1,"nodes":[{"asi":"directseller.com","sid":"00001","hp":1}],"ver":"1.0"}}}`),
+		},
+		{
+			description:  "Wildcard SChain",
+			requestExt:   json.RawMessage(`{"prebid":{"schains":[{"bidders":["*"],"schain":{"complete":0,"nodes":[{"asi":"directseller.com","sid":"00001","hp":1}],"ver":"1.0"}}]}}`),
+			expectSource: json.RawMessage(`{"ext":{"schain":{"complete":0,"nodes":[{"asi":"directseller.com","sid":"00001","hp":1}],"ver":"1.0"}}}`),
+		},
+		{
+			description:  "Request SChain with Host Node",
+			sourceExt:    json.RawMessage(`{"schain":{"complete":1,"nodes":[{"asi":"directseller.com","sid":"00001","hp":1}],"ver":"1.0"}}`),
+			hostNode:     hostNode,
+			expectSource: json.RawMessage(`{"ext":{"schain":{"complete":1,"nodes":[{"asi":"directseller.com","sid":"00001","hp":1},{"asi":"pbshost.com","sid":"host-sid","hp":1}],"ver":"1.0"}}}`),
+		},
+		{
+			description:  "Host Node Only",
+			hostNode:     hostNode,
+			expectSource: json.RawMessage(`{"ext":{"schain":{"complete":0,"nodes":[{"asi":"pbshost.com","sid":"host-sid","hp":1}],"ver":"1.0"}}}`),
+		},
+		{
+			description: "Duplicate Bidder",
+			requestExt:  json.RawMessage(`{"prebid":{"schains":[{"bidders":["appnexus"],"schain":{"ver":"1.0"}},{"bidders":["appnexus"],"schain":{"ver":"1.0"}}]}}`),
+			expectError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		req := newBidRequest(t)
+		req.Ext = test.requestExt
+		if test.sourceExt != nil {
+			req.Source = &openrtb.Source{Ext: test.sourceExt}
+		}
+
+		results, _, errs := cleanOpenRTBRequests(context.Background(), req, &emptyUsersync{}, map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{}, pbsmetrics.Labels{}, &permissionsMock{}, true, &config.Account{}, test.hostNode)
+
+		if test.expectError {
+			assert.NotEmpty(t, errs, test.description)
+			continue
+		}
+		assert.Empty(t, errs, test.description)
+		result := results["appnexus"]
+		if test.expectSource == nil {
+			assert.Nil(t, result.Source, test.description)
+		} else {
+			source, _ := json.Marshal(result.Source)
+			assert.JSONEq(t, string(test.expectSource), string(source), test.description)
+		}
+		if test.requestExt != nil {
+			assert.JSONEq(t, `{"prebid":{}}`, string(result.Ext), "%s: the schains shouldn't be sent to bidders", test.description)
+		}
+	}
+}
+
+// fixedPermissions returns the same AuctionPermissions for every bidder
+type fixedPermissions struct {
+	permissions gdpr.AuctionPermissions
+}
+
+func (p *fixedPermissions) HostCookiesAllowed(ctx context.Context, consent string) (bool, error) {
+	return true, nil
+}
+
+func (p *fixedPermissions) BidderSyncAllowed(ctx context.Context, bidder openrtb_ext.BidderName, consent string) (bool, error) {
+	return true, nil
+}
+
+func (p *fixedPermissions) PersonalInfoAllowed(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (bool, error) {
+	return p.permissions.AllowAll(), nil
+}
+
+func (p *fixedPermissions) AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (gdpr.AuctionPermissions, error) {
+	return p.permissions, nil
+}
+
+// newBidRequest builds a BidRequest for a single appnexus imp without any regulations
+func newBidRequest(t *testing.T) *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		Site: &openrtb.Site{
+			Page:   "www.some.domain.com",
+			Domain: "domain.com",
+			Publisher: &openrtb.Publisher{
+				ID: "some-publisher-id",
+			},
+		},
+		Device: &openrtb.Device{
+			DIDMD5: "some device ID hash",
+			UA:     "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_13_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/67.0.3396.87 Safari/537.36",
+			IFA:    "ifa",
+			IP:     "132.173.230.74",
+		},
+		User: &openrtb.User{
+			ID:       "our-id",
+			BuyerUID: "their-id",
+		},
+		Imp: []openrtb.Imp{{
+			ID: "some-imp-id",
+			Banner: &openrtb.Banner{
+				Format: []openrtb.Format{{
+					W: 300,
+					H: 250,
+				}},
+			},
+			Ext: json.RawMessage(`{"appnexus": {"placementId": 10433394}}`),
+		}},
+	}
+}
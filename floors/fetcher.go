@@ -0,0 +1,147 @@
+package floors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// Fetcher loads floor rules files from request.ext.prebid.floors.floorendpoint.url.
+//
+// Only the URLs in the host's allowed_urls are ever fetched, so the number of cached files is bounded by that list.
+// Each file is fetched the first time a request names it, and kept for the configured refresh rate.
+// Once a file goes stale, requests keep using the old rules while a new copy is fetched in the background.
+// Fetchers are safe for concurrent use.
+type Fetcher struct {
+	client      *http.Client
+	timeout     time.Duration
+	refreshRate time.Duration
+	allowed     map[string]struct{}
+
+	mutex sync.RWMutex
+	files map[string]*rulesFile
+}
+
+type rulesFile struct {
+	table      *Table
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// NewFetcher returns a Fetcher which uses the client to load rules files.
+func NewFetcher(client *http.Client, cfg config.PriceFloorsFetch) *Fetcher {
+	allowed := make(map[string]struct{}, len(cfg.AllowedURLs))
+	for _, url := range cfg.AllowedURLs {
+		allowed[url] = struct{}{}
+	}
+	return &Fetcher{
+		client:      client,
+		timeout:     time.Duration(cfg.TimeoutMS) * time.Millisecond,
+		refreshRate: time.Duration(cfg.RefreshRateSeconds) * time.Second,
+		allowed:     allowed,
+		files:       make(map[string]*rulesFile, len(allowed)),
+	}
+}
+
+// Fetch returns the rules in the file at url. It returns an error if the host doesn't allow that URL.
+func (f *Fetcher) Fetch(ctx context.Context, url string) (*Table, error) {
+	if _, ok := f.allowed[url]; !ok {
+		return nil, fmt.Errorf("Price floors can't be fetched from %s because it isn't in price_floors.fetch.allowed_urls", url)
+	}
+
+	f.mutex.RLock()
+	file, ok := f.files[url]
+	f.mutex.RUnlock()
+
+	if ok {
+		if time.Since(file.fetchedAt) >= f.refreshRate {
+			f.refreshInBackground(url)
+		}
+		return file.table, nil
+	}
+
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+	table, err := f.load(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	f.save(url, table)
+	return table, nil
+}
+
+func (f *Fetcher) refreshInBackground(url string) {
+	f.mutex.Lock()
+	file, ok := f.files[url]
+	if !ok || file.refreshing {
+		f.mutex.Unlock()
+		return
+	}
+	file.refreshing = true
+	f.mutex.Unlock()
+
+	go func() {
+		ctx := context.Background()
+		if f.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, f.timeout)
+			defer cancel()
+		}
+		table, err := f.load(ctx, url)
+		if err != nil {
+			glog.Errorf("Failed to refresh price floors from %s: %v", url, err)
+			f.mutex.Lock()
+			file.refreshing = false
+			f.mutex.Unlock()
+			return
+		}
+		f.save(url, table)
+	}()
+}
+
+func (f *Fetcher) save(url string, table *Table) {
+	f.mutex.Lock()
+	f.files[url] = &rulesFile{
+		table:     table,
+		fetchedAt: time.Now(),
+	}
+	f.mutex.Unlock()
+}
+
+func (f *Fetcher) load(ctx context.Context, url string) (*Table, error) {
+	httpResp, err := ctxhttp.Get(ctx, f.client, url)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching price floors from %s: %v", url, err)
+	}
+	defer httpResp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading price floors from %s: %v", url, err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error fetching price floors from %s: status %d", url, httpResp.StatusCode)
+	}
+
+	var data openrtb_ext.ExtFloorData
+	if err := json.Unmarshal(respBytes, &data); err != nil {
+		return nil, fmt.Errorf("Error decoding price floors from %s: %v", url, err)
+	}
+	table, err := NewTable(&data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid price floors from %s: %v", url, err)
+	}
+	return table, nil
+}
@@ -0,0 +1,75 @@
+package floors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchCachesRules(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"currency":"USD","schema":{"fields":["mediaType"]},"values":{"banner":1.5}}`))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(server.Client(), config.PriceFloorsFetch{TimeoutMS: 1000, RefreshRateSeconds: 300, AllowedURLs: []string{server.URL}})
+
+	for i := 0; i < 2; i++ {
+		table, err := fetcher.Fetch(context.Background(), server.URL)
+		assert.NoError(t, err)
+		if assert.NotNil(t, table) {
+			assert.Equal(t, map[string]float64{"banner": 1.5}, table.rules)
+		}
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "Rules files should only be fetched once within the refresh rate")
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bad-json":
+			w.Write([]byte(`{"values":`))
+		case "/too-many-fields":
+			w.Write([]byte(`{"schema":{"fields":["size","size","size","size","size","size","size"]},"values":{}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(server.Client(), config.PriceFloorsFetch{
+		TimeoutMS:          1000,
+		RefreshRateSeconds: 300,
+		AllowedURLs:        []string{server.URL + "/bad-json", server.URL + "/too-many-fields", server.URL + "/missing"},
+	})
+
+	_, err := fetcher.Fetch(context.Background(), server.URL+"/bad-json")
+	assert.Error(t, err, "Malformed rules files should produce an error")
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/too-many-fields")
+	assert.Error(t, err, "Rules files with too many schema fields should produce an error")
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/missing")
+	assert.Error(t, err, "Non-200 responses should produce an error")
+}
+
+func TestFetchOnlyAllowedURLs(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"currency":"USD","schema":{"fields":["mediaType"]},"values":{"banner":1.5}}`))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(server.Client(), config.PriceFloorsFetch{TimeoutMS: 1000, RefreshRateSeconds: 300, AllowedURLs: []string{server.URL + "/rules.json"}})
+
+	_, err := fetcher.Fetch(context.Background(), server.URL+"/other.json")
+	assert.Error(t, err, "URLs which the host doesn't allow should produce an error")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls), "URLs which the host doesn't allow should never be fetched")
+	assert.Empty(t, fetcher.files, "URLs which the host doesn't allow should never be cached")
+}
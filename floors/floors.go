@@ -0,0 +1,34 @@
+// Package floors implements price floors.
+//
+// Floors come from the rules table in request.ext.prebid.floors.data, which may be set by the request itself,
+// merged in from a Stored Request, or loaded from the rules file at request.ext.prebid.floors.floorendpoint.url.
+// The exchange uses them to set imp.bidfloor before calling the bidders, and to reject any bids which come in
+// below the floor.
+package floors
+
+import (
+	"context"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// Enabled returns true if the host supports price floors and the request hasn't opted out of them.
+func Enabled(cfg config.PriceFloors, reqFloors *openrtb_ext.ExtRequestFloors) bool {
+	if !cfg.Enabled || reqFloors == nil {
+		return false
+	}
+	return reqFloors.Enabled == nil || *reqFloors.Enabled
+}
+
+// Rules returns the rules table for the request. Rules defined in the request take precedence over the rules file.
+// If neither is present, the table will be nil.
+func Rules(ctx context.Context, fetcher *Fetcher, reqFloors *openrtb_ext.ExtRequestFloors) (*Table, error) {
+	if reqFloors.Data != nil {
+		return NewTable(reqFloors.Data)
+	}
+	if reqFloors.FloorEndpoint == nil || reqFloors.FloorEndpoint.URL == "" || fetcher == nil {
+		return nil, nil
+	}
+	return fetcher.Fetch(ctx, reqFloors.FloorEndpoint.URL)
+}
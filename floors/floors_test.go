@@ -0,0 +1,32 @@
+package floors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	data := &openrtb_ext.ExtFloorData{Values: map[string]float64{"*": 1.0}}
+
+	rules, err := Rules(context.Background(), nil, &openrtb_ext.ExtRequestFloors{Data: data})
+	assert.NoError(t, err)
+	if assert.NotNil(t, rules, "Rules in the request should be used") {
+		assert.Equal(t, map[string]float64{"*": 1.0}, rules.rules)
+	}
+
+	rules, err = Rules(context.Background(), nil, &openrtb_ext.ExtRequestFloors{})
+	assert.NoError(t, err)
+	assert.Nil(t, rules)
+}
+
+func TestEnabled(t *testing.T) {
+	disabled := false
+	assert.False(t, Enabled(config.PriceFloors{Enabled: false}, &openrtb_ext.ExtRequestFloors{}), "Hosts must enable price floors")
+	assert.False(t, Enabled(config.PriceFloors{Enabled: true}, nil), "Requests without floors shouldn't use them")
+	assert.False(t, Enabled(config.PriceFloors{Enabled: true}, &openrtb_ext.ExtRequestFloors{Enabled: &disabled}), "Requests should be able to opt out")
+	assert.True(t, Enabled(config.PriceFloors{Enabled: true}, &openrtb_ext.ExtRequestFloors{}))
+}
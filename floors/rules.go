@@ -0,0 +1,212 @@
+package floors
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+const (
+	defaultCurrency  = "USD"
+	defaultDelimiter = "|"
+	wildcard         = "*"
+
+	fieldMediaType  = "mediatype"
+	fieldSize       = "size"
+	fieldDomain     = "domain"
+	fieldAdUnitCode = "adunitcode"
+
+	// MaxSchemaFields caps the fields in a rules table's schema. Matching tries every mix of wildcards,
+	// so each extra field doubles the work per imp.
+	MaxSchemaFields = 6
+)
+
+// Floor is the price floor which applies to a single imp.
+type Floor struct {
+	Value    float64
+	Currency string
+}
+
+// Table is a rules table which is ready to match imps. Tables are safe for concurrent use.
+type Table struct {
+	fields    []string
+	delimiter string
+	// rules holds the table's values under their lower-cased keys.
+	rules map[string]float64
+	floor Floor
+}
+
+// ValidateData returns an error if the rules table can't be used.
+func ValidateData(data *openrtb_ext.ExtFloorData) error {
+	if len(data.Schema.Fields) > MaxSchemaFields {
+		return fmt.Errorf("Price floors schema has %d fields, but can't have more than %d", len(data.Schema.Fields), MaxSchemaFields)
+	}
+	return nil
+}
+
+// NewTable validates the rules table and prepares it for matching.
+func NewTable(data *openrtb_ext.ExtFloorData) (*Table, error) {
+	if err := ValidateData(data); err != nil {
+		return nil, err
+	}
+	table := &Table{
+		fields:    data.Schema.Fields,
+		delimiter: data.Schema.Delimiter,
+		rules:     make(map[string]float64, len(data.Values)),
+		floor: Floor{
+			Value:    data.Default,
+			Currency: data.Currency,
+		},
+	}
+	if table.delimiter == "" {
+		table.delimiter = defaultDelimiter
+	}
+	if table.floor.Currency == "" {
+		table.floor.Currency = defaultCurrency
+	}
+	for key, value := range data.Values {
+		table.rules[strings.ToLower(key)] = value
+	}
+	return table, nil
+}
+
+// FloorForImp finds the floor for an imp in the rules table.
+//
+// When several rules match, the one with the fewest wildcards wins. Ties go to the rule which is specific
+// in the earlier Schema fields. If no rule matches, the table's Default is used.
+// The boolean is false if the imp has no floor at all.
+func (t *Table) FloorForImp(imp *openrtb.Imp, request *openrtb.BidRequest) (Floor, bool) {
+	if t == nil {
+		return Floor{}, false
+	}
+	floor := t.floor
+	if value, ok := t.matchRule(impValues(t.fields, imp, request)); ok {
+		floor.Value = value
+	}
+	return floor, floor.Value > 0
+}
+
+// impValues returns the imp's value for each field in the schema. Unknown fields are treated as wildcards.
+func impValues(fields []string, imp *openrtb.Imp, request *openrtb.BidRequest) []string {
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		switch strings.ToLower(field) {
+		case fieldMediaType:
+			values[i] = mediaType(imp)
+		case fieldSize:
+			values[i] = size(imp)
+		case fieldDomain:
+			values[i] = domain(request)
+		case fieldAdUnitCode:
+			values[i] = imp.TagID
+		}
+		if values[i] == "" {
+			values[i] = wildcard
+		}
+	}
+	return values
+}
+
+// matchRule looks up the most specific rule which matches the values.
+func (t *Table) matchRule(values []string) (float64, bool) {
+	if len(values) == 0 || len(t.rules) == 0 {
+		return 0, false
+	}
+
+	// Bit i of a mask replaces the value of field len(values)-1-i with a wildcard, so that lower masks
+	// keep the earlier fields specific.
+	masks := make([]int, 1<<uint(len(values)))
+	for i := range masks {
+		masks[i] = i
+	}
+	sort.SliceStable(masks, func(i, j int) bool {
+		return countBits(masks[i]) < countBits(masks[j])
+	})
+
+	candidate := make([]string, len(values))
+	for _, mask := range masks {
+		for i, value := range values {
+			if mask&(1<<uint(len(values)-1-i)) != 0 {
+				candidate[i] = wildcard
+			} else {
+				candidate[i] = strings.ToLower(value)
+			}
+		}
+		if value, ok := t.rules[strings.Join(candidate, t.delimiter)]; ok {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+func countBits(mask int) int {
+	count := 0
+	for ; mask > 0; mask >>= 1 {
+		count += mask & 1
+	}
+	return count
+}
+
+// mediaType returns the imp's only media type. Imps with more than one type only match wildcards.
+func mediaType(imp *openrtb.Imp) string {
+	types := make([]openrtb_ext.BidType, 0, 1)
+	if imp.Banner != nil {
+		types = append(types, openrtb_ext.BidTypeBanner)
+	}
+	if imp.Video != nil {
+		types = append(types, openrtb_ext.BidTypeVideo)
+	}
+	if imp.Audio != nil {
+		types = append(types, openrtb_ext.BidTypeAudio)
+	}
+	if imp.Native != nil {
+		types = append(types, openrtb_ext.BidTypeNative)
+	}
+	if len(types) != 1 {
+		return wildcard
+	}
+	return string(types[0])
+}
+
+// size returns the imp's only size as "WxH". Imps with several sizes only match wildcards.
+func size(imp *openrtb.Imp) string {
+	var w, h uint64
+	switch mediaType(imp) {
+	case string(openrtb_ext.BidTypeBanner):
+		if len(imp.Banner.Format) == 1 {
+			w, h = imp.Banner.Format[0].W, imp.Banner.Format[0].H
+		} else if len(imp.Banner.Format) == 0 && imp.Banner.W != nil && imp.Banner.H != nil {
+			w, h = *imp.Banner.W, *imp.Banner.H
+		}
+	case string(openrtb_ext.BidTypeVideo):
+		w, h = imp.Video.W, imp.Video.H
+	}
+	if w == 0 || h == 0 {
+		return wildcard
+	}
+	return strconv.FormatUint(w, 10) + "x" + strconv.FormatUint(h, 10)
+}
+
+func domain(request *openrtb.BidRequest) string {
+	if request.Site != nil {
+		if request.Site.Domain != "" {
+			return request.Site.Domain
+		}
+		if request.Site.Publisher != nil {
+			return request.Site.Publisher.Domain
+		}
+	}
+	if request.App != nil {
+		if request.App.Domain != "" {
+			return request.App.Domain
+		}
+		if request.App.Publisher != nil {
+			return request.App.Publisher.Domain
+		}
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package floors
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloorForImp(t *testing.T) {
+	data := &openrtb_ext.ExtFloorData{
+		Currency: "EUR",
+		Schema: openrtb_ext.ExtFloorSchema{
+			Fields: []string{"mediaType", "size", "domain"},
+		},
+		Values: map[string]float64{
+			"banner|300x250|example.com": 1.5,
+			"banner|*|example.com":       1.0,
+			"banner|300x250|*":           1.2,
+			"video|*|*":                  3.0,
+			"*|*|*":                      0.5,
+		},
+		Default: 0.1,
+	}
+	request := &openrtb.BidRequest{Site: &openrtb.Site{Domain: "Example.com"}}
+
+	testCases := []struct {
+		description string
+		imp         openrtb.Imp
+		expected    float64
+	}{
+		{
+			description: "Exact match",
+			imp:         openrtb.Imp{ID: "1", Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 300, H: 250}}}},
+			expected:    1.5,
+		},
+		{
+			description: "Fewest wildcards, with earlier fields kept specific",
+			imp:         openrtb.Imp{ID: "2", Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 300, H: 600}}}},
+			expected:    1.0,
+		},
+		{
+			description: "Several sizes only match wildcards",
+			imp:         openrtb.Imp{ID: "3", Banner: &openrtb.Banner{Format: []openrtb.Format{{W: 300, H: 250}, {W: 300, H: 600}}}},
+			expected:    1.0,
+		},
+		{
+			description: "Media type match",
+			imp:         openrtb.Imp{ID: "4", Video: &openrtb.Video{W: 640, H: 480}},
+			expected:    3.0,
+		},
+		{
+			description: "Several media types only match wildcards",
+			imp:         openrtb.Imp{ID: "5", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}},
+			expected:    0.5,
+		},
+	}
+
+	table, err := NewTable(data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, test := range testCases {
+		floor, ok := table.FloorForImp(&test.imp, request)
+		assert.True(t, ok, test.description)
+		assert.Equal(t, Floor{Value: test.expected, Currency: "EUR"}, floor, test.description)
+	}
+}
+
+func TestFloorForImpDefault(t *testing.T) {
+	data := &openrtb_ext.ExtFloorData{
+		Schema: openrtb_ext.ExtFloorSchema{
+			Fields:    []string{"adUnitCode"},
+			Delimiter: ";",
+		},
+		Values:  map[string]float64{"top-banner": 2.0},
+		Default: 0.25,
+	}
+	request := &openrtb.BidRequest{}
+	table, err := NewTable(data)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	floor, ok := table.FloorForImp(&openrtb.Imp{ID: "1", TagID: "top-banner"}, request)
+	assert.True(t, ok)
+	assert.Equal(t, Floor{Value: 2.0, Currency: "USD"}, floor, "Floors without a currency should be in USD")
+
+	floor, ok = table.FloorForImp(&openrtb.Imp{ID: "2", TagID: "sidebar"}, request)
+	assert.True(t, ok)
+	assert.Equal(t, 0.25, floor.Value, "Imps without a matching rule should use the default")
+
+	data.Default = 0
+	table, _ = NewTable(data)
+	_, ok = table.FloorForImp(&openrtb.Imp{ID: "2", TagID: "sidebar"}, request)
+	assert.False(t, ok, "Imps without a matching rule or a default shouldn't have a floor")
+}
+
+func TestFloorForImpNoRules(t *testing.T) {
+	var table *Table
+	_, ok := table.FloorForImp(&openrtb.Imp{ID: "1"}, &openrtb.BidRequest{})
+	assert.False(t, ok)
+}
+
+func TestNewTableTooManyFields(t *testing.T) {
+	data := &openrtb_ext.ExtFloorData{
+		Schema: openrtb_ext.ExtFloorSchema{
+			Fields: []string{"mediaType", "size", "domain", "adUnitCode", "mediaType", "size", "domain"},
+		},
+		Values: map[string]float64{"*|*|*|*|*|*|*": 1.0},
+	}
+
+	_, err := NewTable(data)
+	assert.EqualError(t, err, "Price floors schema has 7 fields, but can't have more than 6")
+
+	data.Schema.Fields = data.Schema.Fields[:MaxSchemaFields]
+	_, err = NewTable(data)
+	assert.NoError(t, err)
+}
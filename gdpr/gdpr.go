@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/prebid/go-gdpr/vendorlist"
 	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/openrtb_ext"
 )
@@ -23,6 +24,28 @@ type Permissions interface {
 	//
 	// If the consent string was nonsenical, the returned error will be an ErrorMalformedConsent.
 	PersonalInfoAllowed(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (bool, error)
+
+	// Determines how much of the auction the given bidder may take part in. TCF 1.1 consent strings either allow
+	// or mask out all personal info, while TCF 2.0 consent strings may also limit a bidder to basic ads, or
+	// prevent it from receiving a bid request at all.
+	//
+	// If the consent string was nonsenical, the returned error will be an ErrorMalformedConsent.
+	AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (AuctionPermissions, error)
+}
+
+// AuctionPermissions describes what may be sent to a bidder during an auction.
+type AuctionPermissions struct {
+	// AllowBidRequest is false if the bidder must not be called at all.
+	AllowBidRequest bool
+	// AllowUserIDs is false if user identifiers (buyeruid, eids, device IDs) must be removed, leaving basic ads only.
+	AllowUserIDs bool
+	// AllowPreciseGeo is false if the IP address and geolocation must be truncated.
+	AllowPreciseGeo bool
+}
+
+// AllowAll returns true if the bidder may receive the bid request unaltered.
+func (p AuctionPermissions) AllowAll() bool {
+	return p.AllowBidRequest && p.AllowUserIDs && p.AllowPreciseGeo
 }
 
 // NewPermissions gets an instance of the Permissions for use elsewhere in the project.
@@ -33,9 +56,10 @@ func NewPermissions(ctx context.Context, cfg config.GDPR, vendorIDs map[openrtb_
 	}
 
 	return &permissionsImpl{
-		cfg:             cfg,
-		vendorIDs:       vendorIDs,
-		fetchVendorList: newVendorListFetcher(ctx, cfg, client, vendorListURLMaker),
+		cfg:               cfg,
+		vendorIDs:         vendorIDs,
+		fetchVendorList:   newVendorListFetcher(ctx, cfg, client, vendorListURLMaker, vendorlist.ParseEagerly),
+		fetchVendorListV2: newVendorListFetcher(ctx, cfg, client, vendorListV2URLMaker, parseVendorListV2),
 	}
 }
 
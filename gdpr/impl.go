@@ -16,9 +16,22 @@ import (
 // Nothing in this file is exported. Public APIs can be found in gdpr.go
 
 type permissionsImpl struct {
-	cfg             config.GDPR
-	vendorIDs       map[openrtb_ext.BidderName]uint16
-	fetchVendorList func(ctx context.Context, id uint16) (vendorlist.VendorList, error)
+	cfg               config.GDPR
+	vendorIDs         map[openrtb_ext.BidderName]uint16
+	fetchVendorList   func(ctx context.Context, id uint16) (vendorlist.VendorList, error)
+	fetchVendorListV2 func(ctx context.Context, id uint16) (vendorlist.VendorList, error)
+}
+
+// parsedConsent is the part of a parsed consent string which TCF 1.1 and 2.0 have in common.
+type parsedConsent interface {
+	VendorListVersion() uint16
+	PurposeAllowed(id consentconstants.Purpose) bool
+	VendorConsent(id uint16) bool
+}
+
+// flexibleVendor is implemented by TCF 2.0 vendors, whose purposes may be declared as flexible.
+type flexibleVendor interface {
+	FlexiblePurpose(purpose consentconstants.Purpose) bool
 }
 
 func (p *permissionsImpl) HostCookiesAllowed(ctx context.Context, consent string) (bool, error) {
@@ -39,21 +52,26 @@ func (p *permissionsImpl) BidderSyncAllowed(ctx context.Context, bidder openrtb_
 }
 
 func (p *permissionsImpl) PersonalInfoAllowed(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (bool, error) {
+	permissions, err := p.AuctionPermissions(ctx, bidder, PublisherID, consent)
+	return permissions.AllowAll(), err
+}
+
+func (p *permissionsImpl) AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (AuctionPermissions, error) {
 	_, ok := p.cfg.NonStandardPublisherMap[PublisherID]
 	if ok {
-		return true, nil
+		return allowPersonalInfo(true), nil
 	}
 
 	id, ok := p.vendorIDs[bidder]
 	if ok {
-		return p.allowPI(ctx, id, consent)
+		return p.allowAuction(ctx, id, consent)
 	}
 
 	if consent == "" {
-		return p.cfg.UsersyncIfAmbiguous, nil
+		return allowPersonalInfo(p.cfg.UsersyncIfAmbiguous), nil
 	}
 
-	return false, nil
+	return allowPersonalInfo(false), nil
 }
 
 func (p *permissionsImpl) allowSync(ctx context.Context, vendorID uint16, consent string) (bool, error) {
@@ -67,6 +85,10 @@ func (p *permissionsImpl) allowSync(ctx context.Context, vendorID uint16, consen
 		return false, err
 	}
 
+	if tcf2, ok := parsedConsent.(*tcf2Consent); ok {
+		return p.tcf2PurposeEstablished(tcf2, vendor, vendorID, tcf2PurposeStorageAccess, p.cfg.TCF2.Purpose1), nil
+	}
+
 	if vendor == nil {
 		return false, nil
 	}
@@ -78,30 +100,111 @@ func (p *permissionsImpl) allowSync(ctx context.Context, vendorID uint16, consen
 	return false, nil
 }
 
-func (p *permissionsImpl) allowPI(ctx context.Context, vendorID uint16, consent string) (bool, error) {
+func (p *permissionsImpl) allowAuction(ctx context.Context, vendorID uint16, consent string) (AuctionPermissions, error) {
 	// If we're not given a consent string, respect the preferences in the app config.
 	if consent == "" {
-		return p.cfg.UsersyncIfAmbiguous, nil
+		return allowPersonalInfo(p.cfg.UsersyncIfAmbiguous), nil
 	}
 
 	parsedConsent, vendor, err := p.parseVendor(ctx, vendorID, consent)
 	if err != nil {
-		return false, err
+		return AuctionPermissions{}, err
+	}
+
+	if tcf2, ok := parsedConsent.(*tcf2Consent); ok {
+		return p.allowAuctionTCF2(tcf2, vendor, vendorID), nil
 	}
 
 	if vendor == nil {
-		return false, nil
+		return allowPersonalInfo(false), nil
 	}
 
 	if (vendor.Purpose(consentconstants.InfoStorageAccess) || vendor.LegitimateInterest(consentconstants.InfoStorageAccess)) && parsedConsent.PurposeAllowed(consentconstants.InfoStorageAccess) && (vendor.Purpose(consentconstants.AdSelectionDeliveryReporting) || vendor.LegitimateInterest(consentconstants.AdSelectionDeliveryReporting)) && parsedConsent.PurposeAllowed(consentconstants.AdSelectionDeliveryReporting) && parsedConsent.VendorConsent(vendorID) {
-		return true, nil
+		return allowPersonalInfo(true), nil
 	}
 
-	return false, nil
+	return allowPersonalInfo(false), nil
 }
 
-func (p *permissionsImpl) parseVendor(ctx context.Context, vendorID uint16, consent string) (parsedConsent vendorconsent.VendorConsents, vendor vendorlist.Vendor, err error) {
-	parsedConsent, err = vendorconsent.ParseString(consent)
+// allowAuctionTCF2 decides between a full bid request, a basic ads bid request and no bid request at all.
+// Basic ads (purpose 2) are needed to call the bidder. User IDs additionally need storage access (purpose 1)
+// and ad measurement (purpose 7), while precise geolocation needs the user to opt in to special feature 1.
+func (p *permissionsImpl) allowAuctionTCF2(consent *tcf2Consent, vendor vendorlist.Vendor, vendorID uint16) AuctionPermissions {
+	if !p.tcf2PurposeEstablished(consent, vendor, vendorID, tcf2PurposeBasicAds, p.cfg.TCF2.Purpose2) {
+		return AuctionPermissions{}
+	}
+
+	return AuctionPermissions{
+		AllowBidRequest: true,
+		AllowUserIDs: p.tcf2PurposeEstablished(consent, vendor, vendorID, tcf2PurposeStorageAccess, p.cfg.TCF2.Purpose1) &&
+			p.tcf2PurposeEstablished(consent, vendor, vendorID, tcf2PurposeMeasureAdPerformance, p.cfg.TCF2.Purpose7),
+		AllowPreciseGeo: !p.cfg.TCF2.SpecialFeature1.Enabled || consent.SpecialFeatureOptIn(tcf2SpecialFeaturePreciseGeo),
+	}
+}
+
+// tcf2PurposeEstablished returns true if the vendor has a legal basis for the purpose, either through the
+// user's consent or through a legitimate interest the user didn't object to. Publisher restrictions may
+// forbid the purpose outright, or force a flexible purpose onto one of the two legal bases.
+func (p *permissionsImpl) tcf2PurposeEstablished(consent *tcf2Consent, vendor vendorlist.Vendor, vendorID uint16, purpose consentconstants.Purpose, enforcement config.TCF2Purpose) bool {
+	if !enforcement.Enabled {
+		return true
+	}
+
+	if vendor == nil {
+		return false
+	}
+
+	consentDeclared := vendor.Purpose(purpose)
+	legitInterestDeclared := vendor.LegitimateInterest(purpose)
+	flexible := false
+	if v, ok := vendor.(flexibleVendor); ok {
+		flexible = v.FlexiblePurpose(purpose)
+	}
+
+	switch consent.PubRestriction(purpose, vendorID) {
+	case pubRestrictNotAllowed:
+		return false
+	case pubRestrictRequireConsent:
+		consentDeclared = consentDeclared || (legitInterestDeclared && flexible)
+		legitInterestDeclared = false
+	case pubRestrictRequireLegitInterest:
+		legitInterestDeclared = legitInterestDeclared || (consentDeclared && flexible)
+		consentDeclared = false
+	}
+
+	if consentDeclared && consent.PurposeAllowed(purpose) && consent.VendorConsent(vendorID) {
+		return true
+	}
+
+	if legitInterestDeclared && consent.PurposeLITransparency(purpose) && consent.VendorLegitInterest(vendorID) {
+		return true
+	}
+
+	return false
+}
+
+// parseVendor parses the consent string, detecting whether it is a TCF 1.1 or a TCF 2.0 string,
+// and fetches the vendor from the matching version of the Global Vendor List.
+func (p *permissionsImpl) parseVendor(ctx context.Context, vendorID uint16, consent string) (parsed parsedConsent, vendor vendorlist.Vendor, err error) {
+	version, err := consentVersion(consent)
+	if err != nil {
+		err = &ErrorMalformedConsent{
+			consent: consent,
+			cause:   err,
+		}
+		return
+	}
+
+	fetchVendorList := p.fetchVendorList
+	if version == 2 {
+		var tcf2 *tcf2Consent
+		if tcf2, err = parseTCF2Consent(consent); err == nil {
+			parsed = tcf2
+		}
+		fetchVendorList = p.fetchVendorListV2
+	} else {
+		parsed, err = vendorconsent.ParseString(consent)
+	}
 	if err != nil {
 		err = &ErrorMalformedConsent{
 			consent: consent,
@@ -110,7 +213,7 @@ func (p *permissionsImpl) parseVendor(ctx context.Context, vendorID uint16, cons
 		return
 	}
 
-	vendorList, err := p.fetchVendorList(ctx, parsedConsent.VendorListVersion())
+	vendorList, err := fetchVendorList(ctx, parsed.VendorListVersion())
 	if err != nil {
 		return
 	}
@@ -119,6 +222,16 @@ func (p *permissionsImpl) parseVendor(ctx context.Context, vendorID uint16, cons
 	return
 }
 
+// allowPersonalInfo builds the AuctionPermissions of a TCF 1.1 consent string, which only decides whether
+// personal info is passed to the bidder.
+func allowPersonalInfo(allowed bool) AuctionPermissions {
+	return AuctionPermissions{
+		AllowBidRequest: true,
+		AllowUserIDs:    allowed,
+		AllowPreciseGeo: allowed,
+	}
+}
+
 // Exporting to allow for easy test setups
 type AlwaysAllow struct{}
 
@@ -133,3 +246,7 @@ func (a AlwaysAllow) BidderSyncAllowed(ctx context.Context, bidder openrtb_ext.B
 func (a AlwaysAllow) PersonalInfoAllowed(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (bool, error) {
 	return true, nil
 }
+
+func (a AlwaysAllow) AuctionPermissions(ctx context.Context, bidder openrtb_ext.BidderName, PublisherID string, consent string) (AuctionPermissions, error) {
+	return allowPersonalInfo(true), nil
+}
@@ -190,6 +190,232 @@ func TestAllowPersonalInfo(t *testing.T) {
 	assertBoolsEqual(t, true, allowPI)
 }
 
+func TestAllowedSyncsTCF2(t *testing.T) {
+	vendorList := parseVendorListV2Data(t, mockVendorListV2Data(t, 5, map[uint16]*purposesV2{
+		2: {purposes: []uint8{1}},
+		3: {purposes: []uint8{1}},
+	}))
+	perms := permissionsImpl{
+		cfg: config.GDPR{
+			HostVendorID: 2,
+			TCF2:         enforceAllTCF2(),
+		},
+		vendorIDs: map[openrtb_ext.BidderName]uint16{
+			openrtb_ext.BidderAppnexus: 2,
+			openrtb_ext.BidderPubmatic: 3,
+		},
+		fetchVendorListV2: listFetcher(map[uint16]vendorlist.VendorList{
+			5: vendorList,
+		}),
+	}
+	consent := tcf2TestConsent{
+		vendorListVersion: 5,
+		purposeConsents:   []uint8{1},
+		vendorConsents:    []uint16{2},
+	}.encode()
+
+	allowSync, err := perms.HostCookiesAllowed(context.Background(), consent)
+	assertNilErr(t, err)
+	assertBoolsEqual(t, true, allowSync)
+
+	allowSync, err = perms.BidderSyncAllowed(context.Background(), openrtb_ext.BidderPubmatic, consent)
+	assertNilErr(t, err)
+	assertBoolsEqual(t, false, allowSync)
+
+	perms.cfg.TCF2.Purpose1.Enabled = false
+	allowSync, err = perms.BidderSyncAllowed(context.Background(), openrtb_ext.BidderPubmatic, consent)
+	assertNilErr(t, err)
+	assertBoolsEqual(t, true, allowSync)
+}
+
+func TestAuctionPermissionsTCF2(t *testing.T) {
+	vendorList := parseVendorListV2Data(t, mockVendorListV2Data(t, 5, map[uint16]*purposesV2{
+		2: {purposes: []uint8{1, 2, 7}},
+		3: {purposes: []uint8{1}, legIntPurposes: []uint8{2, 7}, flexiblePurposes: []uint8{2}},
+	}))
+	fullConsent := tcf2TestConsent{
+		vendorListVersion: 5,
+		specialFeatures:   []uint8{1},
+		purposeConsents:   []uint8{1, 2, 7},
+		purposeLI:         []uint8{2, 7},
+		vendorConsents:    []uint16{2, 3},
+		vendorLIs:         []uint16{3},
+	}
+
+	testCases := []struct {
+		description string
+		vendorID    uint16
+		consent     tcf2TestConsent
+		tcf2        config.TCF2
+		expected    AuctionPermissions
+	}{
+		{
+			description: "Full Consent",
+			vendorID:    2,
+			consent:     fullConsent,
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true, AllowPreciseGeo: true},
+		},
+		{
+			description: "Legitimate Interest",
+			vendorID:    3,
+			consent:     fullConsent,
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true, AllowPreciseGeo: true},
+		},
+		{
+			description: "No Geo Opt-In",
+			vendorID:    2,
+			consent:     withSpecialFeatures(fullConsent, nil),
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true},
+		},
+		{
+			description: "No Geo Opt-In - Not Enforced",
+			vendorID:    2,
+			consent:     withSpecialFeatures(fullConsent, nil),
+			tcf2:        config.TCF2{Purpose1: enabled(), Purpose2: enabled(), Purpose7: enabled()},
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true, AllowPreciseGeo: true},
+		},
+		{
+			description: "Basic Ads Only",
+			vendorID:    2,
+			consent:     withPurposeConsents(fullConsent, []uint8{2, 7}),
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowPreciseGeo: true},
+		},
+		{
+			description: "No Basic Ads",
+			vendorID:    2,
+			consent:     withPurposeConsents(fullConsent, []uint8{1, 7}),
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{},
+		},
+		{
+			description: "No Basic Ads - Not Enforced",
+			vendorID:    2,
+			consent:     withPurposeConsents(fullConsent, []uint8{1, 7}),
+			tcf2:        config.TCF2{Purpose1: enabled(), Purpose7: enabled(), SpecialFeature1: enabled()},
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true, AllowPreciseGeo: true},
+		},
+		{
+			description: "Vendor Not In List",
+			vendorID:    4,
+			consent:     fullConsent,
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{},
+		},
+		{
+			description: "Publisher Restriction - Not Allowed",
+			vendorID:    2,
+			consent:     withPubRestriction(fullConsent, tcf2TestPubRestriction{purpose: 2, restrictionType: pubRestrictNotAllowed, vendors: []uint16{2}}),
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{},
+		},
+		{
+			description: "Publisher Restriction - Require Consent For Flexible Purpose",
+			vendorID:    3,
+			consent:     withPubRestriction(fullConsent, tcf2TestPubRestriction{purpose: 2, restrictionType: pubRestrictRequireConsent, vendors: []uint16{3}}),
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowUserIDs: true, AllowPreciseGeo: true},
+		},
+		{
+			description: "Publisher Restriction - Require Consent For Inflexible Purpose",
+			vendorID:    3,
+			consent:     withPubRestriction(fullConsent, tcf2TestPubRestriction{purpose: 7, restrictionType: pubRestrictRequireConsent, vendors: []uint16{3}}),
+			tcf2:        enforceAllTCF2(),
+			expected:    AuctionPermissions{AllowBidRequest: true, AllowPreciseGeo: true},
+		},
+	}
+
+	for _, test := range testCases {
+		perms := permissionsImpl{
+			cfg: config.GDPR{
+				HostVendorID: 2,
+				TCF2:         test.tcf2,
+			},
+			vendorIDs: map[openrtb_ext.BidderName]uint16{
+				openrtb_ext.BidderAppnexus: test.vendorID,
+			},
+			fetchVendorListV2: listFetcher(map[uint16]vendorlist.VendorList{
+				5: vendorList,
+			}),
+		}
+
+		permissions, err := perms.AuctionPermissions(context.Background(), openrtb_ext.BidderAppnexus, "", test.consent.encode())
+		assertNilErr(t, err)
+		if permissions != test.expected {
+			t.Errorf("%s: Expected %+v, got %+v", test.description, test.expected, permissions)
+		}
+
+		allowPI, err := perms.PersonalInfoAllowed(context.Background(), openrtb_ext.BidderAppnexus, "", test.consent.encode())
+		assertNilErr(t, err)
+		assertBoolsEqual(t, test.expected.AllowAll(), allowPI)
+	}
+}
+
+func TestAuctionPermissionsTCF1(t *testing.T) {
+	vendorListData := mockVendorListData(t, 1, map[uint16]*purposes{
+		2: {
+			purposes: []uint8{1}, // cookie reads/writes
+		},
+		3: {
+			purposes: []uint8{1, 3}, // ad personalization
+		},
+	})
+	perms := permissionsImpl{
+		cfg: config.GDPR{
+			HostVendorID: 2,
+		},
+		vendorIDs: map[openrtb_ext.BidderName]uint16{
+			openrtb_ext.BidderAppnexus: 2,
+			openrtb_ext.BidderPubmatic: 3,
+		},
+		fetchVendorList: listFetcher(map[uint16]vendorlist.VendorList{
+			1: parseVendorListData(t, vendorListData),
+		}),
+	}
+
+	// TCF 1.1 never prevents the bid request, and either allows or masks all personal info
+	permissions, err := perms.AuctionPermissions(context.Background(), openrtb_ext.BidderAppnexus, "", "BOS2bx5OS2bx5ABABBAAABoAAAABBwAA")
+	assertNilErr(t, err)
+	assertBoolsEqual(t, true, permissions.AllowBidRequest)
+	assertBoolsEqual(t, false, permissions.AllowUserIDs)
+	assertBoolsEqual(t, false, permissions.AllowPreciseGeo)
+
+	permissions, err = perms.AuctionPermissions(context.Background(), openrtb_ext.BidderPubmatic, "", "BOS2bx5OS2bx5ABABBAAABoAAAABBwAA")
+	assertNilErr(t, err)
+	assertBoolsEqual(t, true, permissions.AllowAll())
+}
+
+func enforceAllTCF2() config.TCF2 {
+	return config.TCF2{
+		Purpose1:        enabled(),
+		Purpose2:        enabled(),
+		Purpose7:        enabled(),
+		SpecialFeature1: enabled(),
+	}
+}
+
+func enabled() config.TCF2Purpose {
+	return config.TCF2Purpose{Enabled: true}
+}
+
+func withSpecialFeatures(consent tcf2TestConsent, features []uint8) tcf2TestConsent {
+	consent.specialFeatures = features
+	return consent
+}
+
+func withPurposeConsents(consent tcf2TestConsent, purposes []uint8) tcf2TestConsent {
+	consent.purposeConsents = purposes
+	return consent
+}
+
+func withPubRestriction(consent tcf2TestConsent, restriction tcf2TestPubRestriction) tcf2TestConsent {
+	consent.pubRestrictions = []tcf2TestPubRestriction{restriction}
+	return consent
+}
+
 func parseVendorListData(t *testing.T, data string) vendorlist.VendorList {
 	t.Helper()
 	parsed, err := vendorlist.ParseEagerly([]byte(data))
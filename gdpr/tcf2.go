@@ -0,0 +1,314 @@
+package gdpr
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// This file decodes the core segment of TCF 2.0 consent strings.
+// For the format, see https://github.com/InteractiveAdvertisingBureau/GDPR-Transparency-and-Consent-Framework/blob/master/TCFv2/IAB%20Tech%20Lab%20-%20Consent%20string%20and%20vendor%20list%20formats%20v2.md
+//
+// Nothing in this file is exported. Public APIs can be found in gdpr.go
+
+// TCF 2.0 purposes and special features which Prebid Server enforces.
+const (
+	tcf2PurposeStorageAccess        consentconstants.Purpose = 1
+	tcf2PurposeBasicAds             consentconstants.Purpose = 2
+	tcf2PurposeMeasureAdPerformance consentconstants.Purpose = 7
+
+	tcf2SpecialFeaturePreciseGeo = 1
+)
+
+// Publisher restriction types which a CMP may place on a purpose for a range of vendors.
+const (
+	pubRestrictNotAllowed           uint8 = 0
+	pubRestrictRequireConsent       uint8 = 1
+	pubRestrictRequireLegitInterest uint8 = 2
+	pubRestrictUndefined            uint8 = 3
+)
+
+// Field widths of the TCF 2.0 core segment.
+const (
+	tcf2HeaderBits             = 213
+	tcf2MaxPurposeID           = 24
+	tcf2MaxSpecialFeatureID    = 12
+	tcf2VendorIDBits           = 16
+	tcf2NumEntriesBits         = 12
+	tcf2RestrictionPurposeBits = 6
+	tcf2RestrictionTypeBits    = 2
+)
+
+// consentVersion returns the TCF version of a consent string. Both TCF 1.1 and 2.0 strings
+// begin with a 6 bit version number, so the first base64 character is enough to tell them apart.
+func consentVersion(consent string) (uint8, error) {
+	if consent == "" {
+		return 0, errors.New("the consent string is empty")
+	}
+	data, err := decodeConsentSegment(consent[:minInt(len(consent), 4)])
+	if err != nil {
+		return 0, err
+	}
+	return data[0] >> 2, nil
+}
+
+// tcf2Consent holds the parts of a TCF 2.0 core segment needed to enforce the purposes Prebid Server cares about.
+type tcf2Consent struct {
+	vendorListVersion     uint16
+	specialFeatureOptIns  uint16
+	purposeConsents       uint32
+	purposeLITransparency uint32
+	vendorConsents        vendorSet
+	vendorLegitInterests  vendorSet
+	pubRestrictions       map[consentconstants.Purpose]map[uint8]vendorSet
+}
+
+// parseTCF2Consent decodes the core segment of a TCF 2.0 consent string. Any other segments
+// (disclosed vendors, allowed vendors, publisher TC) are ignored.
+func parseTCF2Consent(consent string) (*tcf2Consent, error) {
+	core := consent
+	if i := strings.IndexByte(consent, '.'); i != -1 {
+		core = consent[:i]
+	}
+	data, err := decodeConsentSegment(core)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &bitReader{data: data}
+	if r.bitsLeft() < tcf2HeaderBits {
+		return nil, fmt.Errorf("the core segment must have at least %d bits. Got %d", tcf2HeaderBits, r.bitsLeft())
+	}
+
+	if version := r.read(6); version != 2 {
+		return nil, fmt.Errorf("the core segment must be version 2. Got %d", version)
+	}
+	r.skip(36 + 36 + 12 + 12 + 6 + 12) // created, last updated, cmp id, cmp version, consent screen, consent language
+
+	parsed := &tcf2Consent{
+		vendorListVersion: uint16(r.read(12)),
+	}
+	r.skip(6 + 1 + 1) // tcf policy version, is service specific, use non standard stacks
+	parsed.specialFeatureOptIns = uint16(r.read(12))
+	parsed.purposeConsents = uint32(r.read(24))
+	parsed.purposeLITransparency = uint32(r.read(24))
+	r.skip(1 + 12) // purpose one treatment, publisher cc
+
+	if parsed.vendorConsents, err = readVendorSection(r); err != nil {
+		return nil, fmt.Errorf("failed to read the vendor consent section: %v", err)
+	}
+	if parsed.vendorLegitInterests, err = readVendorSection(r); err != nil {
+		return nil, fmt.Errorf("failed to read the vendor legitimate interest section: %v", err)
+	}
+	if parsed.pubRestrictions, err = readPubRestrictions(r); err != nil {
+		return nil, fmt.Errorf("failed to read the publisher restrictions section: %v", err)
+	}
+
+	return parsed, nil
+}
+
+// VendorListVersion returns the version of the Global Vendor List which the consent string was created against.
+func (c *tcf2Consent) VendorListVersion() uint16 {
+	return c.vendorListVersion
+}
+
+// PurposeAllowed returns true if the user consented to the purpose.
+func (c *tcf2Consent) PurposeAllowed(purpose consentconstants.Purpose) bool {
+	return isBitSet(uint64(c.purposeConsents), uint(purpose), tcf2MaxPurposeID)
+}
+
+// PurposeLITransparency returns true if the user was shown the legitimate interest disclosure for the purpose and did not object.
+func (c *tcf2Consent) PurposeLITransparency(purpose consentconstants.Purpose) bool {
+	return isBitSet(uint64(c.purposeLITransparency), uint(purpose), tcf2MaxPurposeID)
+}
+
+// SpecialFeatureOptIn returns true if the user opted in to the special feature.
+func (c *tcf2Consent) SpecialFeatureOptIn(feature uint8) bool {
+	return isBitSet(uint64(c.specialFeatureOptIns), uint(feature), tcf2MaxSpecialFeatureID)
+}
+
+// VendorConsent returns true if the user consented to the vendor.
+func (c *tcf2Consent) VendorConsent(vendorID uint16) bool {
+	return c.vendorConsents.contains(vendorID)
+}
+
+// VendorLegitInterest returns true if the user did not object to the vendor's legitimate interest.
+func (c *tcf2Consent) VendorLegitInterest(vendorID uint16) bool {
+	return c.vendorLegitInterests.contains(vendorID)
+}
+
+// PubRestriction returns the restriction type the publisher placed on the purpose for the vendor.
+// If there is no restriction, pubRestrictUndefined is returned.
+func (c *tcf2Consent) PubRestriction(purpose consentconstants.Purpose, vendorID uint16) uint8 {
+	for restrictionType, vendors := range c.pubRestrictions[purpose] {
+		if vendors.contains(vendorID) {
+			return restrictionType
+		}
+	}
+	return pubRestrictUndefined
+}
+
+// vendorSet is a set of vendor IDs, stored either as a bitfield or as a list of ranges.
+type vendorSet struct {
+	bits   []byte
+	ranges []vendorRange
+}
+
+type vendorRange struct {
+	start uint16
+	end   uint16
+}
+
+func (s vendorSet) contains(vendorID uint16) bool {
+	if vendorID == 0 {
+		return false
+	}
+	if s.bits != nil {
+		index := uint(vendorID - 1)
+		if index/8 >= uint(len(s.bits)) {
+			return false
+		}
+		return s.bits[index/8]&(0x80>>(index%8)) != 0
+	}
+	for _, r := range s.ranges {
+		if vendorID >= r.start && vendorID <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+func readVendorSection(r *bitReader) (vendorSet, error) {
+	if r.bitsLeft() < tcf2VendorIDBits+1 {
+		return vendorSet{}, errors.New("the section is truncated")
+	}
+	maxVendorID := uint(r.read(tcf2VendorIDBits))
+	if r.read(1) == 0 {
+		if r.bitsLeft() < maxVendorID {
+			return vendorSet{}, fmt.Errorf("the bitfield must have %d bits. Got %d", maxVendorID, r.bitsLeft())
+		}
+		bits := make([]byte, (maxVendorID+7)/8)
+		for i := uint(0); i < maxVendorID; i++ {
+			if r.read(1) == 1 {
+				bits[i/8] |= 0x80 >> (i % 8)
+			}
+		}
+		return vendorSet{bits: bits}, nil
+	}
+
+	ranges, err := readRangeEntries(r)
+	return vendorSet{ranges: ranges}, err
+}
+
+func readPubRestrictions(r *bitReader) (map[consentconstants.Purpose]map[uint8]vendorSet, error) {
+	// The publisher restrictions section may be omitted by older CMPs
+	if r.bitsLeft() < tcf2NumEntriesBits {
+		return nil, nil
+	}
+	numRestrictions := int(r.read(tcf2NumEntriesBits))
+	restrictions := make(map[consentconstants.Purpose]map[uint8]vendorSet, numRestrictions)
+	for i := 0; i < numRestrictions; i++ {
+		if r.bitsLeft() < tcf2RestrictionPurposeBits+tcf2RestrictionTypeBits {
+			return nil, errors.New("the section is truncated")
+		}
+		purpose := consentconstants.Purpose(r.read(tcf2RestrictionPurposeBits))
+		restrictionType := uint8(r.read(tcf2RestrictionTypeBits))
+		ranges, err := readRangeEntries(r)
+		if err != nil {
+			return nil, err
+		}
+		if restrictions[purpose] == nil {
+			restrictions[purpose] = make(map[uint8]vendorSet)
+		}
+		restrictions[purpose][restrictionType] = vendorSet{ranges: ranges}
+	}
+	return restrictions, nil
+}
+
+func readRangeEntries(r *bitReader) ([]vendorRange, error) {
+	if r.bitsLeft() < tcf2NumEntriesBits {
+		return nil, errors.New("the range section is truncated")
+	}
+	numEntries := int(r.read(tcf2NumEntriesBits))
+	ranges := make([]vendorRange, 0, numEntries)
+	for i := 0; i < numEntries; i++ {
+		if r.bitsLeft() < 1+tcf2VendorIDBits {
+			return nil, errors.New("the range section is truncated")
+		}
+		isRange := r.read(1) == 1
+		entry := vendorRange{start: uint16(r.read(tcf2VendorIDBits))}
+		entry.end = entry.start
+		if isRange {
+			if r.bitsLeft() < tcf2VendorIDBits {
+				return nil, errors.New("the range section is truncated")
+			}
+			entry.end = uint16(r.read(tcf2VendorIDBits))
+		}
+		if entry.end < entry.start {
+			return nil, fmt.Errorf("the range %d-%d is invalid", entry.start, entry.end)
+		}
+		ranges = append(ranges, entry)
+	}
+	return ranges, nil
+}
+
+// decodeConsentSegment decodes a websafe base64 segment. CMPs are supposed to trim the padding, but not all of them do.
+func decodeConsentSegment(segment string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("the consent string is empty")
+	}
+	return data, nil
+}
+
+// isBitSet checks the 1-indexed id within a field of the given width, where id 1 is the most significant bit.
+func isBitSet(field uint64, id uint, width uint) bool {
+	if id < 1 || id > width {
+		return false
+	}
+	return field&(1<<(width-id)) != 0
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// bitReader reads big-endian bit fields from a byte slice. Callers must check bitsLeft before reading.
+type bitReader struct {
+	data []byte
+	pos  uint
+}
+
+func (r *bitReader) bitsLeft() uint {
+	total := uint(len(r.data)) * 8
+	if r.pos >= total {
+		return 0
+	}
+	return total - r.pos
+}
+
+func (r *bitReader) skip(n uint) {
+	r.pos += n
+}
+
+func (r *bitReader) read(n uint) uint64 {
+	var value uint64
+	for i := uint(0); i < n; i++ {
+		byteIndex := r.pos / 8
+		value <<= 1
+		if byteIndex < uint(len(r.data)) && r.data[byteIndex]&(0x80>>(r.pos%8)) != 0 {
+			value |= 1
+		}
+		r.pos++
+	}
+	return value
+}
@@ -0,0 +1,209 @@
+package gdpr
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsentVersion(t *testing.T) {
+	testCases := []struct {
+		description     string
+		consent         string
+		expectedVersion uint8
+		expectError     bool
+	}{
+		{description: "TCF 1.1", consent: "BOS2bx5OS2bx5ABABBAAABoAAAABBwAA", expectedVersion: 1},
+		{description: "TCF 2.0", consent: tcf2TestConsent{vendorListVersion: 1}.encode(), expectedVersion: 2},
+		{description: "Empty", consent: "", expectError: true},
+		{description: "Not Base64", consent: "!!!!", expectError: true},
+	}
+
+	for _, test := range testCases {
+		version, err := consentVersion(test.consent)
+
+		if test.expectError {
+			assert.Error(t, err, test.description)
+		} else {
+			assert.NoError(t, err, test.description)
+			assert.Equal(t, test.expectedVersion, version, test.description)
+		}
+	}
+}
+
+func TestParseTCF2Consent(t *testing.T) {
+	consent := tcf2TestConsent{
+		vendorListVersion:  15,
+		specialFeatures:    []uint8{1},
+		purposeConsents:    []uint8{1, 2},
+		purposeLI:          []uint8{7},
+		vendorConsents:     []uint16{2, 9},
+		vendorLIs:          []uint16{3, 4, 5},
+		vendorLIsAsRanges:  true,
+		pubRestrictions:    []tcf2TestPubRestriction{{purpose: 7, restrictionType: pubRestrictRequireConsent, vendors: []uint16{3}}},
+		appendOtherSegment: true,
+	}.encode()
+
+	parsed, err := parseTCF2Consent(consent)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, uint16(15), parsed.VendorListVersion())
+	assert.True(t, parsed.SpecialFeatureOptIn(1))
+	assert.False(t, parsed.SpecialFeatureOptIn(2))
+	assert.True(t, parsed.PurposeAllowed(1))
+	assert.True(t, parsed.PurposeAllowed(2))
+	assert.False(t, parsed.PurposeAllowed(7))
+	assert.True(t, parsed.PurposeLITransparency(7))
+	assert.False(t, parsed.PurposeLITransparency(2))
+	assert.True(t, parsed.VendorConsent(2))
+	assert.True(t, parsed.VendorConsent(9))
+	assert.False(t, parsed.VendorConsent(3))
+	assert.False(t, parsed.VendorConsent(100))
+	assert.True(t, parsed.VendorLegitInterest(4))
+	assert.False(t, parsed.VendorLegitInterest(2))
+	assert.Equal(t, pubRestrictRequireConsent, parsed.PubRestriction(7, 3))
+	assert.Equal(t, pubRestrictUndefined, parsed.PubRestriction(7, 2))
+	assert.Equal(t, pubRestrictUndefined, parsed.PubRestriction(2, 3))
+}
+
+func TestParseTCF2ConsentErrors(t *testing.T) {
+	valid := tcf2TestConsent{vendorListVersion: 1, vendorConsents: []uint16{2}}.encode()
+
+	testCases := []struct {
+		description string
+		consent     string
+	}{
+		{description: "TCF 1.1", consent: "BOS2bx5OS2bx5ABABBAAABoAAAABBwAA"},
+		{description: "Truncated Header", consent: valid[:20]},
+		{description: "Not Base64", consent: "C!!!!!!!"},
+	}
+
+	for _, test := range testCases {
+		_, err := parseTCF2Consent(test.consent)
+		assert.Error(t, err, test.description)
+	}
+}
+
+// tcf2TestConsent builds TCF 2.0 consent strings for tests. Fields which Prebid Server ignores are left empty.
+type tcf2TestConsent struct {
+	vendorListVersion  uint16
+	specialFeatures    []uint8
+	purposeConsents    []uint8
+	purposeLI          []uint8
+	vendorConsents     []uint16
+	vendorLIs          []uint16
+	vendorLIsAsRanges  bool
+	pubRestrictions    []tcf2TestPubRestriction
+	appendOtherSegment bool
+}
+
+type tcf2TestPubRestriction struct {
+	purpose         consentconstants.Purpose
+	restrictionType uint8
+	vendors         []uint16
+}
+
+func (c tcf2TestConsent) encode() string {
+	w := &bitWriter{}
+	w.write(2, 6)
+	w.write(0, 36+36+12+12+6+12)
+	w.write(uint64(c.vendorListVersion), 12)
+	w.write(2, 6)
+	w.write(0, 1+1)
+	w.writeFlags(c.specialFeatures, tcf2MaxSpecialFeatureID)
+	w.writeFlags(c.purposeConsents, tcf2MaxPurposeID)
+	w.writeFlags(c.purposeLI, tcf2MaxPurposeID)
+	w.write(0, 1+12)
+	w.writeVendors(c.vendorConsents, false)
+	w.writeVendors(c.vendorLIs, c.vendorLIsAsRanges)
+	w.write(uint64(len(c.pubRestrictions)), tcf2NumEntriesBits)
+	for _, restriction := range c.pubRestrictions {
+		w.write(uint64(restriction.purpose), tcf2RestrictionPurposeBits)
+		w.write(uint64(restriction.restrictionType), tcf2RestrictionTypeBits)
+		w.writeRanges(restriction.vendors)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(w.bytes())
+	if c.appendOtherSegment {
+		encoded += ".YAAAAAAAAAAA"
+	}
+	return encoded
+}
+
+type bitWriter struct {
+	bits []bool
+}
+
+func (w *bitWriter) write(value uint64, n uint) {
+	for i := n; i > 0; i-- {
+		w.bits = append(w.bits, value&(1<<(i-1)) != 0)
+	}
+}
+
+func (w *bitWriter) writeFlags(ids []uint8, width uint) {
+	var field uint64
+	for _, id := range ids {
+		field |= 1 << (width - uint(id))
+	}
+	w.write(field, width)
+}
+
+func (w *bitWriter) writeVendors(ids []uint16, asRanges bool) {
+	var maxVendorID uint16
+	for _, id := range ids {
+		if id > maxVendorID {
+			maxVendorID = id
+		}
+	}
+	w.write(uint64(maxVendorID), tcf2VendorIDBits)
+
+	if asRanges {
+		w.write(1, 1)
+		w.writeRanges(ids)
+		return
+	}
+
+	w.write(0, 1)
+	set := make(map[uint16]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	for id := uint16(1); id <= maxVendorID; id++ {
+		if set[id] {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+	}
+}
+
+// writeRanges writes each vendor as a single entry, except for the first two which are written as a range.
+func (w *bitWriter) writeRanges(ids []uint16) {
+	if len(ids) >= 2 && ids[1] == ids[0]+1 {
+		w.write(uint64(len(ids)-1), tcf2NumEntriesBits)
+		w.write(1, 1)
+		w.write(uint64(ids[0]), tcf2VendorIDBits)
+		w.write(uint64(ids[1]), tcf2VendorIDBits)
+		ids = ids[2:]
+	} else {
+		w.write(uint64(len(ids)), tcf2NumEntriesBits)
+	}
+	for _, id := range ids {
+		w.write(0, 1)
+		w.write(uint64(id), tcf2VendorIDBits)
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	data := make([]byte, (len(w.bits)+7)/8)
+	for i, bit := range w.bits {
+		if bit {
+			data[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return data
+}
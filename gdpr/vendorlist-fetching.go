@@ -18,21 +18,24 @@ import (
 
 type saveVendors func(uint16, vendorlist.VendorList)
 
+// parseVendors parses the response body of a Global Vendor List request.
+type parseVendors func([]byte) (vendorlist.VendorList, error)
+
 // This file provides the vendorlist-fetching function for Prebid Server.
 //
 // For more info, see https://github.com/prebid/prebid-server/issues/504
 //
 // Nothing in this file is exported. Public APIs can be found in gdpr.go
 
-func newVendorListFetcher(initCtx context.Context, cfg config.GDPR, client *http.Client, urlMaker func(uint16) string) func(ctx context.Context, id uint16) (vendorlist.VendorList, error) {
+func newVendorListFetcher(initCtx context.Context, cfg config.GDPR, client *http.Client, urlMaker func(uint16) string, parser parseVendors) func(ctx context.Context, id uint16) (vendorlist.VendorList, error) {
 	// These save and load functions can be used to store & retrieve lists from our cache.
 	save, load := newVendorListCache()
 
 	withTimeout, cancel := context.WithTimeout(initCtx, cfg.Timeouts.InitTimeout())
 	defer cancel()
-	populateCache(withTimeout, client, urlMaker, save)
+	populateCache(withTimeout, client, urlMaker, parser, save)
 
-	saveOneSometimes := newOccasionalSaver(cfg.Timeouts.ActiveTimeout())
+	saveOneSometimes := newOccasionalSaver(cfg.Timeouts.ActiveTimeout(), parser)
 
 	return func(ctx context.Context, id uint16) (vendorlist.VendorList, error) {
 		list := load(id)
@@ -49,11 +52,11 @@ func newVendorListFetcher(initCtx context.Context, cfg config.GDPR, client *http
 }
 
 // populateCache saves all the known versions of the vendor list for future use.
-func populateCache(ctx context.Context, client *http.Client, urlMaker func(uint16) string, saver saveVendors) {
-	latestVersion := saveOne(ctx, client, urlMaker(0), saver)
+func populateCache(ctx context.Context, client *http.Client, urlMaker func(uint16) string, parser parseVendors, saver saveVendors) {
+	latestVersion := saveOne(ctx, client, urlMaker(0), parser, saver)
 
 	for i := uint16(1); i < latestVersion; i++ {
-		saveOne(ctx, client, urlMaker(i), saver)
+		saveOne(ctx, client, urlMaker(i), parser, saver)
 	}
 }
 
@@ -66,12 +69,21 @@ func vendorListURLMaker(version uint16) string {
 	return "https://vendorlist.consensu.org/v-" + strconv.Itoa(int(version)) + "/vendorlist.json"
 }
 
+// Make a URL which can be used to fetch a given version of the TCF 2.0 Global Vendor List. If the version is 0,
+// this will fetch the latest version.
+func vendorListV2URLMaker(version uint16) string {
+	if version == 0 {
+		return "https://vendorlist.consensu.org/v2/vendor-list.json"
+	}
+	return "https://vendorlist.consensu.org/v2/archives/vendor-list-v" + strconv.Itoa(int(version)) + ".json"
+}
+
 // newOccasionalSaver returns a wrapped version of saveOne() which only activates every few minutes.
 //
 // The goal here is to update quickly when new versions of the VendorList are released, but not wreck
 // server performance if a bad CMP starts sending us malformed consent strings that advertize a version
 // that doesn't exist yet.
-func newOccasionalSaver(timeout time.Duration) func(ctx context.Context, client *http.Client, url string, saver saveVendors) {
+func newOccasionalSaver(timeout time.Duration, parser parseVendors) func(ctx context.Context, client *http.Client, url string, saver saveVendors) {
 	lastSaved := &atomic.Value{}
 	lastSaved.Store(time.Time{})
 
@@ -80,13 +92,13 @@ func newOccasionalSaver(timeout time.Duration) func(ctx context.Context, client
 		if now.Sub(lastSaved.Load().(time.Time)).Minutes() > 10 {
 			withTimeout, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
-			saveOne(withTimeout, client, url, saver)
+			saveOne(withTimeout, client, url, parser, saver)
 			lastSaved.Store(now)
 		}
 	}
 }
 
-func saveOne(ctx context.Context, client *http.Client, url string, saver saveVendors) uint16 {
+func saveOne(ctx context.Context, client *http.Client, url string, parser parseVendors, saver saveVendors) uint16 {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		glog.Errorf("Failed to build GET %s request. Cookie syncs may be affected: %v", url, err)
@@ -110,7 +122,7 @@ func saveOne(ctx context.Context, client *http.Client, url string, saver saveVen
 		return 0
 	}
 
-	newList, err := vendorlist.ParseEagerly(respBody)
+	newList, err := parser(respBody)
 	if err != nil {
 		glog.Errorf("GET %s returned malformed JSON. Cookie syncs may be affected. Error was %v. Body was %s", url, err, string(respBody))
 		return 0
@@ -0,0 +1,107 @@
+package gdpr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+	"github.com/prebid/go-gdpr/vendorlist"
+)
+
+// This file parses version 2 of the Global Vendor List, which is used to interpret TCF 2.0 consent strings.
+// For the format, see https://github.com/InteractiveAdvertisingBureau/GDPR-Transparency-and-Consent-Framework/blob/master/TCFv2/IAB%20Tech%20Lab%20-%20Consent%20string%20and%20vendor%20list%20formats%20v2.md#the-global-vendor-list
+//
+// Nothing in this file is exported. Public APIs can be found in gdpr.go
+
+type vendorListV2Contract struct {
+	Version uint16                        `json:"vendorListVersion"`
+	Vendors map[string]vendorListV2Vendor `json:"vendors"`
+}
+
+type vendorListV2Vendor struct {
+	ID               uint16  `json:"id"`
+	Purposes         []uint8 `json:"purposes"`
+	LegIntPurposes   []uint8 `json:"legIntPurposes"`
+	FlexiblePurposes []uint8 `json:"flexiblePurposes"`
+}
+
+// parseVendorListV2 parses a version 2 Global Vendor List into the same interface used for version 1 lists.
+func parseVendorListV2(data []byte) (vendorlist.VendorList, error) {
+	var contract vendorListV2Contract
+	if err := json.Unmarshal(data, &contract); err != nil {
+		return nil, err
+	}
+	if contract.Version == 0 {
+		return nil, errors.New("data.vendorListVersion was 0 or undefined. Versions should start at 1")
+	}
+	if len(contract.Vendors) == 0 {
+		return nil, errors.New("data.vendors was undefined or empty")
+	}
+
+	list := &parsedVendorListV2{
+		version: contract.Version,
+		vendors: make(map[uint16]*parsedVendorV2, len(contract.Vendors)),
+	}
+	for key, vendor := range contract.Vendors {
+		id, err := strconv.ParseUint(key, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("data.vendors contained the invalid vendor id %s", key)
+		}
+		list.vendors[uint16(id)] = &parsedVendorV2{
+			purposes:         toPurposeSet(vendor.Purposes),
+			legIntPurposes:   toPurposeSet(vendor.LegIntPurposes),
+			flexiblePurposes: toPurposeSet(vendor.FlexiblePurposes),
+		}
+	}
+	return list, nil
+}
+
+type parsedVendorListV2 struct {
+	version uint16
+	vendors map[uint16]*parsedVendorV2
+}
+
+func (l *parsedVendorListV2) Version() uint16 {
+	return l.version
+}
+
+func (l *parsedVendorListV2) Vendor(vendorID uint16) vendorlist.Vendor {
+	vendor, ok := l.vendors[vendorID]
+	if !ok {
+		return nil
+	}
+	return vendor
+}
+
+type parsedVendorV2 struct {
+	purposes         map[consentconstants.Purpose]struct{}
+	legIntPurposes   map[consentconstants.Purpose]struct{}
+	flexiblePurposes map[consentconstants.Purpose]struct{}
+}
+
+func (v *parsedVendorV2) Purpose(purpose consentconstants.Purpose) bool {
+	_, ok := v.purposes[purpose]
+	return ok
+}
+
+func (v *parsedVendorV2) LegitimateInterest(purpose consentconstants.Purpose) bool {
+	_, ok := v.legIntPurposes[purpose]
+	return ok
+}
+
+// FlexiblePurpose returns true if the vendor allows publishers to switch the legal basis of the purpose
+// between consent and legitimate interest through a publisher restriction.
+func (v *parsedVendorV2) FlexiblePurpose(purpose consentconstants.Purpose) bool {
+	_, ok := v.flexiblePurposes[purpose]
+	return ok
+}
+
+func toPurposeSet(ids []uint8) map[consentconstants.Purpose]struct{} {
+	set := make(map[consentconstants.Purpose]struct{}, len(ids))
+	for _, id := range ids {
+		set[consentconstants.Purpose(id)] = struct{}{}
+	}
+	return set
+}
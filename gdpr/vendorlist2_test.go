@@ -0,0 +1,94 @@
+package gdpr
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/prebid/go-gdpr/vendorlist"
+)
+
+func TestParseVendorListV2(t *testing.T) {
+	list := parseVendorListV2Data(t, mockVendorListV2Data(t, 12, map[uint16]*purposesV2{
+		32: {
+			purposes:         []uint8{1, 2},
+			legIntPurposes:   []uint8{7},
+			flexiblePurposes: []uint8{2, 7},
+		},
+	}))
+
+	assertBoolsEqual(t, true, list.Version() == 12)
+	assertBoolsEqual(t, true, list.Vendor(33) == nil)
+
+	vendor := list.Vendor(32)
+	assertBoolsEqual(t, true, vendor.Purpose(1))
+	assertBoolsEqual(t, true, vendor.Purpose(2))
+	assertBoolsEqual(t, false, vendor.Purpose(7))
+	assertBoolsEqual(t, true, vendor.LegitimateInterest(7))
+	assertBoolsEqual(t, false, vendor.LegitimateInterest(1))
+	assertBoolsEqual(t, true, vendor.(flexibleVendor).FlexiblePurpose(7))
+	assertBoolsEqual(t, false, vendor.(flexibleVendor).FlexiblePurpose(1))
+}
+
+func TestParseVendorListV2Errors(t *testing.T) {
+	testCases := []struct {
+		description string
+		data        string
+	}{
+		{description: "Malformed", data: `malformed`},
+		{description: "Empty", data: `{}`},
+		{description: "No Vendors", data: `{"vendorListVersion":1,"vendors":{}}`},
+		{description: "Invalid Vendor ID", data: `{"vendorListVersion":1,"vendors":{"abc":{"id":1}}}`},
+	}
+
+	for _, test := range testCases {
+		if _, err := parseVendorListV2([]byte(test.data)); err == nil {
+			t.Errorf("%s: Expected an error", test.description)
+		}
+	}
+}
+
+func parseVendorListV2Data(t *testing.T, data string) vendorlist.VendorList {
+	t.Helper()
+	parsed, err := parseVendorListV2([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to parse vendor list data. %v", err)
+	}
+	return parsed
+}
+
+func mockVendorListV2Data(t *testing.T, version uint16, vendors map[uint16]*purposesV2) string {
+	type vendorContract struct {
+		ID               uint16  `json:"id"`
+		Purposes         []uint8 `json:"purposes"`
+		LegIntPurposes   []uint8 `json:"legIntPurposes"`
+		FlexiblePurposes []uint8 `json:"flexiblePurposes"`
+	}
+
+	type vendorListContract struct {
+		Version uint16                    `json:"vendorListVersion"`
+		Vendors map[string]vendorContract `json:"vendors"`
+	}
+
+	obj := vendorListContract{
+		Version: version,
+		Vendors: make(map[string]vendorContract, len(vendors)),
+	}
+	for id, purpose := range vendors {
+		obj.Vendors[strconv.Itoa(int(id))] = vendorContract{
+			ID:               id,
+			Purposes:         purpose.purposes,
+			LegIntPurposes:   purpose.legIntPurposes,
+			FlexiblePurposes: purpose.flexiblePurposes,
+		}
+	}
+	data, err := json.Marshal(obj)
+	assertNilErr(t, err)
+	return string(data)
+}
+
+type purposesV2 struct {
+	purposes         []uint8
+	legIntPurposes   []uint8
+	flexiblePurposes []uint8
+}
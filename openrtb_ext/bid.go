@@ -13,10 +13,11 @@ type ExtBid struct {
 
 // ExtBidPrebid defines the contract for bidresponse.seatbid.bid[i].ext.prebid
 type ExtBidPrebid struct {
-	Cache     *ExtBidPrebidCache `json:"cache,omitempty"`
-	Targeting map[string]string  `json:"targeting,omitempty"`
-	Type      BidType            `json:"type"`
-	Video     *ExtBidPrebidVideo `json:"video,omitempty"`
+	Cache     *ExtBidPrebidCache  `json:"cache,omitempty"`
+	Targeting map[string]string   `json:"targeting,omitempty"`
+	Type      BidType             `json:"type"`
+	Video     *ExtBidPrebidVideo  `json:"video,omitempty"`
+	Events    *ExtBidPrebidEvents `json:"events,omitempty"`
 }
 
 // ExtBidPrebidCache defines the contract for  bidresponse.seatbid.bid[i].ext.prebid.cache
@@ -31,6 +32,12 @@ type ExtBidPrebidVideo struct {
 	PrimaryCategory string `json:"primary_category"`
 }
 
+// ExtBidPrebidEvents defines the contract for bidresponse.seatbid.bid[i].ext.prebid.events
+type ExtBidPrebidEvents struct {
+	Win string `json:"win,omitempty"`
+	Imp string `json:"imp,omitempty"`
+}
+
 // BidType describes the allowed values for bidresponse.seatbid.bid[i].ext.prebid.type
 type BidType string
 
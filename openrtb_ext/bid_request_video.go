@@ -1,10 +1,20 @@
 package openrtb_ext
 
 import (
+	"encoding/json"
+
 	"github.com/mxmCherry/openrtb"
 )
 
 type BidRequestVideo struct {
+	// Attribute:
+	//   id
+	// Type:
+	//   string; optional
+	// Description:
+	//   ID of the request, which is passed on to the bidders. Prebid Server generates one if it's missing.
+	ID string `json:"id,omitempty"`
+
 	// Attribute:
 	//   storedrequestid
 	// Type:
@@ -128,8 +138,47 @@ type BidRequestVideo struct {
 	// Description:
 	//   Block list of advertisers by their domains (e.g., “ford.com”).
 	BAdv []string `json:"badv,omitempty"`
+
+	// Attribute:
+	//   ext
+	// Type:
+	//   object; optional
+	// Description:
+	//   Prebid extensions for the video request
+	Ext *ExtRequestVideo `json:"ext,omitempty"`
+}
+
+type ExtRequestVideo struct {
+	// Attribute:
+	//   prebid
+	// Type:
+	//   object; optional
+	//  Prebid options for the video request
+	Prebid ExtRequestVideoPrebid `json:"prebid"`
+}
+
+type ExtRequestVideoPrebid struct {
+	// Attribute:
+	//   output
+	// Type:
+	//   string; optional
+	//  Format of the response: "json", "vast" or "vmap". Default is "json".
+	//  The output query param takes precedence over this.
+	Output VideoOutput `json:"output,omitempty"`
 }
 
+// VideoOutput is the format of a /openrtb2/video response.
+type VideoOutput string
+
+const (
+	// VideoOutputJSON responds with a BidResponseVideo, which has the targeting keys for each pod.
+	VideoOutputJSON VideoOutput = "json"
+	// VideoOutputVAST responds with a VAST 4 ad pod, for requests with a single pod.
+	VideoOutputVAST VideoOutput = "vast"
+	// VideoOutputVMAP responds with a VMAP which has an ad break for each pod.
+	VideoOutputVMAP VideoOutput = "vmap"
+)
+
 type PodConfig struct {
 	// Attribute:
 	//   durationrangesec
@@ -146,6 +195,13 @@ type PodConfig struct {
 	//  Flag indicating exact ad duration requirement. Default is false.
 	RequireExactDuration bool `json:"requireexactduration,omitempty"`
 
+	// Attribute:
+	//   categoryexclusion
+	// Type:
+	//   boolean, optional
+	//  Flag indicating that each pod may only contain one ad per ad server category. Default is false.
+	CategoryExclusion bool `json:"categoryexclusion,omitempty"`
+
 	// Attribute:
 	//   pods
 	// Type:
@@ -172,9 +228,32 @@ type Pod struct {
 	// Attribute:
 	//   configid
 	// Type:
-	//   string; required
+	//   string; required unless bidders are set
 	//  ID of the stored config that corresponds to a single pod request
 	ConfigId string `json:"configid"`
+
+	// Attribute:
+	//   bidders
+	// Type:
+	//   object; optional
+	//  Params for the bidders which should bid on the pod, keyed by bidder name, like the ext of an imp.
+	//  These replace the params for the same bidders in the stored config.
+	Bidders map[string]json.RawMessage `json:"bidders,omitempty"`
+
+	// Attribute:
+	//   maxads
+	// Type:
+	//   integer; optional
+	//  Maximum number of ads in the adPod. 0 means no limit.
+	MaxAds int `json:"maxads,omitempty"`
+
+	// Attribute:
+	//   timeoffset
+	// Type:
+	//   string; optional
+	//  VMAP timeOffset of the adPod, like "start", "end", "00:10:00.000" or "#2".
+	//  Defaults to the pod's position in the request, like "#1" for the first pod.
+	TimeOffset string `json:"timeoffset,omitempty"`
 }
 
 type IncludeBrandCategory struct {
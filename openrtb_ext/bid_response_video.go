@@ -11,8 +11,32 @@ type AdPod struct {
 	PodId     int64            `json:"podid"`
 	Targeting []VideoTargeting `json:"targeting"`
 	Errors    []string         `json:"errors"`
+	Excluded  []ExcludedBid    `json:"excluded,omitempty"`
 }
 
+// ExcludedBid is a bid which was left out of an ad pod, and the reason why.
+type ExcludedBid struct {
+	BidID  string          `json:"bidid"`
+	Seat   string          `json:"seat"`
+	Reason ExclusionReason `json:"reason"`
+}
+
+// ExclusionReason describes why a bid was left out of an ad pod.
+type ExclusionReason string
+
+const (
+	// ExclusionDuration means the pod requires exact durations, and the bid's duration isn't one of them.
+	ExclusionDuration ExclusionReason = "duration"
+	// ExclusionInvalidDuration means the bid's duration is negative.
+	ExclusionInvalidDuration ExclusionReason = "invalid_duration"
+	// ExclusionPodDuration means the bid didn't fit in the pod alongside the more valuable bids.
+	ExclusionPodDuration ExclusionReason = "pod_duration"
+	// ExclusionCategory means a bid with the same category was chosen instead.
+	ExclusionCategory ExclusionReason = "category"
+	// ExclusionMaxAds means the pod already has its maximum number of ads.
+	ExclusionMaxAds ExclusionReason = "max_ads"
+)
+
 type VideoTargeting struct {
 	HbPb       string `json:"hb_pb"`
 	HbPbCatDur string `json:"hb_pb_cat_dur"`
@@ -20,6 +20,11 @@ type ExtImp struct {
 type ExtImpPrebid struct {
 	StoredRequest *ExtStoredRequest `json:"storedrequest"`
 
+	// StoredAuctionResponse answers this imp with stored SeatBids, without calling any bidders
+	StoredAuctionResponse *ExtStoredAuctionResponse `json:"storedauctionresponse"`
+	// StoredBidResponse replaces the named bidders' HTTP responses with stored ones
+	StoredBidResponse []ExtStoredBidResponse `json:"storedbidresponse"`
+
 	// NOTE: This is not part of the official API, we are not expecting clients
 	// migrate from imp[...].ext.${BIDDER} to imp[...].ext.prebid.bidder.${BIDDER}
 	// at this time
@@ -30,4 +35,29 @@ type ExtImpPrebid struct {
 // ExtStoredRequest defines the contract for bidrequest.imp[i].ext.prebid.storedrequest
 type ExtStoredRequest struct {
 	ID string `json:"id"`
+	// Merge is set in the Stored Request or Imp data, and chooses how the HTTP request is merged into it.
+	// If empty, the host's stored_requests.merge_strategy is used.
+	Merge MergeStrategy `json:"merge,omitempty"`
+}
+
+// MergeStrategy chooses how the HTTP request is merged into Stored Request and Imp data.
+type MergeStrategy string
+
+const (
+	// MergeReplace merges with RFC 7386 JSON Merge Patch, so arrays in the HTTP request replace the stored ones.
+	MergeReplace MergeStrategy = "replace"
+	// MergeDeep merges arrays too. Elements of "imp" are merged by id, "format" by w and h, and "eids" by source.
+	// Elements of other arrays are appended, unless the stored array already has them.
+	MergeDeep MergeStrategy = "deep"
+)
+
+// ExtStoredAuctionResponse defines the contract for bidrequest.imp[i].ext.prebid.storedauctionresponse
+type ExtStoredAuctionResponse struct {
+	ID string `json:"id"`
+}
+
+// ExtStoredBidResponse defines the contract for bidrequest.imp[i].ext.prebid.storedbidresponse
+type ExtStoredBidResponse struct {
+	ID     string `json:"id"`
+	Bidder string `json:"bidder"`
 }
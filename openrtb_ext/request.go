@@ -15,8 +15,12 @@ type ExtRequestPrebid struct {
 	Aliases              map[string]string      `json:"aliases,omitempty"`
 	BidAdjustmentFactors map[string]float64     `json:"bidadjustmentfactors,omitempty"`
 	Cache                *ExtRequestPrebidCache `json:"cache,omitempty"`
-	StoredRequest        *ExtStoredRequest      `json:"storedrequest,omitempty"`
-	Targeting            *ExtRequestTargeting   `json:"targeting,omitempty"`
+	// Events enables bid.ext.prebid.events in the response when present, even as an empty object.
+	Events        json.RawMessage           `json:"events,omitempty"`
+	Floors        *ExtRequestFloors         `json:"floors,omitempty"`
+	SChains       []*ExtRequestPrebidSChain `json:"schains,omitempty"`
+	StoredRequest *ExtStoredRequest         `json:"storedrequest,omitempty"`
+	Targeting     *ExtRequestTargeting      `json:"targeting,omitempty"`
 }
 
 // ExtRequestPrebidCache defines the contract for bidrequest.ext.prebid.cache
@@ -47,6 +51,37 @@ type ExtRequestPrebidCacheBids struct{}
 // ExtRequestPrebidCacheVAST defines the contract for bidrequest.ext.prebid.cache.vastxml
 type ExtRequestPrebidCacheVAST struct{}
 
+// ExtRequestFloors defines the contract for bidrequest.ext.prebid.floors
+type ExtRequestFloors struct {
+	// Enabled lets a request opt out of price floors on a host which supports them.
+	Enabled *bool `json:"enabled,omitempty"`
+	// FloorEndpoint names a rules file which should be used if the request doesn't define its own Data.
+	FloorEndpoint *ExtFloorEndpoint `json:"floorendpoint,omitempty"`
+	Data          *ExtFloorData     `json:"data,omitempty"`
+}
+
+// ExtFloorEndpoint defines the contract for bidrequest.ext.prebid.floors.floorendpoint
+type ExtFloorEndpoint struct {
+	URL string `json:"url"`
+}
+
+// ExtFloorData defines the contract for bidrequest.ext.prebid.floors.data, and for floor rules files.
+//
+// Each key in Values holds one value per Schema field, joined by the Schema delimiter. "*" matches anything.
+type ExtFloorData struct {
+	Currency string             `json:"currency,omitempty"`
+	Schema   ExtFloorSchema     `json:"schema"`
+	Values   map[string]float64 `json:"values"`
+	Default  float64            `json:"default,omitempty"`
+}
+
+// ExtFloorSchema defines the contract for bidrequest.ext.prebid.floors.data.schema
+type ExtFloorSchema struct {
+	// Fields can hold "mediaType", "size", "domain" and "adUnitCode".
+	Fields    []string `json:"fields"`
+	Delimiter string   `json:"delimiter,omitempty"`
+}
+
 // ExtRequestTargeting defines the contract for bidrequest.ext.prebid.targeting
 type ExtRequestTargeting struct {
 	PriceGranularity     PriceGranularity        `json:"pricegranularity"`
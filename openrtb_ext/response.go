@@ -25,6 +25,27 @@ type ExtResponseDebug struct {
 	HttpCalls map[BidderName][]*ExtHttpCall `json:"httpcalls,omitempty"`
 	// Request after resolution of stored requests and debug overrides
 	ResolvedRequest *openrtb.BidRequest `json:"resolvedrequest,omitempty"`
+	// StoredRequestVersions defines the contract for bidresponse.ext.debug.storedrequestversions
+	StoredRequestVersions *ExtStoredRequestVersions `json:"storedrequestversions,omitempty"`
+	// StoredRequestConflicts defines the contract for bidresponse.ext.debug.storedrequestconflicts
+	StoredRequestConflicts []string `json:"storedrequestconflicts,omitempty"`
+	// StoredRequestLineage defines the contract for bidresponse.ext.debug.storedrequestlineage
+	StoredRequestLineage *ExtStoredRequestLineage `json:"storedrequestlineage,omitempty"`
+}
+
+// ExtStoredRequestVersions names the versions of the Stored Requests and Imps which were chosen for an auction,
+// keyed by their IDs. Stored data which isn't versioned is left out.
+type ExtStoredRequestVersions struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Imps     map[string]string `json:"imps,omitempty"`
+}
+
+// ExtStoredRequestLineage lists the ancestors which the Stored Requests and Imps used in an auction inherited from,
+// keyed by their IDs. Each list starts with the ID itself, and ends with the ancestor which has no parent.
+// Stored data which doesn't inherit from anything is left out.
+type ExtStoredRequestLineage struct {
+	Requests map[string][]string `json:"requests,omitempty"`
+	Imps     map[string][]string `json:"imps,omitempty"`
 }
 
 // ExtResponseSyncData defines the contract for bidresponse.ext.usersync.{bidder}
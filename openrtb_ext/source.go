@@ -0,0 +1,36 @@
+package openrtb_ext
+
+import "encoding/json"
+
+// ExtSource defines the contract for bidrequest.source.ext
+type ExtSource struct {
+	SChain *ExtRequestPrebidSChainSChain `json:"schain,omitempty"`
+}
+
+// ExtRequestPrebidSChain defines the contract for bidrequest.ext.prebid.schains
+//
+// Bidders may list "*" to give every bidder without its own schain this one.
+type ExtRequestPrebidSChain struct {
+	Bidders []string                     `json:"bidders,omitempty"`
+	SChain  ExtRequestPrebidSChainSChain `json:"schain"`
+}
+
+// ExtRequestPrebidSChainSChain defines the contract for a supply chain object.
+// See https://github.com/InteractiveAdvertisingBureau/openrtb/blob/master/supplychainobject.md
+type ExtRequestPrebidSChainSChain struct {
+	Complete int                                 `json:"complete"`
+	Nodes    []*ExtRequestPrebidSChainSChainNode `json:"nodes"`
+	Ver      string                              `json:"ver"`
+	Ext      json.RawMessage                     `json:"ext,omitempty"`
+}
+
+// ExtRequestPrebidSChainSChainNode defines the contract for a single node in a supply chain object.
+type ExtRequestPrebidSChainSChainNode struct {
+	ASI    string          `json:"asi"`
+	SID    string          `json:"sid"`
+	RID    string          `json:"rid,omitempty"`
+	Name   string          `json:"name,omitempty"`
+	Domain string          `json:"domain,omitempty"`
+	HP     int             `json:"hp"`
+	Ext    json.RawMessage `json:"ext,omitempty"`
+}
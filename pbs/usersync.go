@@ -1,6 +1,7 @@
 package pbs
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -42,6 +43,8 @@ type UserSyncDeps struct {
 	HostCookieConfig *config.HostCookie
 	MetricsEngine    pbsmetrics.MetricsEngine
 	PBSAnalytics     analytics.PBSAnalyticsModule
+	UIDStore         usersync.UIDStore
+	UIDStoreConfig   *config.UIDStore
 }
 
 // Struct for parsing json in google's response
@@ -94,11 +97,33 @@ func (deps *UserSyncDeps) OptOut(w http.ResponseWriter, r *http.Request, _ httpr
 
 	pc := usersync.ParsePBSCookieFromRequest(r, deps.HostCookieConfig)
 	pc.SetPreference(optout == "")
+	if optout != "" {
+		deps.deleteStoredUIDs(r)
+	}
 
-	pc.SetCookieOnResponse(w, deps.HostCookieConfig.Domain, deps.HostCookieConfig.TTLDuration())
+	pc.SetCookieOnResponse(w, usersync.UseSameSiteNone(r, deps.HostCookieConfig), deps.HostCookieConfig, deps.HostCookieConfig.TTLDuration())
 	if optout == "" {
 		http.Redirect(w, r, deps.HostCookieConfig.OptInURL, 301)
 	} else {
 		http.Redirect(w, r, deps.HostCookieConfig.OptOutURL, 301)
 	}
 }
+
+// deleteStoredUIDs removes the UIDs which the store keeps for the user, if it has any. Stores keyed by user ID
+// need the same signed fpid that /setuid takes, in the fpid and fpid_sig form values.
+// Errors are logged, since the opt-out cookie keeps the stored UIDs from being used anyway.
+func (deps *UserSyncDeps) deleteStoredUIDs(r *http.Request) {
+	fpid := r.FormValue("fpid")
+	if deps.UIDStoreConfig.KeySource == config.UIDStoreKeyUserID && !usersync.ValidFPID(fpid, r.FormValue("fpid_sig"), deps.UIDStoreConfig.FPIDSecret) {
+		fpid = ""
+	}
+	key := usersync.UIDStoreKey(r, fpid, deps.HostCookieConfig, deps.UIDStoreConfig)
+	if key == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(deps.UIDStoreConfig.TimeoutMS)*time.Millisecond)
+	defer cancel()
+	if err := deps.UIDStore.DeleteUIDs(ctx, key); err != nil {
+		glog.Errorf("Failed to delete the stored UIDs of a user who opted out: %v", err)
+	}
+}
@@ -104,6 +104,13 @@ func (me *MultiMetricsEngine) RecordRequestTime(labels pbsmetrics.Labels, length
 	}
 }
 
+// RecordAdapterFloorRejectedBid across all engines
+func (me *MultiMetricsEngine) RecordAdapterFloorRejectedBid(labels pbsmetrics.AdapterLabels) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterFloorRejectedBid(labels)
+	}
+}
+
 // RecordAdapterPanic across all engines
 func (me *MultiMetricsEngine) RecordAdapterPanic(labels pbsmetrics.AdapterLabels) {
 	for _, thisME := range *me {
@@ -160,10 +167,24 @@ func (me *MultiMetricsEngine) RecordStoredImpCacheResult(cacheResult pbsmetrics.
 	}
 }
 
+// RecordAccountCacheResult across all engines
+func (me *MultiMetricsEngine) RecordAccountCacheResult(cacheResult pbsmetrics.CacheResult, inc int) {
+	for _, thisME := range *me {
+		thisME.RecordAccountCacheResult(cacheResult, inc)
+	}
+}
+
+// RecordAnalyticsEventsDropped across all engines
+func (me *MultiMetricsEngine) RecordAnalyticsEventsDropped(reason pbsmetrics.AnalyticsDropReason, inc int) {
+	for _, thisME := range *me {
+		thisME.RecordAnalyticsEventsDropped(reason, inc)
+	}
+}
+
 // RecordAdapterCookieSync across all engines
-func (me *MultiMetricsEngine) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, gdprBlocked bool) {
+func (me *MultiMetricsEngine) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, source pbsmetrics.CookieSyncSource, gdprBlocked bool, ccpaBlocked bool) {
 	for _, thisME := range *me {
-		thisME.RecordAdapterCookieSync(adapter, gdprBlocked)
+		thisME.RecordAdapterCookieSync(adapter, source, gdprBlocked, ccpaBlocked)
 	}
 }
 
@@ -174,6 +195,13 @@ func (me *MultiMetricsEngine) RecordUserIDSet(userLabels pbsmetrics.UserLabels)
 	}
 }
 
+// RecordUserIDEvicted across all engines
+func (me *MultiMetricsEngine) RecordUserIDEvicted(bidder openrtb_ext.BidderName) {
+	for _, thisME := range *me {
+		thisME.RecordUserIDEvicted(bidder)
+	}
+}
+
 // DummyMetricsEngine is a Noop metrics engine in case no metrics are configured. (may also be useful for tests)
 type DummyMetricsEngine struct{}
 
@@ -207,6 +235,11 @@ func (me *DummyMetricsEngine) RecordRequestTime(labels pbsmetrics.Labels, length
 	return
 }
 
+// RecordAdapterFloorRejectedBid as a noop
+func (me *DummyMetricsEngine) RecordAdapterFloorRejectedBid(labels pbsmetrics.AdapterLabels) {
+	return
+}
+
 // RecordAdapterPanic as a noop
 func (me *DummyMetricsEngine) RecordAdapterPanic(labels pbsmetrics.AdapterLabels) {
 	return
@@ -238,7 +271,7 @@ func (me *DummyMetricsEngine) RecordCookieSync(labels pbsmetrics.Labels) {
 }
 
 // RecordAdapterCookieSync as a noop
-func (me *DummyMetricsEngine) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, gdprBlocked bool) {
+func (me *DummyMetricsEngine) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, source pbsmetrics.CookieSyncSource, gdprBlocked bool, ccpaBlocked bool) {
 	return
 }
 
@@ -247,6 +280,11 @@ func (me *DummyMetricsEngine) RecordUserIDSet(userLabels pbsmetrics.UserLabels)
 	return
 }
 
+// RecordUserIDEvicted as a noop
+func (me *DummyMetricsEngine) RecordUserIDEvicted(bidder openrtb_ext.BidderName) {
+	return
+}
+
 // RecordStoredReqCacheResult as a noop
 func (me *DummyMetricsEngine) RecordStoredReqCacheResult(cacheResult pbsmetrics.CacheResult, inc int) {
 	return
@@ -256,3 +294,13 @@ func (me *DummyMetricsEngine) RecordStoredReqCacheResult(cacheResult pbsmetrics.
 func (me *DummyMetricsEngine) RecordStoredImpCacheResult(cacheResult pbsmetrics.CacheResult, inc int) {
 	return
 }
+
+// RecordAccountCacheResult as a noop
+func (me *DummyMetricsEngine) RecordAccountCacheResult(cacheResult pbsmetrics.CacheResult, inc int) {
+	return
+}
+
+// RecordAnalyticsEventsDropped as a noop
+func (me *DummyMetricsEngine) RecordAnalyticsEventsDropped(reason pbsmetrics.AnalyticsDropReason, inc int) {
+	return
+}
@@ -25,6 +25,8 @@ type Metrics struct {
 	RequestTimer               metrics.Timer
 	StoredReqCacheMeter        map[CacheResult]metrics.Meter
 	StoredImpCacheMeter        map[CacheResult]metrics.Meter
+	AccountCacheMeter          map[CacheResult]metrics.Meter
+	AnalyticsDroppedMeter      map[AnalyticsDropReason]metrics.Meter
 
 	// Metrics for OpenRTB requests specifically. So we can track what % of RequestsMeter are OpenRTB
 	// and know when legacy requests have been abandoned.
@@ -33,10 +35,14 @@ type Metrics struct {
 	CookieSyncMeter       metrics.Meter
 	CookieSyncGen         map[openrtb_ext.BidderName]metrics.Meter
 	CookieSyncGDPRPrevent map[openrtb_ext.BidderName]metrics.Meter
+	CookieSyncCCPAPrevent map[openrtb_ext.BidderName]metrics.Meter
+	CookieSyncCoop        map[openrtb_ext.BidderName]metrics.Meter
 	userSyncOptout        metrics.Meter
 	userSyncBadRequest    metrics.Meter
 	userSyncSet           map[openrtb_ext.BidderName]metrics.Meter
 	userSyncGDPRPrevent   map[openrtb_ext.BidderName]metrics.Meter
+	userSyncCCPAPrevent   map[openrtb_ext.BidderName]metrics.Meter
+	userSyncEvicted       map[openrtb_ext.BidderName]metrics.Meter
 
 	// Media types found in the "imp" JSON object
 	ImpsTypeBanner metrics.Meter
@@ -55,15 +61,16 @@ type Metrics struct {
 
 // AdapterMetrics houses the metrics for a particular adapter
 type AdapterMetrics struct {
-	NoCookieMeter     metrics.Meter
-	ErrorMeters       map[AdapterError]metrics.Meter
-	NoBidMeter        metrics.Meter
-	GotBidsMeter      metrics.Meter
-	RequestTimer      metrics.Timer
-	PriceHistogram    metrics.Histogram
-	BidsReceivedMeter metrics.Meter
-	PanicMeter        metrics.Meter
-	MarkupMetrics     map[openrtb_ext.BidType]*MarkupDeliveryMetrics
+	NoCookieMeter      metrics.Meter
+	ErrorMeters        map[AdapterError]metrics.Meter
+	NoBidMeter         metrics.Meter
+	GotBidsMeter       metrics.Meter
+	RequestTimer       metrics.Timer
+	PriceHistogram     metrics.Histogram
+	BidsReceivedMeter  metrics.Meter
+	PanicMeter         metrics.Meter
+	FloorRejectedMeter metrics.Meter
+	MarkupMetrics      map[openrtb_ext.BidType]*MarkupDeliveryMetrics
 }
 
 type MarkupDeliveryMetrics struct {
@@ -106,14 +113,20 @@ func NewBlankMetrics(registry metrics.Registry, exchanges []openrtb_ext.BidderNa
 		RequestTimer:               &metrics.NilTimer{},
 		StoredReqCacheMeter:        make(map[CacheResult]metrics.Meter),
 		StoredImpCacheMeter:        make(map[CacheResult]metrics.Meter),
+		AccountCacheMeter:          make(map[CacheResult]metrics.Meter),
+		AnalyticsDroppedMeter:      make(map[AnalyticsDropReason]metrics.Meter),
 		AmpNoCookieMeter:           blankMeter,
 		CookieSyncMeter:            blankMeter,
 		CookieSyncGen:              make(map[openrtb_ext.BidderName]metrics.Meter),
 		CookieSyncGDPRPrevent:      make(map[openrtb_ext.BidderName]metrics.Meter),
+		CookieSyncCCPAPrevent:      make(map[openrtb_ext.BidderName]metrics.Meter),
+		CookieSyncCoop:             make(map[openrtb_ext.BidderName]metrics.Meter),
 		userSyncOptout:             blankMeter,
 		userSyncBadRequest:         blankMeter,
 		userSyncSet:                make(map[openrtb_ext.BidderName]metrics.Meter),
 		userSyncGDPRPrevent:        make(map[openrtb_ext.BidderName]metrics.Meter),
+		userSyncCCPAPrevent:        make(map[openrtb_ext.BidderName]metrics.Meter),
+		userSyncEvicted:            make(map[openrtb_ext.BidderName]metrics.Meter),
 
 		ImpsTypeBanner: blankMeter,
 		ImpsTypeVideo:  blankMeter,
@@ -135,6 +148,9 @@ func NewBlankMetrics(registry metrics.Registry, exchanges []openrtb_ext.BidderNa
 			newMetrics.RequestStatuses[t][s] = blankMeter
 		}
 	}
+	for _, r := range AnalyticsDropReasons() {
+		newMetrics.AnalyticsDroppedMeter[r] = blankMeter
+	}
 
 	return newMetrics
 }
@@ -169,8 +185,12 @@ func NewMetrics(registry metrics.Registry, exchanges []openrtb_ext.BidderName) *
 	for _, a := range exchanges {
 		newMetrics.CookieSyncGen[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("cookie_sync.%s.gen", string(a)), registry)
 		newMetrics.CookieSyncGDPRPrevent[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("cookie_sync.%s.gdpr_prevent", string(a)), registry)
+		newMetrics.CookieSyncCCPAPrevent[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("cookie_sync.%s.ccpa_prevent", string(a)), registry)
+		newMetrics.CookieSyncCoop[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("cookie_sync.%s.coop", string(a)), registry)
 		newMetrics.userSyncSet[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("usersync.%s.sets", string(a)), registry)
 		newMetrics.userSyncGDPRPrevent[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("usersync.%s.gdpr_prevent", string(a)), registry)
+		newMetrics.userSyncCCPAPrevent[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("usersync.%s.ccpa_prevent", string(a)), registry)
+		newMetrics.userSyncEvicted[a] = metrics.GetOrRegisterMeter(fmt.Sprintf("usersync.%s.evictions", string(a)), registry)
 		registerAdapterMetrics(registry, "adapter", string(a), newMetrics.AdapterMetrics[a])
 	}
 	for typ, statusMap := range newMetrics.RequestStatuses {
@@ -181,10 +201,16 @@ func NewMetrics(registry metrics.Registry, exchanges []openrtb_ext.BidderName) *
 	for _, cacheRes := range CacheResults() {
 		newMetrics.StoredReqCacheMeter[cacheRes] = metrics.GetOrRegisterMeter(fmt.Sprintf("stored_request_cache_%s", string(cacheRes)), registry)
 		newMetrics.StoredImpCacheMeter[cacheRes] = metrics.GetOrRegisterMeter(fmt.Sprintf("stored_imp_cache_%s", string(cacheRes)), registry)
+		newMetrics.AccountCacheMeter[cacheRes] = metrics.GetOrRegisterMeter(fmt.Sprintf("account_cache_%s", string(cacheRes)), registry)
+	}
+	for _, reason := range AnalyticsDropReasons() {
+		newMetrics.AnalyticsDroppedMeter[reason] = metrics.GetOrRegisterMeter(fmt.Sprintf("analytics.dropped_events.%s", string(reason)), registry)
 	}
 
 	newMetrics.userSyncSet[unknownBidder] = metrics.GetOrRegisterMeter("usersync.unknown.sets", registry)
 	newMetrics.userSyncGDPRPrevent[unknownBidder] = metrics.GetOrRegisterMeter("usersync.unknown.gdpr_prevent", registry)
+	newMetrics.userSyncCCPAPrevent[unknownBidder] = metrics.GetOrRegisterMeter("usersync.unknown.ccpa_prevent", registry)
+	newMetrics.userSyncEvicted[unknownBidder] = metrics.GetOrRegisterMeter("usersync.unknown.evictions", registry)
 	return newMetrics
 }
 
@@ -192,15 +218,16 @@ func NewMetrics(registry metrics.Registry, exchanges []openrtb_ext.BidderName) *
 func makeBlankAdapterMetrics() *AdapterMetrics {
 	blankMeter := &metrics.NilMeter{}
 	newAdapter := &AdapterMetrics{
-		NoCookieMeter:     blankMeter,
-		ErrorMeters:       make(map[AdapterError]metrics.Meter),
-		NoBidMeter:        blankMeter,
-		GotBidsMeter:      blankMeter,
-		RequestTimer:      &metrics.NilTimer{},
-		PriceHistogram:    &metrics.NilHistogram{},
-		BidsReceivedMeter: blankMeter,
-		PanicMeter:        blankMeter,
-		MarkupMetrics:     makeBlankBidMarkupMetrics(),
+		NoCookieMeter:      blankMeter,
+		ErrorMeters:        make(map[AdapterError]metrics.Meter),
+		NoBidMeter:         blankMeter,
+		GotBidsMeter:       blankMeter,
+		RequestTimer:       &metrics.NilTimer{},
+		PriceHistogram:     &metrics.NilHistogram{},
+		BidsReceivedMeter:  blankMeter,
+		PanicMeter:         blankMeter,
+		FloorRejectedMeter: blankMeter,
+		MarkupMetrics:      makeBlankBidMarkupMetrics(),
 	}
 	for _, err := range AdapterErrors() {
 		newAdapter.ErrorMeters[err] = blankMeter
@@ -243,6 +270,7 @@ func registerAdapterMetrics(registry metrics.Registry, adapterOrAccount string,
 		am.BidsReceivedMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.bids_received", adapterOrAccount, exchange), registry)
 	}
 	am.PanicMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.requests.panic", adapterOrAccount, exchange), registry)
+	am.FloorRejectedMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.floor_rejected_bids", adapterOrAccount, exchange), registry)
 }
 
 func makeDeliveryMetrics(registry metrics.Registry, prefix string, bidType openrtb_ext.BidType) *MarkupDeliveryMetrics {
@@ -372,6 +400,16 @@ func (me *Metrics) RecordAdapterPanic(labels AdapterLabels) {
 	am.PanicMeter.Mark(1)
 }
 
+// RecordAdapterFloorRejectedBid implements a part of the MetricsEngine interface
+func (me *Metrics) RecordAdapterFloorRejectedBid(labels AdapterLabels) {
+	am, ok := me.AdapterMetrics[labels.Adapter]
+	if !ok {
+		glog.Errorf("Trying to run adapter metrics on %s: adapter metrics not found", string(labels.Adapter))
+		return
+	}
+	am.FloorRejectedMeter.Mark(1)
+}
+
 // RecordAdapterRequest implements a part of the MetricsEngine interface
 func (me *Metrics) RecordAdapterRequest(labels AdapterLabels) {
 	am, ok := me.AdapterMetrics[labels.Adapter]
@@ -460,12 +498,18 @@ func (me *Metrics) RecordCookieSync(labels Labels) {
 	me.CookieSyncMeter.Mark(1)
 }
 
-// RecordAdapterCookieSync implements a part of the MetricsEngine interface. Records a cookie sync adpter sync request and gdpr status
-func (me *Metrics) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, gdprBlocked bool) {
+// RecordAdapterCookieSync implements a part of the MetricsEngine interface. Records a cookie sync adpter sync request, whether it came from coop syncing, and gdpr/ccpa status
+func (me *Metrics) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, source CookieSyncSource, gdprBlocked bool, ccpaBlocked bool) {
 	me.CookieSyncGen[adapter].Mark(1)
+	if source == CookieSyncSourceCoop {
+		me.CookieSyncCoop[adapter].Mark(1)
+	}
 	if gdprBlocked {
 		me.CookieSyncGDPRPrevent[adapter].Mark(1)
 	}
+	if ccpaBlocked {
+		me.CookieSyncCCPAPrevent[adapter].Mark(1)
+	}
 }
 
 // RecordUserIDSet implements a part of the MetricsEngine interface. Records a cookie setuid request
@@ -479,9 +523,17 @@ func (me *Metrics) RecordUserIDSet(userLabels UserLabels) {
 		doMark(userLabels.Bidder, me.userSyncSet)
 	case RequestActionGDPR:
 		doMark(userLabels.Bidder, me.userSyncGDPRPrevent)
+	case RequestActionCCPA:
+		doMark(userLabels.Bidder, me.userSyncCCPAPrevent)
 	}
 }
 
+// RecordUserIDEvicted implements a part of the MetricsEngine interface. Records a UID which was
+// dropped from the uids cookie to keep it under the max size
+func (me *Metrics) RecordUserIDEvicted(bidder openrtb_ext.BidderName) {
+	doMark(bidder, me.userSyncEvicted)
+}
+
 // RecordStoredReqCacheResult implements a part of the MetricsEngine interface. Records the
 // cache hits and misses when looking up stored requests
 func (me *Metrics) RecordStoredReqCacheResult(cacheResult CacheResult, inc int) {
@@ -492,6 +544,18 @@ func (me *Metrics) RecordStoredImpCacheResult(cacheResult CacheResult, inc int)
 	me.StoredImpCacheMeter[cacheResult].Mark(int64(inc))
 }
 
+// RecordAccountCacheResult implements a part of the MetricsEngine interface. Records the
+// cache hits and misses when looking up accounts
+func (me *Metrics) RecordAccountCacheResult(cacheResult CacheResult, inc int) {
+	me.AccountCacheMeter[cacheResult].Mark(int64(inc))
+}
+
+// RecordAnalyticsEventsDropped implements a part of the MetricsEngine interface. Records the
+// events which the analytics modules threw away
+func (me *Metrics) RecordAnalyticsEventsDropped(reason AnalyticsDropReason, inc int) {
+	me.AnalyticsDroppedMeter[reason].Mark(int64(inc))
+}
+
 func doMark(bidder openrtb_ext.BidderName, meters map[openrtb_ext.BidderName]metrics.Meter) {
 	met, ok := meters[bidder]
 	if ok {
@@ -22,9 +22,12 @@ func TestNewMetrics(t *testing.T) {
 	ensureContains(t, registry, "cookie_sync_requests", m.CookieSyncMeter)
 	ensureContains(t, registry, "cookie_sync.appnexus.gen", m.CookieSyncGen["appnexus"])
 	ensureContains(t, registry, "cookie_sync.appnexus.gdpr_prevent", m.CookieSyncGDPRPrevent["appnexus"])
+	ensureContains(t, registry, "cookie_sync.appnexus.ccpa_prevent", m.CookieSyncCCPAPrevent["appnexus"])
 	ensureContains(t, registry, "usersync.appnexus.gdpr_prevent", m.userSyncGDPRPrevent["appnexus"])
 	ensureContains(t, registry, "usersync.rubicon.gdpr_prevent", m.userSyncGDPRPrevent["rubicon"])
 	ensureContains(t, registry, "usersync.unknown.gdpr_prevent", m.userSyncGDPRPrevent["unknown"])
+	ensureContains(t, registry, "usersync.appnexus.ccpa_prevent", m.userSyncCCPAPrevent["appnexus"])
+	ensureContains(t, registry, "usersync.unknown.ccpa_prevent", m.userSyncCCPAPrevent["unknown"])
 
 	ensureContains(t, registry, "requests.ok.legacy", m.RequestStatuses[ReqTypeLegacy][RequestStatusOK])
 	ensureContains(t, registry, "requests.badinput.legacy", m.RequestStatuses[ReqTypeLegacy][RequestStatusBadInput])
@@ -75,6 +78,60 @@ func TestRecordGDPRRejection(t *testing.T) {
 	VerifyMetrics(t, "GDPR sync rejects", m.userSyncGDPRPrevent[openrtb_ext.BidderAppnexus].Count(), 1)
 }
 
+func TestRecordCCPARejection(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus})
+	m.RecordUserIDSet(UserLabels{
+		Action: RequestActionCCPA,
+		Bidder: openrtb_ext.BidderAppnexus,
+	})
+	VerifyMetrics(t, "CCPA sync rejects", m.userSyncCCPAPrevent[openrtb_ext.BidderAppnexus].Count(), 1)
+}
+
+func TestRecordUserIDEvicted(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus})
+	m.RecordUserIDEvicted(openrtb_ext.BidderAppnexus)
+	m.RecordUserIDEvicted("notabidder")
+	VerifyMetrics(t, "UID evictions", m.userSyncEvicted[openrtb_ext.BidderAppnexus].Count(), 1)
+	VerifyMetrics(t, "Unknown UID evictions", m.userSyncEvicted[unknownBidder].Count(), 1)
+}
+
+func TestRecordAdapterCookieSyncCCPA(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus})
+	m.RecordAdapterCookieSync(openrtb_ext.BidderAppnexus, CookieSyncSourceRequest, false, true)
+	VerifyMetrics(t, "Cookie sync gen", m.CookieSyncGen[openrtb_ext.BidderAppnexus].Count(), 1)
+	VerifyMetrics(t, "Cookie sync GDPR prevent", m.CookieSyncGDPRPrevent[openrtb_ext.BidderAppnexus].Count(), 0)
+	VerifyMetrics(t, "Cookie sync CCPA prevent", m.CookieSyncCCPAPrevent[openrtb_ext.BidderAppnexus].Count(), 1)
+}
+
+func TestRecordAdapterCookieSyncCoop(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus})
+	m.RecordAdapterCookieSync(openrtb_ext.BidderAppnexus, CookieSyncSourceCoop, false, false)
+	m.RecordAdapterCookieSync(openrtb_ext.BidderAppnexus, CookieSyncSourceRequest, false, false)
+	VerifyMetrics(t, "Cookie sync gen", m.CookieSyncGen[openrtb_ext.BidderAppnexus].Count(), 2)
+	VerifyMetrics(t, "Cookie sync coop", m.CookieSyncCoop[openrtb_ext.BidderAppnexus].Count(), 1)
+}
+
+func TestRecordAdapterFloorRejectedBid(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderRubicon})
+	m.RecordAdapterFloorRejectedBid(AdapterLabels{Adapter: openrtb_ext.BidderAppnexus})
+	m.RecordAdapterFloorRejectedBid(AdapterLabels{Adapter: openrtb_ext.BidderAppnexus})
+	VerifyMetrics(t, "Appnexus floor rejections", m.AdapterMetrics[openrtb_ext.BidderAppnexus].FloorRejectedMeter.Count(), 2)
+	VerifyMetrics(t, "Rubicon floor rejections", m.AdapterMetrics[openrtb_ext.BidderRubicon].FloorRejectedMeter.Count(), 0)
+}
+
+func TestRecordAnalyticsEventsDropped(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus})
+	m.RecordAnalyticsEventsDropped(AnalyticsSendFailed, 5)
+	VerifyMetrics(t, "Analytics events dropped after failed sends", m.AnalyticsDroppedMeter[AnalyticsSendFailed].Count(), 5)
+	VerifyMetrics(t, "Analytics events dropped from a full buffer", m.AnalyticsDroppedMeter[AnalyticsBufferFull].Count(), 0)
+}
+
 func ensureContains(t *testing.T, registry metrics.Registry, name string, metric interface{}) {
 	t.Helper()
 	if inRegistry := registry.Get(name); inRegistry == nil {
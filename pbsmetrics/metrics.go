@@ -65,6 +65,12 @@ type AdapterError string
 // CacheResult : Cache hit/miss
 type CacheResult string
 
+// CookieSyncSource : Why a bidder was synced by /cookie_sync
+type CookieSyncSource string
+
+// AnalyticsDropReason : Why an analytics module threw away events
+type AnalyticsDropReason string
+
 // PublisherUnknown: Default value for Labels.PubID
 const PublisherUnknown = "unknown"
 
@@ -212,6 +218,39 @@ func CacheResults() []CacheResult {
 	}
 }
 
+const (
+	// CookieSyncSourceRequest means the /cookie_sync request asked for the bidder
+	CookieSyncSourceRequest CookieSyncSource = "request"
+	// CookieSyncSourceCoop means the bidder was added by cooperative syncing
+	CookieSyncSourceCoop CookieSyncSource = "coop"
+)
+
+// CookieSyncSources returns the possible reasons for syncing a bidder
+func CookieSyncSources() []CookieSyncSource {
+	return []CookieSyncSource{
+		CookieSyncSourceRequest,
+		CookieSyncSourceCoop,
+	}
+}
+
+const (
+	// AnalyticsBufferFull means the events arrived while the module's buffer was full
+	AnalyticsBufferFull AnalyticsDropReason = "buffer_full"
+	// AnalyticsSendFailed means the module couldn't send a batch of events to its backend
+	AnalyticsSendFailed AnalyticsDropReason = "send_failed"
+	// AnalyticsShutdown means the events arrived after the module had been shut down
+	AnalyticsShutdown AnalyticsDropReason = "shutdown"
+)
+
+// AnalyticsDropReasons returns the possible reasons for dropping analytics events
+func AnalyticsDropReasons() []AnalyticsDropReason {
+	return []AnalyticsDropReason{
+		AnalyticsBufferFull,
+		AnalyticsSendFailed,
+		AnalyticsShutdown,
+	}
+}
+
 // UserLabels : Labels for /setuid endpoint
 type UserLabels struct {
 	Action RequestAction
@@ -226,6 +265,7 @@ const (
 	RequestActionSet    RequestAction = "set"
 	RequestActionOptOut RequestAction = "opt_out"
 	RequestActionGDPR   RequestAction = "gdpr"
+	RequestActionCCPA   RequestAction = "ccpa"
 	RequestActionErr    RequestAction = "err"
 )
 
@@ -244,14 +284,18 @@ type MetricsEngine interface {
 	RecordRequestTime(labels Labels, length time.Duration) // ignores adapter. only statusOk and statusErr fom status
 	RecordAdapterRequest(labels AdapterLabels)
 	RecordAdapterPanic(labels AdapterLabels)
+	RecordAdapterFloorRejectedBid(labels AdapterLabels)
 	// This records whether or not a bid of a particular type uses `adm` or `nurl`.
 	// Since the legacy endpoints don't have a bid type, it can only count bids from OpenRTB and AMP.
 	RecordAdapterBidReceived(labels AdapterLabels, bidType openrtb_ext.BidType, hasAdm bool)
 	RecordAdapterPrice(labels AdapterLabels, cpm float64)
 	RecordAdapterTime(labels AdapterLabels, length time.Duration)
 	RecordCookieSync(labels Labels) // May ignore all labels
-	RecordAdapterCookieSync(adapter openrtb_ext.BidderName, gdprBlocked bool)
-	RecordUserIDSet(userLabels UserLabels) // Function should verify bidder values
+	RecordAdapterCookieSync(adapter openrtb_ext.BidderName, source CookieSyncSource, gdprBlocked bool, ccpaBlocked bool)
+	RecordUserIDSet(userLabels UserLabels)             // Function should verify bidder values
+	RecordUserIDEvicted(bidder openrtb_ext.BidderName) // Function should verify bidder values
 	RecordStoredReqCacheResult(cacheResult CacheResult, inc int)
 	RecordStoredImpCacheResult(cacheResult CacheResult, inc int)
+	RecordAccountCacheResult(cacheResult CacheResult, inc int)
+	RecordAnalyticsEventsDropped(reason AnalyticsDropReason, inc int)
 }
@@ -54,6 +54,12 @@ func (me *MetricsEngineMock) RecordAdapterPanic(labels AdapterLabels) {
 	return
 }
 
+// RecordAdapterFloorRejectedBid mock
+func (me *MetricsEngineMock) RecordAdapterFloorRejectedBid(labels AdapterLabels) {
+	me.Called(labels)
+	return
+}
+
 // RecordAdapterRequest mock
 func (me *MetricsEngineMock) RecordAdapterRequest(labels AdapterLabels) {
 	me.Called(labels)
@@ -85,8 +91,8 @@ func (me *MetricsEngineMock) RecordCookieSync(labels Labels) {
 }
 
 // RecordAdapterCookieSync mock
-func (me *MetricsEngineMock) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, gdprBlocked bool) {
-	me.Called(adapter, gdprBlocked)
+func (me *MetricsEngineMock) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, source CookieSyncSource, gdprBlocked bool, ccpaBlocked bool) {
+	me.Called(adapter, source, gdprBlocked, ccpaBlocked)
 	return
 }
 
@@ -96,6 +102,12 @@ func (me *MetricsEngineMock) RecordUserIDSet(userLabels UserLabels) {
 	return
 }
 
+// RecordUserIDEvicted mock
+func (me *MetricsEngineMock) RecordUserIDEvicted(bidder openrtb_ext.BidderName) {
+	me.Called(bidder)
+	return
+}
+
 // RecordStoredReqCacheResult mock
 func (me *MetricsEngineMock) RecordStoredReqCacheResult(cacheResult CacheResult, inc int) {
 	me.Called(cacheResult, inc)
@@ -107,3 +119,14 @@ func (me *MetricsEngineMock) RecordStoredImpCacheResult(cacheResult CacheResult,
 	me.Called(cacheResult, inc)
 	return
 }
+
+// RecordAccountCacheResult mock
+func (me *MetricsEngineMock) RecordAccountCacheResult(cacheResult CacheResult, inc int) {
+	me.Called(cacheResult, inc)
+	return
+}
+
+// RecordAnalyticsEventsDropped mock
+func (me *MetricsEngineMock) RecordAnalyticsEventsDropped(reason AnalyticsDropReason, inc int) {
+	me.Called(reason, inc)
+}
@@ -25,11 +25,15 @@ type Metrics struct {
 	adaptPrices          *prometheus.HistogramVec
 	adaptErrors          *prometheus.CounterVec
 	adaptPanics          *prometheus.CounterVec
+	adaptFloorRejections *prometheus.CounterVec
 	cookieSync           prometheus.Counter
 	adaptCookieSync      *prometheus.CounterVec
 	userID               *prometheus.CounterVec
+	userIDEvicted        *prometheus.CounterVec
 	storedReqCacheResult *prometheus.CounterVec
 	storedImpCacheResult *prometheus.CounterVec
+	accountCacheResult   *prometheus.CounterVec
+	analyticsDropped     *prometheus.CounterVec
 }
 
 const (
@@ -44,7 +48,10 @@ const (
 	bidTypeLabel        = "bid_type"
 	adapterErrLabel     = "adapter_error"
 	cacheResultLabel    = "cache_result"
+	dropReasonLabel     = "drop_reason"
 	gdprBlockedLabel    = "gdpr_blocked"
+	ccpaBlockedLabel    = "ccpa_blocked"
+	syncSourceLabel     = "source"
 	bannerLabel         = "banner"
 	videoLabel          = "video"
 	audioLabel          = "audio"
@@ -105,6 +112,11 @@ func NewMetrics(cfg config.PrometheusMetrics) *Metrics {
 		adapterLabelNames,
 	)
 	metrics.Registry.MustRegister(metrics.adaptPanics)
+	metrics.adaptFloorRejections = newCounter(cfg, "adapter_floor_rejected_bids_total",
+		"Number of bids from each bidder which were rejected for being below the price floor.",
+		adapterLabelNames,
+	)
+	metrics.Registry.MustRegister(metrics.adaptFloorRejections)
 	metrics.adaptTimer = newHistogram(cfg, "adapter_time_seconds",
 		"Seconds to resolve each request to a bidder.",
 		adapterLabelNames, timerBuckets,
@@ -125,6 +137,16 @@ func NewMetrics(cfg config.PrometheusMetrics) *Metrics {
 		[]string{"cache_result"},
 	)
 	metrics.Registry.MustRegister(metrics.storedImpCacheResult)
+	metrics.accountCacheResult = newCounter(cfg, "account_cache_performance",
+		"Number of account cache hits vs miss",
+		[]string{"cache_result"},
+	)
+	metrics.Registry.MustRegister(metrics.accountCacheResult)
+	metrics.analyticsDropped = newCounter(cfg, "analytics_dropped_events",
+		"Number of events which the analytics modules threw away",
+		[]string{dropReasonLabel},
+	)
+	metrics.Registry.MustRegister(metrics.analyticsDropped)
 	metrics.adaptPrices = newHistogram(cfg, "adapter_prices",
 		"Values of the bids from each bidder.",
 		adapterLabelNames, prometheus.LinearBuckets(0.1, 0.1, 200),
@@ -139,7 +161,7 @@ func NewMetrics(cfg config.PrometheusMetrics) *Metrics {
 	metrics.Registry.MustRegister(metrics.cookieSync)
 	metrics.adaptCookieSync = newCounter(cfg, "cookie_sync_returns",
 		"Number of syncs generated for a bidder, and if they were subsequently blocked.",
-		[]string{adapterLabel, gdprBlockedLabel},
+		[]string{adapterLabel, syncSourceLabel, gdprBlockedLabel, ccpaBlockedLabel},
 	)
 	metrics.Registry.MustRegister(metrics.adaptCookieSync)
 	metrics.userID = newCounter(cfg, "setuid_calls",
@@ -147,6 +169,11 @@ func NewMetrics(cfg config.PrometheusMetrics) *Metrics {
 		[]string{"action", "bidder"},
 	)
 	metrics.Registry.MustRegister(metrics.userID)
+	metrics.userIDEvicted = newCounter(cfg, "setuid_evictions",
+		"Number of user IDs dropped from the uids cookie to keep it under the max size",
+		[]string{"bidder"},
+	)
+	metrics.Registry.MustRegister(metrics.userIDEvicted)
 
 	initializeTimeSeries(&metrics)
 
@@ -234,6 +261,10 @@ func (me *Metrics) RecordAdapterPanic(labels pbsmetrics.AdapterLabels) {
 	me.adaptPanics.With(resolveAdapterLabels(labels)).Inc()
 }
 
+func (me *Metrics) RecordAdapterFloorRejectedBid(labels pbsmetrics.AdapterLabels) {
+	me.adaptFloorRejections.With(resolveAdapterLabels(labels)).Inc()
+}
+
 func (me *Metrics) RecordAdapterRequest(labels pbsmetrics.AdapterLabels) {
 	me.adaptRequests.With(resolveAdapterLabels(labels)).Inc()
 	for k := range labels.AdapterErrors {
@@ -258,15 +289,21 @@ func (me *Metrics) RecordCookieSync(labels pbsmetrics.Labels) {
 	me.cookieSync.Inc()
 }
 
-func (me *Metrics) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, gdprBlocked bool) {
+func (me *Metrics) RecordAdapterCookieSync(adapter openrtb_ext.BidderName, source pbsmetrics.CookieSyncSource, gdprBlocked bool, ccpaBlocked bool) {
 	labels := prometheus.Labels{
-		adapterLabel: string(adapter),
+		adapterLabel:    string(adapter),
+		syncSourceLabel: string(source),
 	}
 	if gdprBlocked {
 		labels[gdprBlockedLabel] = "true"
 	} else {
 		labels[gdprBlockedLabel] = "false"
 	}
+	if ccpaBlocked {
+		labels[ccpaBlockedLabel] = "true"
+	} else {
+		labels[ccpaBlockedLabel] = "false"
+	}
 	me.adaptCookieSync.With(labels).Inc()
 }
 
@@ -288,10 +325,33 @@ func (me *Metrics) RecordStoredImpCacheResult(cacheResult pbsmetrics.CacheResult
 	me.storedImpCacheResult.With(labels).Add(float64(inc))
 }
 
+// RecordAccountCacheResult records cache hits and misses when looking up accounts
+func (me *Metrics) RecordAccountCacheResult(cacheResult pbsmetrics.CacheResult, inc int) {
+	labels := prometheus.Labels{
+		cacheResultLabel: string(cacheResult),
+	}
+
+	me.accountCacheResult.With(labels).Add(float64(inc))
+}
+
+// RecordAnalyticsEventsDropped records the events which the analytics modules threw away
+func (me *Metrics) RecordAnalyticsEventsDropped(reason pbsmetrics.AnalyticsDropReason, inc int) {
+	labels := prometheus.Labels{
+		dropReasonLabel: string(reason),
+	}
+
+	me.analyticsDropped.With(labels).Add(float64(inc))
+}
+
 func (me *Metrics) RecordUserIDSet(userLabels pbsmetrics.UserLabels) {
 	me.userID.With(resolveUserSyncLabels(userLabels)).Inc()
 }
 
+// RecordUserIDEvicted records a user ID which was dropped from the uids cookie to keep it under the max size
+func (me *Metrics) RecordUserIDEvicted(bidder openrtb_ext.BidderName) {
+	me.userIDEvicted.With(prometheus.Labels{"bidder": string(bidder)}).Inc()
+}
+
 func resolveLabels(labels pbsmetrics.Labels) prometheus.Labels {
 	return prometheus.Labels{
 		demandSourceLabel: string(labels.Source),
@@ -403,6 +463,7 @@ func initializeTimeSeries(m *Metrics) {
 		_ = m.adaptTimer.With(l)
 		_ = m.adaptPrices.With(l)
 		_ = m.adaptPanics.With(l)
+		_ = m.adaptFloorRejections.With(l)
 	}
 	// AdapterBid labels
 	labels = addDimension(labels, bidTypeLabel, bidTypesAsString())
@@ -415,7 +476,9 @@ func initializeTimeSeries(m *Metrics) {
 		_ = m.adaptErrors.With(l)
 	}
 	cookieLabels := addDimension([]prometheus.Labels{}, adapterLabel, adaptersAsString())
+	cookieLabels = addDimension(cookieLabels, syncSourceLabel, cookieSyncSourcesAsString())
 	cookieLabels = addDimension(cookieLabels, gdprBlockedLabel, []string{"true", "false"})
+	cookieLabels = addDimension(cookieLabels, ccpaBlockedLabel, []string{"true", "false"})
 	for _, l := range cookieLabels {
 		_ = m.adaptCookieSync.With(l)
 	}
@@ -423,6 +486,10 @@ func initializeTimeSeries(m *Metrics) {
 	for _, l := range cacheLabels {
 		_ = m.storedImpCacheResult.With(l)
 		_ = m.storedReqCacheResult.With(l)
+		_ = m.accountCacheResult.With(l)
+	}
+	for _, reason := range pbsmetrics.AnalyticsDropReasons() {
+		_ = m.analyticsDropped.With(prometheus.Labels{dropReasonLabel: string(reason)})
 	}
 
 	// ImpType labels
@@ -540,6 +607,15 @@ func cacheResultAsString() []string {
 	return output
 }
 
+func cookieSyncSourcesAsString() []string {
+	list := pbsmetrics.CookieSyncSources()
+	output := make([]string, len(list))
+	for i, s := range list {
+		output[i] = string(s)
+	}
+	return output
+}
+
 func adaptersAsString() []string {
 	list := openrtb_ext.BidderList()
 	output := make([]string, len(list))
@@ -244,6 +244,22 @@ func TestAdapterRequestMetrics(t *testing.T) {
 	assertCounterValue(t, "adapter_errors[4]", &emetrics4, 0)
 }
 
+func TestAdapterFloorRejectedBidMetrics(t *testing.T) {
+	proMetrics := newTestMetricsEngine()
+
+	metrics0 := dto.Metric{}
+	metrics1 := dto.Metric{}
+
+	proMetrics.RecordAdapterFloorRejectedBid(adaptLabels[0])
+	proMetrics.RecordAdapterFloorRejectedBid(adaptLabels[0])
+
+	proMetrics.adaptFloorRejections.With(resolveAdapterLabels(adaptLabels[0])).Write(&metrics0)
+	proMetrics.adaptFloorRejections.With(resolveAdapterLabels(adaptLabels[1])).Write(&metrics1)
+
+	assertCounterValue(t, "adapter_floor_rejected_bids[0]", &metrics0, 2)
+	assertCounterValue(t, "adapter_floor_rejected_bids[1]", &metrics1, 0)
+}
+
 func TestAdapterBidsMetrics(t *testing.T) {
 	proMetrics := newTestMetricsEngine()
 
@@ -375,6 +391,56 @@ func TestRecordStoredImpCacheResult(t *testing.T) {
 	assertCounterValue(t, "stored_imp_cache_performance[miss]", &metricCacheMiss, 1)
 }
 
+func TestRecordAccountCacheResult(t *testing.T) {
+	proMetrics := newTestMetricsEngine()
+
+	metricCacheHit := dto.Metric{}
+	metricCacheMiss := dto.Metric{}
+
+	proMetrics.RecordAccountCacheResult(pbsmetrics.CacheHit, 2)
+	proMetrics.RecordAccountCacheResult(pbsmetrics.CacheHit, 0)
+	proMetrics.RecordAccountCacheResult(pbsmetrics.CacheMiss, 1)
+
+	proMetrics.accountCacheResult.WithLabelValues(string(pbsmetrics.CacheHit)).Write(&metricCacheHit)
+	proMetrics.accountCacheResult.WithLabelValues(string(pbsmetrics.CacheMiss)).Write(&metricCacheMiss)
+
+	assertCounterValue(t, "account_cache_performance[hit]", &metricCacheHit, 2)
+	assertCounterValue(t, "account_cache_performance[miss]", &metricCacheMiss, 1)
+}
+
+func TestRecordAnalyticsEventsDropped(t *testing.T) {
+	proMetrics := newTestMetricsEngine()
+
+	metricBufferFull := dto.Metric{}
+	metricSendFailed := dto.Metric{}
+
+	proMetrics.RecordAnalyticsEventsDropped(pbsmetrics.AnalyticsBufferFull, 1)
+	proMetrics.RecordAnalyticsEventsDropped(pbsmetrics.AnalyticsBufferFull, 2)
+
+	proMetrics.analyticsDropped.WithLabelValues(string(pbsmetrics.AnalyticsBufferFull)).Write(&metricBufferFull)
+	proMetrics.analyticsDropped.WithLabelValues(string(pbsmetrics.AnalyticsSendFailed)).Write(&metricSendFailed)
+
+	assertCounterValue(t, "analytics_dropped_events[buffer_full]", &metricBufferFull, 3)
+	assertCounterValue(t, "analytics_dropped_events[send_failed]", &metricSendFailed, 0)
+}
+
+func TestRecordAdapterCookieSync(t *testing.T) {
+	proMetrics := newTestMetricsEngine()
+
+	metricRequest := dto.Metric{}
+	metricCoop := dto.Metric{}
+
+	proMetrics.RecordAdapterCookieSync(openrtb_ext.BidderAppnexus, pbsmetrics.CookieSyncSourceRequest, false, false)
+	proMetrics.RecordAdapterCookieSync(openrtb_ext.BidderAppnexus, pbsmetrics.CookieSyncSourceCoop, false, false)
+	proMetrics.RecordAdapterCookieSync(openrtb_ext.BidderAppnexus, pbsmetrics.CookieSyncSourceCoop, false, false)
+
+	proMetrics.adaptCookieSync.WithLabelValues(string(openrtb_ext.BidderAppnexus), string(pbsmetrics.CookieSyncSourceRequest), "false", "false").Write(&metricRequest)
+	proMetrics.adaptCookieSync.WithLabelValues(string(openrtb_ext.BidderAppnexus), string(pbsmetrics.CookieSyncSourceCoop), "false", "false").Write(&metricCoop)
+
+	assertCounterValue(t, "cookie_sync_returns[request]", &metricRequest, 1)
+	assertCounterValue(t, "cookie_sync_returns[coop]", &metricCoop, 2)
+}
+
 func TestCookieMetrics(t *testing.T) {
 	proMetrics := newTestMetricsEngine()
 
@@ -419,6 +485,19 @@ func TestUserMetrics(t *testing.T) {
 	assertCounterValue(t, "usersync[3]", &metrics3, 0)
 }
 
+func TestRecordUserIDEvicted(t *testing.T) {
+	proMetrics := newTestMetricsEngine()
+
+	metricAppnexus := dto.Metric{}
+
+	proMetrics.RecordUserIDEvicted(openrtb_ext.BidderAppnexus)
+	proMetrics.RecordUserIDEvicted(openrtb_ext.BidderAppnexus)
+
+	proMetrics.userIDEvicted.WithLabelValues(string(openrtb_ext.BidderAppnexus)).Write(&metricAppnexus)
+
+	assertCounterValue(t, "setuid_evictions[appnexus]", &metricAppnexus, 2)
+}
+
 func TestMetricsExist(t *testing.T) {
 	// Initialize the metrics engine -> register the metrics to prometheus
 	metrics := newTestMetricsEngine()
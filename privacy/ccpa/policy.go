@@ -0,0 +1,92 @@
+package ccpa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// The IAB US Privacy string is always 4 characters long. See:
+// https://github.com/InteractiveAdvertisingBureau/USPrivacy/blob/master/CCPA/US%20Privacy%20String.md
+const (
+	stringLength        = 4
+	indexVersion        = 0
+	indexNoticeProvided = 1
+	indexOptOutSale     = 2
+	indexLSPACoverage   = 3
+
+	supportedVersion = '1'
+
+	valueYes           = 'Y'
+	valueNo            = 'N'
+	valueNotApplicable = '-'
+)
+
+// Policy represents the CCPA regulatory information from an OpenRTB bid request or a usersync request.
+type Policy struct {
+	Value string
+}
+
+// ReadPolicy extracts the CCPA policy from request.regs.ext.us_privacy. A request without
+// the field yields an empty Policy, which is never enforced.
+func ReadPolicy(req *openrtb.BidRequest) (Policy, error) {
+	var policy Policy
+
+	if req == nil || req.Regs == nil || len(req.Regs.Ext) == 0 {
+		return policy, nil
+	}
+
+	var ext openrtb_ext.ExtRegs
+	if err := json.Unmarshal(req.Regs.Ext, &ext); err != nil {
+		return policy, fmt.Errorf("error reading request.regs.ext: %v", err)
+	}
+
+	policy.Value = ext.USPrivacy
+	return policy, nil
+}
+
+// Validate returns an error if the CCPA policy does not adhere to the IAB US Privacy string format.
+// An empty policy is considered valid.
+func (p Policy) Validate() error {
+	if p.Value == "" {
+		return nil
+	}
+
+	if len(p.Value) != stringLength {
+		return errors.New("must contain 4 characters")
+	}
+
+	if p.Value[indexVersion] != supportedVersion {
+		return errors.New("must specify version 1")
+	}
+
+	if !isValidFlag(p.Value[indexNoticeProvided]) {
+		return errors.New("must specify 'N', 'Y', or '-' for the explicit notice")
+	}
+
+	if !isValidFlag(p.Value[indexOptOutSale]) {
+		return errors.New("must specify 'N', 'Y', or '-' for the opt-out sale")
+	}
+
+	if !isValidFlag(p.Value[indexLSPACoverage]) {
+		return errors.New("must specify 'N', 'Y', or '-' for the limited service provider agreement")
+	}
+
+	return nil
+}
+
+// ShouldEnforce returns true when the user has opted out of the sale of their personal information.
+// Invalid policies are never enforced, since the intent of the publisher cannot be determined.
+func (p Policy) ShouldEnforce() bool {
+	if err := p.Validate(); err != nil || p.Value == "" {
+		return false
+	}
+	return p.Value[indexOptOutSale] == valueYes
+}
+
+func isValidFlag(c byte) bool {
+	return c == valueYes || c == valueNo || c == valueNotApplicable
+}
@@ -0,0 +1,107 @@
+package ccpa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPolicy(t *testing.T) {
+	testCases := []struct {
+		description    string
+		request        *openrtb.BidRequest
+		expectedPolicy Policy
+		expectError    bool
+	}{
+		{
+			description:    "Nil Request",
+			request:        nil,
+			expectedPolicy: Policy{},
+		},
+		{
+			description:    "Nil Regs",
+			request:        &openrtb.BidRequest{},
+			expectedPolicy: Policy{},
+		},
+		{
+			description:    "Empty Regs.Ext",
+			request:        &openrtb.BidRequest{Regs: &openrtb.Regs{}},
+			expectedPolicy: Policy{},
+		},
+		{
+			description:    "Regs.Ext Without US Privacy",
+			request:        &openrtb.BidRequest{Regs: &openrtb.Regs{Ext: json.RawMessage(`{"gdpr":1}`)}},
+			expectedPolicy: Policy{},
+		},
+		{
+			description:    "Regs.Ext With US Privacy",
+			request:        &openrtb.BidRequest{Regs: &openrtb.Regs{Ext: json.RawMessage(`{"us_privacy":"1NYN"}`)}},
+			expectedPolicy: Policy{Value: "1NYN"},
+		},
+		{
+			description: "Malformed Regs.Ext",
+			request:     &openrtb.BidRequest{Regs: &openrtb.Regs{Ext: json.RawMessage(`malformed`)}},
+			expectError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		policy, err := ReadPolicy(test.request)
+
+		if test.expectError {
+			assert.Error(t, err, test.description)
+		} else {
+			assert.NoError(t, err, test.description)
+			assert.Equal(t, test.expectedPolicy, policy, test.description)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		description string
+		policy      Policy
+		expectError bool
+	}{
+		{description: "Empty", policy: Policy{Value: ""}},
+		{description: "Valid", policy: Policy{Value: "1NYN"}},
+		{description: "Valid - Not Applicable", policy: Policy{Value: "1---"}},
+		{description: "Lowercase", policy: Policy{Value: "1nyn"}, expectError: true},
+		{description: "Too Short", policy: Policy{Value: "1NY"}, expectError: true},
+		{description: "Too Long", policy: Policy{Value: "1NYNN"}, expectError: true},
+		{description: "Unsupported Version", policy: Policy{Value: "2NYN"}, expectError: true},
+		{description: "Invalid Notice", policy: Policy{Value: "1XYN"}, expectError: true},
+		{description: "Invalid Opt-Out", policy: Policy{Value: "1NXN"}, expectError: true},
+		{description: "Invalid LSPA", policy: Policy{Value: "1NYX"}, expectError: true},
+	}
+
+	for _, test := range testCases {
+		err := test.policy.Validate()
+
+		if test.expectError {
+			assert.Error(t, err, test.description)
+		} else {
+			assert.NoError(t, err, test.description)
+		}
+	}
+}
+
+func TestShouldEnforce(t *testing.T) {
+	testCases := []struct {
+		description string
+		policy      Policy
+		expected    bool
+	}{
+		{description: "Empty", policy: Policy{Value: ""}, expected: false},
+		{description: "Opt-Out Yes", policy: Policy{Value: "1NYN"}, expected: true},
+		{description: "Opt-Out No", policy: Policy{Value: "1NNN"}, expected: false},
+		{description: "Opt-Out Not Applicable", policy: Policy{Value: "1---"}, expected: false},
+		{description: "Invalid", policy: Policy{Value: "2NYN"}, expected: false},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, test.policy.ShouldEnforce(), test.description)
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"net/http"
 	"net/http/pprof"
 
+	"github.com/prebid/prebid-server/config"
 	"github.com/prebid/prebid-server/currencies"
 	"github.com/prebid/prebid-server/endpoints"
 )
 
-func Admin(revision string, rateConverter *currencies.RateConverter) *http.ServeMux {
+func Admin(revision string, rateConverter *currencies.RateConverter, cfg *config.Configuration, r *Router) *http.ServeMux {
 	// Add endpoints to the admin server
 	// Making sure to add pprof routes
 	mux := http.NewServeMux()
@@ -21,5 +22,13 @@ func Admin(revision string, rateConverter *currencies.RateConverter) *http.Serve
 	// Register prebid-server defined admin handlers
 	mux.HandleFunc("/currency/rates", endpoints.NewCurrencyRatesEndpoint(rateConverter))
 	mux.HandleFunc("/version", endpoints.NewVersionEndpoint(revision))
+	mux.HandleFunc("/config", endpoints.NewConfigEndpoint(cfg))
+	mux.HandleFunc("/config/bidders", endpoints.NewBiddersConfigEndpoint(cfg))
+	mux.HandleFunc("/storedrequests/caches", endpoints.NewStoredDataCachesEndpoint(r.storedDataCaches))
+	mux.HandleFunc("/storedrequests/request", endpoints.NewStoredRequestEndpoint(r.storedRequestFetchers))
+	mux.HandleFunc("/storedrequests/imp", endpoints.NewStoredImpEndpoint(r.storedRequestFetchers["auction"]))
+	if r.storedDataWriteEndpoint != nil {
+		mux.HandleFunc("/storedrequests/save", r.storedDataWriteEndpoint)
+	}
 	return mux
 }
@@ -40,8 +40,10 @@ import (
 	metricsConf "github.com/prebid/prebid-server/pbsmetrics/config"
 	pbc "github.com/prebid/prebid-server/prebid_cache_client"
 	"github.com/prebid/prebid-server/ssl"
+	"github.com/prebid/prebid-server/stored_requests"
 	storedRequestsConf "github.com/prebid/prebid-server/stored_requests/config"
 	"github.com/prebid/prebid-server/usersync/usersyncers"
+	uidStoreConf "github.com/prebid/prebid-server/usersync/uidstores/config"
 
 	"github.com/golang/glog"
 	"github.com/julienschmidt/httprouter"
@@ -166,6 +168,12 @@ type Router struct {
 	MetricsEngine   *metricsConf.DetailedMetricsEngine
 	ParamsValidator openrtb_ext.BidderParamValidator
 	Shutdown        func()
+
+	// storedRequestFetchers and storedDataCaches are exposed by the admin server.
+	storedRequestFetchers map[string]stored_requests.Fetcher
+	storedDataCaches      map[string]stored_requests.CacheInspector
+	// storedDataWriteEndpoint is served by the admin server. It's nil unless the Stored Request write API is enabled.
+	storedDataWriteEndpoint http.HandlerFunc
 }
 
 func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r *Router, err error) {
@@ -189,16 +197,39 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 
 	// Metrics engine
 	r.MetricsEngine = metricsConf.NewMetricsEngine(cfg, legacyBidderList)
-	db, shutdown, fetcher, ampFetcher, categoriesFetcher, videoFetcher := storedRequestsConf.NewStoredRequests(cfg, r.MetricsEngine, theClient, r.Router)
+	db, shutdown, fetcher, ampFetcher, categoriesFetcher, videoFetcher, accountsFetcher, responsesFetcher, storedDataWriter := storedRequestsConf.NewStoredRequests(cfg, r.MetricsEngine, theClient, r.Router)
+	r.storedRequestFetchers = map[string]stored_requests.Fetcher{
+		"auction": fetcher,
+		"amp":     ampFetcher,
+		"video":   videoFetcher,
+	}
+	r.storedDataCaches = make(map[string]stored_requests.CacheInspector)
+	for name, storedDataFetcher := range map[string]interface{}{
+		"auction":    fetcher,
+		"amp":        ampFetcher,
+		"categories": categoriesFetcher,
+		"video":      videoFetcher,
+		"accounts":   accountsFetcher,
+		"responses":  responsesFetcher,
+	} {
+		if inspector, ok := storedDataFetcher.(stored_requests.CacheInspector); ok {
+			r.storedDataCaches[name] = inspector
+		}
+	}
+
+	pbsAnalytics, analyticsShutdown := analyticsConf.NewPBSAnalytics(&cfg.Analytics, theClient, r.MetricsEngine)
+	uidStore, uidStoreShutdown := uidStoreConf.NewUIDStore(&cfg.UserSync.UIDStore)
 
 	// todo(zachbadgett): better shutdown
-	r.Shutdown = shutdown
+	r.Shutdown = func() {
+		shutdown()
+		analyticsShutdown()
+		uidStoreShutdown()
+	}
 	if err := loadDataCache(cfg, db); err != nil {
 		return nil, fmt.Errorf("Prebid Server could not load data cache: %v", err)
 	}
 
-	pbsAnalytics := analyticsConf.NewPBSAnalytics(&cfg.Analytics)
-
 	paramsValidator, err := openrtb_ext.NewBidderParamsValidator(schemaDirectory)
 	if err != nil {
 		glog.Fatalf("Failed to create the bidder params validator. %v", err)
@@ -218,25 +249,33 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 	gdprPerms := gdpr.NewPermissions(context.Background(), cfg.GDPR, adapters.GDPRAwareSyncerIDs(syncers), theClient)
 
 	exchanges = newExchangeMap(cfg)
-	theExchange := exchange.NewExchange(theClient, pbc.NewClient(&cfg.CacheURL), cfg, r.MetricsEngine, bidderInfos, gdprPerms, rateConvertor)
+	cacheClient := pbc.NewClient(&cfg.CacheURL)
+	theExchange := exchange.NewExchange(theClient, cacheClient, cfg, r.MetricsEngine, bidderInfos, gdprPerms, rateConvertor, responsesFetcher)
 
-	openrtbEndpoint, err := openrtb2.NewEndpoint(theExchange, paramsValidator, fetcher, categoriesFetcher, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap)
+	openrtbEndpoint, err := openrtb2.NewEndpoint(theExchange, paramsValidator, fetcher, accountsFetcher, categoriesFetcher, uidStore, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap)
 
 	if err != nil {
 		glog.Fatalf("Failed to create the openrtb endpoint handler. %v", err)
 	}
 
-	ampEndpoint, err := openrtb2.NewAmpEndpoint(theExchange, paramsValidator, ampFetcher, categoriesFetcher, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap)
+	ampEndpoint, err := openrtb2.NewAmpEndpoint(theExchange, paramsValidator, ampFetcher, accountsFetcher, categoriesFetcher, uidStore, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap)
 
 	if err != nil {
 		glog.Fatalf("Failed to create the amp endpoint handler. %v", err)
 	}
 
-	videoEndpoint, err := openrtb2.NewVideoEndpoint(theExchange, paramsValidator, fetcher, videoFetcher, categoriesFetcher, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap)
+	videoEndpoint, err := openrtb2.NewVideoEndpoint(theExchange, paramsValidator, fetcher, videoFetcher, accountsFetcher, categoriesFetcher, uidStore, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON, activeBiddersMap)
 	if err != nil {
 		glog.Fatalf("Failed to create the video endpoint handler. %v", err)
 	}
 
+	if storedDataWriter != nil {
+		r.storedDataWriteEndpoint, err = openrtb2.NewStoredDataWriteEndpoint(paramsValidator, fetcher, storedDataWriter, cfg, disabledBidders, defReqJSON, activeBiddersMap)
+		if err != nil {
+			glog.Fatalf("Failed to create the stored data write endpoint handler. %v", err)
+		}
+	}
+
 	r.POST("/auction", endpoints.Auction(cfg, syncers, gdprPerms, r.MetricsEngine, dataCache, exchanges))
 	r.POST("/openrtb2/auction", openrtbEndpoint)
 	r.POST("/openrtb2/video", videoEndpoint)
@@ -244,7 +283,7 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 	r.GET("/info/bidders", infoEndpoints.NewBiddersEndpoint(defaultAliases))
 	r.GET("/info/bidders/:bidderName", infoEndpoints.NewBidderDetailsEndpoint(bidderInfos, defaultAliases))
 	r.GET("/bidders/params", NewJsonDirectoryServer(schemaDirectory, paramsValidator, defaultAliases))
-	r.POST("/cookie_sync", endpoints.NewCookieSyncEndpoint(syncers, cfg, gdprPerms, r.MetricsEngine, pbsAnalytics))
+	r.POST("/cookie_sync", endpoints.NewCookieSyncEndpoint(syncers, cfg, gdprPerms, r.MetricsEngine, pbsAnalytics, accountsFetcher))
 	r.GET("/status", endpoints.NewStatusEndpoint(cfg.StatusResponse))
 	r.GET("/", serveIndex)
 	r.ServeFiles("/static/*filepath", http.Dir("static"))
@@ -255,10 +294,14 @@ func New(cfg *config.Configuration, rateConvertor *currencies.RateConverter) (r
 		RecaptchaSecret:  cfg.RecaptchaSecret,
 		MetricsEngine:    r.MetricsEngine,
 		PBSAnalytics:     pbsAnalytics,
+		UIDStore:         uidStore,
+		UIDStoreConfig:   &cfg.UserSync.UIDStore,
 	}
 
-	r.GET("/setuid", endpoints.NewSetUIDEndpoint(cfg.HostCookie, gdprPerms, pbsAnalytics, r.MetricsEngine))
+	r.GET("/setuid", endpoints.NewSetUIDEndpoint(syncers, cfg.HostCookie, gdprPerms, pbsAnalytics, r.MetricsEngine, uidStore, cfg.UserSync.UIDStore))
 	r.GET("/getuids", endpoints.NewGetUIDsEndpoint(cfg.HostCookie))
+	r.GET("/event", endpoints.NewEventEndpoint(cfg, accountsFetcher, pbsAnalytics))
+	r.POST("/vtrack", endpoints.NewVTrackEndpoint(cfg, accountsFetcher, cacheClient))
 	r.POST("/optout", userSyncDeps.OptOut)
 	r.GET("/optout", userSyncDeps.OptOut)
 
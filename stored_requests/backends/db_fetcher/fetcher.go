@@ -93,8 +93,157 @@ func (fetcher *dbFetcher) FetchRequests(ctx context.Context, requestIDs []string
 	return storedRequestData, storedImpData, errs
 }
 
+// FetchAccount expects the queryMaker to build an account query when it's asked for a single "request" ID.
+// Rows with type "account" are returned; all other rows are ignored.
+func (fetcher *dbFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	notFound := []error{stored_requests.NotFoundError{
+		ID:       accountID,
+		DataType: "Account",
+	}}
+
+	rows, err := fetcher.db.QueryContext(ctx, fetcher.queryMaker(1, 0), accountID)
+	if err != nil {
+		if err != context.DeadlineExceeded && !isBadInput(err) {
+			glog.Errorf("Error reading from Stored Account DB: %s", err.Error())
+			return nil, notFound
+		}
+		return nil, []error{err}
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			glog.Errorf("error closing DB connection: %v", err)
+		}
+	}()
+
+	var account json.RawMessage
+	for rows.Next() {
+		var id string
+		var data []byte
+		var dataType string
+
+		if err := rows.Scan(&id, &data, &dataType); err != nil {
+			return nil, []error{err}
+		}
+
+		if dataType == "account" && id == accountID {
+			account = data
+		}
+	}
+
+	if rows.Err() != nil {
+		return nil, []error{rows.Err()}
+	}
+	if account == nil {
+		return nil, notFound
+	}
+	return account, nil
+}
+
+// FetchResponses expects the queryMaker to build a Stored Response query when it's asked for "request" IDs.
+// Rows with type "response" are returned; all other rows are ignored.
+func (fetcher *dbFetcher) FetchResponses(ctx context.Context, ids []string) (map[string]json.RawMessage, []error) {
+	if len(ids) < 1 {
+		return nil, nil
+	}
+
+	idInterfaces := make([]interface{}, len(ids))
+	for i := 0; i < len(ids); i++ {
+		idInterfaces[i] = ids[i]
+	}
+
+	rows, err := fetcher.db.QueryContext(ctx, fetcher.queryMaker(len(ids), 0), idInterfaces...)
+	if err != nil {
+		if err != context.DeadlineExceeded && !isBadInput(err) {
+			glog.Errorf("Error reading from Stored Response DB: %s", err.Error())
+			return nil, appendErrors("Response", ids, nil, nil)
+		}
+		return nil, []error{err}
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			glog.Errorf("error closing DB connection: %v", err)
+		}
+	}()
+
+	storedResponseData := make(map[string]json.RawMessage, len(ids))
+	for rows.Next() {
+		var id string
+		var data []byte
+		var dataType string
+
+		if err := rows.Scan(&id, &data, &dataType); err != nil {
+			return nil, []error{err}
+		}
+
+		if dataType == "response" {
+			storedResponseData[id] = data
+		}
+	}
+
+	if rows.Err() != nil {
+		return nil, []error{rows.Err()}
+	}
+
+	return storedResponseData, appendErrors("Response", ids, storedResponseData, nil)
+}
+
 func (fetcher *dbFetcher) FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error) {
-	return "", nil
+	data, err := fetcher.FetchCategoryMapping(ctx, primaryAdServer, publisherId)
+	if err != nil {
+		return "", err
+	}
+	mapping, err := stored_requests.ParseCategoryMapping(data, primaryAdServer, publisherId)
+	if err != nil {
+		return "", err
+	}
+	return stored_requests.FindCategory(mapping, primaryAdServer, publisherId, iabCategory)
+}
+
+// FetchCategoryMapping expects the queryMaker to build a Category Mapping query when it's asked for one "request" ID.
+// The ID is the mapping's CategoryMappingID. Rows with type "category" are returned; all other rows are ignored.
+func (fetcher *dbFetcher) FetchCategoryMapping(ctx context.Context, primaryAdServer, publisherId string) (json.RawMessage, error) {
+	mappingID := stored_requests.CategoryMappingID(primaryAdServer, publisherId)
+	notFound := stored_requests.NotFoundError{
+		ID:       mappingID,
+		DataType: "CategoryMapping",
+	}
+
+	rows, err := fetcher.db.QueryContext(ctx, fetcher.queryMaker(1, 0), mappingID)
+	if err != nil {
+		if err != context.DeadlineExceeded && !isBadInput(err) {
+			glog.Errorf("Error reading from Category Mapping DB: %s", err.Error())
+			return nil, notFound
+		}
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			glog.Errorf("error closing DB connection: %v", err)
+		}
+	}()
+
+	var mapping json.RawMessage
+	for rows.Next() {
+		var id string
+		var data []byte
+		var dataType string
+
+		if err := rows.Scan(&id, &data, &dataType); err != nil {
+			return nil, err
+		}
+
+		if dataType == "category" && id == mappingID {
+			mapping = data
+		}
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	if mapping == nil {
+		return nil, notFound
+	}
+	return mapping, nil
 }
 
 func appendErrors(dataType string, ids []string, data map[string]json.RawMessage, errs []error) []error {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prebid/prebid-server/stored_requests"
 )
 
 func TestEmptyQuery(t *testing.T) {
@@ -52,6 +53,100 @@ func TestGoodResponse(t *testing.T) {
 	assertHasData(t, storedImps, "imp-id-2", `{"imp":true,"value":2}`)
 }
 
+// TestAccountResponse makes sure we interpret DB responses properly when fetching an Account.
+func TestAccountResponse(t *testing.T) {
+	mockQuery := "SELECT id, config, 'account' AS dataType FROM accounts WHERE id IN (?)"
+	mockReturn := sqlmock.NewRows([]string{"id", "data", "dataType"}).
+		AddRow("account-id", `{"disabled":true}`, "account")
+
+	mock, fetcher := newFetcher(t, mockReturn, mockQuery, "account-id")
+	defer fetcher.db.Close()
+
+	account, errs := fetcher.FetchAccount(context.Background(), "account-id")
+
+	assertMockExpectations(t, mock)
+	assertErrorCount(t, 0, errs)
+	if string(account) != `{"disabled":true}` {
+		t.Errorf("Bad account data. Expected %s, Got %s", `{"disabled":true}`, account)
+	}
+}
+
+// TestMissingAccount makes sure we return a NotFoundError when the DB has no matching Account.
+func TestMissingAccount(t *testing.T) {
+	mockQuery := "SELECT id, config, 'account' AS dataType FROM accounts WHERE id IN (?)"
+	mockReturn := sqlmock.NewRows([]string{"id", "data", "dataType"})
+
+	mock, fetcher := newFetcher(t, mockReturn, mockQuery, "account-id")
+	defer fetcher.db.Close()
+
+	account, errs := fetcher.FetchAccount(context.Background(), "account-id")
+
+	assertMockExpectations(t, mock)
+	assertErrorCount(t, 1, errs)
+	if _, ok := errs[0].(stored_requests.NotFoundError); !ok {
+		t.Errorf("Expected a NotFoundError. Got %#v", errs[0])
+	}
+	if account != nil {
+		t.Errorf("Expected no account data. Got %s", account)
+	}
+}
+
+// TestCategoryMapping makes sure that publishers' Category Mappings are found by their CategoryMappingID.
+func TestCategoryMapping(t *testing.T) {
+	mockQuery := "SELECT id, mapping, 'category' AS dataType FROM category_mappings WHERE id IN (?)"
+	mockReturn := sqlmock.NewRows([]string{"id", "data", "dataType"}).
+		AddRow("freewheel_pub", `{"IAB1-1":{"id":"1","name":"Sports"}}`, "category")
+
+	mock, fetcher := newFetcher(t, mockReturn, mockQuery, "freewheel_pub")
+	defer fetcher.db.Close()
+
+	category, err := fetcher.FetchCategories(context.Background(), "freewheel", "pub", "IAB1-1")
+
+	assertMockExpectations(t, mock)
+	if err != nil || category != "1" {
+		t.Errorf("Bad category. Expected 1, Got %s with error %v", category, err)
+	}
+}
+
+// TestMissingCategoryMapping makes sure we return a NotFoundError when the DB has no matching Category Mapping.
+func TestMissingCategoryMapping(t *testing.T) {
+	mockQuery := "SELECT id, mapping, 'category' AS dataType FROM category_mappings WHERE id IN (?)"
+	mockReturn := sqlmock.NewRows([]string{"id", "data", "dataType"})
+
+	mock, fetcher := newFetcher(t, mockReturn, mockQuery, "freewheel")
+	defer fetcher.db.Close()
+
+	mapping, err := fetcher.FetchCategoryMapping(context.Background(), "freewheel", "")
+
+	assertMockExpectations(t, mock)
+	if _, ok := err.(stored_requests.NotFoundError); !ok {
+		t.Errorf("Expected a NotFoundError. Got %#v", err)
+	}
+	if mapping != nil {
+		t.Errorf("Expected no mapping data. Got %s", mapping)
+	}
+}
+
+// TestStoredResponses makes sure Stored Responses are returned, and missing ones produce NotFoundErrors.
+func TestStoredResponses(t *testing.T) {
+	mockQuery := "SELECT id, data, 'response' AS dataType FROM stored_responses WHERE id IN (?, ?)"
+	mockReturn := sqlmock.NewRows([]string{"id", "data", "dataType"}).
+		AddRow("resp-1", `[{"seat":"appnexus"}]`, "response")
+
+	mock, fetcher := newFetcher(t, mockReturn, mockQuery, "resp-1", "resp-2")
+	defer fetcher.db.Close()
+
+	data, errs := fetcher.FetchResponses(context.Background(), []string{"resp-1", "resp-2"})
+
+	assertMockExpectations(t, mock)
+	assertErrorCount(t, 1, errs)
+	if _, ok := errs[0].(stored_requests.NotFoundError); !ok {
+		t.Errorf("Expected a NotFoundError. Got %#v", errs[0])
+	}
+	assertMapLength(t, 1, data)
+	assertHasData(t, data, "resp-1", `[{"seat":"appnexus"}]`)
+}
+
 // TestPartialResponse makes sure we unpack things properly when the DB finds some of the stored requests.
 func TestPartialResponse(t *testing.T) {
 	mockQuery := "SELECT id, data, 'request' AS dataType FROM req_table WHERE id IN (?, ?) UNION ALL SELECT id, data, 'imp' as dataType FROM imp_table WHERE id IN (NULL)"
@@ -0,0 +1,72 @@
+package db_fetcher
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// NewWriter returns a Writer which saves Stored Requests and Imps with the given queries.
+// Each query is run once per ID, with the ID as $1 and the JSON as $2, so they should be upserts like:
+//
+//	INSERT INTO stored_requests (id, requestData) VALUES ($1, $2)
+//	  ON CONFLICT (id) DO UPDATE SET requestData = EXCLUDED.requestData
+//
+// Every save happens in a single transaction.
+func NewWriter(db *sql.DB, requestQuery string, impQuery string) stored_requests.Writer {
+	if db == nil {
+		glog.Fatalf("The Postgres Stored Request Writer requires a database connection. Please report this as a bug.")
+	}
+	return &dbWriter{
+		db:           db,
+		requestQuery: requestQuery,
+		impQuery:     impQuery,
+	}
+}
+
+// dbWriter saves Stored Requests to a database. This should be instantiated through the NewWriter() function.
+type dbWriter struct {
+	db           *sql.DB
+	requestQuery string
+	impQuery     string
+}
+
+func (writer *dbWriter) SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error {
+	if len(requests) == 0 && len(imps) == 0 {
+		return nil
+	}
+
+	tx, err := writer.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := execForEach(ctx, tx, writer.requestQuery, requests); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := execForEach(ctx, tx, writer.impQuery, imps); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// execForEach runs the query for each ID, in sorted order so that concurrent saves take their locks in the same order.
+func execForEach(ctx context.Context, tx *sql.Tx, query string, data map[string]json.RawMessage) error {
+	ids := make([]string, 0, len(data))
+	for id := range data {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, query, id, []byte(data[id])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
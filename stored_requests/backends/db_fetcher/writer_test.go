@@ -0,0 +1,62 @@
+package db_fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const (
+	mockRequestSave = "INSERT INTO req_table (id, data) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data"
+	mockImpSave     = "INSERT INTO imp_table (id, data) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data"
+)
+
+func TestWriterSave(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(mockRequestSave)).WithArgs("a", []byte(`{"tmax":1}`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(mockRequestSave)).WithArgs("b", []byte(`{"tmax":2}`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(mockImpSave)).WithArgs("imp", []byte(`{"banner":{}}`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	writer := NewWriter(db, mockRequestSave, mockImpSave)
+	err = writer.SaveRequests(context.Background(), map[string]json.RawMessage{
+		"b": json.RawMessage(`{"tmax":2}`),
+		"a": json.RawMessage(`{"tmax":1}`),
+	}, map[string]json.RawMessage{
+		"imp": json.RawMessage(`{"banner":{}}`),
+	})
+	if err != nil {
+		t.Errorf("Unexpected error saving: %v", err)
+	}
+	assertMockExpectations(t, mock)
+}
+
+func TestWriterRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(mockRequestSave)).WithArgs("a", []byte(`{}`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(mockImpSave)).WithArgs("imp", []byte(`{}`)).WillReturnError(errors.New("constraint violated"))
+	mock.ExpectRollback()
+
+	writer := NewWriter(db, mockRequestSave, mockImpSave)
+	err = writer.SaveRequests(context.Background(), map[string]json.RawMessage{"a": json.RawMessage(`{}`)}, map[string]json.RawMessage{"imp": json.RawMessage(`{}`)})
+	if err == nil || err.Error() != "constraint violated" {
+		t.Errorf("Expected the query's error. Got %v", err)
+	}
+	assertMockExpectations(t, mock)
+}
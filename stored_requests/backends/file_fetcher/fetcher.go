@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/prebid/prebid-server/stored_requests"
 )
@@ -15,65 +19,196 @@ import (
 //
 // This expects each file in the directory to be named "{config_id}.json".
 // For example, when asked to fetch the request with ID == "23", it will return the data from "directory/23.json".
+//
+// The returned Fetcher is also a stored_requests.Writer, which saves Stored Requests and Imps to
+// "directory/stored_requests/{id}.json" and "directory/stored_imps/{id}.json".
 func NewFileFetcher(directory string) (stored_requests.AllFetcher, error) {
 	storedData, err := collectStoredData(directory, FileSystem{make(map[string]FileSystem), make(map[string]json.RawMessage)}, nil)
-	return &eagerFetcher{storedData, nil}, err
+	return &eagerFetcher{FileSystem: storedData, directory: directory}, err
 }
 
+// eagerFetcher never changes the maps in its FileSystem after they've been loaded. SaveRequests replaces them
+// with updated copies instead, so that the maps returned by FetchRequests are safe to keep reading.
 type eagerFetcher struct {
 	FileSystem FileSystem
-	Categories map[string]map[string]stored_requests.Category
+	directory  string
+	mutex      sync.RWMutex
 }
 
 func (fetcher *eagerFetcher) FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (map[string]json.RawMessage, map[string]json.RawMessage, []error) {
-	storedRequests := fetcher.FileSystem.Directories["stored_requests"].Files
-	storedImpressions := fetcher.FileSystem.Directories["stored_imps"].Files
+	storedRequests := fetcher.files("stored_requests")
+	storedImpressions := fetcher.files("stored_imps")
 	errs := appendErrors("Request", requestIDs, storedRequests, nil)
 	errs = appendErrors("Imp", impIDs, storedImpressions, errs)
 	return storedRequests, storedImpressions, errs
 }
 
+func (fetcher *eagerFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	if account, ok := fetcher.files("accounts")[accountID]; ok {
+		return account, nil
+	}
+	return nil, []error{stored_requests.NotFoundError{
+		ID:       accountID,
+		DataType: "Account",
+	}}
+}
+
+func (fetcher *eagerFetcher) FetchResponses(ctx context.Context, ids []string) (map[string]json.RawMessage, []error) {
+	storedResponses := fetcher.files("stored_responses")
+	return storedResponses, appendErrors("Response", ids, storedResponses, nil)
+}
+
 func (fetcher *eagerFetcher) FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error) {
-	fileName := primaryAdServer
+	data, err := fetcher.FetchCategoryMapping(ctx, primaryAdServer, publisherId)
+	if _, ok := err.(stored_requests.NotFoundError); ok {
+		if fetcher.files(primaryAdServer) != nil {
+			return "", fmt.Errorf("Unable to find mapping file for adserver: '%s', publisherId: '%s'", primaryAdServer, publisherId)
+		}
+		return "", fmt.Errorf("Category '%s' not found for server: '%s', publisherId: '%s'",
+			iabCategory, primaryAdServer, publisherId)
+	}
 
-	if len(publisherId) != 0 {
-		fileName = primaryAdServer + "_" + publisherId
+	mapping, err := stored_requests.ParseCategoryMapping(data, primaryAdServer, publisherId)
+	if err != nil {
+		return "", err
 	}
+	return stored_requests.FindCategory(mapping, primaryAdServer, publisherId, iabCategory)
+}
 
-	if fetcher.Categories == nil {
-		fetcher.Categories = make(map[string]map[string]stored_requests.Category)
+// FetchCategoryMapping reads the mappings from "{directory}/{adserver}/{adserver}_{publisher}.json",
+// or "{directory}/{adserver}/{adserver}.json" for the ad server's own mapping.
+func (fetcher *eagerFetcher) FetchCategoryMapping(ctx context.Context, primaryAdServer, publisherId string) (json.RawMessage, error) {
+	mappingID := stored_requests.CategoryMappingID(primaryAdServer, publisherId)
+	if mapping, ok := fetcher.files(primaryAdServer)[mappingID]; ok {
+		return mapping, nil
 	}
-	if data, ok := fetcher.Categories[fileName]; ok {
-		return data[iabCategory].Id, nil
+	return nil, stored_requests.NotFoundError{
+		ID:       mappingID,
+		DataType: "CategoryMapping",
 	}
+}
 
-	if primaryAdServerDir, found := fetcher.FileSystem.Directories[primaryAdServer]; found {
-
-		if file, ok := primaryAdServerDir.Files[fileName]; ok {
-
-			tmp := make(map[string]stored_requests.Category)
-
-			if err := json.Unmarshal(file, &tmp); err != nil {
-				return "", fmt.Errorf("Unable to unmarshal categories for adserver: '%s', publisherId: '%s'", primaryAdServer, publisherId)
-			}
-			fetcher.Categories[fileName] = tmp
-			resultCategory := tmp[iabCategory].Id
-			primaryAdServerDir.Files[fileName] = nil
+// SaveRequests writes the Stored Requests and Imps to their files, and then makes them visible to FetchRequests.
+//
+// Every file is written to a temporary file before any of them are renamed into place, so a failed write leaves
+// the old data alone. If a rename fails, the error lists the files which were saved before it.
+func (fetcher *eagerFetcher) SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error {
+	fetcher.mutex.Lock()
+	defer fetcher.mutex.Unlock()
 
-			if len(resultCategory) == 0 {
-				return "", fmt.Errorf("Unable to find category for adserver '%s', publisherId: '%s', iab category: '%s'", primaryAdServer, publisherId, iabCategory)
+	var pending []pendingFile
+	for _, save := range []struct {
+		subdirectory string
+		data         map[string]json.RawMessage
+	}{{"stored_requests", requests}, {"stored_imps", imps}} {
+		if len(save.data) == 0 {
+			continue
+		}
+		directory := filepath.Join(fetcher.directory, save.subdirectory)
+		if err := os.MkdirAll(directory, 0755); err != nil {
+			removeTempFiles(pending)
+			return err
+		}
+		for id, data := range save.data {
+			tempName, err := writeTempFile(directory, id, data)
+			if err != nil {
+				removeTempFiles(pending)
+				return err
 			}
-			return resultCategory, nil
-		} else {
-			return "", fmt.Errorf("Unable to find mapping file for adserver: '%s', publisherId: '%s'", primaryAdServer, publisherId)
+			pending = append(pending, pendingFile{
+				subdirectory: save.subdirectory,
+				id:           id,
+				data:         data,
+				tempName:     tempName,
+				name:         filepath.Join(directory, id+".json"),
+			})
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].subdirectory != pending[j].subdirectory {
+			return pending[i].subdirectory < pending[j].subdirectory
+		}
+		return pending[i].id < pending[j].id
+	})
 
+	saved := make(map[string]map[string]json.RawMessage, 2)
+	savedNames := make([]string, 0, len(pending))
+	for i, file := range pending {
+		if err := os.Rename(file.tempName, file.name); err != nil {
+			removeTempFiles(pending[i:])
+			fetcher.addFiles(saved)
+			return fmt.Errorf("Failed to save %s/%s: %v. These were saved before the failure: [%s]", file.subdirectory, file.id, err, strings.Join(savedNames, ", "))
 		}
+		if saved[file.subdirectory] == nil {
+			saved[file.subdirectory] = make(map[string]json.RawMessage)
+		}
+		saved[file.subdirectory][file.id] = file.data
+		savedNames = append(savedNames, file.subdirectory+"/"+file.id)
+	}
+	fetcher.addFiles(saved)
+	return nil
+}
 
+// addFiles makes the saved files visible to the Fetch methods. The caller must hold the write lock.
+func (fetcher *eagerFetcher) addFiles(saved map[string]map[string]json.RawMessage) {
+	for subdirectory, data := range saved {
+		files := make(map[string]json.RawMessage, len(fetcher.FileSystem.Directories[subdirectory].Files)+len(data))
+		for id, file := range fetcher.FileSystem.Directories[subdirectory].Files {
+			files[id] = file
+		}
+		for id, file := range data {
+			files[id] = file
+		}
+		directories := make(map[string]FileSystem, len(fetcher.FileSystem.Directories)+1)
+		for name, fileSystem := range fetcher.FileSystem.Directories {
+			directories[name] = fileSystem
+		}
+		directories[subdirectory] = FileSystem{Directories: fetcher.FileSystem.Directories[subdirectory].Directories, Files: files}
+		fetcher.FileSystem.Directories = directories
 	}
+}
 
-	return "", fmt.Errorf("Category '%s' not found for server: '%s', publisherId: '%s'",
-		iabCategory, primaryAdServer, publisherId)
+func (fetcher *eagerFetcher) files(subdirectory string) map[string]json.RawMessage {
+	fetcher.mutex.RLock()
+	defer fetcher.mutex.RUnlock()
+	return fetcher.FileSystem.Directories[subdirectory].Files
+}
 
+// pendingFile is a file which has been written to tempName, but hasn't been renamed to its real name yet.
+type pendingFile struct {
+	subdirectory string
+	id           string
+	data         json.RawMessage
+	tempName     string
+	name         string
+}
+
+func removeTempFiles(files []pendingFile) {
+	for _, file := range files {
+		os.Remove(file.tempName)
+	}
+}
+
+// writeTempFile writes the data to a temporary file in the directory, and returns its name.
+// The caller renames it to "{directory}/{id}.json" once it's ready, so that nothing ever reads a partly written file.
+func writeTempFile(directory string, id string, data json.RawMessage) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("%q can't be used as a file name", id)
+	}
+	file, err := ioutil.TempFile(directory, "."+id+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
 }
 
 type FileSystem struct {
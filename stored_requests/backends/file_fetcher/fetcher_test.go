@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/prebid/prebid-server/stored_requests"
@@ -24,6 +28,136 @@ func TestFileFetcher(t *testing.T) {
 	validateImp(t, storedImps)
 }
 
+func TestAccountFetcher(t *testing.T) {
+	fetcher, err := NewFileFetcher("./test")
+	assert.NoError(t, err, "Failed to create a Fetcher")
+
+	account, errs := fetcher.FetchAccount(context.Background(), "valid")
+	assertErrorCount(t, 0, errs)
+	assert.JSONEq(t, `{"id": "valid", "disabled": false}`, string(account))
+
+	_, errs = fetcher.FetchAccount(context.Background(), "nonexistent")
+	assertErrorCount(t, 1, errs)
+	assert.Error(t, errs[0])
+	assert.Equal(t, stored_requests.NotFoundError{ID: "nonexistent", DataType: "Account"}, errs[0])
+}
+
+func TestResponseFetcher(t *testing.T) {
+	fetcher, err := NewFileFetcher("./test")
+	assert.NoError(t, err, "Failed to create a Fetcher")
+
+	responses, errs := fetcher.FetchResponses(context.Background(), []string{"1", "nonexistent"})
+	assertErrorCount(t, 1, errs)
+	assert.Equal(t, stored_requests.NotFoundError{ID: "nonexistent", DataType: "Response"}, errs[0])
+	assert.JSONEq(t, `[{"seat": "appnexus", "bid": [{"id": "stored-bid", "impid": "imp-id", "price": 1.5}]}]`, string(responses["1"]))
+}
+
+func TestFileFetcherSave(t *testing.T) {
+	directory, err := ioutil.TempDir("", "file_fetcher")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(directory)
+	if !assert.NoError(t, os.Mkdir(filepath.Join(directory, "stored_requests"), 0755)) {
+		return
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(directory, "stored_requests", "old.json"), []byte(`{"tmax":100}`), 0644))
+
+	fetcher, err := NewFileFetcher(directory)
+	if !assert.NoError(t, err) {
+		return
+	}
+	before, _, _ := fetcher.FetchRequests(context.Background(), []string{"old"}, nil)
+
+	writer := fetcher.(stored_requests.Writer)
+	err = writer.SaveRequests(context.Background(), map[string]json.RawMessage{"new": json.RawMessage(`{"tmax":200}`)}, map[string]json.RawMessage{"imp": json.RawMessage(`{"banner":{}}`)})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	requests, imps, errs := fetcher.FetchRequests(context.Background(), []string{"old", "new"}, []string{"imp"})
+	assertErrorCount(t, 0, errs)
+	assert.JSONEq(t, `{"tmax":100}`, string(requests["old"]))
+	assert.JSONEq(t, `{"tmax":200}`, string(requests["new"]))
+	assert.JSONEq(t, `{"banner":{}}`, string(imps["imp"]))
+	assert.NotContains(t, before, "new", "Maps which were already returned shouldn't change")
+
+	reloaded, err := NewFileFetcher(directory)
+	if !assert.NoError(t, err) {
+		return
+	}
+	requests, imps, errs = reloaded.FetchRequests(context.Background(), []string{"new"}, []string{"imp"})
+	assertErrorCount(t, 0, errs)
+	assert.JSONEq(t, `{"tmax":200}`, string(requests["new"]), "Saves should be written to disk")
+	assert.JSONEq(t, `{"banner":{}}`, string(imps["imp"]), "Saves should be written to disk")
+
+	err = writer.SaveRequests(context.Background(), map[string]json.RawMessage{"../escape": json.RawMessage(`{}`)}, nil)
+	assert.EqualError(t, err, `"../escape" can't be used as a file name`)
+}
+
+func TestFileFetcherSaveIsAllOrNothing(t *testing.T) {
+	directory, err := ioutil.TempDir("", "file_fetcher")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(directory)
+
+	fetcher, err := NewFileFetcher(directory)
+	if !assert.NoError(t, err) {
+		return
+	}
+	writer := fetcher.(stored_requests.Writer)
+	err = writer.SaveRequests(context.Background(), map[string]json.RawMessage{"valid": json.RawMessage(`{"tmax":200}`)}, map[string]json.RawMessage{"..": json.RawMessage(`{}`)})
+	assert.EqualError(t, err, `".." can't be used as a file name`)
+
+	requests, _, errs := fetcher.FetchRequests(context.Background(), []string{"valid"}, nil)
+	assertErrorCount(t, 1, errs)
+	assert.NotContains(t, requests, "valid")
+	infos, err := ioutil.ReadDir(filepath.Join(directory, "stored_requests"))
+	if assert.NoError(t, err) {
+		assert.Empty(t, infos, "A failed save shouldn't leave any files behind")
+	}
+}
+
+func TestFileFetcherSaveReportsPartialSaves(t *testing.T) {
+	directory, err := ioutil.TempDir("", "file_fetcher")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(directory)
+	// A non-empty directory where the file should go makes its rename fail.
+	if !assert.NoError(t, os.MkdirAll(filepath.Join(directory, "stored_requests", "b.json", "child"), 0755)) {
+		return
+	}
+
+	fetcher, err := NewFileFetcher(directory)
+	if !assert.NoError(t, err) {
+		return
+	}
+	writer := fetcher.(stored_requests.Writer)
+	err = writer.SaveRequests(context.Background(), map[string]json.RawMessage{
+		"a": json.RawMessage(`{"tmax":100}`),
+		"b": json.RawMessage(`{"tmax":200}`),
+		"c": json.RawMessage(`{"tmax":300}`),
+	}, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Failed to save stored_requests/b")
+		assert.Contains(t, err.Error(), "These were saved before the failure: [stored_requests/a]")
+	}
+
+	requests, _, _ := fetcher.FetchRequests(context.Background(), []string{"a", "c"}, nil)
+	assert.JSONEq(t, `{"tmax":100}`, string(requests["a"]), "Files which were saved should be visible")
+	assert.NotContains(t, requests, "c")
+	_, err = os.Stat(filepath.Join(directory, "stored_requests", "c.json"))
+	assert.True(t, os.IsNotExist(err), "Files after the failure shouldn't be saved")
+	infos, err := ioutil.ReadDir(filepath.Join(directory, "stored_requests"))
+	if assert.NoError(t, err) {
+		for _, info := range infos {
+			assert.False(t, strings.HasSuffix(info.Name(), ".tmp"), "Temporary files should be removed, but %s was left", info.Name())
+		}
+	}
+}
+
 func TestInvalidDirectory(t *testing.T) {
 	_, err := NewFileFetcher("./nonexistant-directory")
 	if err == nil {
@@ -35,6 +35,22 @@ import (
 //   }
 // }
 //
+// Accounts are fetched from the same endpoint with GET {endpoint}?account-ids=["acc1"], which should return:
+//
+// {
+//   "accounts": {
+//     "acc1": { ... config data for acc1 ... }
+//   }
+// }
+//
+// Stored Responses are fetched with GET {endpoint}?response-ids=["resp1","resp2"], which should return:
+//
+// {
+//   "responses": {
+//     "resp1": { ... stored data for resp1 ... },
+//     "resp2": null // If resp2 is not found
+//   }
+// }
 //
 func NewFetcher(client *http.Client, endpoint string) *HttpFetcher {
 	// Do some work up-front to figure out if the (configurable) endpoint has a query string or not.
@@ -56,10 +72,9 @@ func NewFetcher(client *http.Client, endpoint string) *HttpFetcher {
 }
 
 type HttpFetcher struct {
-	client     *http.Client
-	Endpoint   string
-	hasQuery   bool
-	Categories map[string]map[string]stored_requests.Category
+	client   *http.Client
+	Endpoint string
+	hasQuery bool
 }
 
 func (fetcher *HttpFetcher) FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage, errs []error) {
@@ -81,54 +96,134 @@ func (fetcher *HttpFetcher) FetchRequests(ctx context.Context, requestIDs []stri
 	return
 }
 
+func (fetcher *HttpFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	httpReq, err := http.NewRequest("GET", fetcher.Endpoint+"account-ids=[\""+accountID+"\"]", nil)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	httpResp, err := ctxhttp.Do(ctx, fetcher.client, httpReq)
+	if err != nil {
+		return nil, []error{err}
+	}
+	defer httpResp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, []error{fmt.Errorf("Error fetching Account %s via HTTP. Response code was %d", accountID, httpResp.StatusCode)}
+	}
+
+	var responseObj accountsResponseContract
+	if err := json.Unmarshal(respBytes, &responseObj); err != nil {
+		return nil, []error{err}
+	}
+
+	errs := convertNullsToErrs(responseObj.Accounts, "Account", nil)
+	if account, ok := responseObj.Accounts[accountID]; ok {
+		return account, errs
+	}
+	if len(errs) == 0 {
+		errs = append(errs, stored_requests.NotFoundError{
+			ID:       accountID,
+			DataType: "Account",
+		})
+	}
+	return nil, errs
+}
+
+func (fetcher *HttpFetcher) FetchResponses(ctx context.Context, ids []string) (map[string]json.RawMessage, []error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	httpReq, err := http.NewRequest("GET", fetcher.Endpoint+"response-ids=[\""+strings.Join(ids, "\",\"")+"\"]", nil)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	httpResp, err := ctxhttp.Do(ctx, fetcher.client, httpReq)
+	if err != nil {
+		return nil, []error{err}
+	}
+	defer httpResp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, []error{fmt.Errorf("Error fetching Stored Responses via HTTP. Response code was %d", httpResp.StatusCode)}
+	}
+
+	var responseObj storedResponsesContract
+	if err := json.Unmarshal(respBytes, &responseObj); err != nil {
+		return nil, []error{err}
+	}
+
+	data := make(map[string]json.RawMessage, len(ids))
+	var errs []error
+	for _, id := range ids {
+		if val, ok := responseObj.Responses[id]; ok && !bytes.Equal(val, []byte("null")) {
+			data[id] = val
+		} else {
+			errs = append(errs, stored_requests.NotFoundError{
+				ID:       id,
+				DataType: "Response",
+			})
+		}
+	}
+	return data, errs
+}
+
 func (fetcher *HttpFetcher) FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error) {
-	if fetcher.Categories == nil {
-		fetcher.Categories = make(map[string]map[string]stored_requests.Category)
+	data, err := fetcher.FetchCategoryMapping(ctx, primaryAdServer, publisherId)
+	if err != nil {
+		return "", err
 	}
+	mapping, err := stored_requests.ParseCategoryMapping(data, primaryAdServer, publisherId)
+	if err != nil {
+		return "", err
+	}
+	return stored_requests.FindCategory(mapping, primaryAdServer, publisherId, iabCategory)
+}
 
+// FetchCategoryMapping calls GET {endpoint}/{adserver}/{publisher}.json, or {endpoint}/{adserver}.json for the
+// ad server's own mapping. The mappings aren't kept in memory here. Use a CategoryCache for that.
+func (fetcher *HttpFetcher) FetchCategoryMapping(ctx context.Context, primaryAdServer, publisherId string) (json.RawMessage, error) {
 	//in NewFetcher function there is a code to add "?" at the end of url
 	//in case of categories we don't expect to have any parameters, that's why we need to remove "?"
-	var dataName, url string
+	var url string
 	if publisherId != "" {
-		dataName = fmt.Sprintf("%s_%s", primaryAdServer, publisherId)
 		url = fmt.Sprintf("%s/%s/%s.json", strings.TrimSuffix(fetcher.Endpoint, "?"), primaryAdServer, publisherId)
 	} else {
-		dataName = primaryAdServer
 		url = fmt.Sprintf("%s/%s.json", strings.TrimSuffix(fetcher.Endpoint, "?"), primaryAdServer)
 	}
 
-	if data, ok := fetcher.Categories[dataName]; ok {
-		if val, ok := data[iabCategory]; ok {
-			return val.Id, nil
-		} else {
-			return "", fmt.Errorf("Unable to find category mapping for adserver: '%s', publisherId: '%s'", primaryAdServer, publisherId)
-		}
-	}
-
 	httpReq, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	httpResp, err := ctxhttp.Do(ctx, fetcher.client, httpReq)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer httpResp.Body.Close()
 
-	respBytes, err := ioutil.ReadAll(httpResp.Body)
-	tmp := make(map[string]stored_requests.Category)
-
-	if err := json.Unmarshal(respBytes, &tmp); err != nil {
-		return "", fmt.Errorf("Unable to unmarshal categories for adserver: '%s', publisherId: '%s'", primaryAdServer, publisherId)
+	mappingID := stored_requests.CategoryMappingID(primaryAdServer, publisherId)
+	if httpResp.StatusCode == http.StatusNotFound {
+		return nil, stored_requests.NotFoundError{
+			ID:       mappingID,
+			DataType: "CategoryMapping",
+		}
 	}
-	fetcher.Categories[dataName] = tmp
-
-	if val, ok := tmp[iabCategory]; ok {
-		return val.Id, nil
-	} else {
-		return "", fmt.Errorf("Unable to find category mapping for adserver: '%s', publisherId: '%s'", primaryAdServer, publisherId)
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error fetching Category Mapping %s via HTTP. Response code was %d", mappingID, httpResp.StatusCode)
 	}
+	return ioutil.ReadAll(httpResp.Body)
 }
 
 func buildRequest(endpoint string, requestIDs []string, impIDs []string) (*http.Request, error) {
@@ -186,3 +281,13 @@ type responseContract struct {
 	Requests map[string]json.RawMessage `json:"requests"`
 	Imps     map[string]json.RawMessage `json:"imps"`
 }
+
+// storedResponsesContract is used to unmarshal Stored Response data from the endpoint
+type storedResponsesContract struct {
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+// accountsResponseContract is used to unmarshal account responses from the endpoint
+type accountsResponseContract struct {
+	Accounts map[string]json.RawMessage `json:"accounts"`
+}
@@ -9,6 +9,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/prebid/prebid-server/stored_requests"
 )
 
 func TestSingleReq(t *testing.T) {
@@ -108,6 +110,88 @@ func assertSameErrMsgs(t *testing.T, expected []string, actual []error) {
 	}
 }
 
+func TestFetchAccount(t *testing.T) {
+	fetcher, close := newTestAccountFetcher(t, jsonifyID)
+	defer close()
+
+	account, errs := fetcher.FetchAccount(context.Background(), "acc-1")
+	assertErrLength(t, errs, 0)
+	if string(account) != `"acc-1"` {
+		t.Errorf("Bad account data. Expected %s, Got %s", `"acc-1"`, account)
+	}
+}
+
+func TestMissingAccount(t *testing.T) {
+	fetcher, close := newTestAccountFetcher(t, jsonifyToNull)
+	defer close()
+
+	account, errs := fetcher.FetchAccount(context.Background(), "acc-1")
+	assertErrLength(t, errs, 1)
+	if account != nil {
+		t.Errorf("Expected no account data. Got %s", account)
+	}
+}
+
+func TestFetchResponses(t *testing.T) {
+	fetcher, close := newTestResponseFetcher(t, map[string]json.RawMessage{
+		"resp-1": json.RawMessage(`[{"seat":"appnexus"}]`),
+		"resp-2": json.RawMessage(`null`),
+	})
+	defer close()
+
+	data, errs := fetcher.FetchResponses(context.Background(), []string{"resp-1", "resp-2", "resp-3"})
+	assertErrLength(t, errs, 2)
+	for _, err := range errs {
+		if _, ok := err.(stored_requests.NotFoundError); !ok {
+			t.Errorf("Expected a NotFoundError. Got %#v", err)
+		}
+	}
+	if len(data) != 1 || string(data["resp-1"]) != `[{"seat":"appnexus"}]` {
+		t.Errorf("Bad response data. Got %v", data)
+	}
+}
+
+func TestAccountErrResponse(t *testing.T) {
+	fetcher, close := newFetcherBrokenBackend()
+	defer close()
+
+	account, errs := fetcher.FetchAccount(context.Background(), "acc-1")
+	assertErrLength(t, errs, 1)
+	if account != nil {
+		t.Errorf("Expected no account data. Got %s", account)
+	}
+}
+
+func TestFetchCategories(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/freewheel.json":
+			w.Write([]byte(`{"IAB1-1":{"id":"1","name":"Sports"}}`))
+		case "/freewheel/pub.json":
+			w.Write([]byte(`{"IAB1-1":{"id":"2","name":"Other"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+	fetcher := NewFetcher(server.Client(), server.URL)
+
+	if category, err := fetcher.FetchCategories(context.Background(), "freewheel", "", "IAB1-1"); err != nil || category != "1" {
+		t.Errorf("Bad ad server category. Expected 1, Got %s with error %v", category, err)
+	}
+	if category, err := fetcher.FetchCategories(context.Background(), "freewheel", "pub", "IAB1-1"); err != nil || category != "2" {
+		t.Errorf("Bad publisher category. Expected 2, Got %s with error %v", category, err)
+	}
+	if _, err := fetcher.FetchCategories(context.Background(), "freewheel", "", "IAB1-2"); err == nil {
+		t.Errorf("Expected an error for a category which isn't in the mapping.")
+	}
+	expected := stored_requests.NotFoundError{ID: "freewheel_unknown", DataType: "CategoryMapping"}
+	if _, err := fetcher.FetchCategoryMapping(context.Background(), "freewheel", "unknown"); err != expected {
+		t.Errorf("Expected a NotFoundError for a missing mapping. Got %#v", err)
+	}
+}
+
 type closeWrapper struct {
 	io.Reader
 }
@@ -136,6 +220,43 @@ func newTestFetcher(t *testing.T, expectReqIDs []string, expectImpIDs []string)
 	return NewFetcher(server.Client(), server.URL), server.Close
 }
 
+func newTestAccountFetcher(t *testing.T, jsonifier func(string) json.RawMessage) (fetcher *HttpFetcher, closer func()) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		accountIDs := richSplit(r.URL.Query().Get("account-ids"))
+		accounts := make(map[string]json.RawMessage, len(accountIDs))
+		for _, accountID := range accountIDs {
+			accounts[accountID] = jsonifier(accountID)
+		}
+		if respBytes, err := json.Marshal(accountsResponseContract{Accounts: accounts}); err != nil {
+			t.Errorf("failed to marshal accountsResponseContract in test:  %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.Write(respBytes)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	return NewFetcher(server.Client(), server.URL), server.Close
+}
+
+func newTestResponseFetcher(t *testing.T, responses map[string]json.RawMessage) (fetcher *HttpFetcher, closer func()) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		found := make(map[string]json.RawMessage)
+		for _, id := range richSplit(r.URL.Query().Get("response-ids")) {
+			if data, ok := responses[id]; ok {
+				found[id] = data
+			}
+		}
+		if respBytes, err := json.Marshal(storedResponsesContract{Responses: found}); err != nil {
+			t.Errorf("failed to marshal storedResponsesContract in test:  %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.Write(respBytes)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	return NewFetcher(server.Client(), server.URL), server.Close
+}
+
 func newHandler(t *testing.T, expectReqIDs []string, expectImpIDs []string, jsonifier func(string) json.RawMessage) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query()
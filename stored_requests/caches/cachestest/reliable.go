@@ -9,10 +9,8 @@ import (
 )
 
 const (
-	reqCacheKey = "known-req"
-	reqCacheVal = `{"req":true}`
-	impCacheKey = "known-imp"
-	impCacheVal = `{"imp":true}`
+	cacheKey = "known"
+	cacheVal = `{"data":true}`
 )
 
 // AssertCacheRobustness runs tests which can be used to validate any Cache that is 100% reliable.
@@ -20,84 +18,68 @@ const (
 //
 // The cacheSupplier should be a function which returns a new Cache (with no data inside) on every call.
 // This will be called from separate Goroutines to make sure that different tests don't conflict.
-func AssertCacheRobustness(t *testing.T, cacheSupplier func() stored_requests.Cache) {
+func AssertCacheRobustness(t *testing.T, cacheSupplier func() stored_requests.CacheJSON) {
 	t.Run("TestCacheMiss", cacheMissTester(cacheSupplier()))
 	t.Run("TestCacheHit", cacheHitTester(cacheSupplier()))
 	t.Run("TestCacheMixed", cacheMixedTester(cacheSupplier()))
-	t.Run("TestCacheOverlap", cacheOverlapTester(cacheSupplier()))
+	t.Run("TestCacheOverwrite", cacheOverwriteTester(cacheSupplier()))
 	t.Run("TestCacheSaveInvalidate", cacheSaveInvalidateTester(cacheSupplier()))
 }
 
-func cacheMissTester(cache stored_requests.Cache) func(*testing.T) {
+func cacheMissTester(cache stored_requests.CacheJSON) func(*testing.T) {
 	return func(t *testing.T) {
-		storedReqs, storedImps := cache.Get(context.Background(), []string{"unknown"}, nil)
-		assertMapLength(t, 0, storedReqs)
-		assertMapLength(t, 0, storedImps)
+		data := cache.Get(context.Background(), []string{"unknown"})
+		assertMapLength(t, 0, data)
 	}
 }
 
-func cacheHitTester(cache stored_requests.Cache) func(*testing.T) {
+func cacheHitTester(cache stored_requests.CacheJSON) func(*testing.T) {
 	return func(t *testing.T) {
 		cache.Save(context.Background(), map[string]json.RawMessage{
-			reqCacheKey: json.RawMessage(reqCacheVal),
-		}, map[string]json.RawMessage{
-			impCacheKey: json.RawMessage(impCacheVal),
+			cacheKey: json.RawMessage(cacheVal),
 		})
-		reqData, impData := cache.Get(context.Background(), []string{reqCacheKey}, []string{impCacheKey})
-		if len(reqData) != 1 {
-			t.Errorf("The cache should have returned the data.")
-		}
-		assertMapLength(t, 1, reqData)
-		assertHasValue(t, reqData, reqCacheKey, reqCacheVal)
-
-		assertMapLength(t, 1, impData)
-		assertHasValue(t, impData, impCacheKey, impCacheVal)
+		data := cache.Get(context.Background(), []string{cacheKey})
+		assertMapLength(t, 1, data)
+		assertHasValue(t, data, cacheKey, cacheVal)
 	}
 }
 
-func cacheMixedTester(cache stored_requests.Cache) func(*testing.T) {
+func cacheMixedTester(cache stored_requests.CacheJSON) func(*testing.T) {
 	return func(t *testing.T) {
 		cache.Save(context.Background(), map[string]json.RawMessage{
-			reqCacheKey: json.RawMessage(reqCacheVal),
-		}, nil)
-		reqData, impData := cache.Get(context.Background(), []string{reqCacheKey, "unknown-req"}, nil)
-		assertMapLength(t, 1, reqData)
-		assertHasValue(t, reqData, reqCacheKey, reqCacheVal)
-		assertMapLength(t, 0, impData)
+			cacheKey: json.RawMessage(cacheVal),
+		})
+		data := cache.Get(context.Background(), []string{cacheKey, "unknown"})
+		assertMapLength(t, 1, data)
+		assertHasValue(t, data, cacheKey, cacheVal)
 	}
 }
 
-func cacheOverlapTester(cache stored_requests.Cache) func(*testing.T) {
-	commonKey := "id"
+func cacheOverwriteTester(cache stored_requests.CacheJSON) func(*testing.T) {
 	return func(t *testing.T) {
 		cache.Save(context.Background(), map[string]json.RawMessage{
-			commonKey: json.RawMessage(reqCacheVal),
-		}, map[string]json.RawMessage{
-			commonKey: json.RawMessage(impCacheVal),
+			cacheKey: json.RawMessage(`{"data":false}`),
+		})
+		cache.Save(context.Background(), map[string]json.RawMessage{
+			cacheKey: json.RawMessage(cacheVal),
 		})
-		reqData, impData := cache.Get(context.Background(), []string{commonKey}, []string{commonKey})
-		assertMapLength(t, 1, reqData)
-		assertHasValue(t, reqData, commonKey, reqCacheVal)
-		assertMapLength(t, 1, impData)
-		assertHasValue(t, impData, commonKey, impCacheVal)
+		data := cache.Get(context.Background(), []string{cacheKey})
+		assertMapLength(t, 1, data)
+		assertHasValue(t, data, cacheKey, cacheVal)
 	}
 }
 
-func cacheSaveInvalidateTester(cache stored_requests.Cache) func(*testing.T) {
+func cacheSaveInvalidateTester(cache stored_requests.CacheJSON) func(*testing.T) {
 	return func(t *testing.T) {
 		cache.Save(context.Background(), map[string]json.RawMessage{
-			reqCacheKey: json.RawMessage(reqCacheVal),
-		}, map[string]json.RawMessage{
-			reqCacheKey: json.RawMessage(reqCacheVal),
+			cacheKey: json.RawMessage(cacheVal),
 		})
-		reqData, impData := cache.Get(context.Background(), []string{reqCacheKey}, []string{reqCacheKey})
-		assertMapLength(t, 1, reqData)
-		assertMapLength(t, 1, impData)
+		data := cache.Get(context.Background(), []string{cacheKey})
+		assertMapLength(t, 1, data)
 
-		cache.Invalidate(context.Background(), []string{reqCacheKey}, []string{reqCacheKey})
-		reqData, impData = cache.Get(context.Background(), []string{reqCacheKey}, []string{reqCacheKey})
-		assertMapLength(t, 0, reqData)
-		assertMapLength(t, 0, impData)
+		cache.Invalidate(context.Background(), []string{cacheKey})
+		data = cache.Get(context.Background(), []string{cacheKey})
+		assertMapLength(t, 0, data)
 	}
 }
 
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// NewCategoryCache returns an in-memory CategoryCache which evicts mappings if:
+//
+// 1. They were saved longer than the TTL ago.
+// 2. The cache is too large. This will cause the least recently used mappings to be evicted.
+//
+// The size of a mapping is the size of its JSON. For an unbounded size, use size <= 0.
+// For no TTL, use ttlSeconds <= 0
+func NewCategoryCache(size int, ttlSeconds int) stored_requests.CategoryCache {
+	if size > 0 {
+		glog.Infof("Using a Category Mapping in-memory cache. Max size: %d bytes. TTL: %d seconds.", size, ttlSeconds)
+	} else {
+		glog.Infof("Using an unbounded Category Mapping in-memory cache. TTL: %d seconds.", ttlSeconds)
+	}
+	return &categoryCache{
+		maxSize: size,
+		ttl:     time.Duration(ttlSeconds) * time.Second,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// categoryCache keeps parsed mappings, so that FetchCategories doesn't need to unmarshal them on every bid.
+// Freecache only stores bytes, so this is a simple LRU of its own.
+type categoryCache struct {
+	maxSize int
+	ttl     time.Duration
+	now     func() time.Time
+
+	mutex   sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	size    int
+	hits    int64
+	misses  int64
+}
+
+type categoryCacheEntry struct {
+	id      string
+	mapping map[string]stored_requests.Category
+	size    int
+	expires time.Time
+}
+
+func (c *categoryCache) Get(ctx context.Context, mappingID string) (map[string]stored_requests.Category, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[mappingID]
+	if ok && c.ttl > 0 && c.now().After(element.Value.(*categoryCacheEntry).expires) {
+		c.remove(element)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(element)
+	return element.Value.(*categoryCacheEntry).mapping, true
+}
+
+func (c *categoryCache) Save(ctx context.Context, data map[string]json.RawMessage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for id, value := range data {
+		var mapping map[string]stored_requests.Category
+		if err := json.Unmarshal(value, &mapping); err != nil {
+			glog.Errorf("Category Mapping %s isn't valid JSON, so it won't be cached: %v", id, err)
+			continue
+		}
+		if c.maxSize > 0 && len(value) > c.maxSize {
+			glog.Warningf("Category Mapping %s is %d bytes, which doesn't fit in the cache.", id, len(value))
+			continue
+		}
+		if element, ok := c.entries[id]; ok {
+			c.remove(element)
+		}
+		c.entries[id] = c.lru.PushFront(&categoryCacheEntry{
+			id:      id,
+			mapping: mapping,
+			size:    len(value),
+			expires: c.now().Add(c.ttl),
+		})
+		c.size += len(value)
+		for c.maxSize > 0 && c.size > c.maxSize {
+			c.remove(c.lru.Back())
+		}
+	}
+}
+
+func (c *categoryCache) Invalidate(ctx context.Context, mappingIDs []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, id := range mappingIDs {
+		if element, ok := c.entries[id]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+// Stats reports how many mappings the cache holds, and how many lookups found their mapping in it.
+func (c *categoryCache) Stats() stored_requests.CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return stored_requests.CacheStats{
+		Entries: int64(len(c.entries)),
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+}
+
+// remove must be called with the mutex held.
+func (c *categoryCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*categoryCacheEntry)
+	delete(c.entries, entry.id)
+	c.size -= entry.size
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryCacheSaveAndInvalidate(t *testing.T) {
+	cache := NewCategoryCache(0, 0)
+	cache.Save(context.Background(), map[string]json.RawMessage{
+		"freewheel":     json.RawMessage(`{"IAB1-1":{"id":"1","name":"Sports"}}`),
+		"freewheel_pub": json.RawMessage(`{"IAB1-1":{"id":"2","name":"Other"}}`),
+		"broken":        json.RawMessage(`{`),
+	})
+
+	mapping, ok := cache.Get(context.Background(), "freewheel")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]stored_requests.Category{"IAB1-1": {Id: "1", Name: "Sports"}}, mapping)
+	_, ok = cache.Get(context.Background(), "broken")
+	assert.False(t, ok, "Mappings which aren't valid JSON shouldn't be cached")
+
+	cache.Invalidate(context.Background(), []string{"freewheel"})
+	_, ok = cache.Get(context.Background(), "freewheel")
+	assert.False(t, ok, "Invalidated mappings shouldn't be returned")
+	_, ok = cache.Get(context.Background(), "freewheel_pub")
+	assert.True(t, ok, "Invalidation shouldn't affect other mappings")
+
+	assert.Equal(t, stored_requests.CacheStats{Entries: 1, Hits: 2, Misses: 2}, cache.(stored_requests.CacheStatsReporter).Stats())
+}
+
+func TestCategoryCacheLRU(t *testing.T) {
+	mapping := json.RawMessage(`{"IAB1-1":{"id":"1"}}`)
+	cache := NewCategoryCache(2*len(mapping), 0)
+	cache.Save(context.Background(), map[string]json.RawMessage{"a": mapping})
+	cache.Save(context.Background(), map[string]json.RawMessage{"b": mapping})
+	cache.Get(context.Background(), "a")
+	cache.Save(context.Background(), map[string]json.RawMessage{"c": mapping})
+
+	_, ok := cache.Get(context.Background(), "b")
+	assert.False(t, ok, "The least recently used mapping should be evicted")
+	_, ok = cache.Get(context.Background(), "a")
+	assert.True(t, ok)
+	_, ok = cache.Get(context.Background(), "c")
+	assert.True(t, ok)
+
+	cache.Save(context.Background(), map[string]json.RawMessage{"huge": json.RawMessage(`{"IAB1-1":{"id":"1"},"IAB1-2":{"id":"2"},"IAB1-3":{"id":"3"}}`)})
+	_, ok = cache.Get(context.Background(), "huge")
+	assert.False(t, ok, "Mappings bigger than the cache shouldn't be cached")
+	_, ok = cache.Get(context.Background(), "a")
+	assert.True(t, ok, "Mappings bigger than the cache shouldn't evict others")
+}
+
+func TestCategoryCacheTTL(t *testing.T) {
+	cache := NewCategoryCache(0, 60)
+	now := time.Now()
+	cache.(*categoryCache).now = func() time.Time { return now }
+	cache.Save(context.Background(), map[string]json.RawMessage{"freewheel": json.RawMessage(`{}`)})
+
+	now = now.Add(59 * time.Second)
+	_, ok := cache.Get(context.Background(), "freewheel")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Second)
+	_, ok = cache.Get(context.Background(), "freewheel")
+	assert.False(t, ok, "Mappings should expire once their TTL has passed")
+}
+
+func TestRaceCategoryCacheConcurrency(t *testing.T) {
+	cache := NewCategoryCache(1024, 60)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := strconv.Itoa((worker + j) % 20)
+				cache.Save(context.Background(), map[string]json.RawMessage{id: json.RawMessage(`{"IAB1-1":{"id":"` + id + `"}}`)})
+				cache.Get(context.Background(), id)
+				cache.Invalidate(context.Background(), []string{strconv.Itoa(j % 20)})
+			}
+		}(i)
+	}
+	wg.Wait()
+}
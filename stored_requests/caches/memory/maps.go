@@ -16,6 +16,7 @@ type mapLike interface {
 	Get(id string) (json.RawMessage, bool)
 	Set(id string, value json.RawMessage)
 	Delete(id string)
+	Len() int64
 }
 
 // sync.Map wrapper which implements the interface
@@ -40,6 +41,15 @@ func (m *pbsSyncMap) Delete(id string) {
 	m.Map.Delete(id)
 }
 
+func (m *pbsSyncMap) Len() int64 {
+	var length int64
+	m.Map.Range(func(_, _ interface{}) bool {
+		length++
+		return true
+	})
+	return length
+}
+
 // lruCache wrapper which implements the interface
 type pbsLRUCache struct {
 	*freecache.Cache
@@ -66,3 +76,7 @@ func (m *pbsLRUCache) Set(id string, value json.RawMessage) {
 func (m *pbsLRUCache) Delete(id string) {
 	m.Cache.Del([]byte(id))
 }
+
+func (m *pbsLRUCache) Len() int64 {
+	return m.Cache.EntryCount()
+}
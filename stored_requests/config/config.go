@@ -26,9 +26,13 @@ import (
 
 // This gets set to the connection string used when a database connection is made. We only support a single
 // database currently, so all fetchers need to share the same db connection for now.
+//
+// Likewise, fetchers which load the same directory share a file fetcher, so that Stored Requests which are
+// saved through one of them are seen by all of them.
 type dbConnection struct {
-	conn string
-	db   *sql.DB
+	conn  string
+	db    *sql.DB
+	files map[string]stored_requests.AllFetcher
 }
 
 // CreateStoredRequests returns three things:
@@ -41,7 +45,9 @@ type dbConnection struct {
 //
 // As a side-effect, it will add some endpoints to the router if the config calls for it.
 // In the future we should look for ways to simplify this so that it's not doing two things.
-func CreateStoredRequests(cfg *config.StoredRequestsSlim, metricsEngine pbsmetrics.MetricsEngine, client *http.Client, router *httprouter.Router, dbc *dbConnection) (fetcher stored_requests.AllFetcher, shutdown func()) {
+//
+// If saves is non-nil, the cache will also be updated with the Stored Requests it broadcasts.
+func CreateStoredRequests(cfg *config.StoredRequestsSlim, metricsEngine pbsmetrics.MetricsEngine, client *http.Client, router *httprouter.Router, dbc *dbConnection, saves *events.SaveBroadcaster) (fetcher stored_requests.AllFetcher, shutdown func()) {
 	// Create database connection if given options for one
 	if cfg.Postgres.ConnectionInfo.Database != "" {
 		conn := cfg.Postgres.ConnectionInfo.ConnString()
@@ -64,11 +70,15 @@ func CreateStoredRequests(cfg *config.StoredRequestsSlim, metricsEngine pbsmetri
 	}
 
 	eventProducers := newEventProducers(cfg, client, dbc.db, router)
-	fetcher = newFetcher(cfg, client, dbc.db)
+	fetcher = newFetcher(cfg, client, dbc)
 
 	var shutdown1 func()
 
 	if cfg.InMemoryCache.Type != "" {
+		// Broadcasts block until they're received, so there's only a producer for them if something will listen.
+		if saves != nil {
+			eventProducers = append(eventProducers, saves.NewProducer())
+		}
 		cache := newCache(cfg)
 		fetcher = stored_requests.WithCache(fetcher, cache, metricsEngine)
 		shutdown1 = addListeners(cache, eventProducers)
@@ -91,7 +101,7 @@ func CreateStoredRequests(cfg *config.StoredRequestsSlim, metricsEngine pbsmetri
 	return
 }
 
-// NewStoredRequests returns five things:
+// NewStoredRequests returns nine things:
 //
 // 1. A DB connection, if one was created. This may be nil.
 // 2. A function which should be called on shutdown for graceful cleanups.
@@ -99,40 +109,52 @@ func CreateStoredRequests(cfg *config.StoredRequestsSlim, metricsEngine pbsmetri
 // 4. A Fetcher which can be used to get Stored Requests for /openrtb2/amp
 // 5. A Fetcher which can be used to get Category Mapping data
 // 6. A Fetcher which can be used to get Stored Requests for /openrtb2/video
+// 7. A Fetcher which can be used to get Accounts
+// 8. A Fetcher which can be used to get Stored Auction and Bid Responses
+// 9. A Writer which saves Stored Requests and Imps to the backends, and then updates the caches. This is nil unless the write API is enabled.
 //
 // If any errors occur, the program will exit with an error message.
 // It probably means you have a bad config or networking issue.
 //
 // As a side-effect, it will add some endpoints to the router if the config calls for it.
 // In the future we should look for ways to simplify this so that it's not doing two things.
-func NewStoredRequests(cfg *config.Configuration, metricsEngine pbsmetrics.MetricsEngine, client *http.Client, router *httprouter.Router) (db *sql.DB, shutdown func(), fetcher stored_requests.Fetcher, ampFetcher stored_requests.Fetcher, categoriesFetcher stored_requests.CategoryFetcher, videoFetcher stored_requests.Fetcher) {
+func NewStoredRequests(cfg *config.Configuration, metricsEngine pbsmetrics.MetricsEngine, client *http.Client, router *httprouter.Router) (db *sql.DB, shutdown func(), fetcher stored_requests.Fetcher, ampFetcher stored_requests.Fetcher, categoriesFetcher stored_requests.CategoryFetcher, videoFetcher stored_requests.Fetcher, accountsFetcher stored_requests.AccountFetcher, responsesFetcher stored_requests.ResponseFetcher, writer stored_requests.Writer) {
 	// Build individual slim options from combined config struct
 	slimAuction, slimAmp := resolvedStoredRequestsConfig(cfg)
 
-	// TODO: Switch this to be set in config defaults
-	//if cfg.CategoryMapping.CacheEvents.Enabled && cfg.CategoryMapping.CacheEvents.Endpoint == "" {
-	//	cfg.CategoryMapping.CacheEvents.Endpoint = "/storedrequest/categorymapping"
-	//}
-
 	var dbc dbConnection
+	var saves *events.SaveBroadcaster
+	if cfg.StoredRequests.WriteAPI.Enabled {
+		saves = &events.SaveBroadcaster{}
+	}
 
-	fetcher1, shutdown1 := CreateStoredRequests(&slimAuction, metricsEngine, client, router, &dbc)
-	fetcher2, shutdown2 := CreateStoredRequests(&slimAmp, metricsEngine, client, router, &dbc)
-	fetcher3, shutdown3 := CreateStoredRequests(&cfg.CategoryMapping, metricsEngine, client, router, &dbc)
-	fetcher4, shutdown4 := CreateStoredRequests(&cfg.StoredVideo, metricsEngine, client, router, &dbc)
+	// The auction and AMP endpoints both use Stored Requests and Imps, so saves need to update both of their caches.
+	fetcher1, shutdown1 := CreateStoredRequests(&slimAuction, metricsEngine, client, router, &dbc, saves)
+	fetcher2, shutdown2 := CreateStoredRequests(&slimAmp, metricsEngine, client, router, &dbc, saves)
+	fetcher3, shutdown3 := CreateStoredRequests(&cfg.CategoryMapping, metricsEngine, client, router, &dbc, nil)
+	fetcher4, shutdown4 := CreateStoredRequests(&cfg.StoredVideo, metricsEngine, client, router, &dbc, nil)
+	fetcher5, shutdown5 := CreateStoredRequests(&cfg.Accounts, metricsEngine, client, router, &dbc, nil)
+	fetcher6, shutdown6 := CreateStoredRequests(&cfg.StoredResponses, metricsEngine, client, router, &dbc, nil)
 
 	db = dbc.db
+	if saves != nil {
+		writer = saves.Writer(newWriter(&cfg.StoredRequests, &dbc))
+	}
 
 	fetcher = fetcher1.(stored_requests.Fetcher)
 	ampFetcher = fetcher2.(stored_requests.Fetcher)
 	categoriesFetcher = fetcher3.(stored_requests.CategoryFetcher)
 	videoFetcher = fetcher4.(stored_requests.Fetcher)
+	accountsFetcher = fetcher5.(stored_requests.AccountFetcher)
+	responsesFetcher = fetcher6.(stored_requests.ResponseFetcher)
 
 	shutdown = func() {
 		shutdown1()
 		shutdown2()
 		shutdown3()
 		shutdown4()
+		shutdown5()
+		shutdown6()
 	}
 
 	return
@@ -196,16 +218,16 @@ func addListeners(cache stored_requests.Cache, eventProducers []events.EventProd
 	}
 }
 
-func newFetcher(cfg *config.StoredRequestsSlim, client *http.Client, db *sql.DB) (fetcher stored_requests.AllFetcher) {
+func newFetcher(cfg *config.StoredRequestsSlim, client *http.Client, dbc *dbConnection) (fetcher stored_requests.AllFetcher) {
 	idList := make(stored_requests.MultiFetcher, 0, 3)
 
 	if cfg.Files.Enabled {
-		fFetcher := newFilesystem(cfg.Files.Path)
+		fFetcher := newFilesystem(dbc, cfg.Files.Path)
 		idList = append(idList, fFetcher)
 	}
 	if cfg.Postgres.FetcherQueries.QueryTemplate != "" {
 		glog.Infof("Loading Stored Requests via Postgres.\nQuery: %s", cfg.Postgres.FetcherQueries.QueryTemplate)
-		idList = append(idList, db_fetcher.NewFetcher(db, cfg.Postgres.FetcherQueries.MakeQuery))
+		idList = append(idList, db_fetcher.NewFetcher(dbc.db, cfg.Postgres.FetcherQueries.MakeQuery))
 	}
 	if cfg.HTTP.Endpoint != "" {
 		glog.Infof("Loading Stored Requests via HTTP. endpoint=%s", cfg.HTTP.Endpoint)
@@ -217,12 +239,38 @@ func newFetcher(cfg *config.StoredRequestsSlim, client *http.Client, db *sql.DB)
 }
 
 func newCache(cfg *config.StoredRequestsSlim) stored_requests.Cache {
-	if cfg.InMemoryCache.Type == "none" {
+	cache := stored_requests.Cache{
+		Requests: &nil_cache.NilCache{},
+		Imps:     &nil_cache.NilCache{},
+		Accounts: &nil_cache.NilCache{},
+	}
+	switch cfg.InMemoryCache.Type {
+	case "none":
 		glog.Info("No Stored Request cache configured. The Fetcher backend will be used for all Stored Requests.")
-		return &nil_cache.NilCache{}
+	case "unbounded":
+		cache.Requests = memory.NewCache(0, 0, "Request")
+		cache.Imps = memory.NewCache(0, 0, "Imp")
+		cache.Accounts = memory.NewCache(0, 0, "Account")
+		cache.Categories = memory.NewCategoryCache(0, 0)
+	default:
+		// Config validation makes sure that stored_requests has these sizes. Other configs, like category_mapping,
+		// only need the caches for their own data.
+		if cfg.InMemoryCache.RequestCacheSize > 0 {
+			cache.Requests = memory.NewCache(cfg.InMemoryCache.RequestCacheSize, cfg.InMemoryCache.TTL, "Request")
+		}
+		if cfg.InMemoryCache.ImpCacheSize > 0 {
+			cache.Imps = memory.NewCache(cfg.InMemoryCache.ImpCacheSize, cfg.InMemoryCache.TTL, "Imp")
+		}
+		// The Account cache is optional, so that existing lru configs don't need a new size
+		if cfg.InMemoryCache.AccountCacheSize > 0 {
+			cache.Accounts = memory.NewCache(cfg.InMemoryCache.AccountCacheSize, cfg.InMemoryCache.TTL, "Account")
+		}
+		// Likewise for the Category Mapping cache
+		if cfg.InMemoryCache.CategoryCacheSize > 0 {
+			cache.Categories = memory.NewCategoryCache(cfg.InMemoryCache.CategoryCacheSize, cfg.InMemoryCache.TTL)
+		}
 	}
-
-	return memory.NewCache(&cfg.InMemoryCache)
+	return cache
 }
 
 func newEventProducers(cfg *config.StoredRequestsSlim, client *http.Client, db *sql.DB, router *httprouter.Router) (eventProducers []events.EventProducer) {
@@ -269,15 +317,40 @@ func newHttpEvents(client *http.Client, timeout time.Duration, refreshRate time.
 	return httpEvents.NewHTTPEvents(client, endpoint, ctxProducer, refreshRate)
 }
 
-func newFilesystem(configPath string) stored_requests.AllFetcher {
+func newFilesystem(dbc *dbConnection, configPath string) stored_requests.AllFetcher {
+	if fetcher, ok := dbc.files[configPath]; ok {
+		return fetcher
+	}
 	glog.Infof("Loading Stored Requests from filesystem at path %s", configPath)
 	fetcher, err := file_fetcher.NewFileFetcher(configPath)
 	if err != nil {
 		glog.Fatalf("Failed to create a FileFetcher: %v", err)
 	}
+	if dbc.files == nil {
+		dbc.files = make(map[string]stored_requests.AllFetcher)
+	}
+	dbc.files[configPath] = fetcher
 	return fetcher
 }
 
+// newWriter returns a Writer for every backend which the Stored Request write API saves to.
+// Config validation makes sure that there's at least one.
+func newWriter(cfg *config.StoredRequests, dbc *dbConnection) stored_requests.Writer {
+	writers := make(stored_requests.MultiWriter, 0, 2)
+	if cfg.Files {
+		fileWriter, ok := newFilesystem(dbc, cfg.Path).(stored_requests.Writer)
+		if !ok {
+			glog.Fatal("The filesystem Stored Request Fetcher can't save data. Please report this as a bug.")
+		}
+		writers = append(writers, fileWriter)
+	}
+	if cfg.Postgres.SaveQueries.RequestQuery != "" {
+		glog.Infof("Saving Stored Requests via Postgres.\nRequest query: %s\nImp query: %s", cfg.Postgres.SaveQueries.RequestQuery, cfg.Postgres.SaveQueries.ImpQuery)
+		writers = append(writers, db_fetcher.NewWriter(dbc.db, cfg.Postgres.SaveQueries.RequestQuery, cfg.Postgres.SaveQueries.ImpQuery))
+	}
+	return writers
+}
+
 func newPostgresDB(cfg config.PostgresConnection) *sql.DB {
 	db, err := sql.Open("postgres", cfg.ConnString())
 	if err != nil {
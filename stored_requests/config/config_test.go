@@ -12,6 +12,7 @@ import (
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/julienschmidt/httprouter"
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/stored_requests"
 	"github.com/prebid/prebid-server/stored_requests/backends/empty_fetcher"
 	"github.com/prebid/prebid-server/stored_requests/backends/http_fetcher"
 	"github.com/prebid/prebid-server/stored_requests/events"
@@ -82,6 +83,26 @@ func TestNewHTTPFetcherNoAmp(t *testing.T) {
 	}
 }
 
+func TestFileFetchersAreShared(t *testing.T) {
+	dbc := &dbConnection{}
+	slim := &config.StoredRequestsSlim{
+		Files: config.FileFetcherConfig{
+			Enabled: true,
+			Path:    "../backends/file_fetcher/test",
+		},
+	}
+	fetcher := newFetcher(slim, nil, dbc)
+	ampFetcher := newFetcher(slim, nil, dbc)
+	if fetcher != ampFetcher {
+		t.Errorf("Fetchers for the same directory should share a file fetcher, so that saves are seen by both.")
+	}
+
+	writer := newWriter(&config.StoredRequests{Files: true, Path: "../backends/file_fetcher/test"}, dbc)
+	if multiWriter, ok := writer.(stored_requests.MultiWriter); !ok || len(multiWriter) != 1 || multiWriter[0] != fetcher.(stored_requests.Writer) {
+		t.Errorf("The Writer should save through the shared file fetcher. Got %v", writer)
+	}
+}
+
 func TestResolveConfig(t *testing.T) {
 	cfg := &config.Configuration{
 		StoredRequests: config.StoredRequests{
@@ -166,8 +187,8 @@ func TestNewHTTPEvents(t *testing.T) {
 
 func TestNewEmptyCache(t *testing.T) {
 	cache := newCache(&config.StoredRequestsSlim{InMemoryCache: config.InMemoryCache{Type: "none"}})
-	cache.Save(context.Background(), map[string]json.RawMessage{"foo": json.RawMessage("true")}, nil)
-	reqs, _ := cache.Get(context.Background(), []string{"foo"}, nil)
+	cache.Requests.Save(context.Background(), map[string]json.RawMessage{"foo": json.RawMessage("true")})
+	reqs := cache.Requests.Get(context.Background(), []string{"foo"})
 	if len(reqs) != 0 {
 		t.Errorf("The newCache method should return an empty cache if the config asks for it.")
 	}
@@ -181,11 +202,60 @@ func TestNewInMemoryCache(t *testing.T) {
 			ImpCacheSize:     100,
 		},
 	})
-	cache.Save(context.Background(), map[string]json.RawMessage{"foo": json.RawMessage("true")}, nil)
-	reqs, _ := cache.Get(context.Background(), []string{"foo"}, nil)
+	cache.Requests.Save(context.Background(), map[string]json.RawMessage{"foo": json.RawMessage("true")})
+	reqs := cache.Requests.Get(context.Background(), []string{"foo"})
 	if len(reqs) != 1 {
 		t.Errorf("The newCache method should return an in-memory cache if the config asks for it.")
 	}
+	cache.Accounts.Save(context.Background(), map[string]json.RawMessage{"foo": json.RawMessage("true")})
+	accounts := cache.Accounts.Get(context.Background(), []string{"foo"})
+	if len(accounts) != 0 {
+		t.Errorf("The newCache method should not cache accounts unless account_cache_size_bytes is set.")
+	}
+}
+
+func TestNewInMemoryAccountCache(t *testing.T) {
+	cache := newCache(&config.StoredRequestsSlim{
+		InMemoryCache: config.InMemoryCache{
+			TTL:              60,
+			RequestCacheSize: 100,
+			ImpCacheSize:     100,
+			AccountCacheSize: 100,
+		},
+	})
+	cache.Accounts.Save(context.Background(), map[string]json.RawMessage{"foo": json.RawMessage("true")})
+	accounts := cache.Accounts.Get(context.Background(), []string{"foo"})
+	if len(accounts) != 1 {
+		t.Errorf("The newCache method should return an in-memory account cache if the config asks for it.")
+	}
+}
+
+func TestNewInMemoryCategoryCache(t *testing.T) {
+	cache := newCache(&config.StoredRequestsSlim{
+		InMemoryCache: config.InMemoryCache{
+			Type:              "lru",
+			TTL:               60,
+			CategoryCacheSize: 1024,
+		},
+	})
+	cache.Categories.Save(context.Background(), map[string]json.RawMessage{"freewheel": json.RawMessage(`{"IAB1-1":{"id":"1"}}`)})
+	if _, ok := cache.Categories.Get(context.Background(), "freewheel"); !ok {
+		t.Errorf("The newCache method should return an in-memory category cache if the config asks for it.")
+	}
+	cache.Requests.Save(context.Background(), map[string]json.RawMessage{"foo": json.RawMessage("true")})
+	if reqs := cache.Requests.Get(context.Background(), []string{"foo"}); len(reqs) != 0 {
+		t.Errorf("The newCache method should not cache requests unless request_cache_size_bytes is set.")
+	}
+
+	cache = newCache(&config.StoredRequestsSlim{InMemoryCache: config.InMemoryCache{Type: "unbounded"}})
+	if cache.Categories == nil {
+		t.Errorf("The newCache method should return an unbounded category cache if the config asks for it.")
+	}
+
+	cache = newCache(&config.StoredRequestsSlim{InMemoryCache: config.InMemoryCache{Type: "none"}})
+	if cache.Categories != nil {
+		t.Errorf("The newCache method shouldn't return a category cache if the config asks for none.")
+	}
 }
 
 func TestNewPostgresEventProducers(t *testing.T) {
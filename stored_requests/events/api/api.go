@@ -25,6 +25,8 @@ type eventsAPI struct {
 //
 // The returned HTTP endpoint should not be exposed on a public network without authentication
 // as it allows direct writing to the cache via Update.
+//
+// Saves aren't validated, or written to the backend. The admin server's /storedrequests/save endpoint does both.
 func NewEventsAPI() (events.EventProducer, httprouter.Handle) {
 	api := &eventsAPI{
 		invalidations: make(chan events.Invalidation),
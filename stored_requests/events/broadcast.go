@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/prebid/prebid-server/stored_requests"
+)
+
+// SaveBroadcaster sends each save to any number of EventProducers, so that every cache which holds
+// the data can be updated by its own EventListener.
+type SaveBroadcaster struct {
+	mutex     sync.Mutex
+	producers []*broadcastProducer
+}
+
+// NewProducer returns an EventProducer for the saves which are broadcast from now on.
+// Its Saves() must be listened to, or else Broadcast will block until its context is done.
+func (b *SaveBroadcaster) NewProducer() EventProducer {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	producer := &broadcastProducer{
+		saves:         make(chan Save),
+		invalidations: make(chan Invalidation),
+	}
+	b.producers = append(b.producers, producer)
+	return producer
+}
+
+// Broadcast sends the save to every EventProducer. It returns once all of them have been received,
+// or with the context's error if it's done first. That happens if a listener has stopped, e.g. during shutdown.
+func (b *SaveBroadcaster) Broadcast(ctx context.Context, save Save) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, producer := range b.producers {
+		select {
+		case producer.saves <- save:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Writer returns a stored_requests.Writer which saves data to the backend, and then broadcasts it.
+// Nothing is broadcast if the backend fails. If the broadcast doesn't finish, the data is saved, but some caches
+// may serve the old data until it's evicted.
+func (b *SaveBroadcaster) Writer(backend stored_requests.Writer) stored_requests.Writer {
+	return &broadcastingWriter{backend: backend, broadcaster: b}
+}
+
+type broadcastProducer struct {
+	saves         chan Save
+	invalidations chan Invalidation
+}
+
+func (p *broadcastProducer) Saves() <-chan Save {
+	return p.saves
+}
+
+func (p *broadcastProducer) Invalidations() <-chan Invalidation {
+	return p.invalidations
+}
+
+type broadcastingWriter struct {
+	backend     stored_requests.Writer
+	broadcaster *SaveBroadcaster
+}
+
+func (w *broadcastingWriter) SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error {
+	if err := w.backend.SaveRequests(ctx, requests, imps); err != nil {
+		return err
+	}
+	if err := w.broadcaster.Broadcast(ctx, Save{Requests: requests, Imps: imps}); err != nil {
+		return fmt.Errorf("The data was saved, but not every cache was updated: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-server/stored_requests"
+	"github.com/prebid/prebid-server/stored_requests/caches/memory"
+	"github.com/prebid/prebid-server/stored_requests/caches/nil_cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcastWriter(t *testing.T) {
+	broadcaster := &SaveBroadcaster{}
+	caches := []stored_requests.Cache{newBroadcastCache(), newBroadcastCache()}
+	saveOccurred := make(chan struct{})
+	for _, cache := range caches {
+		listener := NewEventListener(func() { saveOccurred <- struct{}{} }, nil)
+		go listener.Listen(cache, broadcaster.NewProducer())
+		defer listener.Stop()
+	}
+
+	backend := &fakeWriter{}
+	writer := broadcaster.Writer(backend)
+	requests := map[string]json.RawMessage{"req": json.RawMessage(`{"tmax":100}`)}
+	imps := map[string]json.RawMessage{"imp": json.RawMessage(`{"banner":{}}`)}
+
+	go func() {
+		assert.NoError(t, writer.SaveRequests(context.Background(), requests, imps))
+	}()
+	<-saveOccurred
+	<-saveOccurred
+
+	assert.Equal(t, requests, backend.requests, "The data should be saved to the backend")
+	for _, cache := range caches {
+		assert.Equal(t, requests, cache.Requests.Get(context.Background(), []string{"req"}), "Every cache should get the save")
+		assert.Equal(t, imps, cache.Imps.Get(context.Background(), []string{"imp"}), "Every cache should get the save")
+	}
+}
+
+func TestBroadcastWriterBackendError(t *testing.T) {
+	broadcaster := &SaveBroadcaster{}
+	// Nothing listens to this producer, so a broadcast would block.
+	broadcaster.NewProducer()
+
+	writer := broadcaster.Writer(&fakeWriter{err: errors.New("disk full")})
+	err := writer.SaveRequests(context.Background(), map[string]json.RawMessage{"req": json.RawMessage(`{}`)}, nil)
+	assert.EqualError(t, err, "disk full", "Failed saves shouldn't be broadcast")
+}
+
+func TestBroadcastWriterWithoutListener(t *testing.T) {
+	broadcaster := &SaveBroadcaster{}
+	// Nothing listens to this producer, as though its listener had been stopped.
+	broadcaster.NewProducer()
+
+	backend := &fakeWriter{}
+	writer := broadcaster.Writer(backend)
+	requests := map[string]json.RawMessage{"req": json.RawMessage(`{}`)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := writer.SaveRequests(ctx, requests, nil)
+	assert.EqualError(t, err, "The data was saved, but not every cache was updated: context deadline exceeded", "Broadcasts shouldn't outlast the context")
+	assert.Equal(t, requests, backend.requests, "The data should still be saved to the backend")
+}
+
+func newBroadcastCache() stored_requests.Cache {
+	return stored_requests.Cache{
+		Requests: memory.NewCache(256*1024, -1, "Request"),
+		Imps:     memory.NewCache(256*1024, -1, "Imp"),
+		Accounts: &nil_cache.NilCache{},
+	}
+}
+
+type fakeWriter struct {
+	requests map[string]json.RawMessage
+	err      error
+}
+
+func (w *fakeWriter) SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error {
+	w.requests = requests
+	return w.err
+}
@@ -9,14 +9,18 @@ import (
 
 // Save represents a bulk save
 type Save struct {
-	Requests map[string]json.RawMessage `json:"requests"`
-	Imps     map[string]json.RawMessage `json:"imps"`
+	Requests   map[string]json.RawMessage `json:"requests"`
+	Imps       map[string]json.RawMessage `json:"imps"`
+	Accounts   map[string]json.RawMessage `json:"accounts"`
+	Categories map[string]json.RawMessage `json:"categories"`
 }
 
 // Invalidation represents a bulk invalidation
 type Invalidation struct {
-	Requests []string `json:"requests"`
-	Imps     []string `json:"imps"`
+	Requests   []string `json:"requests"`
+	Imps       []string `json:"imps"`
+	Accounts   []string `json:"accounts"`
+	Categories []string `json:"categories"`
 }
 
 // EventProducer will produce cache update and invalidation events on its channels
@@ -61,12 +65,22 @@ func (e *EventListener) Listen(cache stored_requests.Cache, events EventProducer
 	for {
 		select {
 		case save := <-events.Saves():
-			cache.Save(context.Background(), save.Requests, save.Imps)
+			cache.Requests.Save(context.Background(), save.Requests)
+			cache.Imps.Save(context.Background(), save.Imps)
+			cache.Accounts.Save(context.Background(), save.Accounts)
+			if cache.Categories != nil {
+				cache.Categories.Save(context.Background(), save.Categories)
+			}
 			if e.onSave != nil {
 				e.onSave()
 			}
 		case invalidation := <-events.Invalidations():
-			cache.Invalidate(context.Background(), invalidation.Requests, invalidation.Imps)
+			cache.Requests.Invalidate(context.Background(), invalidation.Requests)
+			cache.Imps.Invalidate(context.Background(), invalidation.Imps)
+			cache.Accounts.Invalidate(context.Background(), invalidation.Accounts)
+			if cache.Categories != nil {
+				cache.Categories.Invalidate(context.Background(), invalidation.Categories)
+			}
 			if e.onInvalidate != nil {
 				e.onInvalidate()
 			}
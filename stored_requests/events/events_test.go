@@ -7,8 +7,9 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/stored_requests"
 	"github.com/prebid/prebid-server/stored_requests/caches/memory"
+	"github.com/prebid/prebid-server/stored_requests/caches/nil_cache"
 )
 
 func TestListen(t *testing.T) {
@@ -17,11 +18,11 @@ func TestListen(t *testing.T) {
 		invalidations: make(chan Invalidation),
 	}
 
-	cache := memory.NewCache(&config.InMemoryCache{
-		RequestCacheSize: 256 * 1024,
-		ImpCacheSize:     256 * 1024,
-		TTL:              -1,
-	})
+	cache := stored_requests.Cache{
+		Requests: memory.NewCache(256*1024, -1, "Request"),
+		Imps:     memory.NewCache(256*1024, -1, "Imp"),
+		Accounts: memory.NewCache(256*1024, -1, "Account"),
+	}
 
 	// create channels to syncronize
 	saveOccurred := make(chan struct{})
@@ -41,34 +42,82 @@ func TestListen(t *testing.T) {
 	save := Save{
 		Requests: data,
 		Imps:     data,
+		Accounts: data,
 	}
-	cache.Save(context.Background(), save.Requests, save.Imps)
+	cache.Requests.Save(context.Background(), save.Requests)
+	cache.Imps.Save(context.Background(), save.Imps)
+	cache.Accounts.Save(context.Background(), save.Accounts)
 
 	config = fmt.Sprintf(`{"id": "%s", "updated": true}`, id)
 	data = map[string]json.RawMessage{id: json.RawMessage(config)}
 	save = Save{
 		Requests: data,
 		Imps:     data,
+		Accounts: data,
 	}
 
 	ep.saves <- save
 	<-saveOccurred
 
-	requestData, impData := cache.Get(context.Background(), idSlice, idSlice)
-	if !reflect.DeepEqual(requestData, data) || !reflect.DeepEqual(impData, data) {
+	requestData := cache.Requests.Get(context.Background(), idSlice)
+	impData := cache.Imps.Get(context.Background(), idSlice)
+	accountData := cache.Accounts.Get(context.Background(), idSlice)
+	if !reflect.DeepEqual(requestData, data) || !reflect.DeepEqual(impData, data) || !reflect.DeepEqual(accountData, data) {
 		t.Error("Update failed")
 	}
 
 	invalidation := Invalidation{
 		Requests: idSlice,
 		Imps:     idSlice,
+		Accounts: idSlice,
 	}
 
 	ep.invalidations <- invalidation
 	<-invalidateOccurred
 
-	requestData, impData = cache.Get(context.Background(), idSlice, idSlice)
-	if len(requestData) > 0 || len(impData) > 0 {
+	requestData = cache.Requests.Get(context.Background(), idSlice)
+	impData = cache.Imps.Get(context.Background(), idSlice)
+	accountData = cache.Accounts.Get(context.Background(), idSlice)
+	if len(requestData) > 0 || len(impData) > 0 || len(accountData) > 0 {
+		t.Error("Invalidate failed")
+	}
+}
+
+func TestListenCategories(t *testing.T) {
+	ep := &dummyProducer{
+		saves:         make(chan Save),
+		invalidations: make(chan Invalidation),
+	}
+
+	cache := stored_requests.Cache{
+		Requests:   &nil_cache.NilCache{},
+		Imps:       &nil_cache.NilCache{},
+		Accounts:   &nil_cache.NilCache{},
+		Categories: memory.NewCategoryCache(0, 0),
+	}
+
+	saveOccurred := make(chan struct{})
+	invalidateOccurred := make(chan struct{})
+	listener := NewEventListener(
+		func() { saveOccurred <- struct{}{} },
+		func() { invalidateOccurred <- struct{}{} },
+	)
+
+	go listener.Listen(cache, ep)
+	defer listener.Stop()
+
+	ep.saves <- Save{Categories: map[string]json.RawMessage{"freewheel": json.RawMessage(`{"IAB1-1":{"id":"1"}}`)}}
+	<-saveOccurred
+
+	mapping, ok := cache.Categories.Get(context.Background(), "freewheel")
+	if !ok || mapping["IAB1-1"].Id != "1" {
+		t.Errorf("Update failed. Got %v", mapping)
+	}
+
+	ep.invalidations <- Invalidation{Categories: []string{"freewheel"}}
+	<-invalidateOccurred
+
+	if _, ok := cache.Categories.Get(context.Background(), "freewheel"); ok {
 		t.Error("Invalidate failed")
 	}
 }
@@ -39,6 +39,13 @@ import (
 //   "imps": {
 //     "imp1": { ... stored data for imp1 ... },
 //     "imp2": { ... stored data for imp2 ... },
+//   },
+//   "accounts": {
+//     "account1": { ... account data ... },
+//   },
+//   "categories": {
+//     "freewheel": { ... category mapping of an ad server ... },
+//     "freewheel_pub1": { ... category mapping of a publisher ... },
 //   }
 // }
 //
@@ -81,10 +88,12 @@ func (e *HTTPEvents) fetchAll() {
 	defer cancel()
 	resp, err := ctxhttp.Get(ctx, e.client, e.Endpoint)
 	if respObj, ok := e.parse(e.Endpoint, resp, err); ok &&
-		(len(respObj.StoredRequests) > 0 || len(respObj.StoredImps) > 0) {
+		(len(respObj.StoredRequests) > 0 || len(respObj.StoredImps) > 0 || len(respObj.Accounts) > 0 || len(respObj.Categories) > 0) {
 		e.saves <- events.Save{
-			Requests: respObj.StoredRequests,
-			Imps:     respObj.StoredImps,
+			Requests:   respObj.StoredRequests,
+			Imps:       respObj.StoredImps,
+			Accounts:   respObj.Accounts,
+			Categories: respObj.Categories,
 		}
 	}
 }
@@ -99,16 +108,20 @@ func (e *HTTPEvents) refresh(ticker <-chan time.Time) {
 			resp, err := ctxhttp.Get(ctx, e.client, e.Endpoint)
 			if respObj, ok := e.parse(thisEndpoint, resp, err); ok {
 				invalidations := events.Invalidation{
-					Requests: extractInvalidations(respObj.StoredRequests),
-					Imps:     extractInvalidations(respObj.StoredImps),
+					Requests:   extractInvalidations(respObj.StoredRequests),
+					Imps:       extractInvalidations(respObj.StoredImps),
+					Accounts:   extractInvalidations(respObj.Accounts),
+					Categories: extractInvalidations(respObj.Categories),
 				}
-				if len(respObj.StoredRequests) > 0 || len(respObj.StoredImps) > 0 {
+				if len(respObj.StoredRequests) > 0 || len(respObj.StoredImps) > 0 || len(respObj.Accounts) > 0 || len(respObj.Categories) > 0 {
 					e.saves <- events.Save{
-						Requests: respObj.StoredRequests,
-						Imps:     respObj.StoredImps,
+						Requests:   respObj.StoredRequests,
+						Imps:       respObj.StoredImps,
+						Accounts:   respObj.Accounts,
+						Categories: respObj.Categories,
 					}
 				}
-				if len(invalidations.Requests) > 0 || len(invalidations.Imps) > 0 {
+				if len(invalidations.Requests) > 0 || len(invalidations.Imps) > 0 || len(invalidations.Accounts) > 0 || len(invalidations.Categories) > 0 {
 					e.invalidations <- invalidations
 				}
 				e.lastUpdate = thisTimeInUTC
@@ -169,4 +182,6 @@ func (e *HTTPEvents) Invalidations() <-chan events.Invalidation {
 type responseContract struct {
 	StoredRequests map[string]json.RawMessage `json:"requests"`
 	StoredImps     map[string]json.RawMessage `json:"imps"`
+	Accounts       map[string]json.RawMessage `json:"accounts"`
+	Categories     map[string]json.RawMessage `json:"categories"`
 }
@@ -20,10 +20,10 @@ import (
 //
 //   1. id: string
 //   2. data: JSON
-//   3. type: string ("request" or "imp")
+//   3. type: string ("request", "imp", "account" or "category")
 //
 // If data is empty or the JSON "null", then the ID will be invalidated (e.g. a deletion).
-// If data is not empty, it should be the Stored Request or Stored Imp data associated with the given ID.
+// If data is not empty, it should be the Stored Request, Stored Imp, Account or Category Mapping data associated with the given ID.
 func PollForUpdates(ctxProducer func() (ctx context.Context, canceller func()), db *sql.DB, query string, startUpdatesFrom time.Time, refreshRate time.Duration) (eventProducer *PostgresPoller) {
 	// If we're not given a function to produce Contexts, use the Background one.
 	if ctxProducer == nil {
@@ -96,9 +96,13 @@ func (e *PostgresPoller) refresh(ticker <-chan time.Time) {
 func sendEvents(rows *sql.Rows, saves chan<- events.Save, invalidations chan<- events.Invalidation) (err error) {
 	storedRequestData := make(map[string]json.RawMessage)
 	storedImpData := make(map[string]json.RawMessage)
+	accountData := make(map[string]json.RawMessage)
+	categoryData := make(map[string]json.RawMessage)
 
 	var requestInvalidations []string
 	var impInvalidations []string
+	var accountInvalidations []string
+	var categoryInvalidations []string
 
 	for rows.Next() {
 		var id string
@@ -122,6 +126,18 @@ func sendEvents(rows *sql.Rows, saves chan<- events.Save, invalidations chan<- e
 			} else {
 				storedImpData[id] = data
 			}
+		case "account":
+			if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+				accountInvalidations = append(accountInvalidations, id)
+			} else {
+				accountData[id] = data
+			}
+		case "category":
+			if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+				categoryInvalidations = append(categoryInvalidations, id)
+			} else {
+				categoryData[id] = data
+			}
 		default:
 			glog.Warningf("Stored Data with id=%s has invalid type: %s. This will be ignored.", id, dataType)
 		}
@@ -132,19 +148,23 @@ func sendEvents(rows *sql.Rows, saves chan<- events.Save, invalidations chan<- e
 		return rows.Err()
 	}
 
-	if len(storedRequestData) > 0 || len(storedImpData) > 0 && saves != nil {
+	if (len(storedRequestData) > 0 || len(storedImpData) > 0 || len(accountData) > 0 || len(categoryData) > 0) && saves != nil {
 		saves <- events.Save{
-			Requests: storedRequestData,
-			Imps:     storedImpData,
+			Requests:   storedRequestData,
+			Imps:       storedImpData,
+			Accounts:   accountData,
+			Categories: categoryData,
 		}
 	}
 
 	// There shouldn't be any invalidations with a nil channel (a "startup" query),
 	// but... if there are, we certainly don't want to block forever.
-	if len(requestInvalidations) > 0 || len(impInvalidations) > 0 && invalidations != nil {
+	if (len(requestInvalidations) > 0 || len(impInvalidations) > 0 || len(accountInvalidations) > 0 || len(categoryInvalidations) > 0) && invalidations != nil {
 		invalidations <- events.Invalidation{
-			Requests: requestInvalidations,
-			Imps:     impInvalidations,
+			Requests:   requestInvalidations,
+			Imps:       impInvalidations,
+			Accounts:   accountInvalidations,
+			Categories: categoryInvalidations,
 		}
 	}
 
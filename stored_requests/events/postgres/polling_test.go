@@ -21,7 +21,9 @@ func TestSuccessfulUpdates(t *testing.T) {
 		AddRow("stored-req-2", "null", "request").
 		AddRow("stored-imp-1", `{"id":1}`, "imp").
 		AddRow("stored-imp-2", `{"id":2}`, "imp").
-		AddRow("stored-imp-3", "", "imp")
+		AddRow("stored-imp-3", "", "imp").
+		AddRow("freewheel", `{"IAB1-1":{"id":"1"}}`, "category").
+		AddRow("freewheel_pub", "null", "category")
 
 	updateStart := time.Now()
 
@@ -38,12 +40,16 @@ func TestSuccessfulUpdates(t *testing.T) {
 	assertMapLength(t, 2, save.Imps)
 	assertMapValue(t, save.Imps, "stored-imp-1", `{"id":1}`)
 	assertMapValue(t, save.Imps, "stored-imp-2", `{"id":2}`)
+	assertMapLength(t, 1, save.Categories)
+	assertMapValue(t, save.Categories, "freewheel", `{"IAB1-1":{"id":"1"}}`)
 
 	invalidate := <-evs.Invalidations()
 	assertNumInvalidations(t, 1, invalidate.Requests)
 	assertSliceContains(t, invalidate.Requests, "stored-req-2")
 	assertNumInvalidations(t, 1, invalidate.Imps)
 	assertSliceContains(t, invalidate.Imps, "stored-imp-3")
+	assertNumInvalidations(t, 1, invalidate.Categories)
+	assertSliceContains(t, invalidate.Categories, "freewheel_pub")
 }
 
 func assertNumInvalidations(t *testing.T, expected int, vals []string) {
@@ -25,15 +25,74 @@ type Fetcher interface {
 	FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage, errs []error)
 }
 
+// AccountFetcher knows how to fetch account configuration data by id.
+//
+// Implementations must be safe for concurrent access by multiple goroutines.
+// Callers are expected to share a single instance as much as possible.
+type AccountFetcher interface {
+	// FetchAccount fetches the configuration for the account with the given ID.
+	// If the account doesn't exist, a NotFoundError will be returned.
+	FetchAccount(ctx context.Context, accountID string) (account json.RawMessage, errs []error)
+}
+
+// ResponseFetcher knows how to fetch Stored Responses by id.
+// These are used to answer an auction without calling the real bidders, e.g. for QA traffic.
+//
+// Implementations must be safe for concurrent access by multiple goroutines.
+// Callers are expected to share a single instance as much as possible.
+type ResponseFetcher interface {
+	// FetchResponses fetches the stored responses for the given IDs.
+	// The returned map will have a key for every ID which was found. Missing IDs produce NotFoundErrors.
+	FetchResponses(ctx context.Context, ids []string) (data map[string]json.RawMessage, errs []error)
+}
+
+// CategoryFetcher knows how to map IAB categories to the categories of an ad server.
+//
+// Implementations must be safe for concurrent access by multiple goroutines.
+// Callers are expected to share a single instance as much as possible.
 type CategoryFetcher interface {
 	// FetchCategories fetches the ad-server/publisher specific category for the given IAB category
 	FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error)
 }
 
-// AllFetcher is an iterface that encapsulates both the original Fetcher and the CategoryFetcher
+// AllFetcher is an iterface that encapsulates the original Fetcher, the AccountFetcher, the ResponseFetcher and the CategoryFetcher
 type AllFetcher interface {
 	FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage, errs []error)
+	FetchAccount(ctx context.Context, accountID string) (account json.RawMessage, errs []error)
+	FetchResponses(ctx context.Context, ids []string) (data map[string]json.RawMessage, errs []error)
 	FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error)
+	// FetchCategoryMapping fetches the whole mapping which FetchCategories reads from, as JSON like:
+	//
+	//   { "IAB1-1": { "id": "404", "name": "Publishing" }, ... }
+	//
+	// If the mapping doesn't exist, a NotFoundError will be returned.
+	FetchCategoryMapping(ctx context.Context, primaryAdServer, publisherId string) (mapping json.RawMessage, err error)
+}
+
+// CategoryMappingID returns the ID of the category mapping which an ad server uses for a publisher.
+// Publishers without their own mapping use the ad server's mapping, whose ID is the ad server's name.
+func CategoryMappingID(primaryAdServer, publisherId string) string {
+	if publisherId == "" {
+		return primaryAdServer
+	}
+	return primaryAdServer + "_" + publisherId
+}
+
+// ParseCategoryMapping parses the JSON returned by FetchCategoryMapping.
+func ParseCategoryMapping(data json.RawMessage, primaryAdServer, publisherId string) (map[string]Category, error) {
+	var mapping map[string]Category
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal categories for adserver: '%s', publisherId: '%s'", primaryAdServer, publisherId)
+	}
+	return mapping, nil
+}
+
+// FindCategory returns the ad server's category for the IAB category, from a parsed category mapping.
+func FindCategory(mapping map[string]Category, primaryAdServer, publisherId, iabCategory string) (string, error) {
+	if category, ok := mapping[iabCategory]; ok && category.Id != "" {
+		return category.Id, nil
+	}
+	return "", fmt.Errorf("Unable to find category for adserver '%s', publisherId: '%s', iab category: '%s'", primaryAdServer, publisherId, iabCategory)
 }
 
 // NotFoundError is an error type to flag that an ID was not found by the Fetcher.
@@ -54,49 +113,88 @@ func (e NotFoundError) Error() string {
 }
 
 // Cache is an intermediate layer which can be used to create more complex Fetchers by composition.
-// Implementations must be safe for concurrent access by multiple goroutines.
+// It holds a separate CacheJSON for each type of data which a Fetcher can return.
 // To add a Cache layer in front of a Fetcher, see WithCache()
-type Cache interface {
+type Cache struct {
+	Requests CacheJSON
+	Imps     CacheJSON
+	Accounts CacheJSON
+	// Categories is optional. If nil, category mappings are fetched from the backend every time.
+	Categories CategoryCache
+}
+
+// CacheJSON caches a single type of data, keyed by ID.
+// Implementations must be safe for concurrent access by multiple goroutines.
+type CacheJSON interface {
 	// Get works much like Fetcher.FetchRequests, with a few exceptions:
 	//
 	// 1. Any (actionable) errors should be logged by the implementation, rather than returned.
-	// 2. The returned maps _may_ be written to.
-	// 3. The returned maps must _not_ contain keys unless they were present in the argument ID list.
-	// 4. Callers _should not_ assume that the returned maps contain key for every argument id.
+	// 2. The returned map _may_ be written to.
+	// 3. The returned map must _not_ contain keys unless they were present in the argument ID list.
+	// 4. Callers _should not_ assume that the returned map contains a key for every argument id.
 	//    The returned map will miss entries for keys which don't exist in the cache.
 	//
-	// Nil slices and empty strings are treated as "no ops". That is, a nil requestID will always produce a nil
-	// "stored request data" in the response.
-	Get(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage)
+	// Nil slices are treated as "no ops". That is, a nil ids slice will always produce an empty map.
+	Get(ctx context.Context, ids []string) (data map[string]json.RawMessage)
 
 	// Invalidate will ensure that all values associated with the given IDs
 	// are no longer returned by the cache until new values are saved via Update
-	Invalidate(ctx context.Context, requestIDs []string, impIDs []string)
+	Invalidate(ctx context.Context, ids []string)
 
 	// Save will add or overwrite the data in the cache at the given keys
-	Save(ctx context.Context, requestData map[string]json.RawMessage, impData map[string]json.RawMessage)
+	Save(ctx context.Context, data map[string]json.RawMessage)
+}
+
+// CategoryCache caches parsed category mappings, keyed by their CategoryMappingID.
+// Implementations must be safe for concurrent access by multiple goroutines.
+type CategoryCache interface {
+	// Get returns the mapping with the given ID, if the cache has it. The returned map must not be written to.
+	Get(ctx context.Context, mappingID string) (mapping map[string]Category, ok bool)
+
+	// Save will add or overwrite the mappings in the cache at the given IDs.
+	// Mappings which can't be parsed should be logged by the implementation, and left out.
+	Save(ctx context.Context, data map[string]json.RawMessage)
+
+	// Invalidate will ensure that the mappings with the given IDs are no longer returned by the cache
+	// until new values are saved via Save
+	Invalidate(ctx context.Context, mappingIDs []string)
+}
+
+// CacheStats describes the state of a CacheJSON since the server started.
+type CacheStats struct {
+	Entries int64 `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// CacheStatsReporter is implemented by CacheJSONs and CategoryCaches which keep track of their own hits and misses.
+type CacheStatsReporter interface {
+	Stats() CacheStats
+}
+
+// CacheInspector is implemented by Fetchers which can describe the state of their caches.
+type CacheInspector interface {
+	// CacheStats returns the stats of each cache which keeps track of them, keyed by the type of data it holds.
+	CacheStats() map[string]CacheStats
 }
 
 // ComposedCache creates an interface to treat a slice of caches as a single cache
-type ComposedCache []Cache
+type ComposedCache []CacheJSON
 
 // Get will attempt to Get from the caches in the order in which they are in the slice,
 // stopping as soon as a value is found (or when all caches have been exhausted)
-func (c ComposedCache) Get(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage) {
-	requestData = make(map[string]json.RawMessage, len(requestIDs))
-	impData = make(map[string]json.RawMessage, len(impIDs))
+func (c ComposedCache) Get(ctx context.Context, ids []string) (data map[string]json.RawMessage) {
+	data = make(map[string]json.RawMessage, len(ids))
 
-	remainingReqIDs := requestIDs
-	remainingImpIDs := impIDs
+	remainingIDs := ids
 
 	for _, cache := range c {
-		cachedReqData, cachedImpData := cache.Get(ctx, remainingReqIDs, remainingImpIDs)
+		cachedData := cache.Get(ctx, remainingIDs)
 
-		requestData, remainingReqIDs = updateFromCache(requestData, remainingReqIDs, cachedReqData)
-		impData, remainingImpIDs = updateFromCache(impData, remainingImpIDs, cachedImpData)
+		data, remainingIDs = updateFromCache(data, remainingIDs, cachedData)
 
 		// return if all ids filled
-		if len(remainingReqIDs) == 0 && len(remainingImpIDs) == 0 {
+		if len(remainingIDs) == 0 {
 			return
 		}
 	}
@@ -123,16 +221,16 @@ func updateFromCache(data map[string]json.RawMessage, ids []string, newData map[
 }
 
 // Invalidate will propagate invalidations to all underlying caches
-func (c ComposedCache) Invalidate(ctx context.Context, requestIDs []string, impIDs []string) {
+func (c ComposedCache) Invalidate(ctx context.Context, ids []string) {
 	for _, cache := range c {
-		cache.Invalidate(ctx, requestIDs, impIDs)
+		cache.Invalidate(ctx, ids)
 	}
 }
 
 // Save will propagate saves to all underlying caches
-func (c ComposedCache) Save(ctx context.Context, requestData map[string]json.RawMessage, impData map[string]json.RawMessage) {
+func (c ComposedCache) Save(ctx context.Context, data map[string]json.RawMessage) {
 	for _, cache := range c {
-		cache.Save(ctx, requestData, impData)
+		cache.Save(ctx, data)
 	}
 }
 
@@ -155,7 +253,8 @@ func WithCache(fetcher AllFetcher, cache Cache, metricsEngine pbsmetrics.Metrics
 }
 
 func (f *fetcherWithCache) FetchRequests(ctx context.Context, requestIDs []string, impIDs []string) (requestData map[string]json.RawMessage, impData map[string]json.RawMessage, errs []error) {
-	requestData, impData = f.cache.Get(ctx, requestIDs, impIDs)
+	requestData = f.cache.Requests.Get(ctx, requestIDs)
+	impData = f.cache.Imps.Get(ctx, impIDs)
 
 	// Fixes #311
 	leftoverImps := findLeftovers(impIDs, impData)
@@ -172,7 +271,8 @@ func (f *fetcherWithCache) FetchRequests(ctx context.Context, requestIDs []strin
 		fetcherReqData, fetcherImpData, fetcherErrs := f.fetcher.FetchRequests(ctx, leftoverReqs, leftoverImps)
 		errs = fetcherErrs
 
-		f.cache.Save(ctx, fetcherReqData, fetcherImpData)
+		f.cache.Requests.Save(ctx, fetcherReqData)
+		f.cache.Imps.Save(ctx, fetcherImpData)
 
 		requestData = mergeData(requestData, fetcherReqData)
 		impData = mergeData(impData, fetcherImpData)
@@ -181,8 +281,61 @@ func (f *fetcherWithCache) FetchRequests(ctx context.Context, requestIDs []strin
 	return
 }
 
+func (f *fetcherWithCache) FetchAccount(ctx context.Context, accountID string) (account json.RawMessage, errs []error) {
+	accountData := f.cache.Accounts.Get(ctx, []string{accountID})
+	if account, ok := accountData[accountID]; ok {
+		f.metricsEngine.RecordAccountCacheResult(pbsmetrics.CacheHit, 1)
+		return account, nil
+	}
+	f.metricsEngine.RecordAccountCacheResult(pbsmetrics.CacheMiss, 1)
+
+	account, errs = f.fetcher.FetchAccount(ctx, accountID)
+	if len(errs) == 0 {
+		f.cache.Accounts.Save(ctx, map[string]json.RawMessage{accountID: account})
+	}
+	return
+}
+
+// FetchResponses isn't cached. Stored Responses are meant for test traffic, so they go straight to the backend.
+func (f *fetcherWithCache) FetchResponses(ctx context.Context, ids []string) (data map[string]json.RawMessage, errs []error) {
+	return f.fetcher.FetchResponses(ctx, ids)
+}
+
+// FetchCategories reads the category from the cached mapping, so that a mapping is only fetched and parsed
+// once until it gets evicted or invalidated.
 func (f *fetcherWithCache) FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error) {
-	return "", nil
+	if f.cache.Categories == nil {
+		return f.fetcher.FetchCategories(ctx, primaryAdServer, publisherId, iabCategory)
+	}
+
+	mappingID := CategoryMappingID(primaryAdServer, publisherId)
+	mapping, ok := f.cache.Categories.Get(ctx, mappingID)
+	if !ok {
+		data, err := f.fetcher.FetchCategoryMapping(ctx, primaryAdServer, publisherId)
+		if err != nil {
+			return "", err
+		}
+		if mapping, err = ParseCategoryMapping(data, primaryAdServer, publisherId); err != nil {
+			return "", err
+		}
+		f.cache.Categories.Save(ctx, map[string]json.RawMessage{mappingID: data})
+	}
+	return FindCategory(mapping, primaryAdServer, publisherId, iabCategory)
+}
+
+// FetchCategoryMapping isn't cached. FetchCategories caches the parsed mappings instead.
+func (f *fetcherWithCache) FetchCategoryMapping(ctx context.Context, primaryAdServer, publisherId string) (json.RawMessage, error) {
+	return f.fetcher.FetchCategoryMapping(ctx, primaryAdServer, publisherId)
+}
+
+func (f *fetcherWithCache) CacheStats() map[string]CacheStats {
+	stats := make(map[string]CacheStats, 4)
+	for dataType, cache := range map[string]interface{}{"requests": f.cache.Requests, "imps": f.cache.Imps, "accounts": f.cache.Accounts, "categories": f.cache.Categories} {
+		if reporter, ok := cache.(CacheStatsReporter); ok {
+			stats[dataType] = reporter.Stats()
+		}
+	}
+	return stats
 }
 
 func findLeftovers(ids []string, data map[string]json.RawMessage) (leftovers []string) {
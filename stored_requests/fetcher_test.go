@@ -12,25 +12,28 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
-func setupFetcherWithCacheDeps() (*mockCache, *mockFetcher, AllFetcher, *pbsmetrics.MetricsEngineMock) {
-	cache := &mockCache{}
+func setupFetcherWithCacheDeps() (*mockCache, *mockCache, *mockCache, *mockFetcher, AllFetcher, *pbsmetrics.MetricsEngineMock) {
+	reqCache := &mockCache{}
+	impCache := &mockCache{}
+	accountCache := &mockCache{}
 	metricsEngine := &pbsmetrics.MetricsEngineMock{}
 	fetcher := &mockFetcher{}
-	afetcherWithCache := WithCache(fetcher, cache, metricsEngine)
+	afetcherWithCache := WithCache(fetcher, Cache{reqCache, impCache, accountCache, nil}, metricsEngine)
 
-	return cache, fetcher, afetcherWithCache, metricsEngine
+	return reqCache, impCache, accountCache, fetcher, afetcherWithCache, metricsEngine
 }
 
 func TestPerfectCache(t *testing.T) {
-	cache, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
+	reqCache, impCache, _, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
 	impIDs := []string{"known"}
 	reqIDs := []string{"req-id"}
 	ctx := context.Background()
 
-	cache.On("Get", ctx, reqIDs, impIDs).Return(
+	reqCache.On("Get", ctx, reqIDs).Return(
 		map[string]json.RawMessage{
 			"req-id": json.RawMessage(`{"req":true}`),
-		},
+		})
+	impCache.On("Get", ctx, impIDs).Return(
 		map[string]json.RawMessage{
 			"known": json.RawMessage(`{}`),
 		})
@@ -41,7 +44,8 @@ func TestPerfectCache(t *testing.T) {
 
 	reqData, impData, errs := aFetcherWithCache.FetchRequests(ctx, reqIDs, impIDs)
 
-	cache.AssertExpectations(t)
+	reqCache.AssertExpectations(t)
+	impCache.AssertExpectations(t)
 	fetcher.AssertExpectations(t)
 	metricsEngine.AssertExpectations(t)
 	assert.JSONEq(t, `{"req":true}`, string(reqData["req-id"]), "Fetch requests should fetch the right request data")
@@ -50,12 +54,13 @@ func TestPerfectCache(t *testing.T) {
 }
 
 func TestImperfectCache(t *testing.T) {
-	cache, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
+	reqCache, impCache, _, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
 	impIDs := []string{"cached", "uncached"}
 	ctx := context.Background()
 
-	cache.On("Get", ctx, []string(nil), impIDs).Return(
-		map[string]json.RawMessage{},
+	reqCache.On("Get", ctx, []string(nil)).Return(
+		map[string]json.RawMessage{})
+	impCache.On("Get", ctx, impIDs).Return(
 		map[string]json.RawMessage{
 			"cached": json.RawMessage(`true`),
 		})
@@ -67,8 +72,9 @@ func TestImperfectCache(t *testing.T) {
 		},
 		[]error{},
 	)
-	cache.On("Save", ctx,
-		map[string]json.RawMessage{},
+	reqCache.On("Save", ctx,
+		map[string]json.RawMessage{})
+	impCache.On("Save", ctx,
 		map[string]json.RawMessage{
 			"uncached": json.RawMessage(`false`),
 		})
@@ -79,7 +85,8 @@ func TestImperfectCache(t *testing.T) {
 
 	reqData, impData, errs := aFetcherWithCache.FetchRequests(ctx, nil, impIDs)
 
-	cache.AssertExpectations(t)
+	reqCache.AssertExpectations(t)
+	impCache.AssertExpectations(t)
 	fetcher.AssertExpectations(t)
 	metricsEngine.AssertExpectations(t)
 	assert.Len(t, reqData, 0, "Fetch requests should return nil if no request IDs were passed")
@@ -89,12 +96,14 @@ func TestImperfectCache(t *testing.T) {
 }
 
 func TestMissingData(t *testing.T) {
-	cache, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
+	reqCache, impCache, _, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
 	impIDs := []string{"unknown"}
 	ctx := context.Background()
 
-	cache.On("Get", ctx, []string(nil), impIDs).Return(
+	reqCache.On("Get", ctx, []string(nil)).Return(
 		map[string]json.RawMessage{},
+	)
+	impCache.On("Get", ctx, impIDs).Return(
 		map[string]json.RawMessage{},
 	)
 	fetcher.On("FetchRequests", ctx, []string{}, impIDs).Return(
@@ -104,8 +113,10 @@ func TestMissingData(t *testing.T) {
 			errors.New("Data not found"),
 		},
 	)
-	cache.On("Save", ctx,
+	reqCache.On("Save", ctx,
 		map[string]json.RawMessage{},
+	)
+	impCache.On("Save", ctx,
 		map[string]json.RawMessage{},
 	)
 	metricsEngine.On("RecordStoredReqCacheResult", pbsmetrics.CacheHit, 0)
@@ -115,7 +126,8 @@ func TestMissingData(t *testing.T) {
 
 	reqData, impData, errs := aFetcherWithCache.FetchRequests(ctx, nil, impIDs)
 
-	cache.AssertExpectations(t)
+	reqCache.AssertExpectations(t)
+	impCache.AssertExpectations(t)
 	fetcher.AssertExpectations(t)
 	metricsEngine.AssertExpectations(t)
 	assert.Len(t, errs, 1, "FetchRequests for missing data should return an error")
@@ -125,12 +137,13 @@ func TestMissingData(t *testing.T) {
 
 // Prevents #311
 func TestCacheSaves(t *testing.T) {
-	cache, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
+	reqCache, impCache, _, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
 	impIDs := []string{"abc", "abc"}
 	ctx := context.Background()
 
-	cache.On("Get", ctx, []string(nil), impIDs).Return(
-		map[string]json.RawMessage{},
+	reqCache.On("Get", ctx, []string(nil)).Return(
+		map[string]json.RawMessage{})
+	impCache.On("Get", ctx, impIDs).Return(
 		map[string]json.RawMessage{
 			"abc": json.RawMessage(`{}`),
 		})
@@ -141,7 +154,8 @@ func TestCacheSaves(t *testing.T) {
 
 	_, impData, errs := aFetcherWithCache.FetchRequests(ctx, nil, []string{"abc", "abc"})
 
-	cache.AssertExpectations(t)
+	reqCache.AssertExpectations(t)
+	impCache.AssertExpectations(t)
 	fetcher.AssertExpectations(t)
 	metricsEngine.AssertExpectations(t)
 	assert.Len(t, impData, 1, "FetchRequests should return data only once for duplicate requests")
@@ -149,61 +163,174 @@ func TestCacheSaves(t *testing.T) {
 	assert.Len(t, errs, 0, "FetchRequests with duplicate IDs shouldn't return an error")
 }
 
+func TestAccountCacheHit(t *testing.T) {
+	_, _, accountCache, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
+	ctx := context.Background()
+
+	accountCache.On("Get", ctx, []string{"known"}).Return(
+		map[string]json.RawMessage{
+			"known": json.RawMessage(`{"disabled":true}`),
+		})
+	metricsEngine.On("RecordAccountCacheResult", pbsmetrics.CacheHit, 1)
+
+	account, errs := aFetcherWithCache.FetchAccount(ctx, "known")
+
+	accountCache.AssertExpectations(t)
+	fetcher.AssertExpectations(t)
+	metricsEngine.AssertExpectations(t)
+	assert.JSONEq(t, `{"disabled":true}`, string(account), "FetchAccount should fetch the right account data")
+	assert.Len(t, errs, 0, "FetchAccount shouldn't return any errors")
+}
+
+func TestAccountCacheMiss(t *testing.T) {
+	_, _, accountCache, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
+	ctx := context.Background()
+
+	accountCache.On("Get", ctx, []string{"uncached"}).Return(
+		map[string]json.RawMessage{})
+	fetcher.On("FetchAccount", ctx, "uncached").Return(
+		json.RawMessage(`{"disabled":true}`),
+		[]error{},
+	)
+	accountCache.On("Save", ctx,
+		map[string]json.RawMessage{
+			"uncached": json.RawMessage(`{"disabled":true}`),
+		})
+	metricsEngine.On("RecordAccountCacheResult", pbsmetrics.CacheMiss, 1)
+
+	account, errs := aFetcherWithCache.FetchAccount(ctx, "uncached")
+
+	accountCache.AssertExpectations(t)
+	fetcher.AssertExpectations(t)
+	metricsEngine.AssertExpectations(t)
+	assert.JSONEq(t, `{"disabled":true}`, string(account), "FetchAccount should fetch the right account data")
+	assert.Len(t, errs, 0, "FetchAccount shouldn't return any errors")
+}
+
+func TestMissingAccount(t *testing.T) {
+	_, _, accountCache, fetcher, aFetcherWithCache, metricsEngine := setupFetcherWithCacheDeps()
+	ctx := context.Background()
+
+	accountCache.On("Get", ctx, []string{"unknown"}).Return(
+		map[string]json.RawMessage{})
+	fetcher.On("FetchAccount", ctx, "unknown").Return(
+		json.RawMessage(nil),
+		[]error{NotFoundError{"unknown", "Account"}},
+	)
+	metricsEngine.On("RecordAccountCacheResult", pbsmetrics.CacheMiss, 1)
+
+	account, errs := aFetcherWithCache.FetchAccount(ctx, "unknown")
+
+	accountCache.AssertExpectations(t)
+	fetcher.AssertExpectations(t)
+	metricsEngine.AssertExpectations(t)
+	assert.Nil(t, account, "FetchAccount for a missing account shouldn't return anything")
+	assert.Len(t, errs, 1, "FetchAccount for a missing account should return an error")
+}
+
 func TestComposedCache(t *testing.T) {
 	c1 := &mockCache{}
 	c2 := &mockCache{}
 	c3 := &mockCache{}
 	c4 := &mockCache{}
 	cache := ComposedCache{c1, c2, c3, c4}
-	metricsEngine := &pbsmetrics.MetricsEngineMock{}
-	fetcher := &mockFetcher{}
-	aFetcherWithCache := WithCache(fetcher, cache, metricsEngine)
-	impIDs := []string{"1", "2", "3"}
-	reqIDs := []string{"1", "2", "3"}
+	ids := []string{"1", "2", "3"}
 	ctx := context.Background()
 
-	c1.On("Get", ctx, reqIDs, impIDs).Return(
-		map[string]json.RawMessage{
-			"1": json.RawMessage(`{"id": "1"}`),
-		},
+	c1.On("Get", ctx, ids).Return(
 		map[string]json.RawMessage{
 			"1": json.RawMessage(`{"id": "1"}`),
 		})
-	c2.On("Get", ctx, []string{"2", "3"}, []string{"2", "3"}).Return(
-		map[string]json.RawMessage{
-			"2": json.RawMessage(`{"id": "2"}`),
-		},
+	c2.On("Get", ctx, []string{"2", "3"}).Return(
 		map[string]json.RawMessage{
 			"2": json.RawMessage(`{"id": "2"}`),
 		})
-	c3.On("Get", ctx, []string{"3"}, []string{"3"}).Return(
-		map[string]json.RawMessage{
-			"3": json.RawMessage(`{"id": "3"}`),
-		},
+	c3.On("Get", ctx, []string{"3"}).Return(
 		map[string]json.RawMessage{
 			"3": json.RawMessage(`{"id": "3"}`),
 		})
-	metricsEngine.On("RecordStoredReqCacheResult", pbsmetrics.CacheHit, 3)
-	metricsEngine.On("RecordStoredReqCacheResult", pbsmetrics.CacheMiss, 0)
-	metricsEngine.On("RecordStoredImpCacheResult", pbsmetrics.CacheHit, 3)
-	metricsEngine.On("RecordStoredImpCacheResult", pbsmetrics.CacheMiss, 0)
 
-	reqData, impData, errs := aFetcherWithCache.FetchRequests(ctx, reqIDs, impIDs)
+	data := cache.Get(ctx, ids)
 
 	c1.AssertExpectations(t)
 	c2.AssertExpectations(t)
 	c3.AssertExpectations(t)
+	c4.AssertExpectations(t)
+	assert.Len(t, data, len(ids), "Get should be able to return all data from a composed cache")
+	assert.JSONEq(t, `{"id": "1"}`, string(data["1"]), "Get should fetch the right data")
+	assert.JSONEq(t, `{"id": "2"}`, string(data["2"]), "Get should fetch the right data")
+	assert.JSONEq(t, `{"id": "3"}`, string(data["3"]), "Get should fetch the right data")
+}
+
+func TestCacheStats(t *testing.T) {
+	reqCache := &mockStatsCache{stats: CacheStats{Entries: 2, Hits: 5, Misses: 1}}
+	impCache := &mockStatsCache{stats: CacheStats{Entries: 1, Hits: 3}}
+	categoryCache := &mockCategoryCache{stats: CacheStats{Entries: 1, Misses: 1}}
+	fetcher := WithCache(&mockFetcher{}, Cache{reqCache, impCache, &mockCache{}, categoryCache}, &pbsmetrics.MetricsEngineMock{})
+
+	inspector, ok := fetcher.(CacheInspector)
+	if !assert.True(t, ok, "Fetchers with caches should report their cache stats") {
+		return
+	}
+	assert.Equal(t, map[string]CacheStats{
+		"requests":   {Entries: 2, Hits: 5, Misses: 1},
+		"imps":       {Entries: 1, Hits: 3},
+		"categories": {Entries: 1, Misses: 1},
+	}, inspector.CacheStats(), "Caches which don't keep stats should be left out")
+}
+
+func TestCategoryCacheHit(t *testing.T) {
+	fetcher := &mockFetcher{}
+	categoryCache := &mockCategoryCache{}
+	fetcherWithCache := WithCache(fetcher, Cache{&mockCache{}, &mockCache{}, &mockCache{}, categoryCache}, &pbsmetrics.MetricsEngineMock{})
+	ctx := context.Background()
+
+	categoryCache.On("Get", ctx, "freewheel_pub").Return(map[string]Category{"IAB1-1": {Id: "1"}}, true)
+
+	category, err := fetcherWithCache.FetchCategories(ctx, "freewheel", "pub", "IAB1-1")
+
+	categoryCache.AssertExpectations(t)
+	fetcher.AssertNotCalled(t, "FetchCategoryMapping")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", category)
+
+	_, err = fetcherWithCache.FetchCategories(ctx, "freewheel", "pub", "IAB1-2")
+	assert.EqualError(t, err, "Unable to find category for adserver 'freewheel', publisherId: 'pub', iab category: 'IAB1-2'")
+}
+
+func TestCategoryCacheMiss(t *testing.T) {
+	fetcher := &mockFetcher{}
+	categoryCache := &mockCategoryCache{}
+	fetcherWithCache := WithCache(fetcher, Cache{&mockCache{}, &mockCache{}, &mockCache{}, categoryCache}, &pbsmetrics.MetricsEngineMock{})
+	ctx := context.Background()
+	mapping := json.RawMessage(`{"IAB1-1":{"id":"1"}}`)
+
+	categoryCache.On("Get", ctx, "freewheel").Return(map[string]Category(nil), false)
+	categoryCache.On("Save", ctx, map[string]json.RawMessage{"freewheel": mapping})
+	fetcher.On("FetchCategoryMapping", ctx, "freewheel", "").Return(mapping, nil)
+
+	category, err := fetcherWithCache.FetchCategories(ctx, "freewheel", "", "IAB1-1")
+
+	categoryCache.AssertExpectations(t)
 	fetcher.AssertExpectations(t)
-	metricsEngine.AssertExpectations(t)
-	assert.Len(t, reqData, len(reqIDs), "FetchRequests should be able to return all request data from a composed cache")
-	assert.Len(t, impData, len(impIDs), "FetchRequests should be able to return all imp data from a composed cache")
-	assert.Len(t, errs, 0, "FetchRequests shouldn't return an error when trying to use a composed cache")
-	assert.JSONEq(t, `{"id": "1"}`, string(impData["1"]), "FetchRequests should fetch the right imp data")
-	assert.JSONEq(t, `{"id": "2"}`, string(impData["2"]), "FetchRequests should fetch the right imp data")
-	assert.JSONEq(t, `{"id": "3"}`, string(impData["3"]), "FetchRequests should fetch the right imp data")
-	assert.JSONEq(t, `{"id": "1"}`, string(reqData["1"]), "FetchRequests should fetch the right req data")
-	assert.JSONEq(t, `{"id": "2"}`, string(reqData["2"]), "FetchRequests should fetch the right req data")
-	assert.JSONEq(t, `{"id": "3"}`, string(reqData["3"]), "FetchRequests should fetch the right req data")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", category)
+}
+
+func TestCategoryCacheMissingMapping(t *testing.T) {
+	fetcher := &mockFetcher{}
+	categoryCache := &mockCategoryCache{}
+	fetcherWithCache := WithCache(fetcher, Cache{&mockCache{}, &mockCache{}, &mockCache{}, categoryCache}, &pbsmetrics.MetricsEngineMock{})
+	ctx := context.Background()
+	notFound := NotFoundError{ID: "freewheel", DataType: "CategoryMapping"}
+
+	categoryCache.On("Get", ctx, "freewheel").Return(map[string]Category(nil), false)
+	fetcher.On("FetchCategoryMapping", ctx, "freewheel", "").Return(json.RawMessage(nil), notFound)
+
+	_, err := fetcherWithCache.FetchCategories(ctx, "freewheel", "", "IAB1-1")
+
+	categoryCache.AssertNotCalled(t, "Save")
+	assert.Equal(t, notFound, err, "Missing mappings shouldn't be cached")
 }
 
 type mockFetcher struct {
@@ -215,23 +342,69 @@ func (f *mockFetcher) FetchRequests(ctx context.Context, requestIDs []string, im
 	return args.Get(0).(map[string]json.RawMessage), args.Get(1).(map[string]json.RawMessage), args.Get(2).([]error)
 }
 
+func (f *mockFetcher) FetchAccount(ctx context.Context, accountID string) (json.RawMessage, []error) {
+	args := f.Called(ctx, accountID)
+	return args.Get(0).(json.RawMessage), args.Get(1).([]error)
+}
+
+func (f *mockFetcher) FetchResponses(ctx context.Context, ids []string) (map[string]json.RawMessage, []error) {
+	args := f.Called(ctx, ids)
+	return args.Get(0).(map[string]json.RawMessage), args.Get(1).([]error)
+}
+
 func (f *mockFetcher) FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error) {
 	return "", nil
 }
 
+func (f *mockFetcher) FetchCategoryMapping(ctx context.Context, primaryAdServer, publisherId string) (json.RawMessage, error) {
+	args := f.Called(ctx, primaryAdServer, publisherId)
+	return args.Get(0).(json.RawMessage), args.Error(1)
+}
+
 type mockCache struct {
 	mock.Mock
 }
 
-func (c *mockCache) Get(ctx context.Context, requestIDs []string, impIDs []string) (map[string]json.RawMessage, map[string]json.RawMessage) {
-	args := c.Called(ctx, requestIDs, impIDs)
-	return args.Get(0).(map[string]json.RawMessage), args.Get(1).(map[string]json.RawMessage)
+func (c *mockCache) Get(ctx context.Context, ids []string) map[string]json.RawMessage {
+	args := c.Called(ctx, ids)
+	return args.Get(0).(map[string]json.RawMessage)
+}
+
+func (c *mockCache) Save(ctx context.Context, data map[string]json.RawMessage) {
+	c.Called(ctx, data)
+}
+
+func (c *mockCache) Invalidate(ctx context.Context, ids []string) {
+	c.Called(ctx, ids)
+}
+
+type mockStatsCache struct {
+	mockCache
+	stats CacheStats
+}
+
+func (c *mockStatsCache) Stats() CacheStats {
+	return c.stats
+}
+
+type mockCategoryCache struct {
+	mock.Mock
+	stats CacheStats
+}
+
+func (c *mockCategoryCache) Get(ctx context.Context, mappingID string) (map[string]Category, bool) {
+	args := c.Called(ctx, mappingID)
+	return args.Get(0).(map[string]Category), args.Bool(1)
+}
+
+func (c *mockCategoryCache) Save(ctx context.Context, data map[string]json.RawMessage) {
+	c.Called(ctx, data)
 }
 
-func (c *mockCache) Save(ctx context.Context, storedRequests map[string]json.RawMessage, storedImps map[string]json.RawMessage) {
-	c.Called(ctx, storedRequests, storedImps)
+func (c *mockCategoryCache) Invalidate(ctx context.Context, mappingIDs []string) {
+	c.Called(ctx, mappingIDs)
 }
 
-func (c *mockCache) Invalidate(ctx context.Context, requestIDs []string, impIDs []string) {
-	c.Called(ctx, requestIDs, impIDs)
+func (c *mockCategoryCache) Stats() CacheStats {
+	return c.stats
 }
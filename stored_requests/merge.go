@@ -0,0 +1,185 @@
+package stored_requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// arrayMergeKeys names the fields which identify the elements of the arrays merged by openrtb_ext.MergeDeep,
+// keyed by the array's field name. Elements of other arrays are appended.
+var arrayMergeKeys = map[string][]string{
+	"imp":    {"id"},
+	"format": {"w", "h"},
+	"eids":   {"source"},
+}
+
+// ChooseMergeStrategy returns the merge strategy which the stored data sets in ext.prebid.storedrequest.merge,
+// or hostDefault if it doesn't set one.
+func ChooseMergeStrategy(data json.RawMessage, hostDefault openrtb_ext.MergeStrategy) openrtb_ext.MergeStrategy {
+	if strategy, err := jsonparser.GetString(data, "ext", "prebid", "storedrequest", "merge"); err == nil && strategy != "" {
+		return openrtb_ext.MergeStrategy(strategy)
+	}
+	return hostDefault
+}
+
+// Merge merges the request JSON into the stored JSON with the given strategy. Values in the request take precedence.
+// An empty strategy is the same as openrtb_ext.MergeReplace.
+//
+// It also returns the conflicts: a description of every place where the request replaced a different stored value.
+// Conflicts are only found by openrtb_ext.MergeDeep, since MergeReplace is used when the stored data is just a default.
+func Merge(strategy openrtb_ext.MergeStrategy, stored json.RawMessage, request json.RawMessage) (json.RawMessage, []string, error) {
+	switch strategy {
+	case "", openrtb_ext.MergeReplace:
+		merged, err := jsonpatch.MergePatch(stored, request)
+		return merged, nil, err
+	case openrtb_ext.MergeDeep:
+	default:
+		return nil, nil, fmt.Errorf("merge strategy must be %s or %s. Got %s", openrtb_ext.MergeReplace, openrtb_ext.MergeDeep, strategy)
+	}
+
+	storedValue, err := decodeMergeJSON(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	requestValue, err := decodeMergeJSON(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	var conflicts []string
+	merged, err := json.Marshal(deepMerge("", "", storedValue, requestValue, &conflicts))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(conflicts)
+	return merged, conflicts, nil
+}
+
+// decodeMergeJSON keeps numbers as json.Number, so that they aren't changed by the merge.
+func decodeMergeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// deepMerge merges request into stored. It follows RFC 7386 for objects, so nulls in the request delete stored fields.
+// Arrays are merged by mergeArrays. The path and field describe where the values are in the document.
+func deepMerge(path string, field string, stored interface{}, request interface{}, conflicts *[]string) interface{} {
+	switch requestValue := request.(type) {
+	case map[string]interface{}:
+		storedObject, ok := stored.(map[string]interface{})
+		if !ok {
+			addConflict(path, stored, request, conflicts)
+			storedObject = make(map[string]interface{}, len(requestValue))
+		}
+		for key, value := range requestValue {
+			if value == nil {
+				delete(storedObject, key)
+				continue
+			}
+			storedObject[key] = deepMerge(joinMergePath(path, key), key, storedObject[key], value, conflicts)
+		}
+		return storedObject
+	case []interface{}:
+		if storedArray, ok := stored.([]interface{}); ok {
+			return mergeArrays(path, field, storedArray, requestValue, conflicts)
+		}
+	}
+	if !reflect.DeepEqual(stored, request) {
+		addConflict(path, stored, request, conflicts)
+	}
+	return request
+}
+
+// mergeArrays merges the elements of arrays with keys in arrayMergeKeys, and appends the request's other elements.
+// Elements of arrays without keys are only appended if the stored array doesn't have them already.
+func mergeArrays(path string, field string, stored []interface{}, request []interface{}, conflicts *[]string) []interface{} {
+	merged := make([]interface{}, len(stored), len(stored)+len(request))
+	copy(merged, stored)
+
+	keyFields, isKeyed := arrayMergeKeys[field]
+	indices := make(map[string]int, len(stored))
+	if isKeyed {
+		for i, element := range stored {
+			if key, ok := elementMergeKey(element, keyFields); ok {
+				indices[key] = i
+			}
+		}
+	}
+
+	for _, element := range request {
+		if !isKeyed {
+			if !containsElement(merged, element) {
+				merged = append(merged, element)
+			}
+			continue
+		}
+		key, hasKey := elementMergeKey(element, keyFields)
+		if i, ok := indices[key]; hasKey && ok {
+			merged[i] = deepMerge(fmt.Sprintf("%s[%s]", path, key), "", merged[i], element, conflicts)
+			continue
+		}
+		if hasKey {
+			indices[key] = len(merged)
+		}
+		merged = append(merged, element)
+	}
+	return merged
+}
+
+// elementMergeKey describes the values of the element's key fields, like "w=300,h=250".
+// It returns false if the element doesn't have all of them.
+func elementMergeKey(element interface{}, keyFields []string) (string, bool) {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	parts := make([]string, 0, len(keyFields))
+	for _, keyField := range keyFields {
+		value, ok := object[keyField]
+		if !ok || value == nil {
+			return "", false
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", keyField, value))
+	}
+	return strings.Join(parts, ","), true
+}
+
+func containsElement(array []interface{}, element interface{}) bool {
+	for _, existing := range array {
+		if reflect.DeepEqual(existing, element) {
+			return true
+		}
+	}
+	return false
+}
+
+func addConflict(path string, stored interface{}, request interface{}, conflicts *[]string) {
+	if stored == nil {
+		return
+	}
+	if path == "" {
+		path = "(root)"
+	}
+	storedJSON, _ := json.Marshal(stored)
+	requestJSON, _ := json.Marshal(request)
+	*conflicts = append(*conflicts, fmt.Sprintf("%s: the request's %s replaced the stored %s", path, requestJSON, storedJSON))
+}
+
+func joinMergePath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,92 @@
+package stored_requests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeReplace(t *testing.T) {
+	stored := json.RawMessage(`{"banner":{"format":[{"w":300,"h":250}]},"bidfloor":1}`)
+	request := json.RawMessage(`{"banner":{"format":[{"w":728,"h":90}]}}`)
+
+	for _, strategy := range []openrtb_ext.MergeStrategy{"", openrtb_ext.MergeReplace} {
+		merged, conflicts, err := Merge(strategy, stored, request)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"banner":{"format":[{"w":728,"h":90}]},"bidfloor":1}`, string(merged), "Arrays should be replaced")
+		assert.Empty(t, conflicts)
+	}
+}
+
+func TestMergeDeep(t *testing.T) {
+	testCases := []struct {
+		description string
+		stored      string
+		request     string
+		expected    string
+		conflicts   []string
+	}{
+		{
+			description: "Formats are merged by size",
+			stored:      `{"banner":{"format":[{"w":300,"h":250},{"w":300,"h":600,"wratio":1}]}}`,
+			request:     `{"banner":{"format":[{"w":728,"h":90},{"w":300,"h":600,"wratio":2}]}}`,
+			expected:    `{"banner":{"format":[{"w":300,"h":250},{"w":300,"h":600,"wratio":2},{"w":728,"h":90}]}}`,
+			conflicts:   []string{"banner.format[w=300,h=600].wratio: the request's 2 replaced the stored 1"},
+		},
+		{
+			description: "Imps are merged by id",
+			stored:      `{"imp":[{"id":"1","bidfloor":1,"ext":{"appnexus":{"placementId":1}}}]}`,
+			request:     `{"imp":[{"id":"1","ext":{"rubicon":{"zoneId":2}}},{"id":"2"}]}`,
+			expected:    `{"imp":[{"id":"1","bidfloor":1,"ext":{"appnexus":{"placementId":1},"rubicon":{"zoneId":2}}},{"id":"2"}]}`,
+		},
+		{
+			description: "EIDs are merged by source",
+			stored:      `{"user":{"ext":{"eids":[{"source":"a.com","uids":[{"id":"1"}]}]}}}`,
+			request:     `{"user":{"ext":{"eids":[{"source":"a.com","uids":[{"id":"2"}]},{"source":"b.com","uids":[{"id":"3"}]}]}}}`,
+			expected:    `{"user":{"ext":{"eids":[{"source":"a.com","uids":[{"id":"1"},{"id":"2"}]},{"source":"b.com","uids":[{"id":"3"}]}]}}}`,
+		},
+		{
+			description: "Other arrays are appended without duplicates",
+			stored:      `{"bcat":["IAB1","IAB2"]}`,
+			request:     `{"bcat":["IAB2","IAB3"]}`,
+			expected:    `{"bcat":["IAB1","IAB2","IAB3"]}`,
+		},
+		{
+			description: "Nulls delete stored fields",
+			stored:      `{"tmax":500,"site":{"page":"a.com"}}`,
+			request:     `{"tmax":null,"site":{"page":"b.com"}}`,
+			expected:    `{"site":{"page":"b.com"}}`,
+			conflicts:   []string{`site.page: the request's "b.com" replaced the stored "a.com"`},
+		},
+		{
+			description: "Numbers are kept exactly",
+			stored:      `{"bidfloor":1.50}`,
+			request:     `{"id":"req-1","user":{"id":"9007199254740993"},"cur":["USD"]}`,
+			expected:    `{"bidfloor":1.50,"id":"req-1","user":{"id":"9007199254740993"},"cur":["USD"]}`,
+		},
+	}
+
+	for _, test := range testCases {
+		merged, conflicts, err := Merge(openrtb_ext.MergeDeep, json.RawMessage(test.stored), json.RawMessage(test.request))
+		if !assert.NoError(t, err, test.description) {
+			continue
+		}
+		assert.JSONEq(t, test.expected, string(merged), test.description)
+		assert.Equal(t, test.conflicts, conflicts, test.description)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	_, _, err := Merge("append", json.RawMessage(`{}`), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "merge strategy must be replace or deep. Got append")
+
+	_, _, err = Merge(openrtb_ext.MergeDeep, json.RawMessage(`{`), json.RawMessage(`{}`))
+	assert.Error(t, err)
+}
+
+func TestChooseMergeStrategy(t *testing.T) {
+	assert.Equal(t, openrtb_ext.MergeDeep, ChooseMergeStrategy(json.RawMessage(`{"ext":{"prebid":{"storedrequest":{"merge":"deep"}}}}`), openrtb_ext.MergeReplace))
+	assert.Equal(t, openrtb_ext.MergeReplace, ChooseMergeStrategy(json.RawMessage(`{"ext":{"prebid":{}}}`), openrtb_ext.MergeReplace), "The host's default should be used if the stored data doesn't choose")
+}
@@ -36,6 +36,35 @@ func (mf MultiFetcher) FetchRequests(ctx context.Context, requestIDs []string, i
 	return
 }
 
+// FetchAccount returns the account from the first sub-Fetcher which has it.
+func (mf MultiFetcher) FetchAccount(ctx context.Context, accountID string) (account json.RawMessage, errs []error) {
+	for _, f := range mf {
+		account, accountErrs := f.FetchAccount(ctx, accountID)
+		if len(accountErrs) == 0 {
+			return account, nil
+		}
+		// Drop NotFound errors, as other fetchers may have the account.
+		errs = append(errs, dropMissingIDs(accountErrs)...)
+	}
+	errs = append(errs, NotFoundError{accountID, "Account"})
+	return nil, errs
+}
+
+// FetchResponses implements the ResponseFetcher interface for MultiFetcher
+func (mf MultiFetcher) FetchResponses(ctx context.Context, ids []string) (data map[string]json.RawMessage, errs []error) {
+	data = make(map[string]json.RawMessage, len(ids))
+
+	for _, f := range mf {
+		ids = filter(ids, data)
+		theseData, rerrs := f.FetchResponses(ctx, ids)
+		// Drop NotFound errors, as other fetchers may have them.
+		errs = append(errs, dropMissingIDs(rerrs)...)
+		addAll(data, theseData)
+	}
+	errs = appendNotFoundErrors("Response", ids, data, errs)
+	return
+}
+
 func (mf MultiFetcher) FetchCategories(ctx context.Context, primaryAdServer, publisherId, iabCategory string) (string, error) {
 	for _, f := range mf {
 		if cf, ok := f.(CategoryFetcher); ok {
@@ -51,6 +80,26 @@ func (mf MultiFetcher) FetchCategories(ctx context.Context, primaryAdServer, pub
 	return "", NotFoundError{errtype, "Category"}
 }
 
+// FetchCategoryMapping returns the mapping from the first Fetcher which has it.
+// If none of them have it, the first error which isn't a NotFoundError is returned, so that it isn't mistaken
+// for a missing mapping.
+func (mf MultiFetcher) FetchCategoryMapping(ctx context.Context, primaryAdServer, publisherId string) (json.RawMessage, error) {
+	var fetchErr error
+	for _, f := range mf {
+		mapping, err := f.FetchCategoryMapping(ctx, primaryAdServer, publisherId)
+		if err == nil {
+			return mapping, nil
+		}
+		if _, ok := err.(NotFoundError); !ok && fetchErr == nil {
+			fetchErr = err
+		}
+	}
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	return nil, NotFoundError{CategoryMappingID(primaryAdServer, publisherId), "CategoryMapping"}
+}
+
 func addAll(base map[string]json.RawMessage, toAdd map[string]json.RawMessage) {
 	for k, v := range toAdd {
 		base[k] = v
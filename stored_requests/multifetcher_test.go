@@ -125,3 +125,83 @@ func TestOtherError(t *testing.T) {
 	assert.JSONEq(t, `{"req_id": "def"}`, string(reqData["def"]), "MultiFetcher should return the right request data")
 	assert.JSONEq(t, `{"imp_id": "imp-1"}`, string(impData["imp-1"]), "MultiFetcher should return the right imp data")
 }
+
+func TestMultiFetcherAccountFoundInSecondFetcher(t *testing.T) {
+	f1 := &mockFetcher{}
+	f2 := &mockFetcher{}
+	fetcher := &MultiFetcher{f1, f2}
+	ctx := context.Background()
+
+	f1.On("FetchAccount", ctx, "ONE").Return(json.RawMessage(nil), []error{NotFoundError{"ONE", "Account"}})
+	f2.On("FetchAccount", ctx, "ONE").Return(json.RawMessage(`{"id": "ONE"}`), []error{})
+
+	account, errs := fetcher.FetchAccount(ctx, "ONE")
+
+	f1.AssertExpectations(t)
+	f2.AssertExpectations(t)
+	assert.Empty(t, errs, "MultiFetcher shouldn't return an error if a later fetcher has the account")
+	assert.JSONEq(t, `{"id": "ONE"}`, string(account), "MultiFetcher should return the right account data")
+}
+
+func TestMultiFetcherAccountNotFound(t *testing.T) {
+	f1 := &mockFetcher{}
+	f2 := &mockFetcher{}
+	fetcher := &MultiFetcher{f1, f2}
+	ctx := context.Background()
+
+	f1.On("FetchAccount", ctx, "MISSING").Return(json.RawMessage(nil), []error{NotFoundError{"MISSING", "Account"}})
+	f2.On("FetchAccount", ctx, "MISSING").Return(json.RawMessage(nil), []error{NotFoundError{"MISSING", "Account"}})
+
+	account, errs := fetcher.FetchAccount(ctx, "MISSING")
+
+	f1.AssertExpectations(t)
+	f2.AssertExpectations(t)
+	assert.Nil(t, account, "MultiFetcher shouldn't return data for a missing account")
+	assert.Equal(t, []error{NotFoundError{"MISSING", "Account"}}, errs, "MultiFetcher should return a single NotFoundError for a missing account")
+}
+
+func TestMultiFetcherResponses(t *testing.T) {
+	f1 := &mockFetcher{}
+	f2 := &mockFetcher{}
+	fetcher := &MultiFetcher{f1, f2}
+	ctx := context.Background()
+
+	f1.On("FetchResponses", ctx, []string{"ONE", "TWO", "THREE"}).Return(
+		map[string]json.RawMessage{"ONE": json.RawMessage(`{"id": "ONE"}`)},
+		[]error{NotFoundError{"TWO", "Response"}, NotFoundError{"THREE", "Response"}},
+	)
+	f2.On("FetchResponses", ctx, []string{"TWO", "THREE"}).Return(
+		map[string]json.RawMessage{"TWO": json.RawMessage(`{"id": "TWO"}`)},
+		[]error{NotFoundError{"THREE", "Response"}},
+	)
+
+	data, errs := fetcher.FetchResponses(ctx, []string{"ONE", "TWO", "THREE"})
+
+	f1.AssertExpectations(t)
+	f2.AssertExpectations(t)
+	assert.Len(t, data, 2, "MultiFetcher should return the responses found by any fetcher")
+	assert.JSONEq(t, `{"id": "ONE"}`, string(data["ONE"]), "MultiFetcher should return the right data for ONE")
+	assert.JSONEq(t, `{"id": "TWO"}`, string(data["TWO"]), "MultiFetcher should return the right data for TWO")
+	assert.Equal(t, []error{NotFoundError{"THREE", "Response"}}, errs, "MultiFetcher should return a single NotFoundError for each missing response")
+}
+
+func TestMultiFetcherCategoryMappingErrors(t *testing.T) {
+	f1 := &mockFetcher{}
+	f2 := &mockFetcher{}
+	fetcher := &MultiFetcher{f1, f2}
+	ctx := context.Background()
+
+	dbErr := errors.New("connection refused")
+	f1.On("FetchCategoryMapping", ctx, "freewheel", "pub").Return(json.RawMessage(nil), dbErr)
+	f2.On("FetchCategoryMapping", ctx, "freewheel", "pub").Return(json.RawMessage(nil), NotFoundError{"freewheel_pub", "CategoryMapping"})
+
+	_, err := fetcher.FetchCategoryMapping(ctx, "freewheel", "pub")
+	assert.Equal(t, dbErr, err, "Fetch errors shouldn't be reported as missing mappings")
+
+	f1.On("FetchCategoryMapping", ctx, "freewheel", "other").Return(json.RawMessage(nil), NotFoundError{"freewheel_other", "CategoryMapping"})
+	f2.On("FetchCategoryMapping", ctx, "freewheel", "other").Return(json.RawMessage(`{"IAB1-1":{"id":"1"}}`), nil)
+
+	mapping, err := fetcher.FetchCategoryMapping(ctx, "freewheel", "other")
+	assert.NoError(t, err, "MultiFetcher shouldn't return an error if a later fetcher has the mapping")
+	assert.JSONEq(t, `{"IAB1-1":{"id":"1"}}`, string(mapping))
+}
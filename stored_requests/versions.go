@@ -0,0 +1,122 @@
+package stored_requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/buger/jsonparser"
+)
+
+// StoredDataVersion is one version of a versioned Stored Request or Imp.
+//
+// Stored data is versioned if it's a JSON object with a "versions" array, like:
+//
+//	{
+//	  "versions": [
+//	    { "version": "v1", "weight": 90, "data": { ... stored data ... } },
+//	    { "version": "v2", "weight": 10, "data": { ... stored data ... } }
+//	  ]
+//	}
+//
+// Fetchers and Caches treat versioned data like any other, so every backend supports it.
+type StoredDataVersion struct {
+	Version string          `json:"version"`
+	Weight  int             `json:"weight"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// maxVersionWeight bounds the total weight of a Stored Request's versions, so that ChooseVersion's hash
+// can always be reduced to a bucket without overflowing.
+const maxVersionWeight = 1000000
+
+type versionedStoredData struct {
+	Versions []StoredDataVersion `json:"versions"`
+}
+
+// ChooseVersion picks the version of the stored data with the given ID which a request should use.
+// Each version gets a share of traffic proportional to its weight. The choice is a hash of the ID and the key,
+// so requests with the same key always get the same version.
+//
+// It returns the version's data and name. Data which isn't versioned is returned as-is, with an empty name.
+func ChooseVersion(id string, data json.RawMessage, key string) (json.RawMessage, string, error) {
+	if !isVersioned(data) {
+		return data, "", nil
+	}
+
+	var versioned versionedStoredData
+	if err := json.Unmarshal(data, &versioned); err != nil {
+		return nil, "", err
+	}
+	totalWeight, err := validateVersions(versioned.Versions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(id))
+	hash.Write([]byte{0})
+	hash.Write([]byte(key))
+	bucket := int(hash.Sum32() % uint32(totalWeight))
+	for _, version := range versioned.Versions {
+		if bucket < version.Weight {
+			return version.Data, version.Version, nil
+		}
+		bucket -= version.Weight
+	}
+	// validateVersions makes sure the weights add up, so this can't happen.
+	return nil, "", errors.New("no version was chosen")
+}
+
+// Versions returns every version of the stored data, so that each of them can be checked before it's saved.
+// Data which isn't versioned is returned as a single version with no name.
+func Versions(data json.RawMessage) ([]StoredDataVersion, error) {
+	if !isVersioned(data) {
+		return []StoredDataVersion{{Data: data}}, nil
+	}
+
+	var versioned versionedStoredData
+	if err := json.Unmarshal(data, &versioned); err != nil {
+		return nil, err
+	}
+	if _, err := validateVersions(versioned.Versions); err != nil {
+		return nil, err
+	}
+	return versioned.Versions, nil
+}
+
+func isVersioned(data json.RawMessage) bool {
+	_, dataType, _, err := jsonparser.Get(data, "versions")
+	return err == nil && dataType == jsonparser.Array
+}
+
+func validateVersions(versions []StoredDataVersion) (totalWeight int, err error) {
+	if len(versions) == 0 {
+		return 0, errors.New("versions must not be empty")
+	}
+	names := make(map[string]bool, len(versions))
+	for i, version := range versions {
+		if version.Version == "" {
+			return 0, fmt.Errorf("versions[%d].version must not be empty", i)
+		}
+		if names[version.Version] {
+			return 0, fmt.Errorf("versions[%d].version %s is a duplicate", i, version.Version)
+		}
+		names[version.Version] = true
+		if version.Weight < 0 || version.Weight > maxVersionWeight {
+			return 0, fmt.Errorf("versions[%d].weight must be in the range [0, %d]. Got %d", i, maxVersionWeight, version.Weight)
+		}
+		if len(version.Data) == 0 {
+			return 0, fmt.Errorf("versions[%d].data must not be empty", i)
+		}
+		totalWeight += version.Weight
+	}
+	if totalWeight == 0 {
+		return 0, errors.New("at least one version must have a weight > 0")
+	}
+	if totalWeight > maxVersionWeight {
+		return 0, fmt.Errorf("the versions' weights must add up to at most %d. Got %d", maxVersionWeight, totalWeight)
+	}
+	return totalWeight, nil
+}
@@ -0,0 +1,86 @@
+package stored_requests
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChooseVersionUnversioned(t *testing.T) {
+	data := json.RawMessage(`{"id":"req","imp":[{"id":"1"}]}`)
+	chosen, version, err := ChooseVersion("req", data, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, data, chosen, "Data without versions should be returned as-is")
+	assert.Empty(t, version)
+}
+
+func TestChooseVersionWeights(t *testing.T) {
+	data := json.RawMessage(`{"versions":[
+		{"version":"v1","weight":3,"data":{"tmax":100}},
+		{"version":"v2","weight":1,"data":{"tmax":200}},
+		{"version":"off","weight":0,"data":{"tmax":300}}
+	]}`)
+
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		chosen, version, err := ChooseVersion("req", data, strconv.Itoa(i))
+		if !assert.NoError(t, err) {
+			return
+		}
+		counts[version]++
+		if version == "v1" {
+			assert.JSONEq(t, `{"tmax":100}`, string(chosen))
+		}
+	}
+	assert.InDelta(t, 3000, counts["v1"], 200, "Versions should get traffic in proportion to their weights")
+	assert.InDelta(t, 1000, counts["v2"], 200, "Versions should get traffic in proportion to their weights")
+	assert.Zero(t, counts["off"], "Versions with no weight should never be chosen")
+}
+
+func TestChooseVersionIsStable(t *testing.T) {
+	data := json.RawMessage(`{"versions":[{"version":"v1","weight":1,"data":{}},{"version":"v2","weight":1,"data":{}}]}`)
+	_, first, _ := ChooseVersion("req", data, "some-request")
+	for i := 0; i < 10; i++ {
+		_, version, _ := ChooseVersion("req", data, "some-request")
+		assert.Equal(t, first, version, "The same key should always get the same version")
+	}
+}
+
+func TestChooseVersionInvalid(t *testing.T) {
+	testCases := []struct {
+		description string
+		data        string
+		expected    string
+	}{
+		{"empty", `{"versions":[]}`, "versions must not be empty"},
+		{"no name", `{"versions":[{"weight":1,"data":{}}]}`, "versions[0].version must not be empty"},
+		{"duplicate", `{"versions":[{"version":"a","weight":1,"data":{}},{"version":"a","weight":1,"data":{}}]}`, "versions[1].version a is a duplicate"},
+		{"negative weight", `{"versions":[{"version":"a","weight":-1,"data":{}}]}`, "versions[0].weight must be in the range [0, 1000000]. Got -1"},
+		{"huge weight", `{"versions":[{"version":"a","weight":4294967296,"data":{}}]}`, "versions[0].weight must be in the range [0, 1000000]. Got 4294967296"},
+		{"huge total weight", `{"versions":[{"version":"a","weight":600000,"data":{}},{"version":"b","weight":600000,"data":{}}]}`, "the versions' weights must add up to at most 1000000. Got 1200000"},
+		{"no data", `{"versions":[{"version":"a","weight":1}]}`, "versions[0].data must not be empty"},
+		{"no weight", `{"versions":[{"version":"a","weight":0,"data":{}}]}`, "at least one version must have a weight > 0"},
+	}
+	for _, test := range testCases {
+		_, _, err := ChooseVersion("req", json.RawMessage(test.data), "key")
+		assert.EqualError(t, err, test.expected, test.description)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	data := json.RawMessage(`{"id":"req"}`)
+	versions, err := Versions(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []StoredDataVersion{{Data: data}}, versions, "Data without versions should be a single unnamed version")
+
+	versions, err = Versions(json.RawMessage(`{"versions":[{"version":"v1","weight":1,"data":{"tmax":100}},{"version":"off","weight":0,"data":{"tmax":200}}]}`))
+	if assert.NoError(t, err) && assert.Len(t, versions, 2) {
+		assert.Equal(t, "off", versions[1].Version, "Versions without weight should still be returned")
+		assert.JSONEq(t, `{"tmax":200}`, string(versions[1].Data))
+	}
+
+	_, err = Versions(json.RawMessage(`{"versions":[]}`))
+	assert.EqualError(t, err, "versions must not be empty")
+}
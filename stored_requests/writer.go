@@ -0,0 +1,33 @@
+package stored_requests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Writer knows how to save Stored Requests and Imps to a backend, so that its Fetchers return them.
+//
+// Implementations must be safe for concurrent access by multiple goroutines.
+type Writer interface {
+	// SaveRequests saves the Stored Requests and Imps, replacing any which already have the same IDs.
+	SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error
+}
+
+// MultiWriter is a Writer which saves to every one of its sub-Writers, in order.
+// It stops at the first error. If an earlier Writer had already saved the data, the error says how many did,
+// since they'll keep the new data while the rest still have the old.
+type MultiWriter []Writer
+
+// SaveRequests implements the Writer interface for MultiWriter
+func (mw MultiWriter) SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error {
+	for i, w := range mw {
+		if err := w.SaveRequests(ctx, requests, imps); err != nil {
+			if i > 0 {
+				return fmt.Errorf("The data was saved to the first %d of %d backends, but not the rest: %v", i, len(mw), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
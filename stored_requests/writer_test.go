@@ -0,0 +1,42 @@
+package stored_requests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiWriter(t *testing.T) {
+	w1 := &recordingWriter{}
+	w2 := &recordingWriter{err: errors.New("backend failure")}
+	w3 := &recordingWriter{}
+	requests := map[string]json.RawMessage{"req": json.RawMessage(`{}`)}
+
+	err := MultiWriter{w1, w2, w3}.SaveRequests(context.Background(), requests, nil)
+	assert.EqualError(t, err, "The data was saved to the first 1 of 3 backends, but not the rest: backend failure")
+	assert.Equal(t, []map[string]json.RawMessage{requests}, w1.saved)
+	assert.Equal(t, []map[string]json.RawMessage{requests}, w2.saved)
+	assert.Empty(t, w3.saved, "Writers after a failure shouldn't be called")
+}
+
+func TestMultiWriterFirstFailure(t *testing.T) {
+	w1 := &recordingWriter{err: errors.New("backend failure")}
+	w2 := &recordingWriter{}
+
+	err := MultiWriter{w1, w2}.SaveRequests(context.Background(), map[string]json.RawMessage{"req": json.RawMessage(`{}`)}, nil)
+	assert.EqualError(t, err, "backend failure", "Nothing was saved, so the error shouldn't be wrapped")
+	assert.Empty(t, w2.saved)
+}
+
+type recordingWriter struct {
+	saved []map[string]json.RawMessage
+	err   error
+}
+
+func (w *recordingWriter) SaveRequests(ctx context.Context, requests map[string]json.RawMessage, imps map[string]json.RawMessage) error {
+	w.saved = append(w.saved, requests)
+	return w.err
+}
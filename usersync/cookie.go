@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/prebid/prebid-server/config"
@@ -160,12 +161,66 @@ func (cookie *PBSCookie) GetId(bidderName openrtb_ext.BidderName) (id string, ex
 }
 
 // SetCookieOnResponse is a shortcut for "ToHTTPCookie(); cookie.setDomain(domain); setCookie(w, cookie)"
-func (cookie *PBSCookie) SetCookieOnResponse(w http.ResponseWriter, domain string, ttl time.Duration) {
+//
+// If sameSiteNone is true, the cookie gets the SameSite=None and Secure attributes. Use UseSameSiteNone to
+// decide, since some browsers mishandle them. The cookie is Secure for every browser if the host sets secure.
+//
+// If the cookie would be bigger than the host's max_cookie_size_bytes, UIDs are evicted until it fits.
+// It returns the families whose UIDs were evicted.
+func (cookie *PBSCookie) SetCookieOnResponse(w http.ResponseWriter, sameSiteNone bool, cfg *config.HostCookie, ttl time.Duration) []string {
+	header := cookie.setCookieHeader(sameSiteNone, cfg, ttl)
+
+	var evicted []string
+	if cfg.MaxCookieSizeBytes > 0 {
+		for _, family := range cookie.evictionOrder(cfg.PriorityFamilies) {
+			if len(header) <= cfg.MaxCookieSizeBytes {
+				break
+			}
+			delete(cookie.uids, family)
+			evicted = append(evicted, family)
+			header = cookie.setCookieHeader(sameSiteNone, cfg, ttl)
+		}
+	}
+
+	w.Header().Add("Set-Cookie", header)
+	return evicted
+}
+
+// setCookieHeader returns the value of the Set-Cookie header which writes this cookie.
+func (cookie *PBSCookie) setCookieHeader(sameSiteNone bool, cfg *config.HostCookie, ttl time.Duration) string {
 	httpCookie := cookie.ToHTTPCookie(ttl)
-	if domain != "" {
-		httpCookie.Domain = domain
+	if cfg.Domain != "" {
+		httpCookie.Domain = cfg.Domain
+	}
+	httpCookie.Secure = cfg.Secure || sameSiteNone
+	if sameSiteNone {
+		return httpCookie.String() + sameSiteNoneAttribute
 	}
-	http.SetCookie(w, httpCookie)
+	return httpCookie.String()
+}
+
+// evictionOrder returns the families in the order their UIDs should be evicted. Families which aren't
+// priorities go first, closest to expiry first. Then the priorities go, from least to most important.
+func (cookie *PBSCookie) evictionOrder(priorities []string) []string {
+	rank := make(map[string]int, len(priorities))
+	for i, family := range priorities {
+		rank[family] = len(priorities) - i
+	}
+
+	families := make([]string, 0, len(cookie.uids))
+	for family := range cookie.uids {
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		if rank[families[i]] != rank[families[j]] {
+			return rank[families[i]] < rank[families[j]]
+		}
+		if expiresI, expiresJ := cookie.uids[families[i]].Expires, cookie.uids[families[j]].Expires; !expiresI.Equal(expiresJ) {
+			return expiresI.Before(expiresJ)
+		}
+		return families[i] < families[j]
+	})
+	return families
 }
 
 // Unsync removes the user's ID for the given family from this cookie.
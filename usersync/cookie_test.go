@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -333,6 +334,82 @@ func ensureConsistency(t *testing.T, cookie *PBSCookie) {
 	}
 }
 
+func TestCookieEviction(t *testing.T) {
+	// Each family's UID makes the cookie grow by the same amount, so the max size decides how many are evicted.
+	sizeWithout := func(families ...string) int {
+		cookie := newEvictionCookie()
+		for _, family := range families {
+			cookie.Unsync(family)
+		}
+		return len(cookie.setCookieHeader(false, &config.HostCookie{}, time.Hour))
+	}
+
+	testCases := []struct {
+		description     string
+		maxSize         int
+		priorities      []string
+		expectedEvicted []string
+	}{
+		{
+			description: "No max size",
+		},
+		{
+			description: "Big enough",
+			maxSize:     sizeWithout(),
+		},
+		{
+			description:     "Closest to expiry goes first",
+			maxSize:         sizeWithout("soon"),
+			expectedEvicted: []string{"soon"},
+		},
+		{
+			description:     "Several evictions",
+			maxSize:         sizeWithout("soon", "late"),
+			expectedEvicted: []string{"soon", "late"},
+		},
+		{
+			description:     "Priorities go last",
+			maxSize:         sizeWithout("soon", "late"),
+			priorities:      []string{"soon"},
+			expectedEvicted: []string{"late", "last"},
+		},
+		{
+			description:     "Least important priorities go first",
+			maxSize:         sizeWithout("soon", "late", "last"),
+			priorities:      []string{"last", "late", "soon"},
+			expectedEvicted: []string{"soon", "late", "last"},
+		},
+	}
+
+	for _, test := range testCases {
+		cookie := newEvictionCookie()
+		w := httptest.NewRecorder()
+		evicted := cookie.SetCookieOnResponse(w, false, &config.HostCookie{MaxCookieSizeBytes: test.maxSize, PriorityFamilies: test.priorities}, time.Hour)
+		assert.Equal(t, test.expectedEvicted, evicted, test.description)
+		for _, family := range test.expectedEvicted {
+			assert.False(t, cookie.HasLiveSync(family), test.description)
+		}
+		if test.maxSize > 0 {
+			assert.True(t, len(w.HeaderMap.Get("Set-Cookie")) <= test.maxSize, test.description)
+		}
+	}
+}
+
+// newEvictionCookie has three UIDs with family names and IDs of the same length. Their expiry times are
+// truncated to the second, so that each one takes up the same space in the cookie.
+func newEvictionCookie() *PBSCookie {
+	now := time.Now().Truncate(time.Second)
+	uid := strings.Repeat("x", 100)
+	return &PBSCookie{
+		uids: map[string]uidWithExpiry{
+			"soon": {UID: uid, Expires: now.Add(time.Hour)},
+			"late": {UID: uid, Expires: now.Add(2 * time.Hour)},
+			"last": {UID: uid, Expires: now.Add(3 * time.Hour)},
+		},
+		birthday: &now,
+	}
+}
+
 func newTempId(uid string) uidWithExpiry {
 	return uidWithExpiry{
 		UID:     uid,
@@ -353,7 +430,7 @@ func newSampleCookie() *PBSCookie {
 
 func writeThenRead(cookie *PBSCookie) *PBSCookie {
 	w := httptest.NewRecorder()
-	cookie.SetCookieOnResponse(w, "mock-domain", 90*24*time.Hour)
+	cookie.SetCookieOnResponse(w, false, &config.HostCookie{Domain: "mock-domain"}, 90*24*time.Hour)
 	writtenCookie := w.HeaderMap.Get("Set-Cookie")
 
 	header := http.Header{}
@@ -0,0 +1,74 @@
+package usersync
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/prebid/prebid-server/config"
+)
+
+// sameSiteNoneAttribute is appended to the uids cookie by hand, because net/http can't write SameSite=None
+// before Go 1.13.
+const sameSiteNoneAttribute = "; SameSite=None"
+
+// These come from https://www.chromium.org/updates/same-site/incompatible-clients
+var (
+	iosVersion           = regexp.MustCompile(`\(iP.+; CPU .*OS (\d+)[_\d]*.*\) AppleWebKit/`)
+	macosxVersion        = regexp.MustCompile(`\(Macintosh;.*Mac OS X (\d+)_(\d+)[_\d]*.*\) AppleWebKit/`)
+	safari               = regexp.MustCompile(`Version/.* Safari/`)
+	macEmbeddedBrowser   = regexp.MustCompile(`^Mozilla/[\.\d]+ \(Macintosh;.*Mac OS X [_\d]+\) AppleWebKit/[\.\d]+ \(KHTML, like Gecko\)$`)
+	chromiumBased        = regexp.MustCompile(`Chrom(e|ium)`)
+	chromiumVersion      = regexp.MustCompile(`Chrom[^ /]+/(\d+)[\.\d]* `)
+	ucBrowser            = regexp.MustCompile(`UCBrowser/`)
+	ucBrowserVersion     = regexp.MustCompile(`UCBrowser/(\d+)\.(\d+)\.(\d+)[\.\d]* `)
+	minUCBrowserVersion  = []int{12, 13, 2}
+	minChromiumVersion   = 51
+	fixedChromiumVersion = 67
+)
+
+// UseSameSiteNone is true if the uids cookie for this request should have the SameSite=None and Secure attributes.
+func UseSameSiteNone(r *http.Request, cfg *config.HostCookie) bool {
+	return cfg.SameSiteNone && SameSiteNoneCompatible(r.UserAgent())
+}
+
+// SameSiteNoneCompatible is false for the browsers which mishandle cookies with SameSite=None.
+// Some versions of Safari treat them as SameSite=Strict, and some versions of Chrome reject them.
+func SameSiteNoneCompatible(userAgent string) bool {
+	return !hasWebKitSameSiteBug(userAgent) && !dropsUnrecognizedSameSiteCookies(userAgent)
+}
+
+func hasWebKitSameSiteBug(userAgent string) bool {
+	if match := iosVersion.FindStringSubmatch(userAgent); match != nil {
+		return match[1] == "12"
+	}
+	if match := macosxVersion.FindStringSubmatch(userAgent); match != nil && match[1] == "10" && match[2] == "14" {
+		isSafari := safari.MatchString(userAgent) && !chromiumBased.MatchString(userAgent)
+		return isSafari || macEmbeddedBrowser.MatchString(userAgent)
+	}
+	return false
+}
+
+func dropsUnrecognizedSameSiteCookies(userAgent string) bool {
+	if ucBrowser.MatchString(userAgent) {
+		match := ucBrowserVersion.FindStringSubmatch(userAgent)
+		if match == nil {
+			return false
+		}
+		for i, min := range minUCBrowserVersion {
+			if version, _ := strconv.Atoi(match[i+1]); version != min {
+				return version < min
+			}
+		}
+		return false
+	}
+	if !chromiumBased.MatchString(userAgent) {
+		return false
+	}
+	match := chromiumVersion.FindStringSubmatch(userAgent)
+	if match == nil {
+		return false
+	}
+	version, _ := strconv.Atoi(match[1])
+	return version >= minChromiumVersion && version < fixedChromiumVersion
+}
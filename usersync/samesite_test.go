@@ -0,0 +1,127 @@
+package usersync
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameSiteNoneCompatible(t *testing.T) {
+	testCases := []struct {
+		description string
+		userAgent   string
+		expected    bool
+	}{
+		{
+			description: "Chrome 80",
+			userAgent:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/80.0.3987.87 Safari/537.36",
+			expected:    true,
+		},
+		{
+			description: "Chrome 60 rejects SameSite=None",
+			userAgent:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/60.0.3112.113 Safari/537.36",
+			expected:    false,
+		},
+		{
+			description: "Chrome 50 ignores SameSite",
+			userAgent:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/50.0.2661.102 Safari/537.36",
+			expected:    true,
+		},
+		{
+			description: "iOS 12",
+			userAgent:   "Mozilla/5.0 (iPhone; CPU iPhone OS 12_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Mobile/15E148 Safari/604.1",
+			expected:    false,
+		},
+		{
+			description: "iOS 13",
+			userAgent:   "Mozilla/5.0 (iPhone; CPU iPhone OS 13_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0.4 Mobile/15E148 Safari/604.1",
+			expected:    true,
+		},
+		{
+			description: "Safari on macOS 10.14",
+			userAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_6) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Safari/605.1.15",
+			expected:    false,
+		},
+		{
+			description: "Embedded browser on macOS 10.14",
+			userAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_6) AppleWebKit/605.1.15 (KHTML, like Gecko)",
+			expected:    false,
+		},
+		{
+			description: "Chrome on macOS 10.14",
+			userAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/80.0.3987.87 Safari/537.36",
+			expected:    true,
+		},
+		{
+			description: "Safari on macOS 10.15",
+			userAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0.4 Safari/605.1.15",
+			expected:    true,
+		},
+		{
+			description: "Old UC Browser",
+			userAgent:   "Mozilla/5.0 (Linux; U; Android 8.1.0; en-US; Nexus 6P Build/OPM7.181205.001) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/57.0.2987.108 UCBrowser/12.11.1.1197 Mobile Safari/537.36",
+			expected:    false,
+		},
+		{
+			description: "New UC Browser",
+			userAgent:   "Mozilla/5.0 (Linux; U; Android 8.1.0; en-US; Nexus 6P Build/OPM7.181205.001) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/57.0.2987.108 UCBrowser/12.13.2.1208 Mobile Safari/537.36",
+			expected:    true,
+		},
+		{
+			description: "No user agent",
+			userAgent:   "",
+			expected:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, SameSiteNoneCompatible(test.userAgent), test.description)
+	}
+}
+
+func TestSetCookieOnResponseSameSite(t *testing.T) {
+	testCases := []struct {
+		description    string
+		sameSiteNone   bool
+		secure         bool
+		expectSameSite bool
+		expectSecure   bool
+	}{
+		{
+			description: "Legacy cookie",
+		},
+		{
+			description:    "SameSite=None",
+			sameSiteNone:   true,
+			expectSameSite: true,
+			expectSecure:   true,
+		},
+		{
+			description:  "Secure for every browser",
+			secure:       true,
+			expectSecure: true,
+		},
+	}
+
+	for _, test := range testCases {
+		w := httptest.NewRecorder()
+		newSampleCookie().SetCookieOnResponse(w, test.sameSiteNone, &config.HostCookie{Secure: test.secure}, time.Hour)
+		header := w.Header().Get("Set-Cookie")
+		assert.Equal(t, test.expectSameSite, strings.HasSuffix(header, "; SameSite=None"), test.description)
+		assert.Equal(t, test.expectSecure, strings.Contains(header, "; Secure"), test.description)
+	}
+}
+
+func TestUseSameSiteNone(t *testing.T) {
+	req := httptest.NewRequest("GET", "/setuid", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 12_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Mobile/15E148 Safari/604.1")
+	assert.False(t, UseSameSiteNone(req, &config.HostCookie{SameSiteNone: true}), "Incompatible browsers should get the legacy cookie")
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/80.0.3987.87 Safari/537.36")
+	assert.True(t, UseSameSiteNone(req, &config.HostCookie{SameSiteNone: true}))
+	assert.False(t, UseSameSiteNone(req, &config.HostCookie{SameSiteNone: false}), "The host can turn SameSite=None off")
+}
@@ -0,0 +1,92 @@
+package usersync
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// UIDStore keeps the IDs which bidders have given users on the server, for the users who don't have a uids cookie.
+// Users are identified by a first-party ID. See UIDStoreKey.
+//
+// Implementations must be safe for concurrent use.
+type UIDStore interface {
+	// GetUIDs returns the user's IDs, keyed by bidder family name. Unknown users have no IDs.
+	GetUIDs(ctx context.Context, key string) (map[string]string, error)
+
+	// SetUID saves the ID which a bidder family has given the user. If uid is empty, it removes the family's ID instead.
+	SetUID(ctx context.Context, key string, familyName string, uid string) error
+
+	// DeleteUIDs removes all of the user's IDs. It's used when the user opts out.
+	DeleteUIDs(ctx context.Context, key string) error
+}
+
+// UIDStoreKey returns the first-party ID which a user's UIDs are stored under, or "" if the request doesn't have one.
+//
+// If the host keys the store by its own cookie, it's that cookie's value. If the store is keyed by user ID,
+// it's userID: the user.id of an auction request, or the fpid query param of a /setuid request.
+func UIDStoreKey(r *http.Request, userID string, hostCookie *config.HostCookie, cfg *config.UIDStore) string {
+	switch cfg.KeySource {
+	case config.UIDStoreKeyHostCookie:
+		if hostCookie.CookieName == "" {
+			return ""
+		}
+		if cookie, err := r.Cookie(hostCookie.CookieName); err == nil {
+			return cookie.Value
+		}
+	case config.UIDStoreKeyUserID:
+		return userID
+	}
+	return ""
+}
+
+// SignFPID returns the signature which /setuid needs along with an fpid before it will store UIDs under it:
+// the hex-encoded HMAC-SHA256 of the fpid, keyed by the secret. The host must issue signed fpids itself,
+// so that nobody can write UIDs for a user ID which isn't theirs.
+func SignFPID(fpid string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fpid))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidFPID is true if the signature was made by SignFPID with the same fpid and secret.
+func ValidFPID(fpid string, signature string, secret string) bool {
+	if secret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignFPID(fpid, secret)))
+}
+
+// StoredUIDs are the user's IDs from a UIDStore, keyed by family name.
+// It implements exchange.IdFetcher, so that the IDs can be used in an auction in place of a PBSCookie.
+type StoredUIDs map[string]string
+
+// GetId returns the user's ID for the bidder, like PBSCookie.GetId does.
+func (uids StoredUIDs) GetId(bidderName openrtb_ext.BidderName) (id string, exists bool) {
+	familyName := string(bidderName)
+	if mapped, ok := bidderToFamilyNames[bidderName]; ok {
+		familyName = mapped
+	}
+	id, exists = uids[familyName]
+	return
+}
+
+// LoadStoredUIDs returns the user's IDs from the store. Lookup errors are logged, and leave the user without IDs,
+// since the auction can run without them.
+func LoadStoredUIDs(ctx context.Context, store UIDStore, key string) StoredUIDs {
+	if key == "" {
+		return nil
+	}
+	uids, err := store.GetUIDs(ctx, key)
+	if err != nil {
+		glog.Errorf("Failed to load UIDs from the store: %v", err)
+		return nil
+	}
+	return StoredUIDs(uids)
+}
@@ -0,0 +1,81 @@
+package usersync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUIDStoreKey(t *testing.T) {
+	hostCookie := &config.HostCookie{CookieName: "khaos"}
+	req := httptest.NewRequest("GET", "/openrtb2/auction", nil)
+	req.AddCookie(&http.Cookie{Name: "khaos", Value: "host-id"})
+
+	assert.Equal(t, "host-id", UIDStoreKey(req, "user-id", hostCookie, &config.UIDStore{KeySource: config.UIDStoreKeyHostCookie}))
+	assert.Equal(t, "user-id", UIDStoreKey(req, "user-id", hostCookie, &config.UIDStore{KeySource: config.UIDStoreKeyUserID}))
+	assert.Equal(t, "", UIDStoreKey(req, "user-id", &config.HostCookie{}, &config.UIDStore{KeySource: config.UIDStoreKeyHostCookie}))
+
+	noCookie := httptest.NewRequest("GET", "/openrtb2/auction", nil)
+	assert.Equal(t, "", UIDStoreKey(noCookie, "user-id", hostCookie, &config.UIDStore{KeySource: config.UIDStoreKeyHostCookie}))
+}
+
+func TestValidFPID(t *testing.T) {
+	signature := SignFPID("user-id", "secret")
+
+	assert.True(t, ValidFPID("user-id", signature, "secret"))
+	assert.False(t, ValidFPID("other-id", signature, "secret"), "Signatures should only be valid for their own fpid")
+	assert.False(t, ValidFPID("user-id", signature, "other-secret"), "Signatures should only be valid for their own secret")
+	assert.False(t, ValidFPID("user-id", "", "secret"), "Unsigned fpids should be invalid")
+	assert.False(t, ValidFPID("user-id", SignFPID("user-id", ""), ""), "fpids should never be valid without a secret")
+}
+
+func TestStoredUIDs(t *testing.T) {
+	uids := StoredUIDs{"adnxs": "123", "rubicon": "456"}
+
+	id, exists := uids.GetId(openrtb_ext.BidderAppnexus)
+	assert.True(t, exists)
+	assert.Equal(t, "123", id, "The appnexus bidder should use the adnxs family's UID")
+
+	id, exists = uids.GetId(openrtb_ext.BidderRubicon)
+	assert.True(t, exists)
+	assert.Equal(t, "456", id)
+
+	_, exists = uids.GetId(openrtb_ext.BidderPubmatic)
+	assert.False(t, exists)
+}
+
+func TestLoadStoredUIDs(t *testing.T) {
+	store := &fakeUIDStore{uids: map[string]map[string]string{"user": {"adnxs": "123"}}}
+
+	assert.Equal(t, StoredUIDs{"adnxs": "123"}, LoadStoredUIDs(context.Background(), store, "user"))
+	assert.Nil(t, LoadStoredUIDs(context.Background(), store, ""), "Users without a key shouldn't hit the store")
+	assert.Equal(t, 1, store.calls)
+
+	store.err = errors.New("store is down")
+	assert.Nil(t, LoadStoredUIDs(context.Background(), store, "user"), "Store errors should leave the user without UIDs")
+}
+
+type fakeUIDStore struct {
+	uids  map[string]map[string]string
+	err   error
+	calls int
+}
+
+func (store *fakeUIDStore) GetUIDs(ctx context.Context, key string) (map[string]string, error) {
+	store.calls++
+	return store.uids[key], store.err
+}
+
+func (store *fakeUIDStore) SetUID(ctx context.Context, key string, familyName string, uid string) error {
+	return store.err
+}
+
+func (store *fakeUIDStore) DeleteUIDs(ctx context.Context, key string) error {
+	return store.err
+}
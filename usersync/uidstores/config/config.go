@@ -0,0 +1,42 @@
+package config
+
+import (
+	"database/sql"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/usersync"
+	"github.com/prebid/prebid-server/usersync/uidstores/db_store"
+	"github.com/prebid/prebid-server/usersync/uidstores/empty_store"
+	"github.com/prebid/prebid-server/usersync/uidstores/file_store"
+)
+
+// NewUIDStore returns the UIDStore which the host configured, and a function which closes it on shutdown.
+// If the host didn't configure one, UIDs are only kept in the uids cookie.
+func NewUIDStore(cfg *config.UIDStore) (store usersync.UIDStore, shutdown func()) {
+	shutdown = func() {}
+	switch cfg.Type {
+	case config.UIDStoreTypeFile:
+		fileStore, err := file_store.NewFileStore(cfg.File.Directory)
+		if err != nil {
+			glog.Fatalf("Failed to create the file UID store: %v", err)
+		}
+		glog.Infof("Keeping UIDs in the directory %s", cfg.File.Directory)
+		return fileStore, shutdown
+	case config.UIDStoreTypePostgres:
+		db, err := sql.Open("postgres", cfg.Postgres.Connection.ConnString())
+		if err != nil {
+			glog.Fatalf("Failed to open the UID store's postgres connection: %v", err)
+		}
+		if err := db.Ping(); err != nil {
+			glog.Fatalf("Failed to ping the UID store's postgres: %v", err)
+		}
+		glog.Infof("Keeping UIDs in the postgres table %s", cfg.Postgres.Table)
+		return db_store.NewDBStore(db, cfg.Postgres.Table), func() {
+			if err := db.Close(); err != nil {
+				glog.Errorf("Error closing the UID store's DB connection: %v", err)
+			}
+		}
+	}
+	return empty_store.EmptyStore{}, shutdown
+}
@@ -0,0 +1,78 @@
+package db_store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/usersync"
+)
+
+// NewDBStore returns a UIDStore which keeps UIDs in a Postgres table. The table needs the columns
+// user_key, family and uid, with a primary key on (user_key, family). The table name comes from the host's config, so it must be trusted.
+func NewDBStore(db *sql.DB, table string) usersync.UIDStore {
+	if db == nil {
+		glog.Fatalf("The Postgres UID Store requires a database connection. Please report this as a bug.")
+	}
+	return &dbStore{
+		db:          db,
+		selectQuery: fmt.Sprintf("SELECT family, uid FROM %s WHERE user_key = $1", table),
+		upsertQuery: fmt.Sprintf("INSERT INTO %s (user_key, family, uid) VALUES ($1, $2, $3) ON CONFLICT (user_key, family) DO UPDATE SET uid = EXCLUDED.uid", table),
+		deleteQuery: fmt.Sprintf("DELETE FROM %s WHERE user_key = $1 AND family = $2", table),
+		clearQuery:  fmt.Sprintf("DELETE FROM %s WHERE user_key = $1", table),
+	}
+}
+
+// dbStore keeps UIDs in a database. This should be instantiated through the NewDBStore() function.
+type dbStore struct {
+	db          *sql.DB
+	selectQuery string
+	upsertQuery string
+	deleteQuery string
+	clearQuery  string
+}
+
+func (store *dbStore) GetUIDs(ctx context.Context, key string) (map[string]string, error) {
+	rows, err := store.db.QueryContext(ctx, store.selectQuery, key)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			glog.Errorf("error closing DB connection: %v", err)
+		}
+	}()
+
+	var uids map[string]string
+	for rows.Next() {
+		var family string
+		var uid string
+		if err := rows.Scan(&family, &uid); err != nil {
+			return nil, err
+		}
+		if uids == nil {
+			uids = make(map[string]string)
+		}
+		uids[family] = uid
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return uids, nil
+}
+
+func (store *dbStore) SetUID(ctx context.Context, key string, familyName string, uid string) error {
+	var err error
+	if uid == "" {
+		_, err = store.db.ExecContext(ctx, store.deleteQuery, key, familyName)
+	} else {
+		_, err = store.db.ExecContext(ctx, store.upsertQuery, key, familyName, uid)
+	}
+	return err
+}
+
+func (store *dbStore) DeleteUIDs(ctx context.Context, key string) error {
+	_, err := store.db.ExecContext(ctx, store.clearQuery, key)
+	return err
+}
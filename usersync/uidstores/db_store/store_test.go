@@ -0,0 +1,87 @@
+package db_store
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error stubbing DB: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT family, uid FROM uids WHERE user_key = $1")).
+		WithArgs("user").
+		WillReturnRows(sqlmock.NewRows([]string{"family", "uid"}).AddRow("adnxs", "123").AddRow("rubicon", "456"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT family, uid FROM uids WHERE user_key = $1")).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"family", "uid"}))
+
+	store := NewDBStore(db, "uids")
+	uids, err := store.GetUIDs(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"adnxs": "123", "rubicon": "456"}, uids)
+
+	uids, err = store.GetUIDs(context.Background(), "unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, uids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUIDsError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error stubbing DB: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT family, uid FROM uids WHERE user_key = $1")).
+		WithArgs("user").
+		WillReturnError(errors.New("connection lost"))
+
+	_, err = NewDBStore(db, "uids").GetUIDs(context.Background(), "user")
+	assert.EqualError(t, err, "connection lost")
+}
+
+func TestSetUID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error stubbing DB: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO uids (user_key, family, uid) VALUES ($1, $2, $3) ON CONFLICT (user_key, family) DO UPDATE SET uid = EXCLUDED.uid")).
+		WithArgs("user", "adnxs", "123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM uids WHERE user_key = $1 AND family = $2")).
+		WithArgs("user", "adnxs").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	store := NewDBStore(db, "uids")
+	assert.NoError(t, store.SetUID(context.Background(), "user", "adnxs", "123"))
+	assert.NoError(t, store.SetUID(context.Background(), "user", "adnxs", ""), "An empty UID should delete the row")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error stubbing DB: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM uids WHERE user_key = $1")).
+		WithArgs("user").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	store := NewDBStore(db, "uids")
+	assert.NoError(t, store.DeleteUIDs(context.Background(), "user"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,21 @@
+package empty_store
+
+import (
+	"context"
+)
+
+// EmptyStore is a nil-object which stores no UIDs.
+// If PBS is configured to use this, then UIDs only come from the uids cookie and user.ext.prebid.buyeruids.
+type EmptyStore struct{}
+
+func (store EmptyStore) GetUIDs(ctx context.Context, key string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (store EmptyStore) SetUID(ctx context.Context, key string, familyName string, uid string) error {
+	return nil
+}
+
+func (store EmptyStore) DeleteUIDs(ctx context.Context, key string) error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package file_store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/prebid/prebid-server/usersync"
+)
+
+// NewFileStore returns a UIDStore which keeps each user's UIDs in a JSON file in the directory.
+// Files are named after a hash of the user's key, so that keys can't escape the directory.
+//
+// This is meant for single-instance hosts and testing. Hosts which run several instances of
+// Prebid Server should use a shared store, like Postgres.
+func NewFileStore(directory string) (usersync.UIDStore, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the UID store directory %s: %v", directory, err)
+	}
+	return &fileStore{directory: directory}, nil
+}
+
+type fileStore struct {
+	directory string
+	// mutex keeps concurrent SetUID calls from overwriting each other's changes.
+	mutex sync.Mutex
+}
+
+func (store *fileStore) GetUIDs(ctx context.Context, key string) (map[string]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.read(key)
+}
+
+func (store *fileStore) SetUID(ctx context.Context, key string, familyName string, uid string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	uids, err := store.read(key)
+	if err != nil {
+		return err
+	}
+	if uid == "" {
+		if _, ok := uids[familyName]; !ok {
+			return nil
+		}
+		delete(uids, familyName)
+	} else {
+		if uids == nil {
+			uids = make(map[string]string, 1)
+		}
+		uids[familyName] = uid
+	}
+
+	if len(uids) == 0 {
+		if err := os.Remove(store.path(key)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove the stored UIDs: %v", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(uids)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(store.path(key), data, 0644); err != nil {
+		return fmt.Errorf("failed to write the stored UIDs: %v", err)
+	}
+	return nil
+}
+
+func (store *fileStore) DeleteUIDs(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if err := os.Remove(store.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove the stored UIDs: %v", err)
+	}
+	return nil
+}
+
+func (store *fileStore) read(key string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(store.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the stored UIDs: %v", err)
+	}
+	var uids map[string]string
+	if err := json.Unmarshal(data, &uids); err != nil {
+		return nil, fmt.Errorf("the stored UIDs are malformed: %v", err)
+	}
+	return uids, nil
+}
+
+func (store *fileStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(store.directory, hex.EncodeToString(hash[:])+".json")
+}
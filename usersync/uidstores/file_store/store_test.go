@@ -0,0 +1,73 @@
+package file_store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uidstore")
+	if err != nil {
+		t.Fatalf("Failed to create a temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx := context.Background()
+
+	uids, err := store.GetUIDs(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, uids, "Unknown users should have no UIDs")
+
+	assert.NoError(t, store.SetUID(ctx, "user", "adnxs", "123"))
+	assert.NoError(t, store.SetUID(ctx, "user", "rubicon", "456"))
+	assert.NoError(t, store.SetUID(ctx, "other/../user", "adnxs", "789"))
+
+	uids, err = store.GetUIDs(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"adnxs": "123", "rubicon": "456"}, uids)
+
+	assert.NoError(t, store.SetUID(ctx, "user", "adnxs", ""))
+	uids, err = store.GetUIDs(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"rubicon": "456"}, uids, "An empty UID should remove the family's UID")
+
+	assert.NoError(t, store.SetUID(ctx, "user", "rubicon", ""))
+	assert.NoError(t, store.SetUID(ctx, "user", "rubicon", ""), "Removing a missing UID should be a no-op")
+	uids, err = store.GetUIDs(ctx, "user")
+	assert.NoError(t, err)
+	assert.Empty(t, uids)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "Users without UIDs shouldn't leave files behind")
+
+	assert.NoError(t, store.DeleteUIDs(ctx, "other/../user"))
+	assert.NoError(t, store.DeleteUIDs(ctx, "other/../user"), "Deleting a missing user should be a no-op")
+	uids, err = store.GetUIDs(ctx, "other/../user")
+	assert.NoError(t, err)
+	assert.Empty(t, uids, "Deleted users should have no UIDs")
+}
+
+func TestMalformedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uidstore")
+	if err != nil {
+		t.Fatalf("Failed to create a temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &fileStore{directory: dir}
+	if err := ioutil.WriteFile(store.path("user"), []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to write the test file: %v", err)
+	}
+	_, err = store.GetUIDs(context.Background(), "user")
+	assert.Error(t, err)
+	assert.Error(t, store.SetUID(context.Background(), "user", "adnxs", "123"), "Malformed files shouldn't be overwritten")
+}
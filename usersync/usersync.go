@@ -9,6 +9,15 @@ type Usersyncer interface {
 	//
 	// For more information about user syncs, see http://clearcode.cc/2015/12/cookie-syncing/
 	GetUsersyncInfo(gdpr string, consent string) (*UsersyncInfo, error)
+
+	// SupportedSyncTypes returns the types of sync which this Usersyncer can run, in order of preference.
+	// The first one is the type used by GetUsersyncInfo.
+	SupportedSyncTypes() []SyncType
+
+	// GetUsersyncInfoForType is like GetUsersyncInfo, but for a specific type of sync.
+	// It returns an error if syncType isn't one of the SupportedSyncTypes.
+	GetUsersyncInfoForType(syncType SyncType, gdpr string, consent string) (*UsersyncInfo, error)
+
 	// FamilyName should be the same as the `BidderName` for this Usersyncer.
 	// This function only exists for legacy reasons.
 	// TODO #362: when the appnexus usersyncer is consistent, delete this and use the key
@@ -26,6 +35,16 @@ type Usersyncer interface {
 	GDPRVendorID() uint16
 }
 
+// SyncType is the way a user sync runs in the browser.
+type SyncType string
+
+const (
+	// SyncTypeRedirect syncs are loaded as an image pixel, which redirects to /setuid.
+	SyncTypeRedirect SyncType = "redirect"
+	// SyncTypeIframe syncs are loaded in an iframe.
+	SyncTypeIframe SyncType = "iframe"
+)
+
 type UsersyncInfo struct {
 	URL         string `json:"url,omitempty"`
 	Type        string `json:"type,omitempty"`
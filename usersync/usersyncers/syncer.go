@@ -5,6 +5,7 @@ import (
 	"text/template"
 
 	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/adapters"
 	ttx "github.com/prebid/prebid-server/adapters/33across"
 	"github.com/prebid/prebid-server/adapters/adform"
 	"github.com/prebid/prebid-server/adapters/adkernel"
@@ -103,5 +104,14 @@ func insertIntoMap(cfg *config.Configuration, syncers map[openrtb_ext.BidderName
 		glog.Warningf("adapters." + string(bidder) + ".usersync_url was not defined, and their usersync API isn't flexible enough for Prebid Server to choose a good default. No usersyncs will be performed with " + string(bidder))
 		return
 	}
-	syncers[bidder] = syncerFactory(template.Must(template.New(lowercased + "_usersync_url").Parse(urlString)))
+	syncer := syncerFactory(template.Must(template.New(lowercased + "_usersync_url").Parse(urlString)))
+
+	urlTemplates := make(map[adapters.SyncType]*template.Template, 2)
+	if iframeURL := cfg.Adapters[lowercased].UserSyncIframeURL; iframeURL != "" {
+		urlTemplates[adapters.SyncTypeIframe] = template.Must(template.New(lowercased + "_usersync_iframe_url").Parse(iframeURL))
+	}
+	if redirectURL := cfg.Adapters[lowercased].UserSyncRedirectURL; redirectURL != "" {
+		urlTemplates[adapters.SyncTypeRedirect] = template.Must(template.New(lowercased + "_usersync_redirect_url").Parse(redirectURL))
+	}
+	syncers[bidder] = adapters.WithSyncURLs(syncer, urlTemplates)
 }